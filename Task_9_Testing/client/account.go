@@ -0,0 +1,84 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"task_manager/domain"
+)
+
+func (c *Client) Me(ctx context.Context) (*domain.User, error) {
+	var user domain.User
+	if err := c.do(ctx, request{method: http.MethodGet, path: "/me"}, &user); err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+func (c *Client) UpdateMe(ctx context.Context, update domain.ProfileUpdate) (*domain.User, error) {
+	var user domain.User
+	if err := c.do(ctx, request{method: http.MethodPatch, path: "/me", body: update}, &user); err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// ChangePassword revokes every session token of the user, this client's
+// included, so it forgets the stored token. A client with credentials
+// switches to the new password for its next login.
+func (c *Client) ChangePassword(ctx context.Context, currentPassword, newPassword string) error {
+	body := map[string]string{"current_password": currentPassword, "new_password": newPassword}
+	if err := c.do(ctx, request{method: http.MethodPost, path: "/me/password", body: body}, nil); err != nil {
+		return err
+	}
+	c.loginMu.Lock()
+	defer c.loginMu.Unlock()
+	if c.username != "" {
+		c.password = newPassword
+	}
+	return c.storeToken(ctx, "")
+}
+
+// EnrollTOTP starts two-factor enrollment; ConfirmTOTP with a code from the
+// authenticator app turns it on.
+func (c *Client) EnrollTOTP(ctx context.Context) (*domain.TOTPEnrollment, error) {
+	var enrollment domain.TOTPEnrollment
+	if err := c.do(ctx, request{method: http.MethodPost, path: "/me/2fa/enroll"}, &enrollment); err != nil {
+		return nil, err
+	}
+	return &enrollment, nil
+}
+
+func (c *Client) ConfirmTOTP(ctx context.Context, code string) error {
+	return c.do(ctx, request{method: http.MethodPost, path: "/me/2fa/confirm", body: map[string]string{"code": code}}, nil)
+}
+
+func (c *Client) DisableTOTP(ctx context.Context, code string) error {
+	return c.do(ctx, request{method: http.MethodPost, path: "/me/2fa/disable", body: map[string]string{"code": code}}, nil)
+}
+
+func (c *Client) ListAPIKeys(ctx context.Context) ([]domain.APIKey, error) {
+	var keys []domain.APIKey
+	if err := c.do(ctx, request{method: http.MethodGet, path: "/me/api-keys"}, &keys); err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
+
+// CreateAPIKey returns the stored key and the plain key, which the server
+// shows only this once. Zero expiresInDays takes the server default.
+func (c *Client) CreateAPIKey(ctx context.Context, name string, scopes []string, expiresInDays int) (*domain.APIKey, string, error) {
+	var resp struct {
+		Key    string        `json:"key"`
+		APIKey domain.APIKey `json:"api_key"`
+	}
+	body := map[string]interface{}{"name": name, "scopes": scopes, "expires_in_days": expiresInDays}
+	if err := c.do(ctx, request{method: http.MethodPost, path: "/me/api-keys", body: body}, &resp); err != nil {
+		return nil, "", err
+	}
+	return &resp.APIKey, resp.Key, nil
+}
+
+func (c *Client) RevokeAPIKey(ctx context.Context, id string) error {
+	return c.do(ctx, request{method: http.MethodDelete, path: "/me/api-keys/" + url.PathEscape(id)}, nil)
+}
@@ -0,0 +1,104 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"task_manager/domain"
+)
+
+// The methods in this file need an admin caller.
+
+// PromoteUser makes the user with this username an admin.
+func (c *Client) PromoteUser(ctx context.Context, username string) error {
+	return c.do(ctx, request{method: http.MethodPost, path: "/promote", body: map[string]string{"username": username}}, nil)
+}
+
+// UnlockLogin clears the failed login counters of a username, an IP address
+// or both.
+func (c *Client) UnlockLogin(ctx context.Context, username, ip string) error {
+	body := map[string]string{"username": username, "ip": ip}
+	return c.do(ctx, request{method: http.MethodPost, path: "/unlock", body: body}, nil)
+}
+
+func (c *Client) ListUsers(ctx context.Context, query domain.UserQuery) (*domain.UserPage, error) {
+	params := url.Values{}
+	if query.Search != "" {
+		params.Set("q", query.Search)
+	}
+	if query.Role != "" {
+		params.Set("role", query.Role)
+	}
+	if query.Disabled != nil {
+		params.Set("disabled", strconv.FormatBool(*query.Disabled))
+	}
+	if query.Page > 0 {
+		params.Set("page", strconv.Itoa(query.Page))
+	}
+	if query.PerPage > 0 {
+		params.Set("per_page", strconv.Itoa(query.PerPage))
+	}
+	path := "/users"
+	if len(params) > 0 {
+		path += "?" + params.Encode()
+	}
+
+	var page domain.UserPage
+	if err := c.do(ctx, request{method: http.MethodGet, path: path}, &page); err != nil {
+		return nil, err
+	}
+	return &page, nil
+}
+
+func (c *Client) GetUser(ctx context.Context, id string) (*domain.User, error) {
+	var user domain.User
+	if err := c.do(ctx, request{method: http.MethodGet, path: "/users/" + url.PathEscape(id)}, &user); err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// SetRole takes domain.RoleAdmin or domain.RoleUser.
+func (c *Client) SetRole(ctx context.Context, id, role string) error {
+	action := map[string]string{domain.RoleAdmin: "promote", domain.RoleUser: "demote"}[role]
+	if action == "" {
+		return fmt.Errorf("unknown role %q", role)
+	}
+	return c.do(ctx, request{method: http.MethodPost, path: "/users/" + url.PathEscape(id) + "/" + action}, nil)
+}
+
+func (c *Client) SetDisabled(ctx context.Context, id string, disabled bool) error {
+	action := "enable"
+	if disabled {
+		action = "disable"
+	}
+	return c.do(ctx, request{method: http.MethodPost, path: "/users/" + url.PathEscape(id) + "/" + action}, nil)
+}
+
+// DeleteUser hands the user's tasks to reassignTo, or orphans them when it
+// is empty.
+func (c *Client) DeleteUser(ctx context.Context, id, reassignTo string) error {
+	path := "/users/" + url.PathEscape(id)
+	if reassignTo != "" {
+		path += "?reassign_to=" + url.QueryEscape(reassignTo)
+	}
+	return c.do(ctx, request{method: http.MethodDelete, path: path}, nil)
+}
+
+func (c *Client) SecuritySettings(ctx context.Context) (*domain.SecuritySettings, error) {
+	var settings domain.SecuritySettings
+	if err := c.do(ctx, request{method: http.MethodGet, path: "/settings/security"}, &settings); err != nil {
+		return nil, err
+	}
+	return &settings, nil
+}
+
+func (c *Client) UpdateSecuritySettings(ctx context.Context, settings domain.SecuritySettings) (*domain.SecuritySettings, error) {
+	var saved domain.SecuritySettings
+	if err := c.do(ctx, request{method: http.MethodPut, path: "/settings/security", body: settings}, &saved); err != nil {
+		return nil, err
+	}
+	return &saved, nil
+}
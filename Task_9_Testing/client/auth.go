@@ -0,0 +1,89 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"task_manager/domain"
+)
+
+type tokenResponse struct {
+	Token       string `json:"token"`
+	MFARequired bool   `json:"mfa_required"`
+	MFAToken    string `json:"mfa_token"`
+}
+
+func (c *Client) Register(ctx context.Context, username, password, email string) error {
+	body := map[string]string{"username": username, "password": password, "email": email}
+	return c.do(ctx, request{method: http.MethodPost, path: "/register", body: body, anonymous: true}, nil)
+}
+
+// Login stores the session token in the client's TokenStore and returns it.
+// When the account has two-factor authentication enabled it returns a
+// *domain.MFARequiredError instead, whose Token goes to CompleteLogin.
+func (c *Client) Login(ctx context.Context, username, password string) (string, error) {
+	var resp tokenResponse
+	body := map[string]string{"username": username, "password": password}
+	if err := c.do(ctx, request{method: http.MethodPost, path: "/login", body: body, anonymous: true}, &resp); err != nil {
+		return "", err
+	}
+	if resp.MFARequired {
+		return "", &domain.MFARequiredError{Token: resp.MFAToken}
+	}
+	return resp.Token, c.storeToken(ctx, resp.Token)
+}
+
+// CompleteLogin finishes a login that returned *domain.MFARequiredError with
+// a TOTP or recovery code.
+func (c *Client) CompleteLogin(ctx context.Context, mfaToken, code string) (string, error) {
+	var resp tokenResponse
+	body := map[string]string{"mfa_token": mfaToken, "code": code}
+	if err := c.do(ctx, request{method: http.MethodPost, path: "/login/2fa", body: body, anonymous: true}, &resp); err != nil {
+		return "", err
+	}
+	return resp.Token, c.storeToken(ctx, resp.Token)
+}
+
+// Logout forgets the stored token. Session tokens cannot be revoked one by
+// one on the server; ChangePassword revokes all of them.
+func (c *Client) Logout(ctx context.Context) error {
+	return c.storeToken(ctx, "")
+}
+
+func (c *Client) storeToken(ctx context.Context, token string) error {
+	if err := c.tokens.SetToken(ctx, token); err != nil {
+		return fmt.Errorf("storing token: %w", err)
+	}
+	return nil
+}
+
+func (c *Client) ForgotPassword(ctx context.Context, email string) error {
+	body := map[string]string{"email": email}
+	return c.do(ctx, request{method: http.MethodPost, path: "/password/forgot", body: body, anonymous: true}, nil)
+}
+
+func (c *Client) ResetPassword(ctx context.Context, token, newPassword string) error {
+	body := map[string]string{"token": token, "new_password": newPassword}
+	return c.do(ctx, request{method: http.MethodPost, path: "/password/reset", body: body, anonymous: true}, nil)
+}
+
+func (c *Client) VerifyEmail(ctx context.Context, token string) error {
+	path := "/verify?token=" + url.QueryEscape(token)
+	return c.do(ctx, request{method: http.MethodGet, path: path, anonymous: true}, nil)
+}
+
+func (c *Client) ResendVerification(ctx context.Context, email string) error {
+	body := map[string]string{"email": email}
+	return c.do(ctx, request{method: http.MethodPost, path: "/verify/resend", body: body, anonymous: true}, nil)
+}
+
+// JWKS returns the public keys session tokens are signed with, for services
+// that verify tokens themselves.
+func (c *Client) JWKS(ctx context.Context) (*domain.JSONWebKeySet, error) {
+	var keys domain.JSONWebKeySet
+	if err := c.do(ctx, request{method: http.MethodGet, path: "/.well-known/jwks.json", anonymous: true, root: true}, &keys); err != nil {
+		return nil, err
+	}
+	return &keys, nil
+}
@@ -0,0 +1,348 @@
+// Package client is a typed Go client for the task manager REST API. It
+// speaks the /v1 routes, exchanges domain types with the server, keeps the
+// session token in a TokenStore, logs in again when the token runs out, and
+// retries idempotent requests that failed for transient reasons.
+//
+// Browser only flows, the session cookie and single sign-on, are not
+// covered.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultMaxRetries = 3
+	defaultBaseDelay  = 200 * time.Millisecond
+	defaultMaxDelay   = 5 * time.Second
+	// refreshLeeway is how long before its expiry a token is replaced, so a
+	// request does not race the expiry on its way to the server.
+	refreshLeeway = time.Minute
+)
+
+// TokenStore keeps the session token between requests, and between
+// processes if the implementation persists it. An empty token means none.
+type TokenStore interface {
+	Token(ctx context.Context) (string, error)
+	SetToken(ctx context.Context, token string) error
+}
+
+// MemoryTokenStore is the default TokenStore.
+type MemoryTokenStore struct {
+	mu    sync.Mutex
+	token string
+}
+
+func (s *MemoryTokenStore) Token(ctx context.Context) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.token, nil
+}
+
+func (s *MemoryTokenStore) SetToken(ctx context.Context, token string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.token = token
+	return nil
+}
+
+type Client struct {
+	baseURL    string
+	httpClient *http.Client
+	tokens     TokenStore
+	apiKey     string
+	username   string
+	password   string
+	userAgent  string
+
+	maxRetries int
+	baseDelay  time.Duration
+	maxDelay   time.Duration
+	now        func() time.Time
+	sleep      func(ctx context.Context, d time.Duration) error
+
+	// loginMu makes concurrent requests that find the token expired log in
+	// once between them.
+	loginMu sync.Mutex
+}
+
+type Option func(*Client)
+
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) { c.httpClient = httpClient }
+}
+
+func WithTokenStore(store TokenStore) Option {
+	return func(c *Client) { c.tokens = store }
+}
+
+// WithAPIKey authenticates every request with a personal access token
+// instead of a session token. API keys are not refreshed.
+func WithAPIKey(key string) Option {
+	return func(c *Client) { c.apiKey = key }
+}
+
+// WithCredentials lets the client log in on its own: before the first
+// request, when the stored token is about to expire and once after a request
+// is refused with 401. Accounts with two-factor authentication cannot log in
+// unattended; for them the client returns *domain.MFARequiredError.
+func WithCredentials(username, password string) Option {
+	return func(c *Client) { c.username, c.password = username, password }
+}
+
+// WithRetries sets how often an idempotent request is retried and the delay
+// before the first retry, which doubles on every further attempt. Zero
+// retries turns retrying off.
+func WithRetries(maxRetries int, baseDelay time.Duration) Option {
+	return func(c *Client) { c.maxRetries, c.baseDelay = maxRetries, baseDelay }
+}
+
+func WithUserAgent(userAgent string) Option {
+	return func(c *Client) { c.userAgent = userAgent }
+}
+
+// New returns a client for the server at baseURL, for example
+// "https://tasks.example.com". The /v1 prefix is added by the client.
+func New(baseURL string, opts ...Option) *Client {
+	c := &Client{
+		baseURL:    strings.TrimSuffix(baseURL, "/"),
+		httpClient: http.DefaultClient,
+		tokens:     &MemoryTokenStore{},
+		userAgent:  "task-manager-go-client",
+		maxRetries: defaultMaxRetries,
+		baseDelay:  defaultBaseDelay,
+		maxDelay:   defaultMaxDelay,
+		now:        time.Now,
+		sleep:      sleepContext,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// request describes one API call. Paths are relative to /v1 unless root is
+// set.
+type request struct {
+	method      string
+	path        string
+	body        interface{}
+	contentType string
+	// anonymous calls never carry a token, and never trigger a login.
+	anonymous bool
+	root      bool
+}
+
+// do sends req and decodes a successful response into out, when out is not
+// nil. Failed responses come back as *APIError.
+func (c *Client) do(ctx context.Context, req request, out interface{}) error {
+	var body []byte
+	if req.body != nil {
+		if raw, ok := req.body.([]byte); ok {
+			body = raw
+		} else {
+			var err error
+			if body, err = json.Marshal(req.body); err != nil {
+				return fmt.Errorf("encoding request: %w", err)
+			}
+		}
+		if req.contentType == "" {
+			req.contentType = "application/json"
+		}
+	}
+
+	token := ""
+	if !req.anonymous {
+		var err error
+		if token, err = c.token(ctx); err != nil {
+			return err
+		}
+	}
+
+	resp, err := c.send(ctx, req, body, token)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode == http.StatusUnauthorized && c.canLogin() && token != "" {
+		// The token was revoked or expired early, for example by a password
+		// change elsewhere. Log in again and repeat the request once.
+		resp.Body.Close()
+		if token, err = c.relogin(ctx, token); err != nil {
+			return err
+		}
+		if resp, err = c.send(ctx, req, body, token); err != nil {
+			return err
+		}
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return newAPIError(resp)
+	}
+	if out == nil || resp.StatusCode == http.StatusNoContent {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("decoding response of %s %s: %w", req.method, req.path, err)
+	}
+	return nil
+}
+
+// send performs the request, retrying idempotent methods on network errors,
+// 429 and the 5xx statuses that mean "try again later".
+func (c *Client) send(ctx context.Context, req request, body []byte, token string) (*http.Response, error) {
+	url := c.baseURL + "/v1" + req.path
+	if req.root {
+		url = c.baseURL + req.path
+	}
+
+	for attempt := 0; ; attempt++ {
+		httpReq, err := http.NewRequestWithContext(ctx, req.method, url, bytes.NewReader(body))
+		if err != nil {
+			return nil, err
+		}
+		if body != nil {
+			httpReq.Header.Set("Content-Type", req.contentType)
+		}
+		httpReq.Header.Set("Accept", "application/json")
+		httpReq.Header.Set("User-Agent", c.userAgent)
+		if token != "" {
+			httpReq.Header.Set("Authorization", "Bearer "+token)
+		}
+
+		resp, err := c.httpClient.Do(httpReq)
+		if attempt >= c.maxRetries || !idempotent(req.method) || !retryable(resp, err) {
+			return resp, err
+		}
+		delay := c.backoff(attempt)
+		if resp != nil {
+			if wait, ok := retryAfter(resp); ok {
+				if wait > c.maxDelay {
+					// Waiting that long is the caller's decision.
+					return resp, nil
+				}
+				delay = wait
+			}
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+		if err := c.sleep(ctx, delay); err != nil {
+			return nil, err
+		}
+	}
+}
+
+func (c *Client) backoff(attempt int) time.Duration {
+	delay := c.baseDelay << attempt
+	if delay > c.maxDelay || delay <= 0 {
+		return c.maxDelay
+	}
+	return delay
+}
+
+func idempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+func retryable(resp *http.Response, err error) bool {
+	if err != nil {
+		return !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded)
+	}
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// retryAfter reads a Retry-After header given in seconds, which is the only
+// form the server sends.
+func retryAfter(resp *http.Response) (time.Duration, bool) {
+	seconds, err := strconv.Atoi(resp.Header.Get("Retry-After"))
+	if err != nil || seconds < 0 {
+		return 0, false
+	}
+	return time.Duration(seconds) * time.Second, true
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+func (c *Client) canLogin() bool {
+	return c.apiKey == "" && c.username != ""
+}
+
+// token returns the bearer token for the next request, logging in first
+// when there is none or it is about to expire and credentials are set.
+func (c *Client) token(ctx context.Context) (string, error) {
+	if c.apiKey != "" {
+		return c.apiKey, nil
+	}
+	token, err := c.tokens.Token(ctx)
+	if err != nil {
+		return "", fmt.Errorf("reading token: %w", err)
+	}
+	if !c.canLogin() || (token != "" && !c.expiring(token)) {
+		return token, nil
+	}
+	return c.relogin(ctx, token)
+}
+
+// relogin replaces stale with a fresh token, unless another request already
+// did while this one waited for the lock.
+func (c *Client) relogin(ctx context.Context, stale string) (string, error) {
+	c.loginMu.Lock()
+	defer c.loginMu.Unlock()
+	current, err := c.tokens.Token(ctx)
+	if err != nil {
+		return "", fmt.Errorf("reading token: %w", err)
+	}
+	if current != "" && current != stale && !c.expiring(current) {
+		return current, nil
+	}
+	return c.Login(ctx, c.username, c.password)
+}
+
+// expiring reports whether token expires within refreshLeeway. The client
+// cannot verify the signature and does not need to: it only reads the expiry
+// to decide when to log in again, the server still checks the token.
+func (c *Client) expiring(token string) bool {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return false
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return false
+	}
+	var claims struct {
+		ExpiresAt int64 `json:"exp"`
+	}
+	if err := json.Unmarshal(payload, &claims); err != nil || claims.ExpiresAt == 0 {
+		return false
+	}
+	return c.now().Add(refreshLeeway).After(time.Unix(claims.ExpiresAt, 0))
+}
@@ -0,0 +1,368 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"task_manager/delivery/controllers"
+	"task_manager/delivery/graph"
+	"task_manager/delivery/routers"
+	"task_manager/domain"
+	"task_manager/infrastructure"
+	"task_manager/internal/mocks"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+// stubUserRepository answers the revocation check of AuthMiddleware.
+type stubUserRepository struct {
+	domain.UserRepository
+	users map[string]*domain.User
+}
+
+func (s stubUserRepository) FindUserByID(ctx context.Context, id string) (*domain.User, error) {
+	return s.users[id], nil
+}
+
+type stubSettingsRepository struct{ domain.SettingsRepository }
+
+func (stubSettingsRepository) GetSecuritySettings(ctx context.Context) (*domain.SecuritySettings, error) {
+	return &domain.SecuritySettings{}, nil
+}
+
+// flakyHandler fails the next failures requests with status before passing
+// requests on to the router.
+type flakyHandler struct {
+	next http.Handler
+
+	mu         sync.Mutex
+	failures   int
+	status     int
+	retryAfter string
+	requests   int
+}
+
+func (h *flakyHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.mu.Lock()
+	h.requests++
+	fail := h.failures > 0
+	if fail {
+		h.failures--
+	}
+	h.mu.Unlock()
+	if fail {
+		if h.retryAfter != "" {
+			w.Header().Set("Retry-After", h.retryAfter)
+		}
+		w.WriteHeader(h.status)
+		return
+	}
+	h.next.ServeHTTP(w, r)
+}
+
+func (h *flakyHandler) fail(failures, status int, retryAfter string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.failures, h.status, h.retryAfter, h.requests = failures, status, retryAfter, 0
+}
+
+type ClientTestSuite struct {
+	suite.Suite
+	taskUsecase      *mocks.TaskUsecase
+	userUsecase      *mocks.UserUsecase
+	userAdminUsecase *mocks.UserAdminUsecase
+	jwtSvc           domain.JWTService
+	flaky            *flakyHandler
+	server           *httptest.Server
+	slept            []time.Duration
+}
+
+var (
+	alice = &domain.User{ID: "1", Username: "alice", Role: domain.RoleUser}
+	admin = &domain.User{ID: "2", Username: "root", Role: domain.RoleAdmin}
+)
+
+func (s *ClientTestSuite) SetupTest() {
+	gin.SetMode(gin.TestMode)
+	s.taskUsecase = new(mocks.TaskUsecase)
+	s.userUsecase = new(mocks.UserUsecase)
+	s.userAdminUsecase = new(mocks.UserAdminUsecase)
+	s.jwtSvc = infrastructure.NewJWTService("client-test-secret")
+	s.slept = nil
+
+	userRepo := stubUserRepository{users: map[string]*domain.User{alice.ID: alice, admin.ID: admin}}
+	router := routers.SetupRouter(
+		controllers.NewTaskController(s.taskUsecase),
+		controllers.NewUserController(s.userUsecase),
+		controllers.NewUserAdminController(s.userAdminUsecase),
+		controllers.NewPasswordController(nil),
+		controllers.NewVerificationController(nil),
+		controllers.NewProfileController(nil),
+		controllers.NewMFAController(nil),
+		controllers.NewAPIKeyController(nil),
+		controllers.NewJWKSController(s.jwtSvc),
+		controllers.NewOIDCController(nil, false),
+		controllers.NewSessionController(nil, false),
+		graph.NewHandler(s.taskUsecase, s.userUsecase, s.userAdminUsecase, stubSettingsRepository{}, nil),
+		s.jwtSvc, nil, nil, userRepo, stubSettingsRepository{},
+		infrastructure.NewMemoryRateLimitStore(),
+		slog.New(slog.NewTextHandler(io.Discard, nil)),
+		infrastructure.NewPrometheusMetrics(),
+	)
+	s.flaky = &flakyHandler{next: router}
+	s.server = httptest.NewServer(s.flaky)
+}
+
+func (s *ClientTestSuite) TearDownTest() {
+	s.server.Close()
+}
+
+func (s *ClientTestSuite) newClient(opts ...Option) *Client {
+	c := New(s.server.URL, opts...)
+	c.sleep = func(ctx context.Context, d time.Duration) error {
+		s.slept = append(s.slept, d)
+		return nil
+	}
+	return c
+}
+
+func (s *ClientTestSuite) tokenFor(user *domain.User) string {
+	token, err := s.jwtSvc.GenerateToken(user.ID, user.Username, user.Role)
+	s.Require().NoError(err)
+	return token
+}
+
+func (s *ClientTestSuite) TestLoginStoresToken() {
+	token := s.tokenFor(alice)
+	s.userUsecase.On("Login", mock.Anything, "alice", "secret", mock.Anything).Return(token, nil)
+	tasks := []domain.Task{{ID: "t1", Title: "Write client", Status: "Pending", OwnerID: alice.ID}}
+	s.taskUsecase.On("GetAllTasks", mock.Anything).Return(tasks, nil)
+
+	store := &MemoryTokenStore{}
+	c := s.newClient(WithTokenStore(store))
+	got, err := c.Login(context.Background(), "alice", "secret")
+	s.Require().NoError(err)
+	s.Equal(token, got)
+	stored, _ := store.Token(context.Background())
+	s.Equal(token, stored)
+
+	listed, err := c.ListTasks(context.Background())
+	s.Require().NoError(err)
+	s.Equal(tasks, listed)
+}
+
+func (s *ClientTestSuite) TestLoginMFARequired() {
+	s.userUsecase.On("Login", mock.Anything, "alice", "secret", mock.Anything).Return("", &domain.MFARequiredError{Token: "pending"})
+
+	_, err := s.newClient().Login(context.Background(), "alice", "secret")
+	var mfaRequired *domain.MFARequiredError
+	s.Require().ErrorAs(err, &mfaRequired)
+	s.Equal("pending", mfaRequired.Token)
+}
+
+func (s *ClientTestSuite) TestLoginBlocked() {
+	blocked := &domain.LoginBlockedError{Until: time.Now().Add(30 * time.Second)}
+	s.userUsecase.On("Login", mock.Anything, "alice", "wrong", mock.Anything).Return("", blocked)
+
+	_, err := s.newClient().Login(context.Background(), "alice", "wrong")
+	s.ErrorIs(err, ErrRateLimited)
+	var got *domain.LoginBlockedError
+	s.Require().ErrorAs(err, &got)
+	s.WithinDuration(blocked.Until, got.Until, 2*time.Second)
+	s.Empty(s.slept, "Logins are not idempotent and must not be retried")
+}
+
+func (s *ClientTestSuite) TestCredentialsLogInBeforeFirstRequest() {
+	s.userUsecase.On("Login", mock.Anything, "alice", "secret", mock.Anything).Return(s.tokenFor(alice), nil).Once()
+	s.taskUsecase.On("GetAllTasks", mock.Anything).Return([]domain.Task{}, nil)
+
+	c := s.newClient(WithCredentials("alice", "secret"))
+	_, err := c.ListTasks(context.Background())
+	s.Require().NoError(err)
+	_, err = c.ListTasks(context.Background())
+	s.Require().NoError(err)
+	s.userUsecase.AssertNumberOfCalls(s.T(), "Login", 1)
+}
+
+func (s *ClientTestSuite) TestRefreshAfterUnauthorized() {
+	fresh := s.tokenFor(alice)
+	s.userUsecase.On("Login", mock.Anything, "alice", "secret", mock.Anything).Return(fresh, nil).Once()
+	s.taskUsecase.On("DeleteTask", mock.Anything, "t1").Return(nil)
+
+	store := &MemoryTokenStore{}
+	store.SetToken(context.Background(), "revoked")
+	c := s.newClient(WithTokenStore(store), WithCredentials("alice", "secret"))
+	s.Require().NoError(c.DeleteTask(context.Background(), "t1"))
+
+	stored, _ := store.Token(context.Background())
+	s.Equal(fresh, stored)
+	s.taskUsecase.AssertCalled(s.T(), "DeleteTask", mock.Anything, "t1")
+}
+
+func (s *ClientTestSuite) TestRefreshBeforeExpiry() {
+	old, fresh := s.tokenFor(alice), s.tokenFor(alice)
+	s.userUsecase.On("Login", mock.Anything, "alice", "secret", mock.Anything).Return(fresh, nil).Once()
+	s.taskUsecase.On("GetAllTasks", mock.Anything).Return([]domain.Task{}, nil)
+
+	store := &MemoryTokenStore{}
+	store.SetToken(context.Background(), old)
+	c := s.newClient(WithTokenStore(store), WithCredentials("alice", "secret"))
+	c.now = func() time.Time { return time.Now().Add(infrastructure.TokenTTL - 30*time.Second) }
+
+	_, err := c.ListTasks(context.Background())
+	s.Require().NoError(err)
+	s.userUsecase.AssertNumberOfCalls(s.T(), "Login", 1)
+}
+
+func (s *ClientTestSuite) TestUnauthorizedWithoutCredentials() {
+	c := s.newClient()
+	_, err := c.ListTasks(context.Background())
+	s.ErrorIs(err, ErrUnauthorized)
+	var apiErr *APIError
+	s.Require().ErrorAs(err, &apiErr)
+	s.Equal("Authorization header required", apiErr.Message)
+}
+
+func (s *ClientTestSuite) TestRetriesIdempotentRequests() {
+	s.taskUsecase.On("GetTaskByID", mock.Anything, "t1").Return(&domain.Task{ID: "t1", Title: "Retry"}, nil)
+	s.flaky.fail(2, http.StatusServiceUnavailable, "")
+
+	c := s.newClient(WithAPIKey(s.tokenFor(alice)))
+	task, err := c.GetTask(context.Background(), "t1")
+	s.Require().NoError(err)
+	s.Equal("Retry", task.Title)
+	s.Equal(3, s.flaky.requests)
+	s.Equal([]time.Duration{defaultBaseDelay, 2 * defaultBaseDelay}, s.slept)
+}
+
+func (s *ClientTestSuite) TestRetryHonoursRetryAfter() {
+	s.taskUsecase.On("GetAllTasks", mock.Anything).Return([]domain.Task{}, nil)
+	s.flaky.fail(1, http.StatusTooManyRequests, "2")
+
+	c := s.newClient(WithAPIKey(s.tokenFor(alice)))
+	_, err := c.ListTasks(context.Background())
+	s.Require().NoError(err)
+	s.Equal([]time.Duration{2 * time.Second}, s.slept)
+}
+
+func (s *ClientTestSuite) TestGivesUpAfterMaxRetries() {
+	s.flaky.fail(10, http.StatusBadGateway, "")
+
+	c := s.newClient(WithAPIKey(s.tokenFor(alice)), WithRetries(1, time.Millisecond))
+	_, err := c.ListTasks(context.Background())
+	s.ErrorIs(err, ErrServer)
+	s.Equal(2, s.flaky.requests)
+}
+
+func (s *ClientTestSuite) TestDoesNotRetryCreate() {
+	s.flaky.fail(1, http.StatusServiceUnavailable, "")
+
+	c := s.newClient(WithAPIKey(s.tokenFor(alice)))
+	_, err := c.CreateTask(context.Background(), domain.Task{Title: "Once"})
+	s.ErrorIs(err, ErrServer)
+	s.Equal(1, s.flaky.requests)
+	s.taskUsecase.AssertNotCalled(s.T(), "AddTask", mock.Anything, mock.Anything)
+}
+
+func (s *ClientTestSuite) TestCreateTask() {
+	task := domain.Task{Title: "New", Status: "Pending"}
+	s.taskUsecase.On("AddTask", mock.Anything, task).Return("t9", nil)
+
+	id, err := s.newClient(WithAPIKey(s.tokenFor(alice))).CreateTask(context.Background(), task)
+	s.Require().NoError(err)
+	s.Equal("t9", id)
+}
+
+func (s *ClientTestSuite) TestValidationError() {
+	verrs := domain.ValidationErrors{{Field: "title", Message: "is required"}}
+	s.taskUsecase.On("AddTask", mock.Anything, domain.Task{}).Return("", verrs)
+
+	_, err := s.newClient(WithAPIKey(s.tokenFor(alice))).CreateTask(context.Background(), domain.Task{})
+	s.ErrorIs(err, ErrValidation)
+	var got domain.ValidationErrors
+	s.Require().ErrorAs(err, &got)
+	s.Equal("title", got[0].Field)
+}
+
+func (s *ClientTestSuite) TestTaskNotFound() {
+	s.taskUsecase.On("GetTaskByID", mock.Anything, "missing").Return((*domain.Task)(nil), domain.ErrTaskNotFound)
+
+	_, err := s.newClient(WithAPIKey(s.tokenFor(alice))).GetTask(context.Background(), "missing")
+	s.ErrorIs(err, ErrNotFound)
+	s.ErrorIs(err, domain.ErrTaskNotFound)
+}
+
+func (s *ClientTestSuite) TestPromoteUserRequiresAdmin() {
+	err := s.newClient(WithAPIKey(s.tokenFor(alice))).PromoteUser(context.Background(), "bob")
+	s.ErrorIs(err, ErrForbidden)
+	s.userUsecase.AssertNotCalled(s.T(), "PromoteUser", mock.Anything, mock.Anything)
+}
+
+func (s *ClientTestSuite) TestPromoteUser() {
+	s.userUsecase.On("PromoteUser", mock.Anything, "bob").Return(nil)
+	s.userUsecase.On("PromoteUser", mock.Anything, "nobody").Return(domain.ErrUserNotFound)
+
+	c := s.newClient(WithAPIKey(s.tokenFor(admin)))
+	s.NoError(c.PromoteUser(context.Background(), "bob"))
+	s.ErrorIs(c.PromoteUser(context.Background(), "nobody"), domain.ErrUserNotFound)
+}
+
+func (s *ClientTestSuite) TestListUsers() {
+	disabled := true
+	query := domain.UserQuery{Search: "al ice", Role: domain.RoleUser, Disabled: &disabled, Page: 2, PerPage: 5}
+	page := &domain.UserPage{Users: []domain.User{{ID: "1", Username: "alice", Role: domain.RoleUser}}, Page: 2, PerPage: 5, Total: 6}
+	s.userAdminUsecase.On("ListUsers", mock.Anything, query).Return(page, nil)
+
+	got, err := s.newClient(WithAPIKey(s.tokenFor(admin))).ListUsers(context.Background(), query)
+	s.Require().NoError(err)
+	s.Equal(page.Total, got.Total)
+	s.Equal("alice", got.Users[0].Username)
+}
+
+func (s *ClientTestSuite) TestSetRoleAndDisabled() {
+	s.userAdminUsecase.On("SetRole", mock.Anything, "1", domain.RoleAdmin).Return(nil)
+	s.userAdminUsecase.On("SetDisabled", mock.Anything, "1", true).Return(nil)
+	s.userAdminUsecase.On("SetDisabled", mock.Anything, "2", true).Return(domain.ErrCannotModifySelf)
+
+	c := s.newClient(WithAPIKey(s.tokenFor(admin)))
+	s.NoError(c.SetRole(context.Background(), "1", domain.RoleAdmin))
+	s.NoError(c.SetDisabled(context.Background(), "1", true))
+	err := c.SetDisabled(context.Background(), "2", true)
+	s.ErrorIs(err, ErrConflict)
+	s.ErrorIs(err, domain.ErrCannotModifySelf)
+}
+
+func (s *ClientTestSuite) TestGraphQL() {
+	s.taskUsecase.On("GetTaskByID", mock.Anything, "t1").Return(&domain.Task{ID: "t1", Title: "Via graph"}, nil)
+
+	var data struct {
+		Task struct {
+			Title string `json:"title"`
+		} `json:"task"`
+		User interface{} `json:"user"`
+	}
+	c := s.newClient(WithAPIKey(s.tokenFor(alice)))
+	err := c.GraphQL(context.Background(), `{ task(id: "t1") { title } user(id: "2") { username } }`, nil, &data)
+	s.Equal("Via graph", data.Task.Title)
+	var gqlErrs GraphQLErrors
+	s.Require().True(errors.As(err, &gqlErrs))
+	s.Equal("FORBIDDEN", gqlErrs[0].Code())
+}
+
+func (s *ClientTestSuite) TestJWKS() {
+	keys, err := s.newClient().JWKS(context.Background())
+	s.Require().NoError(err)
+	s.Equal(s.jwtSvc.JWKS(), *keys)
+}
+
+func TestClientTestSuite(t *testing.T) {
+	suite.Run(t, new(ClientTestSuite))
+}
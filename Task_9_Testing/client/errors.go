@@ -0,0 +1,149 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"task_manager/domain"
+	"time"
+)
+
+// Errors an *APIError matches with errors.Is, by status code, so callers can
+// branch on the kind of failure without looking at numbers.
+var (
+	ErrBadRequest   = errors.New("bad request")
+	ErrUnauthorized = errors.New("unauthorized")
+	ErrForbidden    = errors.New("forbidden")
+	ErrNotFound     = errors.New("not found")
+	ErrConflict     = errors.New("conflict")
+	ErrValidation   = errors.New("validation failed")
+	ErrRateLimited  = errors.New("rate limited")
+	ErrServer       = errors.New("server error")
+)
+
+// APIError is a response with a status of 300 or more.
+type APIError struct {
+	StatusCode int
+	// Message is the error the server reported.
+	Message string
+	// Fields lists the rejected fields of a 422 response.
+	Fields domain.ValidationErrors
+	// RetryAfter is set when the server said how long to wait.
+	RetryAfter time.Duration
+	// RequestID identifies the request in the server logs.
+	RequestID string
+
+	blocked *domain.LoginBlockedError
+}
+
+func (e *APIError) Error() string {
+	if e.Message == "" {
+		return http.StatusText(e.StatusCode)
+	}
+	return e.Message
+}
+
+func (e *APIError) Is(target error) bool {
+	switch target {
+	case ErrBadRequest:
+		return e.StatusCode == http.StatusBadRequest
+	case ErrUnauthorized:
+		return e.StatusCode == http.StatusUnauthorized
+	case ErrForbidden:
+		return e.StatusCode == http.StatusForbidden
+	case ErrNotFound:
+		return e.StatusCode == http.StatusNotFound
+	case ErrConflict:
+		return e.StatusCode == http.StatusConflict
+	case ErrValidation:
+		return e.StatusCode == http.StatusUnprocessableEntity
+	case ErrRateLimited:
+		return e.StatusCode == http.StatusTooManyRequests
+	case ErrServer:
+		return e.StatusCode >= 500
+	}
+	return false
+}
+
+// Unwrap exposes the field errors and, when the server passed a domain error
+// through, that error, so errors.As(err, &domain.ValidationErrors{}) and
+// errors.Is(err, domain.ErrEmailInUse) work on the client as on the server.
+func (e *APIError) Unwrap() []error {
+	var errs []error
+	if e.Fields != nil {
+		errs = append(errs, e.Fields)
+	}
+	if err := domainError(e.StatusCode, e.Message); err != nil {
+		errs = append(errs, err)
+	}
+	if e.blocked != nil {
+		errs = append(errs, e.blocked)
+	}
+	return errs
+}
+
+// passedThrough are the domain errors whose message the controllers send
+// as is.
+var passedThrough = []error{
+	domain.ErrUnsupportedPatch,
+	domain.ErrInvalidPatch,
+	domain.ErrIncorrectPassword,
+	domain.ErrInvalidResetToken,
+	domain.ErrEmailNotVerified,
+	domain.ErrInvalidVerifyToken,
+	domain.ErrInvalidMFACode,
+	domain.ErrInvalidMFAToken,
+	domain.ErrMFANotEnrolled,
+	domain.ErrMFAAlreadyEnabled,
+	domain.ErrAPIKeyNotFound,
+	domain.ErrUsernameTaken,
+	domain.ErrEmailInUse,
+	domain.ErrUserNotFound,
+	domain.ErrAccountDisabled,
+	domain.ErrCannotModifySelf,
+}
+
+func domainError(status int, message string) error {
+	// The task endpoints word their 404 differently from the usecase error.
+	if status == http.StatusNotFound && message == "Task not found" {
+		return domain.ErrTaskNotFound
+	}
+	for _, err := range passedThrough {
+		if strings.EqualFold(message, err.Error()) {
+			return err
+		}
+	}
+	return nil
+}
+
+func newAPIError(resp *http.Response) error {
+	apiErr := &APIError{StatusCode: resp.StatusCode, RequestID: resp.Header.Get(domain.RequestIDHeader)}
+	if wait, ok := retryAfter(resp); ok {
+		apiErr.RetryAfter = wait
+	}
+	// Most handlers report {"error": ...}, a few {"message": ...}.
+	var body struct {
+		Error   string                  `json:"error"`
+		Message string                  `json:"message"`
+		Fields  domain.ValidationErrors `json:"fields"`
+	}
+	if raw, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20)); err == nil && json.Unmarshal(raw, &body) == nil {
+		apiErr.Message = body.Error
+		if apiErr.Message == "" {
+			apiErr.Message = body.Message
+		}
+		apiErr.Fields = body.Fields
+	}
+	// A blocked login is also reported as the domain error, with the time
+	// it ends, like Login on the server returns it.
+	for _, locked := range []bool{false, true} {
+		blocked := &domain.LoginBlockedError{Locked: locked}
+		if resp.StatusCode == http.StatusTooManyRequests && apiErr.Message == blocked.Error() {
+			blocked.Until = time.Now().Add(apiErr.RetryAfter)
+			apiErr.blocked = blocked
+		}
+	}
+	return apiErr
+}
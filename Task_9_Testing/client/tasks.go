@@ -0,0 +1,58 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"task_manager/domain"
+)
+
+func (c *Client) ListTasks(ctx context.Context) ([]domain.Task, error) {
+	var resp struct {
+		Tasks []domain.Task `json:"tasks"`
+	}
+	if err := c.do(ctx, request{method: http.MethodGet, path: "/tasks"}, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Tasks, nil
+}
+
+func (c *Client) GetTask(ctx context.Context, id string) (*domain.Task, error) {
+	var task domain.Task
+	if err := c.do(ctx, request{method: http.MethodGet, path: "/tasks/" + url.PathEscape(id)}, &task); err != nil {
+		return nil, err
+	}
+	return &task, nil
+}
+
+// CreateTask returns the id of the new task. Creating is not idempotent, so
+// it is never retried.
+func (c *Client) CreateTask(ctx context.Context, task domain.Task) (string, error) {
+	var resp struct {
+		ID string `json:"id"`
+	}
+	if err := c.do(ctx, request{method: http.MethodPost, path: "/tasks", body: task}, &resp); err != nil {
+		return "", err
+	}
+	return resp.ID, nil
+}
+
+// UpdateTask replaces a task. Admins only.
+func (c *Client) UpdateTask(ctx context.Context, id string, task domain.Task) error {
+	return c.do(ctx, request{method: http.MethodPut, path: "/tasks/" + url.PathEscape(id), body: task}, nil)
+}
+
+// PatchTask applies a domain.MergePatchMediaType or domain.JSONPatchMediaType
+// document and returns the patched task. Admins only.
+func (c *Client) PatchTask(ctx context.Context, id, contentType string, patch []byte) (*domain.Task, error) {
+	var task domain.Task
+	req := request{method: http.MethodPatch, path: "/tasks/" + url.PathEscape(id), body: patch, contentType: contentType}
+	if err := c.do(ctx, req, &task); err != nil {
+		return nil, err
+	}
+	return &task, nil
+}
+
+func (c *Client) DeleteTask(ctx context.Context, id string) error {
+	return c.do(ctx, request{method: http.MethodDelete, path: "/tasks/" + url.PathEscape(id)}, nil)
+}
@@ -0,0 +1,181 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"os"
+	"strings"
+	"task_manager/domain"
+	"task_manager/usecases"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+func (a *app) ensureAdminCmd() *cobra.Command {
+	var email string
+	var passwordStdin, generate, reset2FA bool
+	cmd := &cobra.Command{
+		Use:   "ensure-admin <username>",
+		Short: "Create an admin, or recover an existing account as admin",
+		Long: `Create an admin user, or, when the username exists, make it an admin,
+set its password, enable it, revoke its sessions and clear its login lock.
+Only the first user to register becomes admin on their own; this is how the
+next one is made, or a lost admin account is recovered.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			username := args[0]
+			password, err := a.password(cmd, passwordStdin, generate)
+			if err != nil {
+				return err
+			}
+			if err := usecases.ValidateRegistration(domain.User{Username: username, Password: password, Email: email}); err != nil {
+				return err
+			}
+			return a.withStore(cmd, func(ctx context.Context, s *store) error {
+				created, err := ensureAdmin(ctx, s, a.now(), username, password, email, reset2FA)
+				if err != nil {
+					return err
+				}
+				out := cmd.OutOrStdout()
+				if created {
+					fmt.Fprintf(out, "Created admin %s\n", username)
+				} else {
+					fmt.Fprintf(out, "Reset %s: admin, enabled, new password, sessions revoked, login unlocked\n", username)
+				}
+				if generate {
+					fmt.Fprintf(out, "Password: %s\n", password)
+				}
+				return nil
+			})
+		},
+	}
+	cmd.Flags().StringVar(&email, "email", "", "email address, marked verified")
+	cmd.Flags().BoolVar(&passwordStdin, "password-stdin", false, "read the password from stdin")
+	cmd.Flags().BoolVar(&generate, "generate-password", false, "generate a password and print it")
+	cmd.Flags().BoolVar(&reset2FA, "reset-2fa", false, "also turn off two-factor authentication, for a lost authenticator")
+	cmd.MarkFlagsMutuallyExclusive("password-stdin", "generate-password")
+	return cmd
+}
+
+// ensureAdmin reports whether it created the user.
+func ensureAdmin(ctx context.Context, s *store, now time.Time, username, password, email string, reset2FA bool) (bool, error) {
+	hashed, err := s.passwords.HashPassword(password)
+	if err != nil {
+		return false, err
+	}
+	if email != "" {
+		other, err := s.users.FindUserByEmail(ctx, email)
+		if err != nil {
+			return false, err
+		}
+		if other != nil && other.Username != username {
+			return false, domain.ErrEmailInUse
+		}
+	}
+
+	user, err := s.users.FindUserByUsername(ctx, username)
+	if err != nil {
+		return false, err
+	}
+	if user == nil {
+		return true, s.users.CreateUser(ctx, domain.User{
+			ID:        uuid.New().String(),
+			Username:  username,
+			Password:  hashed,
+			Role:      domain.RoleAdmin,
+			Email:     email,
+			Verified:  true,
+			CreatedAt: now,
+		})
+	}
+
+	// Changing the password with the current time revokes every token and
+	// session issued before, which is the point when recovering an account.
+	if err := s.users.UpdatePassword(ctx, user.ID, hashed, now); err != nil {
+		return false, err
+	}
+	if err := s.users.SetRole(ctx, user.ID, domain.RoleAdmin); err != nil {
+		return false, err
+	}
+	if err := s.users.SetDisabled(ctx, user.ID, false); err != nil {
+		return false, err
+	}
+	if err := s.users.ResetLoginAttempts(ctx, "user:"+username); err != nil {
+		return false, err
+	}
+	if email != "" {
+		if err := s.users.UpdateProfile(ctx, user.ID, map[string]interface{}{"email": email, "verified": true}); err != nil {
+			return false, err
+		}
+	}
+	if reset2FA {
+		if err := s.users.DisableTOTP(ctx, user.ID); err != nil {
+			return false, err
+		}
+	}
+	return false, nil
+}
+
+// password reads the password from the terminal, twice, or from stdin, or
+// generates one.
+func (a *app) password(cmd *cobra.Command, fromStdin, generate bool) (string, error) {
+	if generate {
+		return generatePassword()
+	}
+	if f, ok := a.stdin.(*os.File); ok && !fromStdin && term.IsTerminal(int(f.Fd())) {
+		prompt := cmd.ErrOrStderr()
+		fmt.Fprint(prompt, "Password: ")
+		first, err := term.ReadPassword(int(f.Fd()))
+		fmt.Fprintln(prompt)
+		if err != nil {
+			return "", err
+		}
+		fmt.Fprint(prompt, "Repeat password: ")
+		second, err := term.ReadPassword(int(f.Fd()))
+		fmt.Fprintln(prompt)
+		if err != nil {
+			return "", err
+		}
+		if string(first) != string(second) {
+			return "", errors.New("passwords do not match")
+		}
+		return string(first), nil
+	}
+	line, err := bufio.NewReader(a.stdin).ReadString('\n')
+	if err != nil && (err != io.EOF || line == "") {
+		return "", fmt.Errorf("reading password: %w", err)
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+const passwordAlphabet = "abcdefghijkmnopqrstuvwxyzABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+
+// generatePassword returns 20 characters that pass the password policy.
+func generatePassword() (string, error) {
+	for {
+		var b strings.Builder
+		for i := 0; i < 20; i++ {
+			n, err := rand.Int(rand.Reader, big.NewInt(int64(len(passwordAlphabet))))
+			if err != nil {
+				return "", err
+			}
+			b.WriteByte(passwordAlphabet[n.Int64()])
+		}
+		password := b.String()
+		if strings.ContainsAny(password, "23456789") && strings.IndexFunc(password, isLetter) >= 0 {
+			return password, nil
+		}
+	}
+}
+
+func isLetter(r rune) bool {
+	return ('a' <= r && r <= 'z') || ('A' <= r && r <= 'Z')
+}
@@ -0,0 +1,106 @@
+// Command taskadmin works on the database directly, without a running server
+// or an admin account: it bootstraps or recovers an admin, seeds demo data,
+// re-hashes passwords, lists and promotes users, and runs data migrations.
+//
+//	taskadmin ensure-admin root --email root@example.com
+//	taskadmin users list --role admin
+//	taskadmin migrate
+//
+// MONGO_URI and MONGO_DB, or --mongo-uri and --db, pick the database. The
+// defaults match the server's.
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"task_manager/domain"
+	"task_manager/infrastructure"
+	"task_manager/repositories"
+	"time"
+
+	"github.com/spf13/cobra"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	defaultMongoURI = "mongodb://localhost:27017"
+	defaultDatabase = "task_maanager"
+)
+
+// store is what the commands work on. db is nil in tests, which replace
+// open with in-memory repositories.
+type store struct {
+	db        *mongo.Database
+	users     domain.UserRepository
+	tasks     domain.TaskRepository
+	passwords domain.PasswordService
+}
+
+type app struct {
+	mongoURI string
+	database string
+	stdin    io.Reader
+	now      func() time.Time
+	open     func(ctx context.Context, a *app) (*store, func(), error)
+}
+
+func main() {
+	if err := newRootCmd(&app{stdin: os.Stdin, now: time.Now, open: openMongo}).Execute(); err != nil {
+		fmt.Fprintln(os.Stderr, "Error:", err)
+		os.Exit(1)
+	}
+}
+
+func newRootCmd(a *app) *cobra.Command {
+	root := &cobra.Command{
+		Use:           "taskadmin",
+		Short:         "Maintain the task manager database directly",
+		SilenceUsage:  true,
+		SilenceErrors: true,
+	}
+	root.PersistentFlags().StringVar(&a.mongoURI, "mongo-uri", envOr("MONGO_URI", defaultMongoURI), "MongoDB connection string")
+	root.PersistentFlags().StringVar(&a.database, "db", envOr("MONGO_DB", defaultDatabase), "database name")
+
+	root.AddCommand(a.ensureAdminCmd(), a.seedCmd(), a.rehashPasswordsCmd(), a.usersCmd(), a.migrateCmd())
+	return root
+}
+
+func openMongo(ctx context.Context, a *app) (*store, func(), error) {
+	client, err := mongo.Connect(ctx, options.Client().ApplyURI(a.mongoURI).SetServerSelectionTimeout(10*time.Second))
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := client.Ping(ctx, nil); err != nil {
+		client.Disconnect(ctx)
+		return nil, nil, fmt.Errorf("connecting to %s: %w", a.mongoURI, err)
+	}
+	db := client.Database(a.database)
+	s := &store{
+		db:        db,
+		users:     repositories.NewUserRepository(db.Collection("users")),
+		tasks:     repositories.NewTaskRepository(db.Collection("tasks")),
+		passwords: infrastructure.NewPasswordService(),
+	}
+	return s, func() { client.Disconnect(context.Background()) }, nil
+}
+
+// withStore opens the database for the duration of run.
+func (a *app) withStore(cmd *cobra.Command, run func(ctx context.Context, s *store) error) error {
+	ctx := cmd.Context()
+	s, closeStore, err := a.open(ctx, a)
+	if err != nil {
+		return err
+	}
+	defer closeStore()
+	return run(ctx, s)
+}
+
+func envOr(key, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return fallback
+}
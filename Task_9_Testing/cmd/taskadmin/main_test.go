@@ -0,0 +1,274 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"sort"
+	"strings"
+	"task_manager/domain"
+	"task_manager/infrastructure"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// memoryUserRepository keeps users in a map. Methods the commands do not
+// use panic through the embedded nil interface.
+type memoryUserRepository struct {
+	domain.UserRepository
+	users         map[string]*domain.User
+	resetAttempts []string
+}
+
+func (r *memoryUserRepository) CreateUser(ctx context.Context, user domain.User) error {
+	r.users[user.ID] = &user
+	return nil
+}
+
+func (r *memoryUserRepository) FindUserByUsername(ctx context.Context, username string) (*domain.User, error) {
+	for _, u := range r.users {
+		if u.Username == username {
+			copied := *u
+			return &copied, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *memoryUserRepository) FindUserByEmail(ctx context.Context, email string) (*domain.User, error) {
+	for _, u := range r.users {
+		if u.Email == email {
+			copied := *u
+			return &copied, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *memoryUserRepository) UpdatePassword(ctx context.Context, id, hashed string, changedAt time.Time) error {
+	r.users[id].Password, r.users[id].PasswordChangedAt = hashed, changedAt
+	return nil
+}
+
+func (r *memoryUserRepository) SetRole(ctx context.Context, id, role string) error {
+	r.users[id].Role = role
+	return nil
+}
+
+func (r *memoryUserRepository) SetDisabled(ctx context.Context, id string, disabled bool) error {
+	r.users[id].Disabled = disabled
+	return nil
+}
+
+func (r *memoryUserRepository) ResetLoginAttempts(ctx context.Context, key string) error {
+	r.resetAttempts = append(r.resetAttempts, key)
+	return nil
+}
+
+func (r *memoryUserRepository) UpdateProfile(ctx context.Context, id string, fields map[string]interface{}) error {
+	if email, ok := fields["email"].(string); ok {
+		r.users[id].Email = email
+	}
+	if verified, ok := fields["verified"].(bool); ok {
+		r.users[id].Verified = verified
+	}
+	return nil
+}
+
+func (r *memoryUserRepository) DisableTOTP(ctx context.Context, id string) error {
+	r.users[id].TOTPEnabled = false
+	return nil
+}
+
+func (r *memoryUserRepository) PromoteUser(ctx context.Context, username string) error {
+	for _, u := range r.users {
+		if u.Username == username {
+			u.Role = domain.RoleAdmin
+		}
+	}
+	return nil
+}
+
+func (r *memoryUserRepository) GetAllUsers(ctx context.Context) ([]*domain.User, error) {
+	var users []*domain.User
+	for _, u := range r.users {
+		copied := *u
+		users = append(users, &copied)
+	}
+	sort.Slice(users, func(i, j int) bool { return users[i].Username < users[j].Username })
+	return users, nil
+}
+
+func (r *memoryUserRepository) ListUsers(ctx context.Context, query domain.UserQuery) ([]domain.User, int64, error) {
+	var users []domain.User
+	for _, u := range r.users {
+		if query.Role == "" || u.Role == query.Role {
+			users = append(users, *u)
+		}
+	}
+	sort.Slice(users, func(i, j int) bool { return users[i].Username < users[j].Username })
+	return users, int64(len(users)), nil
+}
+
+type memoryTaskRepository struct {
+	domain.TaskRepository
+	tasks []domain.Task
+}
+
+func (r *memoryTaskRepository) AddTask(ctx context.Context, task domain.Task) (string, error) {
+	r.tasks = append(r.tasks, task)
+	return task.ID, nil
+}
+
+type TaskadminTestSuite struct {
+	suite.Suite
+	users *memoryUserRepository
+	tasks *memoryTaskRepository
+	now   time.Time
+}
+
+func (s *TaskadminTestSuite) SetupTest() {
+	s.users = &memoryUserRepository{users: map[string]*domain.User{}}
+	s.tasks = &memoryTaskRepository{}
+	s.now = time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+}
+
+func (s *TaskadminTestSuite) run(stdin string, args ...string) (string, error) {
+	a := &app{
+		stdin: strings.NewReader(stdin),
+		now:   func() time.Time { return s.now },
+		open: func(ctx context.Context, a *app) (*store, func(), error) {
+			return &store{users: s.users, tasks: s.tasks, passwords: infrastructure.NewPasswordService()}, func() {}, nil
+		},
+	}
+	cmd := newRootCmd(a)
+	var out bytes.Buffer
+	cmd.SetOut(&out)
+	cmd.SetErr(io.Discard)
+	cmd.SetArgs(args)
+	err := cmd.Execute()
+	return out.String(), err
+}
+
+func (s *TaskadminTestSuite) addUser(user domain.User) *domain.User {
+	s.users.users[user.ID] = &user
+	return &user
+}
+
+func (s *TaskadminTestSuite) TestEnsureAdminCreates() {
+	out, err := s.run("s3cret-pass\n", "ensure-admin", "root", "--email", "root@example.com", "--password-stdin")
+	s.Require().NoError(err)
+	s.Equal("Created admin root\n", out)
+
+	user, _ := s.users.FindUserByUsername(context.Background(), "root")
+	s.Require().NotNil(user)
+	s.Equal(domain.RoleAdmin, user.Role)
+	s.True(user.Verified)
+	s.Equal(s.now, user.CreatedAt)
+	s.NoError(bcrypt.CompareHashAndPassword([]byte(user.Password), []byte("s3cret-pass")))
+}
+
+func (s *TaskadminTestSuite) TestEnsureAdminRecoversExistingAccount() {
+	s.addUser(domain.User{ID: "1", Username: "alice", Role: domain.RoleUser, Disabled: true, TOTPEnabled: true, Password: "old"})
+
+	out, err := s.run("", "ensure-admin", "alice", "--generate-password", "--reset-2fa")
+	s.Require().NoError(err)
+	s.Contains(out, "Reset alice")
+
+	user := s.users.users["1"]
+	s.Equal(domain.RoleAdmin, user.Role)
+	s.False(user.Disabled)
+	s.False(user.TOTPEnabled)
+	s.Equal(s.now, user.PasswordChangedAt, "Existing sessions must be revoked")
+	s.Equal([]string{"user:alice"}, s.users.resetAttempts)
+
+	password := strings.TrimPrefix(strings.Split(strings.TrimSpace(out), "\n")[1], "Password: ")
+	s.Len(password, 20)
+	s.NoError(bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password)))
+}
+
+func (s *TaskadminTestSuite) TestEnsureAdminValidatesPassword() {
+	_, err := s.run("short\n", "ensure-admin", "root")
+	var verrs domain.ValidationErrors
+	s.Require().ErrorAs(err, &verrs)
+	s.Equal("password", verrs[0].Field)
+	s.Empty(s.users.users)
+}
+
+func (s *TaskadminTestSuite) TestEnsureAdminRejectsEmailOfAnotherUser() {
+	s.addUser(domain.User{ID: "1", Username: "alice", Email: "shared@example.com"})
+
+	_, err := s.run("s3cret-pass\n", "ensure-admin", "root", "--email", "shared@example.com")
+	s.ErrorIs(err, domain.ErrEmailInUse)
+}
+
+func (s *TaskadminTestSuite) TestSeedIsRepeatable() {
+	out, err := s.run("", "seed", "--users", "2", "--tasks", "3")
+	s.Require().NoError(err)
+	s.Contains(out, "Created 2 users with 3 tasks each")
+	s.Len(s.users.users, 2)
+	s.Len(s.tasks.tasks, 6)
+	for _, task := range s.tasks.tasks {
+		s.NotEmpty(task.OwnerID)
+		s.True(task.DueDate.After(s.now))
+	}
+
+	out, err = s.run("", "seed", "--users", "2", "--tasks", "3")
+	s.Require().NoError(err)
+	s.Contains(out, "Created 0 users")
+	s.Len(s.tasks.tasks, 6)
+}
+
+func (s *TaskadminTestSuite) TestRehashPasswords() {
+	lowCost, _ := bcrypt.GenerateFromPassword([]byte("pw"), bcrypt.MinCost)
+	current, _ := infrastructure.NewPasswordService().HashPassword("pw")
+	changedAt := s.now.Add(-time.Hour)
+	s.addUser(domain.User{ID: "1", Username: "edited", Password: "by-hand-1", PasswordChangedAt: changedAt})
+	s.addUser(domain.User{ID: "2", Username: "old", Password: string(lowCost)})
+	s.addUser(domain.User{ID: "3", Username: "current", Password: current})
+	s.addUser(domain.User{ID: "4", Username: "sso"})
+
+	out, err := s.run("", "rehash-passwords", "--dry-run")
+	s.Require().NoError(err)
+	s.Contains(out, "Would hash 1 plain text passwords: [edited]")
+	s.Equal("by-hand-1", s.users.users["1"].Password)
+
+	out, err = s.run("", "rehash-passwords")
+	s.Require().NoError(err)
+	s.Contains(out, "Hashed 1 plain text passwords: [edited]")
+	s.Contains(out, "1 users have a hash with another cost")
+	s.NoError(bcrypt.CompareHashAndPassword([]byte(s.users.users["1"].Password), []byte("by-hand-1")))
+	s.Equal(changedAt, s.users.users["1"].PasswordChangedAt, "Sessions are kept")
+	s.Empty(s.users.users["4"].Password)
+}
+
+func (s *TaskadminTestSuite) TestUsersListAndPromote() {
+	s.addUser(domain.User{ID: "1", Username: "alice", Role: domain.RoleUser, Email: "alice@example.com"})
+	s.addUser(domain.User{ID: "2", Username: "bob", Role: domain.RoleUser})
+
+	_, err := s.run("", "users", "promote", "bob")
+	s.Require().NoError(err)
+	_, err = s.run("", "users", "promote", "nobody")
+	s.ErrorIs(err, domain.ErrUserNotFound)
+
+	out, err := s.run("", "users", "list", "--role", domain.RoleAdmin)
+	s.Require().NoError(err)
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	s.Require().Len(lines, 3)
+	s.Regexp(`^2\s+bob\s+admin\s+false\s+false$`, lines[1])
+	s.Equal("1 of 1 users", lines[2])
+}
+
+func (s *TaskadminTestSuite) TestMigrateNeedsMongo() {
+	_, err := s.run("", "migrate", "--dry-run")
+	s.EqualError(err, "migrations need a MongoDB database")
+	_, err = s.run("", "migrate", "status")
+	s.EqualError(err, "migrations need a MongoDB database")
+}
+
+func TestTaskadminTestSuite(t *testing.T) {
+	suite.Run(t, new(TaskadminTestSuite))
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"task_manager/repositories"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var errNeedsMongo = errors.New("migrations need a MongoDB database")
+
+func (a *app) migrateCmd() *cobra.Command {
+	var dryRun bool
+	cmd := &cobra.Command{
+		Use:   "migrate",
+		Short: "Apply pending schema migrations and indexes",
+		Long: `Apply the migrations that have not run on this database, in order,
+recording each in the schema_migrations collection. The server does the same
+at startup unless MIGRATE_ON_START is false.
+
+With --dry-run nothing is written; each pending migration reports what it
+would change in the current data.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return a.withStore(cmd, func(ctx context.Context, s *store) error {
+				if s.db == nil {
+					return errNeedsMongo
+				}
+				results, err := repositories.NewMigrator(s.db).Up(ctx, dryRun)
+				out := cmd.OutOrStdout()
+				for _, r := range results {
+					fmt.Fprintf(out, "%d: %s\n", r.Version, r.Description)
+					for _, change := range r.Changes {
+						fmt.Fprintf(out, "    %s\n", change)
+					}
+				}
+				if err != nil {
+					return err
+				}
+				verb := "Applied"
+				if dryRun {
+					verb = "Would apply"
+				}
+				fmt.Fprintf(out, "%s %d migrations\n", verb, len(results))
+				return nil
+			})
+		},
+	}
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "only report what would change")
+	cmd.AddCommand(a.migrateStatusCmd())
+	return cmd
+}
+
+func (a *app) migrateStatusCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "status",
+		Short: "List migrations and when they were applied",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return a.withStore(cmd, func(ctx context.Context, s *store) error {
+				if s.db == nil {
+					return errNeedsMongo
+				}
+				statuses, err := repositories.NewMigrator(s.db).Status(ctx)
+				if err != nil {
+					return err
+				}
+				w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 4, 2, ' ', 0)
+				fmt.Fprintln(w, "VERSION\tAPPLIED\tDESCRIPTION")
+				for _, st := range statuses {
+					applied := "pending"
+					if st.AppliedAt != nil {
+						applied = st.AppliedAt.Format(time.RFC3339)
+					}
+					if !st.Known {
+						applied += " (unknown to this version)"
+					}
+					fmt.Fprintf(w, "%d\t%s\t%s\n", st.Version, applied, st.Description)
+				}
+				return w.Flush()
+			})
+		},
+	}
+}
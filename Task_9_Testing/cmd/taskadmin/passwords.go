@@ -0,0 +1,81 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func (a *app) rehashPasswordsCmd() *cobra.Command {
+	var dryRun bool
+	cmd := &cobra.Command{
+		Use:   "rehash-passwords",
+		Short: "Hash passwords that were stored in plain text",
+		Long: `Hash every stored password that is not a bcrypt hash, which is what
+editing a user by hand in the database leaves behind. Such users cannot log
+in until it is hashed. Their sessions are kept.
+
+Hashes made with a different bcrypt cost than the current one are counted
+but left alone: without the password they cannot be hashed again.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return a.withStore(cmd, func(ctx context.Context, s *store) error {
+				report, err := rehashPasswords(ctx, s, dryRun)
+				if err != nil {
+					return err
+				}
+				verb := "Hashed"
+				if dryRun {
+					verb = "Would hash"
+				}
+				out := cmd.OutOrStdout()
+				fmt.Fprintf(out, "%s %d plain text passwords: %v\n", verb, len(report.hashed), report.hashed)
+				fmt.Fprintf(out, "%d users have a hash with another cost than %d\n", report.otherCost, bcrypt.DefaultCost)
+				return nil
+			})
+		},
+	}
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "only report what would change")
+	return cmd
+}
+
+type rehashReport struct {
+	hashed    []string
+	otherCost int
+}
+
+func rehashPasswords(ctx context.Context, s *store, dryRun bool) (*rehashReport, error) {
+	users, err := s.users.GetAllUsers(ctx)
+	if err != nil {
+		return nil, err
+	}
+	report := &rehashReport{hashed: []string{}}
+	for _, user := range users {
+		// Accounts created through single sign-on have no password.
+		if user.Password == "" {
+			continue
+		}
+		cost, err := bcrypt.Cost([]byte(user.Password))
+		if err == nil {
+			if cost != bcrypt.DefaultCost {
+				report.otherCost++
+			}
+			continue
+		}
+		report.hashed = append(report.hashed, user.Username)
+		if dryRun {
+			continue
+		}
+		hashed, err := s.passwords.HashPassword(user.Password)
+		if err != nil {
+			return nil, err
+		}
+		// Keeping the old change time keeps the user's sessions.
+		if err := s.users.UpdatePassword(ctx, user.ID, hashed, user.PasswordChangedAt); err != nil {
+			return nil, err
+		}
+	}
+	return report, nil
+}
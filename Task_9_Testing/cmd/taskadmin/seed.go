@@ -0,0 +1,103 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"task_manager/domain"
+	"task_manager/usecases"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/spf13/cobra"
+)
+
+var demoTitles = []string{
+	"Write the quarterly report",
+	"Review open pull requests",
+	"Plan the team offsite",
+	"Update the onboarding guide",
+	"Fix the flaky login test",
+	"Prepare the demo",
+	"Answer support tickets",
+	"Clean up old feature flags",
+}
+
+var demoStatuses = []string{domain.TaskStatusPending, domain.TaskStatusInProgress, domain.TaskStatusDone}
+
+func (a *app) seedCmd() *cobra.Command {
+	var users, tasksPerUser int
+	var password string
+	cmd := &cobra.Command{
+		Use:   "seed",
+		Short: "Add demo users, named demo1, demo2 and so on, with tasks",
+		Long: `Add demo users with tasks for local development and demos. Users that
+already exist are left alone, so running seed again adds nothing.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return a.withStore(cmd, func(ctx context.Context, s *store) error {
+				created, err := seed(ctx, s, a.now(), users, tasksPerUser, password)
+				if err != nil {
+					return err
+				}
+				fmt.Fprintf(cmd.OutOrStdout(), "Created %d users with %d tasks each, password %q\n", created, tasksPerUser, password)
+				return nil
+			})
+		},
+	}
+	cmd.Flags().IntVar(&users, "users", 3, "number of demo users")
+	cmd.Flags().IntVar(&tasksPerUser, "tasks", 5, "tasks per demo user")
+	cmd.Flags().StringVar(&password, "password", "demo-password-1", "password of the demo users")
+	return cmd
+}
+
+// seed returns how many users it created.
+func seed(ctx context.Context, s *store, now time.Time, users, tasksPerUser int, password string) (int, error) {
+	hashed, err := s.passwords.HashPassword(password)
+	if err != nil {
+		return 0, err
+	}
+	created := 0
+	for i := 1; i <= users; i++ {
+		username := fmt.Sprintf("demo%d", i)
+		if err := usecases.ValidateRegistration(domain.User{Username: username, Password: password}); err != nil {
+			return created, err
+		}
+		existing, err := s.users.FindUserByUsername(ctx, username)
+		if err != nil {
+			return created, err
+		}
+		if existing != nil {
+			continue
+		}
+
+		user := domain.User{
+			ID:        uuid.New().String(),
+			Username:  username,
+			Password:  hashed,
+			Role:      domain.RoleUser,
+			Email:     username + "@example.com",
+			Verified:  true,
+			CreatedAt: now,
+		}
+		if err := s.users.CreateUser(ctx, user); err != nil {
+			return created, err
+		}
+		created++
+
+		for j := 0; j < tasksPerUser; j++ {
+			n := (i-1)*tasksPerUser + j
+			task := domain.Task{
+				ID:          uuid.New().String(),
+				Title:       demoTitles[n%len(demoTitles)],
+				Description: "Demo task created by taskadmin seed.",
+				DueDate:     now.Truncate(24*time.Hour).AddDate(0, 0, n%14+1),
+				Status:      demoStatuses[n%len(demoStatuses)],
+				OwnerID:     user.ID,
+			}
+			if _, err := s.tasks.AddTask(ctx, task); err != nil {
+				return created, err
+			}
+		}
+	}
+	return created, nil
+}
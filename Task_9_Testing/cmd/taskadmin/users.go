@@ -0,0 +1,83 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"task_manager/domain"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+)
+
+func (a *app) usersCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "users",
+		Short: "List and promote users",
+	}
+	cmd.AddCommand(a.usersListCmd(), a.usersPromoteCmd())
+	return cmd
+}
+
+func (a *app) usersListCmd() *cobra.Command {
+	query := domain.UserQuery{Page: 1, PerPage: 50}
+	var disabled bool
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "List users, sorted by username",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if cmd.Flags().Changed("disabled") {
+				query.Disabled = &disabled
+			}
+			if query.Page < 1 || query.PerPage < 1 {
+				return fmt.Errorf("--page and --per-page must be at least 1")
+			}
+			return a.withStore(cmd, func(ctx context.Context, s *store) error {
+				users, total, err := s.users.ListUsers(ctx, query)
+				if err != nil {
+					return err
+				}
+				w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 4, 2, ' ', 0)
+				fmt.Fprintln(w, "ID\tUSERNAME\tROLE\tEMAIL\tVERIFIED\tDISABLED")
+				for _, u := range users {
+					fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%t\t%t\n", u.ID, u.Username, u.Role, u.Email, u.Verified, u.Disabled)
+				}
+				if err := w.Flush(); err != nil {
+					return err
+				}
+				fmt.Fprintf(cmd.OutOrStdout(), "%d of %d users\n", len(users), total)
+				return nil
+			})
+		},
+	}
+	cmd.Flags().StringVar(&query.Search, "search", "", "only users whose username or email contains this text")
+	cmd.Flags().StringVar(&query.Role, "role", "", "only users with this role")
+	cmd.Flags().BoolVar(&disabled, "disabled", false, "only disabled users, or with =false only enabled ones")
+	cmd.Flags().IntVar(&query.Page, "page", query.Page, "page number")
+	cmd.Flags().IntVar(&query.PerPage, "per-page", query.PerPage, "users per page")
+	return cmd
+}
+
+func (a *app) usersPromoteCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "promote <username>",
+		Short: "Make a user an admin",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return a.withStore(cmd, func(ctx context.Context, s *store) error {
+				user, err := s.users.FindUserByUsername(ctx, args[0])
+				if err != nil {
+					return err
+				}
+				if user == nil {
+					return domain.ErrUserNotFound
+				}
+				if err := s.users.PromoteUser(ctx, user.Username); err != nil {
+					return err
+				}
+				fmt.Fprintf(cmd.OutOrStdout(), "Promoted %s to admin\n", user.Username)
+				return nil
+			})
+		},
+	}
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"gopkg.in/yaml.v3"
+)
+
+const defaultProfile = "default"
+
+// config is the file taskctl keeps its profiles in. It holds tokens, so it
+// is written readable by its owner only.
+type config struct {
+	Current  string              `yaml:"current,omitempty"`
+	Profiles map[string]*profile `yaml:"profiles,omitempty"`
+
+	path string
+}
+
+// profile is one server and the credentials for it: a session token from
+// taskctl login or an API key.
+type profile struct {
+	Server   string `yaml:"server"`
+	Username string `yaml:"username,omitempty"`
+	Token    string `yaml:"token,omitempty"`
+	APIKey   string `yaml:"api_key,omitempty"`
+}
+
+// configPath is $TASKCTL_CONFIG, or config.yaml in the taskctl directory of
+// the user's configuration directory.
+func configPath() (string, error) {
+	if path := os.Getenv("TASKCTL_CONFIG"); path != "" {
+		return path, nil
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "taskctl", "config.yaml"), nil
+}
+
+// loadConfig returns an empty config when the file does not exist yet.
+func loadConfig(path string) (*config, error) {
+	cfg := &config{Profiles: map[string]*profile{}, path: path}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return cfg, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := yaml.Unmarshal(data, cfg); err != nil {
+		return nil, fmt.Errorf("reading %s: %w", path, err)
+	}
+	if cfg.Profiles == nil {
+		cfg.Profiles = map[string]*profile{}
+	}
+	return cfg, nil
+}
+
+func (c *config) save() error {
+	data, err := yaml.Marshal(c)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(c.path), 0o700); err != nil {
+		return err
+	}
+	// Write and rename, so an interrupted save never leaves half a file.
+	tmp := c.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, c.path)
+}
+
+// profileName picks the --profile flag, then the current profile, then
+// "default".
+func (c *config) profileName(flag string) string {
+	switch {
+	case flag != "":
+		return flag
+	case c.Current != "":
+		return c.Current
+	}
+	return defaultProfile
+}
+
+func (c *config) names() []string {
+	names := make([]string, 0, len(c.Profiles))
+	for name := range c.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// profileTokenStore keeps the session token in a profile, so a token the
+// client replaces is saved for the next run.
+type profileTokenStore struct {
+	cfg     *config
+	profile *profile
+}
+
+func (s *profileTokenStore) Token(ctx context.Context) (string, error) {
+	return s.profile.Token, nil
+}
+
+func (s *profileTokenStore) SetToken(ctx context.Context, token string) error {
+	s.profile.Token = token
+	return s.cfg.save()
+}
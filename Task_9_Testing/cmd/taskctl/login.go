@@ -0,0 +1,160 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"task_manager/client"
+	"task_manager/domain"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+func (a *app) loginCmd() *cobra.Command {
+	var username, apiKey string
+	var passwordStdin bool
+	cmd := &cobra.Command{
+		Use:   "login",
+		Short: "Log in and save the session in a profile",
+		Long: `Log in with a username and password, or save an API key, and make the
+profile current. The password is never saved; when the session token
+expires, log in again.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			path, err := configPath()
+			if err != nil {
+				return err
+			}
+			cfg, err := loadConfig(path)
+			if err != nil {
+				return err
+			}
+			name := cfg.profileName(a.profileFlag)
+			p := cfg.Profiles[name]
+			if p == nil {
+				p = &profile{}
+			}
+			if a.serverFlag != "" && !sameServer(a.serverFlag, p.Server) {
+				// Credentials of the old server are no good on the new one.
+				p.Server, p.Token, p.APIKey = a.serverFlag, "", ""
+			}
+			if p.Server == "" {
+				return errors.New("--server is required for a new profile")
+			}
+			cfg.Profiles[name] = p
+			cfg.Current = name
+
+			in := bufio.NewReader(a.stdin)
+			prompt := cmd.ErrOrStderr()
+			ctx := cmd.Context()
+			if apiKey != "" {
+				p.APIKey, p.Token = apiKey, ""
+			} else {
+				p.APIKey = ""
+				c := a.clientFor(cfg, p)
+				if username == "" {
+					username = p.Username
+				}
+				if username == "" {
+					if username, err = readLine(in, prompt, "Username: "); err != nil {
+						return err
+					}
+				}
+				password, err := a.readPassword(in, prompt, passwordStdin)
+				if err != nil {
+					return err
+				}
+				p.Username = username
+				if err := login(ctx, c, in, prompt, username, password); err != nil {
+					return err
+				}
+			}
+
+			me, err := a.clientFor(cfg, p).Me(ctx)
+			if err != nil {
+				return err
+			}
+			if err := cfg.save(); err != nil {
+				return err
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "Logged in to %s as %s (profile %s)\n", p.Server, me.Username, name)
+			return nil
+		},
+	}
+	cmd.Flags().StringVarP(&username, "username", "u", "", "username (default: the profile's, or prompt)")
+	cmd.Flags().StringVar(&apiKey, "api-key", "", "save this API key instead of logging in with a password")
+	cmd.Flags().BoolVar(&passwordStdin, "password-stdin", false, "read the password from stdin")
+	return cmd
+}
+
+// login asks for a two-factor code when the account needs one. The client
+// saves the token in the profile.
+func login(ctx context.Context, c *client.Client, in *bufio.Reader, prompt io.Writer, username, password string) error {
+	_, err := c.Login(ctx, username, password)
+	var mfaRequired *domain.MFARequiredError
+	if !errors.As(err, &mfaRequired) {
+		return err
+	}
+	code, err := readLine(in, prompt, "Two-factor code: ")
+	if err != nil {
+		return err
+	}
+	_, err = c.CompleteLogin(ctx, mfaRequired.Token, code)
+	return err
+}
+
+func (a *app) readPassword(in *bufio.Reader, prompt io.Writer, fromStdin bool) (string, error) {
+	if f, ok := a.stdin.(*os.File); ok && !fromStdin && term.IsTerminal(int(f.Fd())) {
+		fmt.Fprint(prompt, "Password: ")
+		password, err := term.ReadPassword(int(f.Fd()))
+		fmt.Fprintln(prompt)
+		return string(password), err
+	}
+	if !fromStdin {
+		fmt.Fprint(prompt, "Password: ")
+	}
+	return readLine(in, io.Discard, "")
+}
+
+func readLine(in *bufio.Reader, prompt io.Writer, label string) (string, error) {
+	fmt.Fprint(prompt, label)
+	line, err := in.ReadString('\n')
+	if err != nil && (err != io.EOF || line == "") {
+		return "", fmt.Errorf("reading %s: %w", strings.TrimSuffix(strings.ToLower(label), ": "), err)
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+func (a *app) logoutCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "logout",
+		Short: "Forget the session token and API key of a profile",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			path, err := configPath()
+			if err != nil {
+				return err
+			}
+			cfg, err := loadConfig(path)
+			if err != nil {
+				return err
+			}
+			name := cfg.profileName(a.profileFlag)
+			p, ok := cfg.Profiles[name]
+			if !ok {
+				return fmt.Errorf("no profile %q", name)
+			}
+			p.Token, p.APIKey = "", ""
+			if err := cfg.save(); err != nil {
+				return err
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "Logged out of profile %s\n", name)
+			return nil
+		},
+	}
+}
@@ -0,0 +1,112 @@
+// Command taskctl manages tasks and users from the terminal, through the
+// REST API and the client package.
+//
+//	taskctl login --server https://tasks.example.com --username alice
+//	taskctl tasks list --status pending -o yaml
+//	taskctl tasks add "Write the report" --due 2026-11-01
+//	taskctl tasks done 6650c0ffee
+//	taskctl users promote bob
+//	source <(taskctl completion bash)
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"task_manager/client"
+
+	"github.com/spf13/cobra"
+)
+
+// app holds the global flags and what the commands share.
+type app struct {
+	profileFlag string
+	serverFlag  string
+	output      string
+	stdin       io.Reader
+}
+
+func main() {
+	if err := newRootCmd(os.Stdin).Execute(); err != nil {
+		fmt.Fprintln(os.Stderr, "Error:", hint(err))
+		os.Exit(1)
+	}
+}
+
+func newRootCmd(stdin io.Reader) *cobra.Command {
+	a := &app{stdin: stdin}
+	root := &cobra.Command{
+		Use:           "taskctl",
+		Short:         "Manage tasks and users of a task manager server",
+		SilenceUsage:  true,
+		SilenceErrors: true,
+	}
+	root.PersistentFlags().StringVar(&a.profileFlag, "profile", os.Getenv("TASKCTL_PROFILE"), "config profile to use (default: the current profile)")
+	root.PersistentFlags().StringVar(&a.serverFlag, "server", "", "server URL; login switches the profile to it, other commands refuse a server other than the profile's")
+	root.PersistentFlags().StringVarP(&a.output, "output", "o", outputTable, "output format: table, json or yaml")
+	root.RegisterFlagCompletionFunc("output", cobra.FixedCompletions(outputFormats, cobra.ShellCompDirectiveNoFileComp))
+	root.RegisterFlagCompletionFunc("profile", a.completeProfiles)
+
+	root.AddCommand(a.loginCmd(), a.logoutCmd(), a.tasksCmd(), a.usersCmd())
+	return root
+}
+
+// session loads the profile the command runs against and a client for it.
+func (a *app) session() (*client.Client, error) {
+	path, err := configPath()
+	if err != nil {
+		return nil, err
+	}
+	cfg, err := loadConfig(path)
+	if err != nil {
+		return nil, err
+	}
+	name := cfg.profileName(a.profileFlag)
+	p, ok := cfg.Profiles[name]
+	if !ok {
+		return nil, fmt.Errorf("no profile %q, run taskctl login first", name)
+	}
+	// The profile's token or API key must not go to another server.
+	if a.serverFlag != "" && !sameServer(a.serverFlag, p.Server) {
+		return nil, fmt.Errorf("--server %s is not the server of profile %q (%s), log in to it with taskctl login --server or use another profile", a.serverFlag, name, p.Server)
+	}
+	return a.clientFor(cfg, p), nil
+}
+
+// clientFor keeps tokens the client obtains in p.
+func (a *app) clientFor(cfg *config, p *profile) *client.Client {
+	opts := []client.Option{client.WithUserAgent("taskctl"), client.WithTokenStore(&profileTokenStore{cfg: cfg, profile: p})}
+	if p.APIKey != "" {
+		opts = append(opts, client.WithAPIKey(p.APIKey))
+	}
+	return client.New(p.Server, opts...)
+}
+
+func sameServer(a, b string) bool {
+	return strings.TrimRight(a, "/") == strings.TrimRight(b, "/")
+}
+
+func (a *app) completeProfiles(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	path, err := configPath()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveError
+	}
+	cfg, err := loadConfig(path)
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveError
+	}
+	return cfg.names(), cobra.ShellCompDirectiveNoFileComp
+}
+
+// hint adds what to do next to the errors people run into most.
+func hint(err error) error {
+	switch {
+	case errors.Is(err, client.ErrUnauthorized):
+		return fmt.Errorf("%w (not logged in or the session expired, run taskctl login)", err)
+	case errors.Is(err, client.ErrForbidden):
+		return fmt.Errorf("%w (the account is not allowed to do this)", err)
+	}
+	return err
+}
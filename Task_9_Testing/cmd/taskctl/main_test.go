@@ -0,0 +1,220 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"task_manager/domain"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+	"gopkg.in/yaml.v3"
+)
+
+// fakeAPI answers the few routes taskctl uses, the way the server does.
+type fakeAPI struct {
+	token   string
+	tasks   []domain.Task
+	patches []string
+}
+
+func (f *fakeAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if r.URL.Path == "/v1/login" {
+		var creds struct{ Username, Password string }
+		json.NewDecoder(r.Body).Decode(&creds)
+		if creds.Password != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			io.WriteString(w, `{"error":"invalid credentials"}`)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]string{"token": f.token})
+		return
+	}
+	if r.Header.Get("Authorization") != "Bearer "+f.token {
+		w.WriteHeader(http.StatusUnauthorized)
+		io.WriteString(w, `{"error":"Invalid token"}`)
+		return
+	}
+	switch {
+	case r.Method == http.MethodGet && r.URL.Path == "/v1/me":
+		json.NewEncoder(w).Encode(domain.User{ID: "1", Username: "alice", Role: domain.RoleUser})
+	case r.Method == http.MethodGet && r.URL.Path == "/v1/tasks":
+		json.NewEncoder(w).Encode(map[string]interface{}{"tasks": f.tasks})
+	case r.Method == http.MethodPatch && r.URL.Path == "/v1/tasks/t1":
+		body, _ := io.ReadAll(r.Body)
+		f.patches = append(f.patches, r.Header.Get("Content-Type")+" "+string(body))
+		task := f.tasks[0]
+		task.Status = domain.TaskStatusDone
+		json.NewEncoder(w).Encode(task)
+	case r.Method == http.MethodPost && r.URL.Path == "/v1/promote":
+		w.WriteHeader(http.StatusForbidden)
+		io.WriteString(w, `{"error":"Admin access required"}`)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+type TaskctlTestSuite struct {
+	suite.Suite
+	api        *fakeAPI
+	server     *httptest.Server
+	configPath string
+}
+
+func (s *TaskctlTestSuite) SetupTest() {
+	due := time.Date(2026, 11, 1, 0, 0, 0, 0, time.UTC)
+	s.api = &fakeAPI{token: "session-token", tasks: []domain.Task{
+		{ID: "t1", Title: "Write report", Status: domain.TaskStatusPending, DueDate: due, OwnerID: "1"},
+		{ID: "t2", Title: "Review PR", Description: "the report one", Status: domain.TaskStatusDone, DueDate: due.AddDate(0, 1, 0), OwnerID: "2"},
+	}}
+	s.server = httptest.NewServer(s.api)
+	s.configPath = filepath.Join(s.T().TempDir(), "config.yaml")
+	s.T().Setenv("TASKCTL_CONFIG", s.configPath)
+	s.T().Setenv("TASKCTL_PROFILE", "")
+}
+
+func (s *TaskctlTestSuite) TearDownTest() {
+	s.server.Close()
+}
+
+func (s *TaskctlTestSuite) run(stdin string, args ...string) (string, error) {
+	cmd := newRootCmd(strings.NewReader(stdin))
+	var out bytes.Buffer
+	cmd.SetOut(&out)
+	cmd.SetErr(io.Discard)
+	cmd.SetArgs(args)
+	err := cmd.Execute()
+	return out.String(), err
+}
+
+func (s *TaskctlTestSuite) login() {
+	_, err := s.run("secret\n", "login", "--server", s.server.URL, "--username", "alice", "--password-stdin")
+	s.Require().NoError(err)
+}
+
+func (s *TaskctlTestSuite) TestLoginSavesProfile() {
+	out, err := s.run("alice\nsecret\n", "login", "--server", s.server.URL, "--profile", "work")
+	s.Require().NoError(err)
+	s.Contains(out, "Logged in to "+s.server.URL+" as alice (profile work)")
+
+	cfg, err := loadConfig(s.configPath)
+	s.Require().NoError(err)
+	s.Equal("work", cfg.Current)
+	s.Equal(&profile{Server: s.server.URL, Username: "alice", Token: "session-token"}, cfg.Profiles["work"])
+
+	info, err := os.Stat(s.configPath)
+	s.Require().NoError(err)
+	s.Equal(os.FileMode(0o600), info.Mode().Perm(), "The config holds tokens")
+
+	data, err := os.ReadFile(s.configPath)
+	s.Require().NoError(err)
+	s.NotContains(string(data), "secret", "The password is never saved")
+}
+
+func (s *TaskctlTestSuite) TestLoginWrongPassword() {
+	_, err := s.run("wrong\n", "login", "--server", s.server.URL, "--username", "alice", "--password-stdin")
+	s.Error(err)
+	_, statErr := os.Stat(s.configPath)
+	s.True(errors.Is(statErr, os.ErrNotExist))
+}
+
+func (s *TaskctlTestSuite) TestServerFlagKeepsCredentialsHome() {
+	s.login()
+	var authorizations []string
+	other := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorizations = append(authorizations, r.Header.Get("Authorization"))
+	}))
+	defer other.Close()
+
+	_, err := s.run("", "--server", other.URL, "tasks", "list")
+	s.ErrorContains(err, "is not the server of profile")
+	s.Empty(authorizations, "No request may carry the profile's token to another server")
+
+	_, err = s.run("", "--server", s.server.URL+"/", "tasks", "list")
+	s.NoError(err, "The profile's own server is fine")
+}
+
+func (s *TaskctlTestSuite) TestListTable() {
+	s.login()
+	out, err := s.run("", "tasks", "list")
+	s.Require().NoError(err)
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	s.Require().Len(lines, 3)
+	s.Regexp(`^ID\s+TITLE\s+STATUS\s+DUE\s+OWNER$`, lines[0])
+	s.Regexp(`^t1\s+Write report\s+pending\s+2026-11-01\s+1$`, lines[1])
+}
+
+func (s *TaskctlTestSuite) TestListFiltersJSON() {
+	s.login()
+	out, err := s.run("", "tasks", "list", "--search", "REPORT", "--due-after", "2026-11-15", "-o", "json")
+	s.Require().NoError(err)
+	var tasks []domain.Task
+	s.Require().NoError(json.Unmarshal([]byte(out), &tasks))
+	s.Require().Len(tasks, 1)
+	s.Equal("t2", tasks[0].ID)
+}
+
+func (s *TaskctlTestSuite) TestListYAML() {
+	s.login()
+	out, err := s.run("", "tasks", "list", "--status", domain.TaskStatusPending, "-o", "yaml")
+	s.Require().NoError(err)
+	var tasks []map[string]interface{}
+	s.Require().NoError(yaml.Unmarshal([]byte(out), &tasks))
+	s.Require().Len(tasks, 1)
+	s.Equal("t1", tasks[0]["id"])
+	s.Equal("2026-11-01T00:00:00Z", tasks[0]["due_date"], "YAML uses the field names of the API")
+}
+
+func (s *TaskctlTestSuite) TestDone() {
+	s.login()
+	out, err := s.run("", "tasks", "done", "t1")
+	s.Require().NoError(err)
+	s.Equal([]string{domain.MergePatchMediaType + ` {"status":"done"}`}, s.api.patches)
+	s.Regexp(`Status:\s+done`, out)
+}
+
+func (s *TaskctlTestSuite) TestPromoteForbidden() {
+	s.login()
+	_, err := s.run("", "users", "promote", "bob")
+	s.Require().Error(err)
+	s.Equal("Admin access required (the account is not allowed to do this)", hint(err).Error())
+}
+
+func (s *TaskctlTestSuite) TestNotLoggedIn() {
+	_, err := s.run("", "tasks", "list")
+	s.EqualError(err, `no profile "default", run taskctl login first`)
+}
+
+func (s *TaskctlTestSuite) TestLogout() {
+	s.login()
+	_, err := s.run("", "logout")
+	s.Require().NoError(err)
+	_, err = s.run("", "tasks", "list")
+	s.Contains(hint(err).Error(), "run taskctl login")
+}
+
+func (s *TaskctlTestSuite) TestCompleteTaskIDs() {
+	s.login()
+	out, err := s.run("", "__complete", "tasks", "done", "t")
+	s.Require().NoError(err)
+	s.Contains(out, "t1\tWrite report")
+	s.Contains(out, "t2\tReview PR")
+}
+
+func (s *TaskctlTestSuite) TestUnknownOutput() {
+	s.login()
+	_, err := s.run("", "tasks", "list", "-o", "xml")
+	s.ErrorContains(err, `unknown output format "xml"`)
+}
+
+func TestTaskctlTestSuite(t *testing.T) {
+	suite.Run(t, new(TaskctlTestSuite))
+}
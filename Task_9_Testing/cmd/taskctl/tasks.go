@@ -0,0 +1,249 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"task_manager/domain"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var taskStatuses = []string{domain.TaskStatusPending, domain.TaskStatusInProgress, domain.TaskStatusDone}
+
+func (a *app) tasksCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "tasks",
+		Aliases: []string{"task"},
+		Short:   "List, add, complete and delete tasks",
+	}
+	cmd.AddCommand(a.tasksListCmd(), a.tasksGetCmd(), a.tasksAddCmd(), a.tasksDoneCmd(), a.tasksDeleteCmd())
+	return cmd
+}
+
+func (a *app) tasksListCmd() *cobra.Command {
+	var filter domain.TaskFilter
+	var owner, dueAfter, dueBefore string
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "List tasks, optionally filtered",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if owner != "" {
+				filter.OwnerIDs = strings.Split(owner, ",")
+			}
+			for _, bound := range []struct {
+				flag string
+				dst  **time.Time
+			}{{dueAfter, &filter.DueAfter}, {dueBefore, &filter.DueBefore}} {
+				if bound.flag == "" {
+					continue
+				}
+				t, err := parseDate(bound.flag)
+				if err != nil {
+					return err
+				}
+				*bound.dst = &t
+			}
+
+			c, err := a.session()
+			if err != nil {
+				return err
+			}
+			tasks, err := c.ListTasks(cmd.Context())
+			if err != nil {
+				return err
+			}
+			tasks = filterTasks(tasks, filter)
+			return render(cmd.OutOrStdout(), a.output, tasks, func(w io.Writer) {
+				fmt.Fprintln(w, "ID\tTITLE\tSTATUS\tDUE\tOWNER")
+				for _, task := range tasks {
+					fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", task.ID, task.Title, task.Status, formatDate(task.DueDate), orDash(task.OwnerID))
+				}
+			})
+		},
+	}
+	cmd.Flags().StringVar(&filter.Status, "status", "", "only tasks with this status")
+	cmd.Flags().StringVar(&owner, "owner", "", "only tasks of these owner ids, comma separated")
+	cmd.Flags().StringVar(&dueAfter, "due-after", "", "only tasks due at or after this date")
+	cmd.Flags().StringVar(&dueBefore, "due-before", "", "only tasks due before this date")
+	cmd.Flags().StringVar(&filter.Search, "search", "", "only tasks whose title or description contains this text")
+	cmd.RegisterFlagCompletionFunc("status", cobra.FixedCompletions(taskStatuses, cobra.ShellCompDirectiveNoFileComp))
+	return cmd
+}
+
+// filterTasks applies filter the way FindTasks does on the server. The REST
+// list has no query parameters, so taskctl filters what it gets back.
+func filterTasks(tasks []domain.Task, filter domain.TaskFilter) []domain.Task {
+	search := strings.ToLower(filter.Search)
+	var matched []domain.Task
+	for _, task := range tasks {
+		switch {
+		case filter.Status != "" && task.Status != filter.Status:
+		case filter.OwnerIDs != nil && !contains(filter.OwnerIDs, task.OwnerID):
+		case filter.DueAfter != nil && task.DueDate.Before(*filter.DueAfter):
+		case filter.DueBefore != nil && !task.DueDate.Before(*filter.DueBefore):
+		case search != "" && !strings.Contains(strings.ToLower(task.Title), search) && !strings.Contains(strings.ToLower(task.Description), search):
+		default:
+			matched = append(matched, task)
+		}
+	}
+	if matched == nil {
+		matched = []domain.Task{}
+	}
+	return matched
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func (a *app) tasksGetCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:               "get <id>",
+		Short:             "Show one task",
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: a.completeTaskIDs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			c, err := a.session()
+			if err != nil {
+				return err
+			}
+			task, err := c.GetTask(cmd.Context(), args[0])
+			if err != nil {
+				return err
+			}
+			return a.renderTask(cmd.OutOrStdout(), task)
+		},
+	}
+}
+
+func (a *app) tasksAddCmd() *cobra.Command {
+	var task domain.Task
+	var due string
+	cmd := &cobra.Command{
+		Use:   "add <title>",
+		Short: "Add a task",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			task.Title = args[0]
+			if due != "" {
+				t, err := parseDate(due)
+				if err != nil {
+					return err
+				}
+				task.DueDate = t
+			}
+			c, err := a.session()
+			if err != nil {
+				return err
+			}
+			id, err := c.CreateTask(cmd.Context(), task)
+			if err != nil {
+				return err
+			}
+			task.ID = id
+			return render(cmd.OutOrStdout(), a.output, task, func(w io.Writer) {
+				fmt.Fprintf(w, "Created task %s\n", id)
+			})
+		},
+	}
+	cmd.Flags().StringVarP(&task.Description, "description", "d", "", "task description")
+	cmd.Flags().StringVar(&due, "due", "", "due date, YYYY-MM-DD or RFC 3339")
+	cmd.Flags().StringVar(&task.Status, "status", "", "initial status (default: the server's)")
+	cmd.RegisterFlagCompletionFunc("status", cobra.FixedCompletions(taskStatuses, cobra.ShellCompDirectiveNoFileComp))
+	return cmd
+}
+
+func (a *app) tasksDoneCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:               "done <id>",
+		Short:             "Mark a task done (admins only, like every task update)",
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: a.completeTaskIDs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			c, err := a.session()
+			if err != nil {
+				return err
+			}
+			patch, err := json.Marshal(map[string]string{"status": domain.TaskStatusDone})
+			if err != nil {
+				return err
+			}
+			task, err := c.PatchTask(cmd.Context(), args[0], domain.MergePatchMediaType, patch)
+			if err != nil {
+				return err
+			}
+			return a.renderTask(cmd.OutOrStdout(), task)
+		},
+	}
+}
+
+func (a *app) tasksDeleteCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:               "delete <id>",
+		Aliases:           []string{"rm"},
+		Short:             "Delete a task",
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: a.completeTaskIDs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			c, err := a.session()
+			if err != nil {
+				return err
+			}
+			if err := c.DeleteTask(cmd.Context(), args[0]); err != nil {
+				return err
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "Deleted task %s\n", args[0])
+			return nil
+		},
+	}
+}
+
+func (a *app) renderTask(w io.Writer, task *domain.Task) error {
+	return render(w, a.output, task, func(w io.Writer) {
+		fmt.Fprintf(w, "ID:\t%s\n", task.ID)
+		fmt.Fprintf(w, "Title:\t%s\n", task.Title)
+		fmt.Fprintf(w, "Description:\t%s\n", orDash(task.Description))
+		fmt.Fprintf(w, "Status:\t%s\n", task.Status)
+		fmt.Fprintf(w, "Due:\t%s\n", formatDate(task.DueDate))
+		fmt.Fprintf(w, "Owner:\t%s\n", orDash(task.OwnerID))
+	})
+}
+
+// completeTaskIDs offers the ids of the caller's tasks, with their titles as
+// descriptions.
+func (a *app) completeTaskIDs(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	c, err := a.session()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveError
+	}
+	tasks, err := c.ListTasks(cmd.Context())
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveError
+	}
+	var ids []string
+	for _, task := range tasks {
+		if strings.HasPrefix(task.ID, toComplete) {
+			ids = append(ids, task.ID+"\t"+task.Title)
+		}
+	}
+	return ids, cobra.ShellCompDirectiveNoFileComp
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
@@ -0,0 +1,32 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+func (a *app) usersCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "users",
+		Aliases: []string{"user"},
+		Short:   "Manage users (admins only)",
+	}
+	cmd.AddCommand(&cobra.Command{
+		Use:   "promote <username>",
+		Short: "Make a user an admin",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			c, err := a.session()
+			if err != nil {
+				return err
+			}
+			if err := c.PromoteUser(cmd.Context(), args[0]); err != nil {
+				return err
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "Promoted %s to admin\n", args[0])
+			return nil
+		},
+	})
+	return cmd
+}
@@ -0,0 +1,69 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"task_manager/domain"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// defaultAPIKeyDays applies when a request does not say when the key expires.
+const defaultAPIKeyDays = 90
+
+type APIKeyController struct {
+	apiKeyUsecase domain.APIKeyUsecase
+}
+
+func NewAPIKeyController(apiKeyUsecase domain.APIKeyUsecase) *APIKeyController {
+	return &APIKeyController{apiKeyUsecase: apiKeyUsecase}
+}
+
+func (ctrl *APIKeyController) CreateAPIKey(c *gin.Context) {
+	var req struct {
+		Name          string   `json:"name"`
+		Scopes        []string `json:"scopes"`
+		ExpiresInDays int      `json:"expires_in_days"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.ExpiresInDays == 0 {
+		req.ExpiresInDays = defaultAPIKeyDays
+	}
+
+	ttl := time.Duration(req.ExpiresInDays) * 24 * time.Hour
+	key, plain, err := ctrl.apiKeyUsecase.CreateAPIKey(c.Request.Context(), req.Name, req.Scopes, ttl)
+	if respondValidationError(c, err) {
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error creating API key"})
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"key": plain, "api_key": key})
+}
+
+func (ctrl *APIKeyController) ListAPIKeys(c *gin.Context) {
+	keys, err := ctrl.apiKeyUsecase.ListAPIKeys(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error listing API keys"})
+		return
+	}
+	c.JSON(http.StatusOK, keys)
+}
+
+func (ctrl *APIKeyController) RevokeAPIKey(c *gin.Context) {
+	err := ctrl.apiKeyUsecase.RevokeAPIKey(c.Request.Context(), c.Param("id"))
+	if errors.Is(err, domain.ErrAPIKeyNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error revoking API key"})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
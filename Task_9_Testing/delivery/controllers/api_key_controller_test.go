@@ -0,0 +1,128 @@
+package controllers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"task_manager/domain"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+type MockAPIKeyUsecase struct {
+	mock.Mock
+}
+
+func (m *MockAPIKeyUsecase) CreateAPIKey(ctx context.Context, name string, scopes []string, ttl time.Duration) (*domain.APIKey, string, error) {
+	args := m.Called(ctx, name, scopes, ttl)
+	return args.Get(0).(*domain.APIKey), args.String(1), args.Error(2)
+}
+
+func (m *MockAPIKeyUsecase) ListAPIKeys(ctx context.Context) ([]domain.APIKey, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]domain.APIKey), args.Error(1)
+}
+
+func (m *MockAPIKeyUsecase) RevokeAPIKey(ctx context.Context, id string) error {
+	return m.Called(ctx, id).Error(0)
+}
+
+func (m *MockAPIKeyUsecase) Authenticate(ctx context.Context, key string) (*domain.User, *domain.APIKey, error) {
+	args := m.Called(ctx, key)
+	return args.Get(0).(*domain.User), args.Get(1).(*domain.APIKey), args.Error(2)
+}
+
+type APIKeyControllerTestSuite struct {
+	suite.Suite
+	mockUsecase *MockAPIKeyUsecase
+	router      *gin.Engine
+}
+
+func (s *APIKeyControllerTestSuite) SetupTest() {
+	gin.SetMode(gin.TestMode)
+	s.mockUsecase = &MockAPIKeyUsecase{}
+	ctrl := NewAPIKeyController(s.mockUsecase)
+	s.router = gin.New()
+	me := s.router.Group("/me")
+	me.GET("/api-keys", ctrl.ListAPIKeys)
+	me.POST("/api-keys", ctrl.CreateAPIKey)
+	me.DELETE("/api-keys/:id", ctrl.RevokeAPIKey)
+}
+
+func (s *APIKeyControllerTestSuite) TearDownTest() {
+	s.mockUsecase.AssertExpectations(s.T())
+}
+
+func (s *APIKeyControllerTestSuite) TestCreateAPIKey() {
+	scopes := []string{domain.ScopeTasksRead}
+
+	s.Run("DefaultExpiry", func() {
+		key := &domain.APIKey{ID: "k1", Name: "ci", Prefix: "tm_abcdef", KeyHash: "hash", Scopes: scopes}
+		s.mockUsecase.On("CreateAPIKey", mock.Anything, "ci", scopes, 90*24*time.Hour).Return(key, "tm_abcdefsecret", nil).Once()
+
+		req, _ := http.NewRequest("POST", "/me/api-keys", strings.NewReader(`{"name":"ci","scopes":["tasks:read"]}`))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		s.router.ServeHTTP(w, req)
+
+		s.Equal(http.StatusCreated, w.Code)
+		s.Contains(w.Body.String(), `"key":"tm_abcdefsecret"`)
+		s.NotContains(w.Body.String(), "hash", "The stored hash should never be returned")
+	})
+
+	s.Run("InvalidInput", func() {
+		verrs := domain.ValidationErrors{{Field: "name", Message: "is required"}}
+		s.mockUsecase.On("CreateAPIKey", mock.Anything, "", scopes, 24*time.Hour).Return((*domain.APIKey)(nil), "", verrs).Once()
+
+		req, _ := http.NewRequest("POST", "/me/api-keys", strings.NewReader(`{"scopes":["tasks:read"],"expires_in_days":1}`))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		s.router.ServeHTTP(w, req)
+
+		s.Equal(http.StatusUnprocessableEntity, w.Code)
+	})
+}
+
+func (s *APIKeyControllerTestSuite) TestListAPIKeys() {
+	lastUsed := time.Date(2025, 4, 3, 12, 0, 0, 0, time.UTC)
+	keys := []domain.APIKey{{ID: "k1", Name: "ci", LastUsedAt: &lastUsed}}
+	s.mockUsecase.On("ListAPIKeys", mock.Anything).Return(keys, nil).Once()
+
+	req, _ := http.NewRequest("GET", "/me/api-keys", nil)
+	w := httptest.NewRecorder()
+	s.router.ServeHTTP(w, req)
+
+	s.Equal(http.StatusOK, w.Code)
+	s.Contains(w.Body.String(), `"last_used_at":"2025-04-03T12:00:00Z"`)
+}
+
+func (s *APIKeyControllerTestSuite) TestRevokeAPIKey() {
+	s.Run("Success", func() {
+		s.mockUsecase.On("RevokeAPIKey", mock.Anything, "k1").Return(nil).Once()
+
+		req, _ := http.NewRequest("DELETE", "/me/api-keys/k1", nil)
+		w := httptest.NewRecorder()
+		s.router.ServeHTTP(w, req)
+
+		s.Equal(http.StatusNoContent, w.Code)
+	})
+
+	s.Run("NotFound", func() {
+		s.mockUsecase.On("RevokeAPIKey", mock.Anything, "k2").Return(domain.ErrAPIKeyNotFound).Once()
+
+		req, _ := http.NewRequest("DELETE", "/me/api-keys/k2", nil)
+		w := httptest.NewRecorder()
+		s.router.ServeHTTP(w, req)
+
+		s.Equal(http.StatusNotFound, w.Code)
+	})
+}
+
+func TestAPIKeyControllerSuite(t *testing.T) {
+	suite.Run(t, new(APIKeyControllerTestSuite))
+}
@@ -1,12 +1,26 @@
 package controllers
 
 import (
+	"errors"
+	"math"
 	"net/http"
+	"strconv"
 	"task_manager/domain"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
 
+// respondValidationError renders field errors as 422 and reports whether err was one.
+func respondValidationError(c *gin.Context, err error) bool {
+	var verrs domain.ValidationErrors
+	if !errors.As(err, &verrs) {
+		return false
+	}
+	c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "validation failed", "fields": verrs})
+	return true
+}
+
 type TaskController struct {
 	taskUsecase domain.TaskUsecase
 }
@@ -16,7 +30,7 @@ func NewTaskController(taskUsecase domain.TaskUsecase) *TaskController {
 }
 
 func (ctrl *TaskController) GetTasks(c *gin.Context) {
-	tasks, err := ctrl.taskUsecase.GetAllTasks(c)
+	tasks, err := ctrl.taskUsecase.GetAllTasks(c.Request.Context())
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error fetching tasks"})
 		return
@@ -26,7 +40,7 @@ func (ctrl *TaskController) GetTasks(c *gin.Context) {
 
 func (ctrl *TaskController) GetTask(c *gin.Context) {
 	id := c.Param("id")
-	task, err := ctrl.taskUsecase.GetTaskByID(c, id)
+	task, err := ctrl.taskUsecase.GetTaskByID(c.Request.Context(), id)
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"message": "Task not found"})
 		return
@@ -40,7 +54,10 @@ func (ctrl *TaskController) AddTask(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-	id, err := ctrl.taskUsecase.AddTask(c, task)
+	id, err := ctrl.taskUsecase.AddTask(c.Request.Context(), task)
+	if respondValidationError(c, err) {
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -55,16 +72,50 @@ func (ctrl *TaskController) UpdateTask(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-	if err := ctrl.taskUsecase.UpdateTask(c, id, task); err != nil {
+	if err := ctrl.taskUsecase.UpdateTask(c.Request.Context(), id, task); err != nil {
+		if respondValidationError(c, err) {
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error updating task"})
 		return
 	}
 	c.JSON(http.StatusOK, gin.H{"message": "Task updated"})
 }
 
+func (ctrl *TaskController) PatchTask(c *gin.Context) {
+	id := c.Param("id")
+	patch, err := c.GetRawData()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	task, err := ctrl.taskUsecase.PatchTask(c.Request.Context(), id, c.ContentType(), patch)
+	if respondValidationError(c, err) {
+		return
+	}
+	switch {
+	case errors.Is(err, domain.ErrTaskNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"message": "Task not found"})
+		return
+	case errors.Is(err, domain.ErrUnsupportedPatch):
+		c.JSON(http.StatusUnsupportedMediaType, gin.H{"error": err.Error()})
+		return
+	case errors.Is(err, domain.ErrInvalidPatch):
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		return
+	case errors.Is(err, domain.ErrTaskConflict):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error updating task"})
+		return
+	}
+	c.JSON(http.StatusOK, task)
+}
+
 func (ctrl *TaskController) RemoveTask(c *gin.Context) {
 	id := c.Param("id")
-	if err := ctrl.taskUsecase.DeleteTask(c, id); err != nil {
+	if err := ctrl.taskUsecase.DeleteTask(c.Request.Context(), id); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error deleting task"})
 		return
 	}
@@ -80,12 +131,26 @@ func NewUserController(userUsecase domain.UserUsecase) *UserController {
 }
 
 func (ctrl *UserController) Register(c *gin.Context) {
-	var user domain.User
-	if err := c.ShouldBindJSON(&user); err != nil {
+	// A dedicated request type: the password is never bound or rendered
+	// through domain.User, and clients cannot pick their own role.
+	var req struct {
+		Username string `json:"username"`
+		Password string `json:"password"`
+		Email    string `json:"email"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-	if err := ctrl.userUsecase.Register(c, user); err != nil {
+	user := domain.User{Username: req.Username, Password: req.Password, Email: req.Email}
+	if err := ctrl.userUsecase.Register(c.Request.Context(), user); err != nil {
+		if respondValidationError(c, err) {
+			return
+		}
+		if errors.Is(err, domain.ErrUsernameTaken) || errors.Is(err, domain.ErrEmailInUse) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -101,7 +166,23 @@ func (ctrl *UserController) Login(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-	token, err := ctrl.userUsecase.Login(c, creds.Username, creds.Password)
+	token, err := ctrl.userUsecase.Login(c.Request.Context(), creds.Username, creds.Password, c.ClientIP())
+	var blocked *domain.LoginBlockedError
+	if errors.As(err, &blocked) {
+		retryAfter := int(math.Ceil(time.Until(blocked.Until).Seconds()))
+		c.Header("Retry-After", strconv.Itoa(retryAfter))
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": blocked.Error()})
+		return
+	}
+	var mfaRequired *domain.MFARequiredError
+	if errors.As(err, &mfaRequired) {
+		c.JSON(http.StatusOK, gin.H{"mfa_required": true, "mfa_token": mfaRequired.Token})
+		return
+	}
+	if errors.Is(err, domain.ErrEmailNotVerified) || errors.Is(err, domain.ErrAccountDisabled) {
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
 		return
@@ -117,20 +198,33 @@ func (ctrl *UserController) PromoteUser(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-	if err := ctrl.userUsecase.PromoteUser(c, req.Username); err != nil {
+	err := ctrl.userUsecase.PromoteUser(c.Request.Context(), req.Username)
+	if errors.Is(err, domain.ErrUserNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 	c.JSON(http.StatusOK, gin.H{"message": "User promoted to admin"})
 }
 
-// In controllers/user_controller.go
-func (ctrl *UserController) GetAllUsers(c *gin.Context) {
-	users, err := ctrl.userUsecase.GetAllUsers(c)
-	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+func (ctrl *UserController) UnlockLogin(c *gin.Context) {
+	var req struct {
+		Username string `json:"username"`
+		IP       string `json:"ip"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-
-	c.JSON(http.StatusOK, gin.H{"users": users})
-}
\ No newline at end of file
+	if err := ctrl.userUsecase.UnlockLogin(c.Request.Context(), req.Username, req.IP); err != nil {
+		if respondValidationError(c, err) {
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Login unlocked"})
+}
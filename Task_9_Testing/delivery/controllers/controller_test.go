@@ -1,70 +1,22 @@
 package controllers
 
 import (
-	"context"
 	"net/http"
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 	"task_manager/domain"
+	"task_manager/internal/mocks"
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/suite"
 )
 
-type MockTaskUsecase struct {
-	mock.Mock
-}
-
-func (m *MockTaskUsecase) AddTask(ctx context.Context, task domain.Task) (string, error) {
-	args := m.Called(ctx, task)
-	return args.String(0), args.Error(1)
-}
-
-func (m *MockTaskUsecase) GetAllTasks(ctx context.Context) ([]domain.Task, error) {
-	args := m.Called(ctx)
-	return args.Get(0).([]domain.Task), args.Error(1)
-}
-
-func (m *MockTaskUsecase) GetTaskByID(ctx context.Context, id string) (*domain.Task, error) {
-	args := m.Called(ctx, id)
-	return args.Get(0).(*domain.Task), args.Error(1)
-}
-
-func (m *MockTaskUsecase) UpdateTask(ctx context.Context, id string, task domain.Task) error {
-	return m.Called(ctx, id, task).Error(0)
-}
-
-func (m *MockTaskUsecase) DeleteTask(ctx context.Context, id string) error {
-	return m.Called(ctx, id).Error(0)
-}
-
-type MockUserUsecase struct {
-	mock.Mock
-}
-
-func (m *MockUserUsecase) Register(ctx context.Context, user domain.User) error {
-	return m.Called(ctx, user).Error(0)
-}
-
-func (m *MockUserUsecase) Login(ctx context.Context, username, password string) (string, error) {
-	args := m.Called(ctx, username, password)
-	return args.String(0), args.Error(1)
-}
-
-func (m *MockUserUsecase) PromoteUser(ctx context.Context, username string) error {
-	return m.Called(ctx, username).Error(0)
-}
-
-func (m *MockUserUsecase) GetAllUsers(ctx context.Context) ([]*domain.User, error) {
-	args := m.Called(ctx)
-	return args.Get(0).([]*domain.User), args.Error(1)
-}
-
 type ControllerTestSuite struct {
 	suite.Suite
-	mockTaskUsecase *MockTaskUsecase
-	mockUserUsecase *MockUserUsecase
+	mockTaskUsecase *mocks.TaskUsecase
+	mockUserUsecase *mocks.UserUsecase
 	taskController  *TaskController
 	userController  *UserController
 	router          *gin.Engine
@@ -72,8 +24,8 @@ type ControllerTestSuite struct {
 
 func (s *ControllerTestSuite) SetupTest() {
 	gin.SetMode(gin.TestMode)
-	s.mockTaskUsecase = &MockTaskUsecase{}
-	s.mockUserUsecase = &MockUserUsecase{}
+	s.mockTaskUsecase = &mocks.TaskUsecase{}
+	s.mockUserUsecase = &mocks.UserUsecase{}
 	s.taskController = NewTaskController(s.mockTaskUsecase)
 	s.userController = NewUserController(s.mockUserUsecase)
 	s.router = gin.New()
@@ -82,11 +34,12 @@ func (s *ControllerTestSuite) SetupTest() {
 	s.router.GET("/tasks", s.taskController.GetTasks)
 	s.router.GET("/tasks/:id", s.taskController.GetTask)
 	s.router.PUT("/tasks/:id", s.taskController.UpdateTask)
+	s.router.PATCH("/tasks/:id", s.taskController.PatchTask)
 	s.router.DELETE("/tasks/:id", s.taskController.RemoveTask)
 	s.router.POST("/register", s.userController.Register)
 	s.router.POST("/login", s.userController.Login)
 	s.router.PUT("/promote", s.userController.PromoteUser) // Uses JSON body
-	s.router.GET("/users", s.userController.GetAllUsers)
+	s.router.POST("/unlock", s.userController.UnlockLogin)
 }
 
 func (s *ControllerTestSuite) TearDownTest() {
@@ -108,6 +61,19 @@ func (s *ControllerTestSuite) TestAddTask() {
 		s.Contains(w.Body.String(), `"id":"1"`)
 		s.Contains(w.Body.String(), `"message":"Task created"`)
 	})
+
+	s.Run("ValidationFailed", func() {
+		verrs := domain.ValidationErrors{{Field: "title", Message: "is required"}}
+		s.mockTaskUsecase.On("AddTask", mock.Anything, mock.AnythingOfType("domain.Task")).Return("", verrs).Once()
+
+		req, _ := http.NewRequest("POST", "/tasks", strings.NewReader(`{"status":"pending"}`))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		s.router.ServeHTTP(w, req)
+
+		s.Equal(http.StatusUnprocessableEntity, w.Code)
+		s.Contains(w.Body.String(), `"fields":[{"field":"title","message":"is required"}]`)
+	})
 }
 
 func (s *ControllerTestSuite) TestGetTasks() {
@@ -155,6 +121,66 @@ func (s *ControllerTestSuite) TestUpdateTask() {
 	})
 }
 
+func (s *ControllerTestSuite) TestPatchTask() {
+	s.Run("Success", func() {
+		patchJSON := `{"status":"done"}`
+		task := &domain.Task{ID: "1", Title: "Task", Status: "done"}
+		s.mockTaskUsecase.On("PatchTask", mock.Anything, "1", domain.MergePatchMediaType, []byte(patchJSON)).Return(task, nil).Once()
+
+		req, _ := http.NewRequest("PATCH", "/tasks/1", strings.NewReader(patchJSON))
+		req.Header.Set("Content-Type", "application/merge-patch+json; charset=utf-8")
+		w := httptest.NewRecorder()
+		s.router.ServeHTTP(w, req)
+
+		s.Equal(http.StatusOK, w.Code)
+		s.Contains(w.Body.String(), `"status":"done"`)
+	})
+
+	s.Run("UnsupportedMediaType", func() {
+		s.mockTaskUsecase.On("PatchTask", mock.Anything, "1", "application/json", mock.Anything).Return((*domain.Task)(nil), domain.ErrUnsupportedPatch).Once()
+
+		req, _ := http.NewRequest("PATCH", "/tasks/1", strings.NewReader(`{}`))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		s.router.ServeHTTP(w, req)
+
+		s.Equal(http.StatusUnsupportedMediaType, w.Code)
+	})
+
+	s.Run("InvalidPatch", func() {
+		s.mockTaskUsecase.On("PatchTask", mock.Anything, "1", domain.JSONPatchMediaType, mock.Anything).Return((*domain.Task)(nil), domain.ErrInvalidPatch).Once()
+
+		req, _ := http.NewRequest("PATCH", "/tasks/1", strings.NewReader(`[{"op":"remove","path":"/nope"}]`))
+		req.Header.Set("Content-Type", domain.JSONPatchMediaType)
+		w := httptest.NewRecorder()
+		s.router.ServeHTTP(w, req)
+
+		s.Equal(http.StatusUnprocessableEntity, w.Code)
+	})
+
+	s.Run("NotFound", func() {
+		s.mockTaskUsecase.On("PatchTask", mock.Anything, "9", domain.MergePatchMediaType, mock.Anything).Return((*domain.Task)(nil), domain.ErrTaskNotFound).Once()
+
+		req, _ := http.NewRequest("PATCH", "/tasks/9", strings.NewReader(`{}`))
+		req.Header.Set("Content-Type", domain.MergePatchMediaType)
+		w := httptest.NewRecorder()
+		s.router.ServeHTTP(w, req)
+
+		s.Equal(http.StatusNotFound, w.Code)
+	})
+
+	s.Run("Conflict", func() {
+		s.mockTaskUsecase.On("PatchTask", mock.Anything, "1", domain.MergePatchMediaType, mock.Anything).Return((*domain.Task)(nil), domain.ErrTaskConflict).Once()
+
+		req, _ := http.NewRequest("PATCH", "/tasks/1", strings.NewReader(`{"status":"done"}`))
+		req.Header.Set("Content-Type", domain.MergePatchMediaType)
+		w := httptest.NewRecorder()
+		s.router.ServeHTTP(w, req)
+
+		s.Equal(http.StatusConflict, w.Code)
+	})
+}
+
 func (s *ControllerTestSuite) TestRemoveTask() {
 	s.Run("Success", func() {
 		s.mockTaskUsecase.On("DeleteTask", mock.Anything, "1").Return(nil).Once()
@@ -170,8 +196,10 @@ func (s *ControllerTestSuite) TestRemoveTask() {
 
 func (s *ControllerTestSuite) TestRegister() {
 	s.Run("Success", func() {
-		userJSON := `{"username":"testuser","password":"pass"}`
-		s.mockUserUsecase.On("Register", mock.Anything, mock.AnythingOfType("domain.User")).Return(nil).Once()
+		userJSON := `{"username":"testuser","password":"pass","role":"admin"}`
+		s.mockUserUsecase.On("Register", mock.Anything, mock.MatchedBy(func(u domain.User) bool {
+			return u.Username == "testuser" && u.Password == "pass" && u.Role == ""
+		})).Return(nil).Once()
 
 		req, _ := http.NewRequest("POST", "/register", strings.NewReader(userJSON))
 		req.Header.Set("Content-Type", "application/json")
@@ -181,12 +209,39 @@ func (s *ControllerTestSuite) TestRegister() {
 		s.Equal(http.StatusCreated, w.Code)
 		s.Contains(w.Body.String(), `"message":"User created"`)
 	})
+
+	s.Run("ValidationFailed", func() {
+		verrs := domain.ValidationErrors{{Field: "password", Message: "must be at least 8 characters"}}
+		s.mockUserUsecase.On("Register", mock.Anything, mock.AnythingOfType("domain.User")).Return(verrs).Once()
+
+		req, _ := http.NewRequest("POST", "/register", strings.NewReader(`{"username":"testuser","password":"pass"}`))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		s.router.ServeHTTP(w, req)
+
+		s.Equal(http.StatusUnprocessableEntity, w.Code)
+		s.Contains(w.Body.String(), `"field":"password"`)
+	})
+
+	s.Run("Conflict", func() {
+		for _, err := range []error{domain.ErrUsernameTaken, domain.ErrEmailInUse} {
+			s.mockUserUsecase.On("Register", mock.Anything, mock.AnythingOfType("domain.User")).Return(err).Once()
+
+			req, _ := http.NewRequest("POST", "/register", strings.NewReader(`{"username":"testuser","password":"password1"}`))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			s.router.ServeHTTP(w, req)
+
+			s.Equal(http.StatusConflict, w.Code)
+			s.Contains(w.Body.String(), err.Error())
+		}
+	})
 }
 
 func (s *ControllerTestSuite) TestLogin() {
 	s.Run("Success", func() {
 		credsJSON := `{"username":"testuser","password":"pass"}`
-		s.mockUserUsecase.On("Login", mock.Anything, "testuser", "pass").Return("token", nil).Once()
+		s.mockUserUsecase.On("Login", mock.Anything, "testuser", "pass", mock.AnythingOfType("string")).Return("token", nil).Once()
 
 		req, _ := http.NewRequest("POST", "/login", strings.NewReader(credsJSON))
 		req.Header.Set("Content-Type", "application/json")
@@ -196,6 +251,75 @@ func (s *ControllerTestSuite) TestLogin() {
 		s.Equal(http.StatusOK, w.Code)
 		s.Contains(w.Body.String(), `"token":"token"`)
 	})
+
+	s.Run("Blocked", func() {
+		credsJSON := `{"username":"testuser","password":"pass"}`
+		blocked := &domain.LoginBlockedError{Until: time.Now().Add(10 * time.Second), Locked: true}
+		s.mockUserUsecase.On("Login", mock.Anything, "testuser", "pass", mock.AnythingOfType("string")).Return("", blocked).Once()
+
+		req, _ := http.NewRequest("POST", "/login", strings.NewReader(credsJSON))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		s.router.ServeHTTP(w, req)
+
+		s.Equal(http.StatusTooManyRequests, w.Code)
+		s.Equal("10", w.Header().Get("Retry-After"))
+		s.Contains(w.Body.String(), "temporarily locked")
+	})
+}
+
+func (s *ControllerTestSuite) TestLoginUnverified() {
+	s.Run("Forbidden", func() {
+		s.mockUserUsecase.On("Login", mock.Anything, "testuser", "pass", mock.AnythingOfType("string")).Return("", domain.ErrEmailNotVerified).Once()
+
+		req, _ := http.NewRequest("POST", "/login", strings.NewReader(`{"username":"testuser","password":"pass"}`))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		s.router.ServeHTTP(w, req)
+
+		s.Equal(http.StatusForbidden, w.Code)
+		s.Contains(w.Body.String(), "not verified")
+	})
+}
+
+func (s *ControllerTestSuite) TestLoginDisabled() {
+	s.mockUserUsecase.On("Login", mock.Anything, "testuser", "pass", mock.AnythingOfType("string")).Return("", domain.ErrAccountDisabled).Once()
+
+	req, _ := http.NewRequest("POST", "/login", strings.NewReader(`{"username":"testuser","password":"pass"}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	s.router.ServeHTTP(w, req)
+
+	s.Equal(http.StatusForbidden, w.Code)
+	s.Contains(w.Body.String(), "disabled")
+}
+
+func (s *ControllerTestSuite) TestLoginMFARequired() {
+	s.Run("ReturnsChallenge", func() {
+		s.mockUserUsecase.On("Login", mock.Anything, "testuser", "pass", mock.AnythingOfType("string")).Return("", &domain.MFARequiredError{Token: "mfa-token"}).Once()
+
+		req, _ := http.NewRequest("POST", "/login", strings.NewReader(`{"username":"testuser","password":"pass"}`))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		s.router.ServeHTTP(w, req)
+
+		s.Equal(http.StatusOK, w.Code)
+		s.JSONEq(`{"mfa_required":true,"mfa_token":"mfa-token"}`, w.Body.String())
+	})
+}
+
+func (s *ControllerTestSuite) TestUnlockLogin() {
+	s.Run("Success", func() {
+		s.mockUserUsecase.On("UnlockLogin", mock.Anything, "testuser", "").Return(nil).Once()
+
+		req, _ := http.NewRequest("POST", "/unlock", strings.NewReader(`{"username":"testuser"}`))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		s.router.ServeHTTP(w, req)
+
+		s.Equal(http.StatusOK, w.Code)
+		s.Contains(w.Body.String(), `"message":"Login unlocked"`)
+	})
 }
 
 func (s *ControllerTestSuite) TestPromoteUser() {
@@ -211,22 +335,19 @@ func (s *ControllerTestSuite) TestPromoteUser() {
 		s.Equal(http.StatusOK, w.Code)
 		s.Contains(w.Body.String(), `"message":"User promoted to admin"`)
 	})
-}
 
-func (s *ControllerTestSuite) TestGetAllUsers() {
-	s.Run("Success", func() {
-		users := []*domain.User{{ID: "1", Username: "user1"}}
-		s.mockUserUsecase.On("GetAllUsers", mock.Anything).Return(users, nil).Once()
+	s.Run("UnknownUser", func() {
+		s.mockUserUsecase.On("PromoteUser", mock.Anything, "nobody").Return(domain.ErrUserNotFound).Once()
 
-		req, _ := http.NewRequest("GET", "/users", nil)
+		req, _ := http.NewRequest("PUT", "/promote", strings.NewReader(`{"username":"nobody"}`))
+		req.Header.Set("Content-Type", "application/json")
 		w := httptest.NewRecorder()
 		s.router.ServeHTTP(w, req)
 
-		s.Equal(http.StatusOK, w.Code)
-		s.Contains(w.Body.String(), `"username":"user1"`)
+		s.Equal(http.StatusNotFound, w.Code)
 	})
 }
 
 func TestControllerSuite(t *testing.T) {
 	suite.Run(t, new(ControllerTestSuite))
-}
\ No newline at end of file
+}
@@ -0,0 +1,23 @@
+package controllers
+
+import (
+	"net/http"
+	"task_manager/domain"
+
+	"github.com/gin-gonic/gin"
+)
+
+type JWKSController struct {
+	jwtSvc domain.JWTService
+}
+
+func NewJWKSController(jwtSvc domain.JWTService) *JWKSController {
+	return &JWKSController{jwtSvc: jwtSvc}
+}
+
+// GetJWKS publishes the token verification keys. Verifiers may cache the set
+// but should fetch it again when they meet a kid they do not know.
+func (ctrl *JWKSController) GetJWKS(c *gin.Context) {
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, ctrl.jwtSvc.JWKS())
+}
@@ -0,0 +1,43 @@
+package controllers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"task_manager/domain"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+)
+
+type stubJWTService struct {
+	jwks domain.JSONWebKeySet
+}
+
+func (s *stubJWTService) GenerateToken(userID, username, role string, amr ...string) (string, error) {
+	return "", nil
+}
+
+func (s *stubJWTService) ValidateToken(tokenString string) (*domain.Claims, error) {
+	return nil, jwt.ErrTokenMalformed
+}
+
+func (s *stubJWTService) JWKS() domain.JSONWebKeySet {
+	return s.jwks
+}
+
+func TestGetJWKS(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	jwks := domain.JSONWebKeySet{Keys: []domain.JSONWebKey{{Kty: "OKP", Kid: "k1", Use: "sig", Alg: "EdDSA", Crv: "Ed25519", X: "abc"}}}
+	router := gin.New()
+	router.GET("/.well-known/jwks.json", NewJWKSController(&stubJWTService{jwks: jwks}).GetJWKS)
+
+	req, _ := http.NewRequest("GET", "/.well-known/jwks.json", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "public, max-age=300", w.Header().Get("Cache-Control"))
+	assert.JSONEq(t, `{"keys":[{"kty":"OKP","kid":"k1","use":"sig","alg":"EdDSA","crv":"Ed25519","x":"abc"}]}`, w.Body.String())
+}
@@ -0,0 +1,112 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"task_manager/domain"
+
+	"github.com/gin-gonic/gin"
+)
+
+type MFAController struct {
+	mfaUsecase domain.MFAUsecase
+}
+
+func NewMFAController(mfaUsecase domain.MFAUsecase) *MFAController {
+	return &MFAController{mfaUsecase: mfaUsecase}
+}
+
+// respondMFAError maps the two-factor errors shared by several handlers.
+func respondMFAError(c *gin.Context, err error) {
+	var blocked *domain.LoginBlockedError
+	switch {
+	case errors.As(err, &blocked):
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": blocked.Error()})
+	case errors.Is(err, domain.ErrInvalidMFACode), errors.Is(err, domain.ErrInvalidMFAToken):
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+	case errors.Is(err, domain.ErrMFAAlreadyEnabled), errors.Is(err, domain.ErrMFANotEnrolled):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, domain.ErrAccountDisabled):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error processing two-factor request"})
+	}
+}
+
+func (ctrl *MFAController) EnrollTOTP(c *gin.Context) {
+	enrollment, err := ctrl.mfaUsecase.EnrollTOTP(c.Request.Context())
+	if err != nil {
+		respondMFAError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, enrollment)
+}
+
+func (ctrl *MFAController) ConfirmTOTP(c *gin.Context) {
+	var req struct {
+		Code string `json:"code"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := ctrl.mfaUsecase.ConfirmTOTP(c.Request.Context(), req.Code); err != nil {
+		respondMFAError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Two-factor authentication enabled"})
+}
+
+func (ctrl *MFAController) DisableTOTP(c *gin.Context) {
+	var req struct {
+		Code string `json:"code"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := ctrl.mfaUsecase.DisableTOTP(c.Request.Context(), req.Code); err != nil {
+		respondMFAError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Two-factor authentication disabled"})
+}
+
+func (ctrl *MFAController) CompleteLogin(c *gin.Context) {
+	var req struct {
+		MFAToken string `json:"mfa_token"`
+		Code     string `json:"code"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	token, err := ctrl.mfaUsecase.CompleteLogin(c.Request.Context(), req.MFAToken, req.Code, c.ClientIP())
+	if err != nil {
+		respondMFAError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"token": token})
+}
+
+func (ctrl *MFAController) GetSecuritySettings(c *gin.Context) {
+	settings, err := ctrl.mfaUsecase.GetSecuritySettings(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error loading security settings"})
+		return
+	}
+	c.JSON(http.StatusOK, settings)
+}
+
+func (ctrl *MFAController) UpdateSecuritySettings(c *gin.Context) {
+	var settings domain.SecuritySettings
+	if err := c.ShouldBindJSON(&settings); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := ctrl.mfaUsecase.UpdateSecuritySettings(c.Request.Context(), settings); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error saving security settings"})
+		return
+	}
+	c.JSON(http.StatusOK, settings)
+}
@@ -0,0 +1,126 @@
+package controllers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"task_manager/domain"
+	"task_manager/internal/mocks"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+type MFAControllerTestSuite struct {
+	suite.Suite
+	mockUsecase *mocks.MFAUsecase
+	router      *gin.Engine
+}
+
+func (s *MFAControllerTestSuite) SetupTest() {
+	gin.SetMode(gin.TestMode)
+	s.mockUsecase = &mocks.MFAUsecase{}
+	ctrl := NewMFAController(s.mockUsecase)
+	s.router = gin.New()
+	me := s.router.Group("/me")
+	me.POST("/2fa/enroll", ctrl.EnrollTOTP)
+	me.POST("/2fa/confirm", ctrl.ConfirmTOTP)
+	me.POST("/2fa/disable", ctrl.DisableTOTP)
+	s.router.POST("/login/2fa", ctrl.CompleteLogin)
+	s.router.GET("/settings/security", ctrl.GetSecuritySettings)
+	s.router.PUT("/settings/security", ctrl.UpdateSecuritySettings)
+}
+
+func (s *MFAControllerTestSuite) TearDownTest() {
+	s.mockUsecase.AssertExpectations(s.T())
+}
+
+func (s *MFAControllerTestSuite) post(path, body string) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest("POST", path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	s.router.ServeHTTP(w, req)
+	return w
+}
+
+func (s *MFAControllerTestSuite) TestEnrollTOTP() {
+	s.Run("Success", func() {
+		enrollment := &domain.TOTPEnrollment{Secret: "SECRET", URI: "otpauth://totp/x", RecoveryCodes: []string{"abcde-fghij"}}
+		s.mockUsecase.On("EnrollTOTP", mock.Anything).Return(enrollment, nil).Once()
+
+		w := s.post("/me/2fa/enroll", "")
+		s.Equal(http.StatusOK, w.Code)
+		s.Contains(w.Body.String(), `"otpauth_uri":"otpauth://totp/x"`)
+	})
+
+	s.Run("AlreadyEnabled", func() {
+		s.mockUsecase.On("EnrollTOTP", mock.Anything).Return((*domain.TOTPEnrollment)(nil), domain.ErrMFAAlreadyEnabled).Once()
+
+		w := s.post("/me/2fa/enroll", "")
+		s.Equal(http.StatusConflict, w.Code)
+	})
+}
+
+func (s *MFAControllerTestSuite) TestConfirmTOTP() {
+	s.Run("Success", func() {
+		s.mockUsecase.On("ConfirmTOTP", mock.Anything, "123456").Return(nil).Once()
+
+		w := s.post("/me/2fa/confirm", `{"code":"123456"}`)
+		s.Equal(http.StatusOK, w.Code)
+	})
+
+	s.Run("WrongCode", func() {
+		s.mockUsecase.On("ConfirmTOTP", mock.Anything, "000000").Return(domain.ErrInvalidMFACode).Once()
+
+		w := s.post("/me/2fa/confirm", `{"code":"000000"}`)
+		s.Equal(http.StatusUnauthorized, w.Code)
+	})
+}
+
+func (s *MFAControllerTestSuite) TestDisableTOTP() {
+	s.mockUsecase.On("DisableTOTP", mock.Anything, "abcde-fghij").Return(nil).Once()
+
+	w := s.post("/me/2fa/disable", `{"code":"abcde-fghij"}`)
+	s.Equal(http.StatusOK, w.Code)
+}
+
+func (s *MFAControllerTestSuite) TestCompleteLogin() {
+	s.Run("Success", func() {
+		s.mockUsecase.On("CompleteLogin", mock.Anything, "mfa-token", "123456", mock.AnythingOfType("string")).Return("token", nil).Once()
+
+		w := s.post("/login/2fa", `{"mfa_token":"mfa-token","code":"123456"}`)
+		s.Equal(http.StatusOK, w.Code)
+		s.Contains(w.Body.String(), `"token":"token"`)
+	})
+
+	s.Run("ExpiredChallenge", func() {
+		s.mockUsecase.On("CompleteLogin", mock.Anything, "old", "123456", mock.AnythingOfType("string")).Return("", domain.ErrInvalidMFAToken).Once()
+
+		w := s.post("/login/2fa", `{"mfa_token":"old","code":"123456"}`)
+		s.Equal(http.StatusUnauthorized, w.Code)
+	})
+}
+
+func (s *MFAControllerTestSuite) TestSecuritySettings() {
+	settings := domain.SecuritySettings{RequireAdminMFA: true}
+	s.mockUsecase.On("UpdateSecuritySettings", mock.Anything, settings).Return(nil).Once()
+	s.mockUsecase.On("GetSecuritySettings", mock.Anything).Return(&settings, nil).Once()
+
+	req, _ := http.NewRequest("PUT", "/settings/security", strings.NewReader(`{"require_admin_mfa":true}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	s.router.ServeHTTP(w, req)
+	s.Equal(http.StatusOK, w.Code)
+
+	req, _ = http.NewRequest("GET", "/settings/security", nil)
+	w = httptest.NewRecorder()
+	s.router.ServeHTTP(w, req)
+	s.Equal(http.StatusOK, w.Code)
+	s.Contains(w.Body.String(), `"require_admin_mfa":true`)
+}
+
+func TestMFAControllerSuite(t *testing.T) {
+	suite.Run(t, new(MFAControllerTestSuite))
+}
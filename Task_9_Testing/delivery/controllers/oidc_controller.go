@@ -0,0 +1,73 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"task_manager/domain"
+
+	"github.com/gin-gonic/gin"
+)
+
+// The state, nonce and PKCE verifier of a login in progress travel in this
+// cookie, which ties the callback to the browser that started the login.
+const (
+	oidcCookieName   = "oidc_login"
+	oidcCookiePath   = "/auth/oidc"
+	oidcCookieMaxAge = 600
+)
+
+type OIDCController struct {
+	oidcUsecase   domain.OIDCUsecase
+	secureCookies bool
+}
+
+// NewOIDCController takes secureCookies false only for plain-HTTP local runs.
+func NewOIDCController(oidcUsecase domain.OIDCUsecase, secureCookies bool) *OIDCController {
+	return &OIDCController{oidcUsecase: oidcUsecase, secureCookies: secureCookies}
+}
+
+func (ctrl *OIDCController) Login(c *gin.Context) {
+	req, authURL, err := ctrl.oidcUsecase.BeginLogin(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error starting single sign-on"})
+		return
+	}
+	value := strings.Join([]string{req.State, req.Nonce, req.Verifier}, ".")
+	// Lax, not Strict: the callback is a cross-site redirect from the provider.
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(oidcCookieName, value, oidcCookieMaxAge, oidcCookiePath, "", ctrl.secureCookies, true)
+	c.Redirect(http.StatusFound, authURL)
+}
+
+func (ctrl *OIDCController) Callback(c *gin.Context) {
+	value, _ := c.Cookie(oidcCookieName)
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(oidcCookieName, "", -1, oidcCookiePath, "", ctrl.secureCookies, true)
+
+	if c.Query("error") != "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": domain.ErrOIDCLoginFailed.Error(), "reason": c.Query("error")})
+		return
+	}
+	var req domain.OIDCAuthRequest
+	if parts := strings.Split(value, "."); len(parts) == 3 {
+		req = domain.OIDCAuthRequest{State: parts[0], Nonce: parts[1], Verifier: parts[2]}
+	}
+
+	token, err := ctrl.oidcUsecase.CompleteLogin(c.Request.Context(), req, c.Query("state"), c.Query("code"))
+	var mfaRequired *domain.MFARequiredError
+	switch {
+	case errors.As(err, &mfaRequired):
+		c.JSON(http.StatusOK, gin.H{"mfa_required": true, "mfa_token": mfaRequired.Token})
+	case errors.Is(err, domain.ErrOIDCLoginFailed):
+		c.JSON(http.StatusUnauthorized, gin.H{"error": domain.ErrOIDCLoginFailed.Error()})
+	case errors.Is(err, domain.ErrOIDCEmailConflict):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, domain.ErrAccountDisabled):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error completing single sign-on"})
+	default:
+		c.JSON(http.StatusOK, gin.H{"token": token})
+	}
+}
@@ -0,0 +1,119 @@
+package controllers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"task_manager/domain"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+type MockOIDCUsecase struct {
+	mock.Mock
+}
+
+func (m *MockOIDCUsecase) BeginLogin(ctx context.Context) (*domain.OIDCAuthRequest, string, error) {
+	args := m.Called(ctx)
+	return args.Get(0).(*domain.OIDCAuthRequest), args.String(1), args.Error(2)
+}
+
+func (m *MockOIDCUsecase) CompleteLogin(ctx context.Context, req domain.OIDCAuthRequest, state, code string) (string, error) {
+	args := m.Called(ctx, req, state, code)
+	return args.String(0), args.Error(1)
+}
+
+type OIDCControllerTestSuite struct {
+	suite.Suite
+	mockUsecase *MockOIDCUsecase
+	router      *gin.Engine
+	req         domain.OIDCAuthRequest
+}
+
+func (s *OIDCControllerTestSuite) SetupTest() {
+	gin.SetMode(gin.TestMode)
+	s.mockUsecase = &MockOIDCUsecase{}
+	ctrl := NewOIDCController(s.mockUsecase, true)
+	s.router = gin.New()
+	s.router.GET("/auth/oidc/login", ctrl.Login)
+	s.router.GET("/auth/oidc/callback", ctrl.Callback)
+	s.req = domain.OIDCAuthRequest{State: "state", Nonce: "nonce", Verifier: "verifier"}
+}
+
+func (s *OIDCControllerTestSuite) TearDownTest() {
+	s.mockUsecase.AssertExpectations(s.T())
+}
+
+func (s *OIDCControllerTestSuite) TestLogin() {
+	s.mockUsecase.On("BeginLogin", mock.Anything).Return(&s.req, "https://idp.example.com/authorize?state=state", nil).Once()
+
+	req, _ := http.NewRequest("GET", "/auth/oidc/login", nil)
+	w := httptest.NewRecorder()
+	s.router.ServeHTTP(w, req)
+
+	s.Equal(http.StatusFound, w.Code)
+	s.Equal("https://idp.example.com/authorize?state=state", w.Header().Get("Location"))
+	cookie := w.Result().Cookies()[0]
+	s.Equal("oidc_login", cookie.Name)
+	s.Equal("state.nonce.verifier", cookie.Value)
+	s.Equal("/auth/oidc", cookie.Path)
+	s.True(cookie.HttpOnly)
+	s.True(cookie.Secure)
+	s.Equal(http.SameSiteLaxMode, cookie.SameSite)
+}
+
+func (s *OIDCControllerTestSuite) TestCallback() {
+	callback := func(query string, withCookie bool) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("GET", "/auth/oidc/callback?"+query, nil)
+		if withCookie {
+			req.AddCookie(&http.Cookie{Name: "oidc_login", Value: "state.nonce.verifier"})
+		}
+		w := httptest.NewRecorder()
+		s.router.ServeHTTP(w, req)
+		return w
+	}
+
+	s.Run("Success", func() {
+		s.mockUsecase.On("CompleteLogin", mock.Anything, s.req, "state", "code").Return("token", nil).Once()
+
+		w := callback("state=state&code=code", true)
+		s.Equal(http.StatusOK, w.Code)
+		s.Contains(w.Body.String(), `"token":"token"`)
+		s.Equal(-1, w.Result().Cookies()[0].MaxAge, "The login cookie should be cleared")
+	})
+
+	s.Run("MFARequired", func() {
+		s.mockUsecase.On("CompleteLogin", mock.Anything, s.req, "state", "code").Return("", &domain.MFARequiredError{Token: "challenge"}).Once()
+
+		w := callback("state=state&code=code", true)
+		s.Equal(http.StatusOK, w.Code)
+		s.JSONEq(`{"mfa_required":true,"mfa_token":"challenge"}`, w.Body.String())
+	})
+
+	s.Run("NoCookie", func() {
+		s.mockUsecase.On("CompleteLogin", mock.Anything, domain.OIDCAuthRequest{}, "state", "code").Return("", domain.ErrOIDCLoginFailed).Once()
+
+		w := callback("state=state&code=code", false)
+		s.Equal(http.StatusUnauthorized, w.Code)
+	})
+
+	s.Run("ProviderError", func() {
+		w := callback("error=access_denied&state=state", true)
+		s.Equal(http.StatusUnauthorized, w.Code)
+		s.Contains(w.Body.String(), "access_denied")
+	})
+
+	s.Run("EmailConflict", func() {
+		s.mockUsecase.On("CompleteLogin", mock.Anything, s.req, "state", "code").Return("", domain.ErrOIDCEmailConflict).Once()
+
+		w := callback("state=state&code=code", true)
+		s.Equal(http.StatusConflict, w.Code)
+	})
+}
+
+func TestOIDCControllerSuite(t *testing.T) {
+	suite.Run(t, new(OIDCControllerTestSuite))
+}
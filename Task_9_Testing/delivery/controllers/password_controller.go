@@ -0,0 +1,84 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"task_manager/domain"
+
+	"github.com/gin-gonic/gin"
+)
+
+type PasswordController struct {
+	passwordUsecase domain.PasswordUsecase
+}
+
+func NewPasswordController(passwordUsecase domain.PasswordUsecase) *PasswordController {
+	return &PasswordController{passwordUsecase: passwordUsecase}
+}
+
+func (ctrl *PasswordController) ChangePassword(c *gin.Context) {
+	var req struct {
+		CurrentPassword string `json:"current_password"`
+		NewPassword     string `json:"new_password"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	err := ctrl.passwordUsecase.ChangePassword(c.Request.Context(), req.CurrentPassword, req.NewPassword)
+	if respondValidationError(c, err) {
+		return
+	}
+	if errors.Is(err, domain.ErrIncorrectPassword) {
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error changing password"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Password changed, please log in again"})
+}
+
+func (ctrl *PasswordController) ForgotPassword(c *gin.Context) {
+	var req struct {
+		Email string `json:"email"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	err := ctrl.passwordUsecase.ForgotPassword(c.Request.Context(), req.Email)
+	if respondValidationError(c, err) {
+		return
+	}
+	if err != nil {
+		// Answer the same way as for unknown addresses; only the log shows the failure.
+		domain.Logger(c.Request.Context()).Error("forgot password", "error", err)
+	}
+	c.JSON(http.StatusAccepted, gin.H{"message": "If the address is registered, a reset email is on its way"})
+}
+
+func (ctrl *PasswordController) ResetPassword(c *gin.Context) {
+	var req struct {
+		Token       string `json:"token"`
+		NewPassword string `json:"new_password"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	err := ctrl.passwordUsecase.ResetPassword(c.Request.Context(), req.Token, req.NewPassword)
+	if respondValidationError(c, err) {
+		return
+	}
+	if errors.Is(err, domain.ErrInvalidResetToken) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error resetting password"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Password reset"})
+}
@@ -0,0 +1,106 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"task_manager/domain"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+type MockPasswordUsecase struct {
+	mock.Mock
+}
+
+func (m *MockPasswordUsecase) ChangePassword(ctx context.Context, currentPassword, newPassword string) error {
+	return m.Called(ctx, currentPassword, newPassword).Error(0)
+}
+
+func (m *MockPasswordUsecase) ForgotPassword(ctx context.Context, email string) error {
+	return m.Called(ctx, email).Error(0)
+}
+
+func (m *MockPasswordUsecase) ResetPassword(ctx context.Context, token, newPassword string) error {
+	return m.Called(ctx, token, newPassword).Error(0)
+}
+
+type PasswordControllerTestSuite struct {
+	suite.Suite
+	mockUsecase *MockPasswordUsecase
+	router      *gin.Engine
+}
+
+func (s *PasswordControllerTestSuite) SetupTest() {
+	gin.SetMode(gin.TestMode)
+	s.mockUsecase = &MockPasswordUsecase{}
+	ctrl := NewPasswordController(s.mockUsecase)
+	s.router = gin.New()
+	s.router.POST("/me/password", ctrl.ChangePassword)
+	s.router.POST("/password/forgot", ctrl.ForgotPassword)
+	s.router.POST("/password/reset", ctrl.ResetPassword)
+}
+
+func (s *PasswordControllerTestSuite) TearDownTest() {
+	s.mockUsecase.AssertExpectations(s.T())
+}
+
+func (s *PasswordControllerTestSuite) post(path, body string) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest("POST", path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	s.router.ServeHTTP(w, req)
+	return w
+}
+
+func (s *PasswordControllerTestSuite) TestChangePassword() {
+	s.Run("Success", func() {
+		s.mockUsecase.On("ChangePassword", mock.Anything, "old", "new9password").Return(nil).Once()
+
+		w := s.post("/me/password", `{"current_password":"old","new_password":"new9password"}`)
+		s.Equal(http.StatusOK, w.Code)
+	})
+
+	s.Run("IncorrectPassword", func() {
+		s.mockUsecase.On("ChangePassword", mock.Anything, "bad", "new9password").Return(domain.ErrIncorrectPassword).Once()
+
+		w := s.post("/me/password", `{"current_password":"bad","new_password":"new9password"}`)
+		s.Equal(http.StatusForbidden, w.Code)
+	})
+}
+
+func (s *PasswordControllerTestSuite) TestForgotPassword() {
+	s.Run("AlwaysAccepted", func() {
+		s.mockUsecase.On("ForgotPassword", mock.Anything, "test@example.com").Return(errors.New("smtp down")).Once()
+
+		w := s.post("/password/forgot", `{"email":"test@example.com"}`)
+		s.Equal(http.StatusAccepted, w.Code)
+		s.NotContains(w.Body.String(), "smtp")
+	})
+}
+
+func (s *PasswordControllerTestSuite) TestResetPassword() {
+	s.Run("Success", func() {
+		s.mockUsecase.On("ResetPassword", mock.Anything, "tok", "new9password").Return(nil).Once()
+
+		w := s.post("/password/reset", `{"token":"tok","new_password":"new9password"}`)
+		s.Equal(http.StatusOK, w.Code)
+		s.Contains(w.Body.String(), `"message":"Password reset"`)
+	})
+
+	s.Run("InvalidToken", func() {
+		s.mockUsecase.On("ResetPassword", mock.Anything, "old", "new9password").Return(domain.ErrInvalidResetToken).Once()
+
+		w := s.post("/password/reset", `{"token":"old","new_password":"new9password"}`)
+		s.Equal(http.StatusBadRequest, w.Code)
+	})
+}
+
+func TestPasswordControllerSuite(t *testing.T) {
+	suite.Run(t, new(PasswordControllerTestSuite))
+}
@@ -0,0 +1,47 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"task_manager/domain"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ProfileController struct {
+	profileUsecase domain.ProfileUsecase
+}
+
+func NewProfileController(profileUsecase domain.ProfileUsecase) *ProfileController {
+	return &ProfileController{profileUsecase: profileUsecase}
+}
+
+func (ctrl *ProfileController) GetProfile(c *gin.Context) {
+	user, err := ctrl.profileUsecase.GetProfile(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error fetching profile"})
+		return
+	}
+	c.JSON(http.StatusOK, user)
+}
+
+func (ctrl *ProfileController) UpdateProfile(c *gin.Context) {
+	var update domain.ProfileUpdate
+	if err := c.ShouldBindJSON(&update); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	user, err := ctrl.profileUsecase.UpdateProfile(c.Request.Context(), update)
+	if respondValidationError(c, err) {
+		return
+	}
+	if errors.Is(err, domain.ErrEmailInUse) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error updating profile"})
+		return
+	}
+	c.JSON(http.StatusOK, user)
+}
@@ -0,0 +1,103 @@
+package controllers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"task_manager/domain"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+type MockProfileUsecase struct {
+	mock.Mock
+}
+
+func (m *MockProfileUsecase) GetProfile(ctx context.Context) (*domain.User, error) {
+	args := m.Called(ctx)
+	return args.Get(0).(*domain.User), args.Error(1)
+}
+
+func (m *MockProfileUsecase) UpdateProfile(ctx context.Context, update domain.ProfileUpdate) (*domain.User, error) {
+	args := m.Called(ctx, update)
+	return args.Get(0).(*domain.User), args.Error(1)
+}
+
+type ProfileControllerTestSuite struct {
+	suite.Suite
+	mockUsecase *MockProfileUsecase
+	router      *gin.Engine
+}
+
+func (s *ProfileControllerTestSuite) SetupTest() {
+	gin.SetMode(gin.TestMode)
+	s.mockUsecase = &MockProfileUsecase{}
+	ctrl := NewProfileController(s.mockUsecase)
+	s.router = gin.New()
+	s.router.GET("/me", ctrl.GetProfile)
+	s.router.PATCH("/me", ctrl.UpdateProfile)
+}
+
+func (s *ProfileControllerTestSuite) TearDownTest() {
+	s.mockUsecase.AssertExpectations(s.T())
+}
+
+func (s *ProfileControllerTestSuite) TestGetProfile() {
+	user := &domain.User{ID: "1", Username: "testuser", Password: "hashed", DisplayName: "Test User"}
+	s.mockUsecase.On("GetProfile", mock.Anything).Return(user, nil).Once()
+
+	req, _ := http.NewRequest("GET", "/me", nil)
+	w := httptest.NewRecorder()
+	s.router.ServeHTTP(w, req)
+
+	s.Equal(http.StatusOK, w.Code)
+	s.Contains(w.Body.String(), `"display_name":"Test User"`)
+	s.NotContains(w.Body.String(), "hashed")
+}
+
+func (s *ProfileControllerTestSuite) TestUpdateProfile() {
+	s.Run("Success", func() {
+		s.mockUsecase.On("UpdateProfile", mock.Anything, mock.MatchedBy(func(u domain.ProfileUpdate) bool {
+			return u.Locale != nil && *u.Locale == "pt-BR" && u.DisplayName == nil
+		})).Return(&domain.User{ID: "1", Locale: "pt-BR"}, nil).Once()
+
+		req, _ := http.NewRequest("PATCH", "/me", strings.NewReader(`{"locale":"pt-BR"}`))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		s.router.ServeHTTP(w, req)
+
+		s.Equal(http.StatusOK, w.Code)
+		s.Contains(w.Body.String(), `"locale":"pt-BR"`)
+	})
+
+	s.Run("EmailInUse", func() {
+		s.mockUsecase.On("UpdateProfile", mock.Anything, mock.Anything).Return((*domain.User)(nil), domain.ErrEmailInUse).Once()
+
+		req, _ := http.NewRequest("PATCH", "/me", strings.NewReader(`{"email":"taken@example.com"}`))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		s.router.ServeHTTP(w, req)
+
+		s.Equal(http.StatusConflict, w.Code)
+	})
+
+	s.Run("ValidationFailed", func() {
+		verrs := domain.ValidationErrors{{Field: "time_zone", Message: "must be an IANA time zone such as Europe/Berlin"}}
+		s.mockUsecase.On("UpdateProfile", mock.Anything, mock.Anything).Return((*domain.User)(nil), verrs).Once()
+
+		req, _ := http.NewRequest("PATCH", "/me", strings.NewReader(`{"time_zone":"Mars/Olympus"}`))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		s.router.ServeHTTP(w, req)
+
+		s.Equal(http.StatusUnprocessableEntity, w.Code)
+	})
+}
+
+func TestProfileControllerSuite(t *testing.T) {
+	suite.Run(t, new(ProfileControllerTestSuite))
+}
@@ -0,0 +1,53 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"task_manager/domain"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// csrfCookieName holds a readable copy of the CSRF token, so a browser client
+// that reloads can pick it up again and send it in the X-CSRF-Token header.
+const csrfCookieName = "csrf_token"
+
+type SessionController struct {
+	sessionUsecase domain.SessionUsecase
+	secureCookies  bool
+}
+
+// NewSessionController takes secureCookies false only for plain-HTTP local runs.
+func NewSessionController(sessionUsecase domain.SessionUsecase, secureCookies bool) *SessionController {
+	return &SessionController{sessionUsecase: sessionUsecase, secureCookies: secureCookies}
+}
+
+// StartSession trades the caller's bearer token for a session cookie.
+func (ctrl *SessionController) StartSession(c *gin.Context) {
+	session, token, csrfToken, err := ctrl.sessionUsecase.StartSession(c.Request.Context())
+	if errors.Is(err, domain.ErrSessionExists) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error starting session"})
+		return
+	}
+	maxAge := int(time.Until(session.ExpiresAt).Seconds())
+	c.SetSameSite(http.SameSiteStrictMode)
+	c.SetCookie(domain.SessionCookieName, token, maxAge, "/", "", ctrl.secureCookies, true)
+	c.SetCookie(csrfCookieName, csrfToken, maxAge, "/", "", ctrl.secureCookies, false)
+	c.JSON(http.StatusCreated, gin.H{"csrf_token": csrfToken, "expires_at": session.ExpiresAt})
+}
+
+func (ctrl *SessionController) EndSession(c *gin.Context) {
+	if err := ctrl.sessionUsecase.EndSession(c.Request.Context()); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error ending session"})
+		return
+	}
+	c.SetSameSite(http.SameSiteStrictMode)
+	c.SetCookie(domain.SessionCookieName, "", -1, "/", "", ctrl.secureCookies, true)
+	c.SetCookie(csrfCookieName, "", -1, "/", "", ctrl.secureCookies, false)
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,113 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"task_manager/domain"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+type MockSessionUsecase struct {
+	mock.Mock
+}
+
+func (m *MockSessionUsecase) StartSession(ctx context.Context) (*domain.Session, string, string, error) {
+	args := m.Called(ctx)
+	return args.Get(0).(*domain.Session), args.String(1), args.String(2), args.Error(3)
+}
+
+func (m *MockSessionUsecase) Authenticate(ctx context.Context, token string) (*domain.Session, error) {
+	args := m.Called(ctx, token)
+	return args.Get(0).(*domain.Session), args.Error(1)
+}
+
+func (m *MockSessionUsecase) VerifyCSRF(session *domain.Session, csrfToken string) bool {
+	return m.Called(session, csrfToken).Bool(0)
+}
+
+func (m *MockSessionUsecase) EndSession(ctx context.Context) error {
+	return m.Called(ctx).Error(0)
+}
+
+type SessionControllerTestSuite struct {
+	suite.Suite
+	mockUsecase *MockSessionUsecase
+	router      *gin.Engine
+}
+
+func (s *SessionControllerTestSuite) SetupTest() {
+	gin.SetMode(gin.TestMode)
+	s.mockUsecase = &MockSessionUsecase{}
+	ctrl := NewSessionController(s.mockUsecase, true)
+	s.router = gin.New()
+	s.router.POST("/me/session", ctrl.StartSession)
+	s.router.DELETE("/me/session", ctrl.EndSession)
+}
+
+func (s *SessionControllerTestSuite) TearDownTest() {
+	s.mockUsecase.AssertExpectations(s.T())
+}
+
+func (s *SessionControllerTestSuite) send(method string) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest(method, "/me/session", nil)
+	w := httptest.NewRecorder()
+	s.router.ServeHTTP(w, req)
+	return w
+}
+
+func (s *SessionControllerTestSuite) TestStartSession() {
+	s.Run("Success", func() {
+		session := &domain.Session{ExpiresAt: time.Now().Add(time.Hour)}
+		s.mockUsecase.On("StartSession", mock.Anything).Return(session, "token", "csrf", nil).Once()
+
+		w := s.send("POST")
+		s.Equal(http.StatusCreated, w.Code)
+		s.Contains(w.Body.String(), `"csrf_token":"csrf"`)
+		cookies := w.Result().Cookies()
+		s.Require().Len(cookies, 2)
+		s.Equal(domain.SessionCookieName, cookies[0].Name)
+		s.Equal("token", cookies[0].Value)
+		s.True(cookies[0].HttpOnly)
+		s.True(cookies[0].Secure)
+		s.Equal(http.SameSiteStrictMode, cookies[0].SameSite)
+		s.Equal("csrf_token", cookies[1].Name)
+		s.False(cookies[1].HttpOnly, "Scripts need to read the CSRF token")
+	})
+
+	s.Run("FromSession", func() {
+		s.mockUsecase.On("StartSession", mock.Anything).Return((*domain.Session)(nil), "", "", domain.ErrSessionExists).Once()
+
+		w := s.send("POST")
+		s.Equal(http.StatusConflict, w.Code)
+		s.Empty(w.Result().Cookies())
+	})
+
+	s.Run("Error", func() {
+		s.mockUsecase.On("StartSession", mock.Anything).Return((*domain.Session)(nil), "", "", errors.New("db down")).Once()
+
+		w := s.send("POST")
+		s.Equal(http.StatusInternalServerError, w.Code)
+		s.Empty(w.Result().Cookies())
+	})
+}
+
+func (s *SessionControllerTestSuite) TestEndSession() {
+	s.mockUsecase.On("EndSession", mock.Anything).Return(nil).Once()
+
+	w := s.send("DELETE")
+	s.Equal(http.StatusNoContent, w.Code)
+	for _, cookie := range w.Result().Cookies() {
+		s.Equal(-1, cookie.MaxAge, "Both cookies should be cleared")
+	}
+}
+
+func TestSessionControllerSuite(t *testing.T) {
+	suite.Run(t, new(SessionControllerTestSuite))
+}
@@ -0,0 +1,96 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"task_manager/domain"
+
+	"github.com/gin-gonic/gin"
+)
+
+type UserAdminController struct {
+	userAdminUsecase domain.UserAdminUsecase
+}
+
+func NewUserAdminController(userAdminUsecase domain.UserAdminUsecase) *UserAdminController {
+	return &UserAdminController{userAdminUsecase: userAdminUsecase}
+}
+
+func respondUserAdminError(c *gin.Context, err error) {
+	if respondValidationError(c, err) {
+		return
+	}
+	switch {
+	case errors.Is(err, domain.ErrUserNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, domain.ErrCannotModifySelf):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error managing user"})
+	}
+}
+
+func (ctrl *UserAdminController) ListUsers(c *gin.Context) {
+	var query domain.UserQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	page, err := ctrl.userAdminUsecase.ListUsers(c.Request.Context(), query)
+	if err != nil {
+		respondUserAdminError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, page)
+}
+
+func (ctrl *UserAdminController) GetUser(c *gin.Context) {
+	user, err := ctrl.userAdminUsecase.GetUser(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		respondUserAdminError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, user)
+}
+
+func (ctrl *UserAdminController) Promote(c *gin.Context) {
+	ctrl.setRole(c, domain.RoleAdmin, "User promoted to admin")
+}
+
+func (ctrl *UserAdminController) Demote(c *gin.Context) {
+	ctrl.setRole(c, domain.RoleUser, "User demoted")
+}
+
+func (ctrl *UserAdminController) Disable(c *gin.Context) {
+	ctrl.setDisabled(c, true, "User disabled")
+}
+
+func (ctrl *UserAdminController) Enable(c *gin.Context) {
+	ctrl.setDisabled(c, false, "User enabled")
+}
+
+// DeleteUser hands the user's tasks to the user named by ?reassign_to, or
+// leaves them without an owner when it is absent.
+func (ctrl *UserAdminController) DeleteUser(c *gin.Context) {
+	if err := ctrl.userAdminUsecase.DeleteUser(c.Request.Context(), c.Param("id"), c.Query("reassign_to")); err != nil {
+		respondUserAdminError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+func (ctrl *UserAdminController) setRole(c *gin.Context, role, message string) {
+	if err := ctrl.userAdminUsecase.SetRole(c.Request.Context(), c.Param("id"), role); err != nil {
+		respondUserAdminError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": message})
+}
+
+func (ctrl *UserAdminController) setDisabled(c *gin.Context, disabled bool, message string) {
+	if err := ctrl.userAdminUsecase.SetDisabled(c.Request.Context(), c.Param("id"), disabled); err != nil {
+		respondUserAdminError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": message})
+}
@@ -0,0 +1,120 @@
+package controllers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"task_manager/domain"
+	"task_manager/internal/mocks"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+type UserAdminControllerTestSuite struct {
+	suite.Suite
+	mockUsecase *mocks.UserAdminUsecase
+	router      *gin.Engine
+}
+
+func (s *UserAdminControllerTestSuite) SetupTest() {
+	gin.SetMode(gin.TestMode)
+	s.mockUsecase = &mocks.UserAdminUsecase{}
+	ctrl := NewUserAdminController(s.mockUsecase)
+	s.router = gin.New()
+	s.router.GET("/users", ctrl.ListUsers)
+	s.router.GET("/users/:id", ctrl.GetUser)
+	s.router.POST("/users/:id/demote", ctrl.Demote)
+	s.router.POST("/users/:id/disable", ctrl.Disable)
+	s.router.DELETE("/users/:id", ctrl.DeleteUser)
+}
+
+func (s *UserAdminControllerTestSuite) TearDownTest() {
+	s.mockUsecase.AssertExpectations(s.T())
+}
+
+func (s *UserAdminControllerTestSuite) TestListUsers() {
+	s.Run("Success", func() {
+		disabled := false
+		query := domain.UserQuery{Search: "bo", Role: "user", Disabled: &disabled, Page: 2, PerPage: 10}
+		page := &domain.UserPage{Users: []domain.User{{ID: "2", Username: "bob", Password: "hashed"}}, Page: 2, PerPage: 10, Total: 11}
+		s.mockUsecase.On("ListUsers", mock.Anything, query).Return(page, nil).Once()
+
+		req, _ := http.NewRequest("GET", "/users?q=bo&role=user&disabled=false&page=2&per_page=10", nil)
+		w := httptest.NewRecorder()
+		s.router.ServeHTTP(w, req)
+
+		s.Equal(http.StatusOK, w.Code)
+		s.Contains(w.Body.String(), `"username":"bob"`)
+		s.Contains(w.Body.String(), `"total":11`)
+		s.NotContains(w.Body.String(), "hashed", "Password hashes should never be returned")
+	})
+
+	s.Run("InvalidQuery", func() {
+		verrs := domain.ValidationErrors{{Field: "per_page", Message: "must be between 1 and 100"}}
+		s.mockUsecase.On("ListUsers", mock.Anything, domain.UserQuery{PerPage: 500}).Return((*domain.UserPage)(nil), verrs).Once()
+
+		req, _ := http.NewRequest("GET", "/users?per_page=500", nil)
+		w := httptest.NewRecorder()
+		s.router.ServeHTTP(w, req)
+
+		s.Equal(http.StatusUnprocessableEntity, w.Code)
+	})
+}
+
+func (s *UserAdminControllerTestSuite) TestGetUser() {
+	s.mockUsecase.On("GetUser", mock.Anything, "9").Return((*domain.User)(nil), domain.ErrUserNotFound).Once()
+
+	req, _ := http.NewRequest("GET", "/users/9", nil)
+	w := httptest.NewRecorder()
+	s.router.ServeHTTP(w, req)
+
+	s.Equal(http.StatusNotFound, w.Code)
+}
+
+func (s *UserAdminControllerTestSuite) TestDemote() {
+	s.Run("Success", func() {
+		s.mockUsecase.On("SetRole", mock.Anything, "2", domain.RoleUser).Return(nil).Once()
+
+		req, _ := http.NewRequest("POST", "/users/2/demote", nil)
+		w := httptest.NewRecorder()
+		s.router.ServeHTTP(w, req)
+
+		s.Equal(http.StatusOK, w.Code)
+	})
+
+	s.Run("Self", func() {
+		s.mockUsecase.On("SetRole", mock.Anything, "1", domain.RoleUser).Return(domain.ErrCannotModifySelf).Once()
+
+		req, _ := http.NewRequest("POST", "/users/1/demote", nil)
+		w := httptest.NewRecorder()
+		s.router.ServeHTTP(w, req)
+
+		s.Equal(http.StatusConflict, w.Code)
+	})
+}
+
+func (s *UserAdminControllerTestSuite) TestDisable() {
+	s.mockUsecase.On("SetDisabled", mock.Anything, "2", true).Return(nil).Once()
+
+	req, _ := http.NewRequest("POST", "/users/2/disable", nil)
+	w := httptest.NewRecorder()
+	s.router.ServeHTTP(w, req)
+
+	s.Equal(http.StatusOK, w.Code)
+}
+
+func (s *UserAdminControllerTestSuite) TestDeleteUser() {
+	s.mockUsecase.On("DeleteUser", mock.Anything, "2", "3").Return(nil).Once()
+
+	req, _ := http.NewRequest("DELETE", "/users/2?reassign_to=3", nil)
+	w := httptest.NewRecorder()
+	s.router.ServeHTTP(w, req)
+
+	s.Equal(http.StatusNoContent, w.Code)
+}
+
+func TestUserAdminControllerSuite(t *testing.T) {
+	suite.Run(t, new(UserAdminControllerTestSuite))
+}
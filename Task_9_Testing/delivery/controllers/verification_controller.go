@@ -0,0 +1,48 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"task_manager/domain"
+
+	"github.com/gin-gonic/gin"
+)
+
+type VerificationController struct {
+	verificationUsecase domain.VerificationUsecase
+}
+
+func NewVerificationController(verificationUsecase domain.VerificationUsecase) *VerificationController {
+	return &VerificationController{verificationUsecase: verificationUsecase}
+}
+
+func (ctrl *VerificationController) VerifyEmail(c *gin.Context) {
+	err := ctrl.verificationUsecase.VerifyEmail(c.Request.Context(), c.Query("token"))
+	if errors.Is(err, domain.ErrInvalidVerifyToken) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error verifying email"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Email verified"})
+}
+
+func (ctrl *VerificationController) ResendVerification(c *gin.Context) {
+	var req struct {
+		Email string `json:"email"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	err := ctrl.verificationUsecase.ResendVerification(c.Request.Context(), req.Email)
+	if respondValidationError(c, err) {
+		return
+	}
+	if err != nil {
+		domain.Logger(c.Request.Context()).Error("resend verification", "error", err)
+	}
+	c.JSON(http.StatusAccepted, gin.H{"message": "If the address needs verifying, a new link is on its way"})
+}
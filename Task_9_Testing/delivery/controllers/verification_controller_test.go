@@ -0,0 +1,89 @@
+package controllers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"task_manager/domain"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+type MockVerificationUsecase struct {
+	mock.Mock
+}
+
+func (m *MockVerificationUsecase) SendVerification(ctx context.Context, user domain.User) error {
+	return m.Called(ctx, user).Error(0)
+}
+
+func (m *MockVerificationUsecase) VerifyEmail(ctx context.Context, token string) error {
+	return m.Called(ctx, token).Error(0)
+}
+
+func (m *MockVerificationUsecase) ResendVerification(ctx context.Context, email string) error {
+	return m.Called(ctx, email).Error(0)
+}
+
+type VerificationControllerTestSuite struct {
+	suite.Suite
+	mockUsecase *MockVerificationUsecase
+	router      *gin.Engine
+}
+
+func (s *VerificationControllerTestSuite) SetupTest() {
+	gin.SetMode(gin.TestMode)
+	s.mockUsecase = &MockVerificationUsecase{}
+	ctrl := NewVerificationController(s.mockUsecase)
+	s.router = gin.New()
+	s.router.GET("/verify", ctrl.VerifyEmail)
+	s.router.POST("/verify/resend", ctrl.ResendVerification)
+}
+
+func (s *VerificationControllerTestSuite) TearDownTest() {
+	s.mockUsecase.AssertExpectations(s.T())
+}
+
+func (s *VerificationControllerTestSuite) TestVerifyEmail() {
+	s.Run("Success", func() {
+		s.mockUsecase.On("VerifyEmail", mock.Anything, "tok").Return(nil).Once()
+
+		req, _ := http.NewRequest("GET", "/verify?token=tok", nil)
+		w := httptest.NewRecorder()
+		s.router.ServeHTTP(w, req)
+
+		s.Equal(http.StatusOK, w.Code)
+		s.Contains(w.Body.String(), `"message":"Email verified"`)
+	})
+
+	s.Run("InvalidLink", func() {
+		s.mockUsecase.On("VerifyEmail", mock.Anything, "bad").Return(domain.ErrInvalidVerifyToken).Once()
+
+		req, _ := http.NewRequest("GET", "/verify?token=bad", nil)
+		w := httptest.NewRecorder()
+		s.router.ServeHTTP(w, req)
+
+		s.Equal(http.StatusBadRequest, w.Code)
+	})
+}
+
+func (s *VerificationControllerTestSuite) TestResendVerification() {
+	s.Run("Accepted", func() {
+		s.mockUsecase.On("ResendVerification", mock.Anything, "test@example.com").Return(nil).Once()
+
+		req, _ := http.NewRequest("POST", "/verify/resend", strings.NewReader(`{"email":"test@example.com"}`))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		s.router.ServeHTTP(w, req)
+
+		s.Equal(http.StatusAccepted, w.Code)
+	})
+}
+
+func TestVerificationControllerSuite(t *testing.T) {
+	suite.Run(t, new(VerificationControllerTestSuite))
+}
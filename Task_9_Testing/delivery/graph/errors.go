@@ -0,0 +1,67 @@
+package graph
+
+import (
+	"context"
+	"errors"
+	"task_manager/domain"
+)
+
+// gqlError is a resolver error with a machine readable code in its
+// extensions, next to the message and path graphql-go reports anyway.
+type gqlError struct {
+	message string
+	code    string
+	fields  domain.ValidationErrors
+}
+
+func (e *gqlError) Error() string { return e.message }
+
+func (e *gqlError) Extensions() map[string]interface{} {
+	ext := map[string]interface{}{"code": e.code}
+	if e.fields != nil {
+		ext["fields"] = e.fields
+	}
+	return ext
+}
+
+var (
+	errUnauthenticated = &gqlError{message: "Authorization header required", code: "UNAUTHENTICATED"}
+	errTooManyRequests = &gqlError{message: "Too many requests", code: "TOO_MANY_REQUESTS"}
+)
+
+// forbidden wraps a refusal from infrastructure.CheckAdmin, whose messages
+// are meant for the client. Errors that already carry a code pass through.
+func forbidden(err error) error {
+	var gerr *gqlError
+	if errors.As(err, &gerr) {
+		return gerr
+	}
+	return &gqlError{message: err.Error(), code: "FORBIDDEN"}
+}
+
+func requireScope(ctx context.Context, scope string) error {
+	p, ok := domain.PrincipalFrom(ctx)
+	if !ok {
+		return errUnauthenticated
+	}
+	if !p.HasScope(scope) {
+		return &gqlError{message: "API key is missing the " + scope + " scope", code: "FORBIDDEN"}
+	}
+	return nil
+}
+
+// resolverError turns usecase errors into client errors like the REST
+// controllers do, and logs and hides everything it does not recognise.
+func resolverError(ctx context.Context, err error) error {
+	var verrs domain.ValidationErrors
+	switch {
+	case errors.As(err, &verrs):
+		return &gqlError{message: "validation failed", code: "BAD_USER_INPUT", fields: verrs}
+	case errors.Is(err, domain.ErrTaskNotFound):
+		return &gqlError{message: "Task not found", code: "NOT_FOUND"}
+	case errors.Is(err, domain.ErrUserNotFound):
+		return &gqlError{message: "User not found", code: "NOT_FOUND"}
+	}
+	domain.Logger(ctx).Error("graphql resolver", "error", err)
+	return &gqlError{message: "Internal server error", code: "INTERNAL_SERVER_ERROR"}
+}
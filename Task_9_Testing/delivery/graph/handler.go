@@ -0,0 +1,55 @@
+// Package graph serves a GraphQL API over tasks and users, so a client can
+// fetch exactly the fields it renders in one round trip. It sits behind
+// AuthMiddleware like the REST routes and calls the same usecases.
+package graph
+
+import (
+	_ "embed"
+	"net/http"
+	"task_manager/domain"
+
+	"github.com/gin-gonic/gin"
+	"github.com/graph-gophers/graphql-go"
+)
+
+//go:embed schema.graphql
+var Schema string
+
+// Limits on what one request may ask for.
+const (
+	maxDepth       = 8
+	maxQueryLength = 10000
+)
+
+type Handler struct {
+	schema       *graphql.Schema
+	taskUsecase  domain.TaskUsecase
+	userUsecase  domain.UserUsecase
+	settingsRepo domain.SettingsRepository
+	limiter      domain.RateLimitStore
+}
+
+// NewHandler panics if the schema and the resolvers disagree, which is a
+// programming error caught by the tests.
+func NewHandler(taskUsecase domain.TaskUsecase, userUsecase domain.UserUsecase, userAdminUsecase domain.UserAdminUsecase, settingsRepo domain.SettingsRepository, limiter domain.RateLimitStore) *Handler {
+	root := &resolver{taskUsecase: taskUsecase, userUsecase: userUsecase, userAdminUsecase: userAdminUsecase}
+	schema := graphql.MustParseSchema(Schema, root, graphql.MaxDepth(maxDepth), graphql.MaxQueryLength(maxQueryLength))
+	return &Handler{schema: schema, taskUsecase: taskUsecase, userUsecase: userUsecase, settingsRepo: settingsRepo, limiter: limiter}
+}
+
+// Serve runs one GraphQL request. As usual for GraphQL over HTTP, errors from
+// resolvers come back with status 200 in the errors list next to the data
+// that could be resolved.
+func (h *Handler) Serve(c *gin.Context) {
+	var req struct {
+		Query         string                 `json:"query"`
+		OperationName string                 `json:"operationName"`
+		Variables     map[string]interface{} `json:"variables"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	ctx := withState(c.Request.Context(), newRequestState(h.taskUsecase, h.userUsecase, h.settingsRepo, h.limiter))
+	c.JSON(http.StatusOK, h.schema.Exec(ctx, req.Query, req.OperationName, req.Variables))
+}
@@ -0,0 +1,240 @@
+package graph
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"task_manager/domain"
+	"task_manager/infrastructure"
+	"task_manager/internal/mocks"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+type stubSettingsRepository struct {
+	domain.SettingsRepository
+	settings domain.SecuritySettings
+}
+
+func (r stubSettingsRepository) GetSecuritySettings(ctx context.Context) (*domain.SecuritySettings, error) {
+	return &r.settings, nil
+}
+
+var (
+	admin = &domain.Principal{UserID: "1", Username: "admin", Role: domain.RoleAdmin}
+	bob   = &domain.Principal{UserID: "2", Username: "bob", Role: domain.RoleUser}
+)
+
+type gqlResponse struct {
+	Data   map[string]json.RawMessage `json:"data"`
+	Errors []struct {
+		Message    string                 `json:"message"`
+		Path       []interface{}          `json:"path"`
+		Extensions map[string]interface{} `json:"extensions"`
+	} `json:"errors"`
+}
+
+type HandlerTestSuite struct {
+	suite.Suite
+	mockTaskUsecase      *mocks.TaskUsecase
+	mockUserUsecase      *mocks.UserUsecase
+	mockUserAdminUsecase *mocks.UserAdminUsecase
+	settings             domain.SecuritySettings
+	limiter              domain.RateLimitStore
+}
+
+func (s *HandlerTestSuite) SetupTest() {
+	gin.SetMode(gin.TestMode)
+	s.mockTaskUsecase = &mocks.TaskUsecase{}
+	s.mockUserUsecase = &mocks.UserUsecase{}
+	s.mockUserAdminUsecase = &mocks.UserAdminUsecase{}
+	s.settings = domain.SecuritySettings{}
+	s.limiter = infrastructure.NewMemoryRateLimitStore()
+}
+
+func (s *HandlerTestSuite) TearDownTest() {
+	s.mockTaskUsecase.AssertExpectations(s.T())
+	s.mockUserUsecase.AssertExpectations(s.T())
+	s.mockUserAdminUsecase.AssertExpectations(s.T())
+}
+
+// exec runs query as caller, standing in for AuthMiddleware.
+func (s *HandlerTestSuite) exec(caller *domain.Principal, query string, variables map[string]interface{}) gqlResponse {
+	handler := NewHandler(s.mockTaskUsecase, s.mockUserUsecase, s.mockUserAdminUsecase, stubSettingsRepository{settings: s.settings}, s.limiter)
+	router := gin.New()
+	router.POST("/graphql", func(c *gin.Context) {
+		c.Request = c.Request.WithContext(domain.WithPrincipal(c.Request.Context(), caller))
+	}, handler.Serve)
+
+	body, _ := json.Marshal(map[string]interface{}{"query": query, "variables": variables})
+	req, _ := http.NewRequest("POST", "/graphql", strings.NewReader(string(body)))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	s.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+
+	var resp gqlResponse
+	s.Require().NoError(json.Unmarshal(w.Body.Bytes(), &resp))
+	return resp
+}
+
+func (s *HandlerTestSuite) TestTasksBatchOwners() {
+	due := time.Date(2025, 4, 3, 0, 0, 0, 0, time.UTC)
+	tasks := []domain.Task{
+		{ID: "t1", Title: "Write docs", Status: domain.TaskStatusPending, DueDate: due, OwnerID: "1"},
+		{ID: "t2", Title: "Ship it", Status: domain.TaskStatusPending, OwnerID: "2"},
+		{ID: "t3", Title: "Celebrate", Status: domain.TaskStatusPending, OwnerID: "1"},
+		{ID: "t4", Title: "Orphan", Status: domain.TaskStatusDone},
+	}
+	s.mockTaskUsecase.On("FindTasks", mock.Anything, domain.TaskFilter{}).Return(tasks, nil).Once()
+	// One lookup for every owner, however many tasks there are.
+	s.mockUserUsecase.On("FindUsersByIDs", mock.Anything, []string{"1", "2"}).
+		Return([]domain.User{{ID: "1", Username: "admin"}, {ID: "2", Username: "bob"}}, nil).Once()
+
+	resp := s.exec(bob, `{ tasks { id dueDate owner { username } } }`, nil)
+	s.Empty(resp.Errors)
+	s.JSONEq(`[
+		{"id":"t1","dueDate":"2025-04-03T00:00:00Z","owner":{"username":"admin"}},
+		{"id":"t2","dueDate":null,"owner":{"username":"bob"}},
+		{"id":"t3","dueDate":null,"owner":{"username":"admin"}},
+		{"id":"t4","dueDate":null,"owner":null}
+	]`, string(resp.Data["tasks"]))
+}
+
+func (s *HandlerTestSuite) TestTasksFilter() {
+	after := time.Date(2025, 4, 1, 0, 0, 0, 0, time.UTC)
+	s.mockTaskUsecase.On("FindTasks", mock.Anything, domain.TaskFilter{
+		Status:   domain.TaskStatusDone,
+		OwnerIDs: []string{"2"},
+		DueAfter: &after,
+		Search:   "docs",
+	}).Return([]domain.Task{}, nil).Once()
+
+	resp := s.exec(bob, `query($f: TaskFilter) { tasks(filter: $f) { id } }`, map[string]interface{}{
+		"f": map[string]interface{}{"status": "done", "ownerIds": []string{"2"}, "dueAfter": "2025-04-01T00:00:00Z", "search": "docs"},
+	})
+	s.Empty(resp.Errors)
+	s.JSONEq(`[]`, string(resp.Data["tasks"]))
+}
+
+func (s *HandlerTestSuite) TestUsersBatchTasks() {
+	page := &domain.UserPage{Users: []domain.User{{ID: "1", Username: "admin"}, {ID: "2", Username: "bob"}}, Page: 1, PerPage: 20, Total: 2}
+	s.mockUserAdminUsecase.On("ListUsers", mock.Anything, domain.UserQuery{Page: 1, PerPage: 20}).Return(page, nil).Once()
+	s.mockTaskUsecase.On("FindTasks", mock.Anything, domain.TaskFilter{OwnerIDs: []string{"1", "2"}}).
+		Return([]domain.Task{{ID: "t1", OwnerID: "2"}, {ID: "t2", OwnerID: "2"}}, nil).Once()
+
+	resp := s.exec(admin, `{ users { total users { username tasks { id owner { username } } } } }`, nil)
+	s.Empty(resp.Errors)
+	s.JSONEq(`{"total":2,"users":[
+		{"username":"admin","tasks":[]},
+		{"username":"bob","tasks":[{"id":"t1","owner":{"username":"bob"}},{"id":"t2","owner":{"username":"bob"}}]}
+	]}`, string(resp.Data["users"]))
+}
+
+func (s *HandlerTestSuite) TestUsersIsAdminOnly() {
+	s.Run("User", func() {
+		resp := s.exec(bob, `{ users { total } }`, nil)
+		s.Require().Len(resp.Errors, 1)
+		s.Equal("Unauthorized access", resp.Errors[0].Message)
+		s.Equal("FORBIDDEN", resp.Errors[0].Extensions["code"])
+	})
+
+	s.Run("Admin without a second factor when required", func() {
+		s.settings = domain.SecuritySettings{RequireAdminMFA: true}
+		resp := s.exec(admin, `{ users { total } }`, nil)
+		s.Require().Len(resp.Errors, 1)
+		s.Equal("Two-factor authentication required for admin access", resp.Errors[0].Message)
+	})
+}
+
+// TestAdminMutationPolicy checks that admin mutations get the policy of the
+// REST admin routes, which /graphql does not sit behind.
+func (s *HandlerTestSuite) TestAdminMutationPolicy() {
+	s.Run("Admin without a second factor when required", func() {
+		s.settings = domain.SecuritySettings{RequireAdminMFA: true}
+		defer func() { s.settings = domain.SecuritySettings{} }()
+
+		resp := s.exec(admin, `mutation { promoteUser(username: "bob") }`, nil)
+		s.Require().Len(resp.Errors, 1)
+		s.Equal("Two-factor authentication required for admin access", resp.Errors[0].Message)
+		s.Equal("FORBIDDEN", resp.Errors[0].Extensions["code"])
+	})
+
+	s.Run("Admin rate limit", func() {
+		s.mockUserUsecase.On("PromoteUser", mock.Anything, "bob").Return(nil).Times(infrastructure.AdminLimit.Limit)
+		for i := 0; i < infrastructure.AdminLimit.Limit; i++ {
+			resp := s.exec(admin, `mutation { promoteUser(username: "bob") }`, nil)
+			s.Require().Empty(resp.Errors)
+		}
+
+		resp := s.exec(admin, `mutation { promoteUser(username: "bob") }`, nil)
+		s.Require().Len(resp.Errors, 1)
+		s.Equal("TOO_MANY_REQUESTS", resp.Errors[0].Extensions["code"])
+	})
+}
+
+func (s *HandlerTestSuite) TestPrivateFields() {
+	s.mockTaskUsecase.On("FindTasks", mock.Anything, domain.TaskFilter{}).
+		Return([]domain.Task{{ID: "t1", OwnerID: "1"}, {ID: "t2", OwnerID: "2"}}, nil).Once()
+	s.mockUserUsecase.On("FindUsersByIDs", mock.Anything, []string{"1", "2"}).
+		Return([]domain.User{{ID: "1", Username: "admin", Email: "admin@example.com"}, {ID: "2", Username: "bob", Email: "bob@example.com"}}, nil).Once()
+
+	resp := s.exec(bob, `{ tasks { owner { username email } } }`, nil)
+	s.JSONEq(`[{"owner":{"username":"admin","email":null}},{"owner":{"username":"bob","email":"bob@example.com"}}]`, string(resp.Data["tasks"]))
+	s.Require().Len(resp.Errors, 1, "Only the other user's email should be refused")
+	s.Equal("FORBIDDEN", resp.Errors[0].Extensions["code"])
+	s.Equal([]interface{}{"tasks", float64(0), "owner", "email"}, resp.Errors[0].Path)
+}
+
+func (s *HandlerTestSuite) TestAPIKeyScopes() {
+	readOnly := &domain.Principal{UserID: "2", Role: domain.RoleUser, APIKeyID: "k1", Scopes: []string{domain.ScopeTasksRead}}
+
+	resp := s.exec(readOnly, `mutation { deleteTask(id: "t1") }`, nil)
+	s.Require().Len(resp.Errors, 1)
+	s.Equal("API key is missing the "+domain.ScopeTasksWrite+" scope", resp.Errors[0].Message)
+}
+
+func (s *HandlerTestSuite) TestCreateTask() {
+	s.Run("Success", func() {
+		due := time.Date(2025, 4, 3, 0, 0, 0, 0, time.UTC)
+		s.mockTaskUsecase.On("AddTask", mock.Anything, domain.Task{Title: "Write docs", DueDate: due}).Return("t9", nil).Once()
+		s.mockTaskUsecase.On("GetTaskByID", mock.Anything, "t9").
+			Return(&domain.Task{ID: "t9", Title: "Write docs", DueDate: due, Status: domain.TaskStatusPending, OwnerID: "2"}, nil).Once()
+
+		resp := s.exec(bob, `mutation { createTask(input: {title: "Write docs", dueDate: "2025-04-03T00:00:00Z"}) { id status } }`, nil)
+		s.Empty(resp.Errors)
+		s.JSONEq(`{"id":"t9","status":"pending"}`, string(resp.Data["createTask"]))
+	})
+
+	s.Run("Validation error", func() {
+		s.mockTaskUsecase.On("AddTask", mock.Anything, domain.Task{Status: "someday"}).
+			Return("", domain.ValidationErrors{{Field: "title", Message: "is required"}}).Once()
+
+		resp := s.exec(bob, `mutation { createTask(input: {title: "", status: "someday"}) { id } }`, nil)
+		s.Require().Len(resp.Errors, 1)
+		s.Equal("BAD_USER_INPUT", resp.Errors[0].Extensions["code"])
+		s.Equal([]interface{}{map[string]interface{}{"field": "title", "message": "is required"}}, resp.Errors[0].Extensions["fields"])
+	})
+}
+
+func (s *HandlerTestSuite) TestUpdateTaskIsAdminOnly() {
+	resp := s.exec(bob, `mutation { updateTask(id: "t1", input: {title: "Renamed"}) { id } }`, nil)
+	s.Require().Len(resp.Errors, 1)
+	s.Equal("FORBIDDEN", resp.Errors[0].Extensions["code"])
+}
+
+func (s *HandlerTestSuite) TestQueryDepthIsLimited() {
+	resp := s.exec(bob, `{ tasks { owner { tasks { owner { tasks { owner { tasks { owner { username } } } } } } } } }`, nil)
+	s.Require().NotEmpty(resp.Errors)
+	s.Nil(resp.Data)
+}
+
+func TestHandlerSuite(t *testing.T) {
+	suite.Run(t, new(HandlerTestSuite))
+}
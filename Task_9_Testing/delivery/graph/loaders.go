@@ -0,0 +1,151 @@
+package graph
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"task_manager/domain"
+	"task_manager/infrastructure"
+)
+
+// batch loads values by key in as few calls as possible. Resolvers announce
+// the keys they are about to need with want as soon as they know them, for
+// example when a list of tasks comes back, and the first load fetches every
+// announced key in one call. A list of tasks with their owners therefore
+// costs two repository calls however long the list is.
+type batch[V any] struct {
+	fetch func(ctx context.Context, keys []string) (map[string]V, error)
+
+	mu      sync.Mutex
+	wanted  map[string]bool
+	results map[string]V
+	errs    map[string]error
+}
+
+func newBatch[V any](fetch func(ctx context.Context, keys []string) (map[string]V, error)) *batch[V] {
+	return &batch[V]{fetch: fetch, wanted: map[string]bool{}, results: map[string]V{}, errs: map[string]error{}}
+}
+
+func (b *batch[V]) want(keys ...string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for _, key := range keys {
+		if !b.known(key) {
+			b.wanted[key] = true
+		}
+	}
+}
+
+// prime records a value that is already at hand, so it is never fetched.
+func (b *batch[V]) prime(key string, value V) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.results[key] = value
+	delete(b.wanted, key)
+}
+
+// load returns the zero value for keys the fetch did not return.
+func (b *batch[V]) load(ctx context.Context, key string) (V, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if !b.known(key) {
+		b.wanted[key] = true
+		keys := make([]string, 0, len(b.wanted))
+		for k := range b.wanted {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		b.wanted = map[string]bool{}
+
+		values, err := b.fetch(ctx, keys)
+		for _, k := range keys {
+			if err != nil {
+				b.errs[k] = err
+			} else {
+				b.results[k] = values[k]
+			}
+		}
+	}
+	return b.results[key], b.errs[key]
+}
+
+func (b *batch[V]) known(key string) bool {
+	if _, ok := b.results[key]; ok {
+		return true
+	}
+	_, ok := b.errs[key]
+	return ok
+}
+
+// requestState is what the resolvers of one request share: the batches and
+// the outcome of the admin check, which needs a settings lookup and a token
+// from the admin rate limit.
+type requestState struct {
+	users        *batch[*domain.User]
+	tasksByOwner *batch[[]domain.Task]
+	settingsRepo domain.SettingsRepository
+	limiter      domain.RateLimitStore
+
+	adminOnce sync.Once
+	adminErr  error
+}
+
+func newRequestState(taskUsecase domain.TaskUsecase, userUsecase domain.UserUsecase, settingsRepo domain.SettingsRepository, limiter domain.RateLimitStore) *requestState {
+	return &requestState{
+		settingsRepo: settingsRepo,
+		limiter:      limiter,
+		users: newBatch(func(ctx context.Context, ids []string) (map[string]*domain.User, error) {
+			users, err := userUsecase.FindUsersByIDs(ctx, ids)
+			if err != nil {
+				return nil, err
+			}
+			byID := make(map[string]*domain.User, len(users))
+			for i := range users {
+				byID[users[i].ID] = &users[i]
+			}
+			return byID, nil
+		}),
+		tasksByOwner: newBatch(func(ctx context.Context, ownerIDs []string) (map[string][]domain.Task, error) {
+			tasks, err := taskUsecase.FindTasks(ctx, domain.TaskFilter{OwnerIDs: ownerIDs})
+			if err != nil {
+				return nil, err
+			}
+			byOwner := make(map[string][]domain.Task, len(ownerIDs))
+			for _, task := range tasks {
+				byOwner[task.OwnerID] = append(byOwner[task.OwnerID], task)
+			}
+			return byOwner, nil
+		}),
+	}
+}
+
+// checkAdmin runs infrastructure.CheckAdmin once per request and charges the
+// request to infrastructure.AdminLimit, like the middleware of the admin
+// routes does.
+func (s *requestState) checkAdmin(ctx context.Context) error {
+	s.adminOnce.Do(func() {
+		if s.adminErr = infrastructure.CheckAdmin(ctx, s.settingsRepo); s.adminErr != nil || s.limiter == nil {
+			return
+		}
+		p, _ := domain.PrincipalFrom(ctx)
+		policy := infrastructure.AdminLimit
+		result, err := s.limiter.Take(ctx, policy.Name+":user:"+p.UserID, policy)
+		if err != nil {
+			// A broken limiter should not take the whole API down with it.
+			domain.Logger(ctx).Error("rate limiter", "policy", policy.Name, "error", err)
+		} else if !result.Allowed {
+			s.adminErr = errTooManyRequests
+		}
+	})
+	return s.adminErr
+}
+
+type stateKey struct{}
+
+func withState(ctx context.Context, state *requestState) context.Context {
+	return context.WithValue(ctx, stateKey{}, state)
+}
+
+func stateFrom(ctx context.Context) *requestState {
+	return ctx.Value(stateKey{}).(*requestState)
+}
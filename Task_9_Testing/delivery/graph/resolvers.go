@@ -0,0 +1,361 @@
+package graph
+
+import (
+	"context"
+	"errors"
+	"task_manager/domain"
+	"time"
+
+	"github.com/graph-gophers/graphql-go"
+)
+
+// resolver is the root of both queries and mutations. Mutations call the
+// same usecases as the REST controllers; authorization happens per field,
+// on the caller AuthMiddleware stored in the context.
+type resolver struct {
+	taskUsecase      domain.TaskUsecase
+	userUsecase      domain.UserUsecase
+	userAdminUsecase domain.UserAdminUsecase
+}
+
+type taskFilterInput struct {
+	Status    *string
+	OwnerIDs  *[]graphql.ID
+	DueAfter  *graphql.Time
+	DueBefore *graphql.Time
+	Search    *string
+}
+
+func (r *resolver) Tasks(ctx context.Context, args struct{ Filter *taskFilterInput }) ([]*taskResolver, error) {
+	if err := requireScope(ctx, domain.ScopeTasksRead); err != nil {
+		return nil, err
+	}
+	var filter domain.TaskFilter
+	if f := args.Filter; f != nil {
+		filter.Status = deref(f.Status)
+		filter.Search = deref(f.Search)
+		if f.OwnerIDs != nil {
+			filter.OwnerIDs = []string{}
+			for _, id := range *f.OwnerIDs {
+				filter.OwnerIDs = append(filter.OwnerIDs, string(id))
+			}
+		}
+		if f.DueAfter != nil {
+			filter.DueAfter = &f.DueAfter.Time
+		}
+		if f.DueBefore != nil {
+			filter.DueBefore = &f.DueBefore.Time
+		}
+	}
+	tasks, err := r.taskUsecase.FindTasks(ctx, filter)
+	if err != nil {
+		return nil, resolverError(ctx, err)
+	}
+	return newTaskResolvers(ctx, tasks), nil
+}
+
+func (r *resolver) Task(ctx context.Context, args struct{ ID graphql.ID }) (*taskResolver, error) {
+	if err := requireScope(ctx, domain.ScopeTasksRead); err != nil {
+		return nil, err
+	}
+	task, err := r.taskUsecase.GetTaskByID(ctx, string(args.ID))
+	if errors.Is(err, domain.ErrTaskNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, resolverError(ctx, err)
+	}
+	return newTaskResolvers(ctx, []domain.Task{*task})[0], nil
+}
+
+func (r *resolver) Me(ctx context.Context) (*userResolver, error) {
+	p, ok := domain.PrincipalFrom(ctx)
+	if !ok {
+		return nil, errUnauthenticated
+	}
+	user, err := stateFrom(ctx).users.load(ctx, p.UserID)
+	if err != nil {
+		return nil, resolverError(ctx, err)
+	}
+	if user == nil {
+		return nil, errUnauthenticated
+	}
+	return &userResolver{user: *user}, nil
+}
+
+func (r *resolver) Users(ctx context.Context, args struct {
+	Search   *string
+	Role     *string
+	Disabled *bool
+	Page     int32
+	PerPage  int32
+}) (*userPageResolver, error) {
+	if err := stateFrom(ctx).checkAdmin(ctx); err != nil {
+		return nil, forbidden(err)
+	}
+	page, err := r.userAdminUsecase.ListUsers(ctx, domain.UserQuery{
+		Search:   deref(args.Search),
+		Role:     deref(args.Role),
+		Disabled: args.Disabled,
+		Page:     int(args.Page),
+		PerPage:  int(args.PerPage),
+	})
+	if err != nil {
+		return nil, resolverError(ctx, err)
+	}
+	return newUserPageResolver(ctx, page), nil
+}
+
+func (r *resolver) User(ctx context.Context, args struct{ ID graphql.ID }) (*userResolver, error) {
+	if err := stateFrom(ctx).checkAdmin(ctx); err != nil {
+		return nil, forbidden(err)
+	}
+	user, err := r.userAdminUsecase.GetUser(ctx, string(args.ID))
+	if errors.Is(err, domain.ErrUserNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, resolverError(ctx, err)
+	}
+	stateFrom(ctx).users.prime(user.ID, user)
+	return &userResolver{user: *user}, nil
+}
+
+type taskInput struct {
+	Title       string
+	Description *string
+	DueDate     *graphql.Time
+	Status      *string
+}
+
+func (in taskInput) task() domain.Task {
+	task := domain.Task{Title: in.Title, Description: deref(in.Description), Status: deref(in.Status)}
+	if in.DueDate != nil {
+		task.DueDate = in.DueDate.Time
+	}
+	return task
+}
+
+func (r *resolver) CreateTask(ctx context.Context, args struct{ Input taskInput }) (*taskResolver, error) {
+	if err := requireScope(ctx, domain.ScopeTasksWrite); err != nil {
+		return nil, err
+	}
+	id, err := r.taskUsecase.AddTask(ctx, args.Input.task())
+	if err != nil {
+		return nil, resolverError(ctx, err)
+	}
+	return r.reload(ctx, id)
+}
+
+func (r *resolver) UpdateTask(ctx context.Context, args struct {
+	ID    graphql.ID
+	Input taskInput
+}) (*taskResolver, error) {
+	if err := stateFrom(ctx).checkAdmin(ctx); err != nil {
+		return nil, forbidden(err)
+	}
+	if err := r.taskUsecase.UpdateTask(ctx, string(args.ID), args.Input.task()); err != nil {
+		return nil, resolverError(ctx, err)
+	}
+	return r.reload(ctx, string(args.ID))
+}
+
+// reload reads a task back after a write, so the response shows what was
+// stored, defaults included.
+func (r *resolver) reload(ctx context.Context, id string) (*taskResolver, error) {
+	task, err := r.taskUsecase.GetTaskByID(ctx, id)
+	if err != nil {
+		return nil, resolverError(ctx, err)
+	}
+	return newTaskResolvers(ctx, []domain.Task{*task})[0], nil
+}
+
+func (r *resolver) DeleteTask(ctx context.Context, args struct{ ID graphql.ID }) (bool, error) {
+	if err := requireScope(ctx, domain.ScopeTasksWrite); err != nil {
+		return false, err
+	}
+	if err := r.taskUsecase.DeleteTask(ctx, string(args.ID)); err != nil {
+		return false, resolverError(ctx, err)
+	}
+	return true, nil
+}
+
+func (r *resolver) PromoteUser(ctx context.Context, args struct{ Username string }) (bool, error) {
+	if err := stateFrom(ctx).checkAdmin(ctx); err != nil {
+		return false, forbidden(err)
+	}
+	if err := r.userUsecase.PromoteUser(ctx, args.Username); err != nil {
+		return false, resolverError(ctx, err)
+	}
+	return true, nil
+}
+
+func (r *resolver) UnlockLogin(ctx context.Context, args struct {
+	Username *string
+	IP       *string
+}) (bool, error) {
+	if err := stateFrom(ctx).checkAdmin(ctx); err != nil {
+		return false, forbidden(err)
+	}
+	if err := r.userUsecase.UnlockLogin(ctx, deref(args.Username), deref(args.IP)); err != nil {
+		return false, resolverError(ctx, err)
+	}
+	return true, nil
+}
+
+type taskResolver struct {
+	task domain.Task
+}
+
+// newTaskResolvers announces the owners of tasks to the user batch, so that
+// resolving owner on all of them takes one lookup.
+func newTaskResolvers(ctx context.Context, tasks []domain.Task) []*taskResolver {
+	state := stateFrom(ctx)
+	resolvers := make([]*taskResolver, len(tasks))
+	for i, task := range tasks {
+		if task.OwnerID != "" {
+			state.users.want(task.OwnerID)
+		}
+		resolvers[i] = &taskResolver{task: task}
+	}
+	return resolvers
+}
+
+func (t *taskResolver) ID() graphql.ID      { return graphql.ID(t.task.ID) }
+func (t *taskResolver) Title() string       { return t.task.Title }
+func (t *taskResolver) Description() string { return t.task.Description }
+func (t *taskResolver) Status() string      { return t.task.Status }
+func (t *taskResolver) DueDate() *graphql.Time {
+	return optionalTime(t.task.DueDate)
+}
+
+func (t *taskResolver) Owner(ctx context.Context) (*userResolver, error) {
+	if t.task.OwnerID == "" {
+		return nil, nil
+	}
+	user, err := stateFrom(ctx).users.load(ctx, t.task.OwnerID)
+	if err != nil {
+		return nil, resolverError(ctx, err)
+	}
+	if user == nil {
+		return nil, nil
+	}
+	return &userResolver{user: *user}, nil
+}
+
+type userResolver struct {
+	user domain.User
+}
+
+func (u *userResolver) ID() graphql.ID       { return graphql.ID(u.user.ID) }
+func (u *userResolver) Username() string     { return u.user.Username }
+func (u *userResolver) Role() string         { return u.user.Role }
+func (u *userResolver) DisplayName() *string { return optionalString(u.user.DisplayName) }
+func (u *userResolver) AvatarURL() *string   { return optionalString(u.user.AvatarURL) }
+
+// private lets the user and admins through to the fields marked private.
+func (u *userResolver) private(ctx context.Context) error {
+	if p, ok := domain.PrincipalFrom(ctx); ok && p.UserID == u.user.ID {
+		return nil
+	}
+	if err := stateFrom(ctx).checkAdmin(ctx); err != nil {
+		return forbidden(err)
+	}
+	return nil
+}
+
+func (u *userResolver) Email(ctx context.Context) (*string, error) {
+	if err := u.private(ctx); err != nil {
+		return nil, err
+	}
+	return optionalString(u.user.Email), nil
+}
+
+func (u *userResolver) Verified(ctx context.Context) (*bool, error) {
+	if err := u.private(ctx); err != nil {
+		return nil, err
+	}
+	return &u.user.Verified, nil
+}
+
+func (u *userResolver) Disabled(ctx context.Context) (*bool, error) {
+	if err := u.private(ctx); err != nil {
+		return nil, err
+	}
+	return &u.user.Disabled, nil
+}
+
+func (u *userResolver) CreatedAt(ctx context.Context) (*graphql.Time, error) {
+	if err := u.private(ctx); err != nil {
+		return nil, err
+	}
+	return optionalTime(u.user.CreatedAt), nil
+}
+
+func (u *userResolver) LastLoginAt(ctx context.Context) (*graphql.Time, error) {
+	if err := u.private(ctx); err != nil {
+		return nil, err
+	}
+	if u.user.LastLoginAt == nil {
+		return nil, nil
+	}
+	return optionalTime(*u.user.LastLoginAt), nil
+}
+
+func (u *userResolver) Tasks(ctx context.Context) ([]*taskResolver, error) {
+	if err := requireScope(ctx, domain.ScopeTasksRead); err != nil {
+		return nil, err
+	}
+	state := stateFrom(ctx)
+	// The owner of these tasks is this user; there is nothing to look up.
+	state.users.prime(u.user.ID, &u.user)
+	tasks, err := state.tasksByOwner.load(ctx, u.user.ID)
+	if err != nil {
+		return nil, resolverError(ctx, err)
+	}
+	return newTaskResolvers(ctx, tasks), nil
+}
+
+type userPageResolver struct {
+	page  *domain.UserPage
+	users []*userResolver
+}
+
+// newUserPageResolver announces the users of the page to the task batch, so
+// that resolving tasks on all of them takes one lookup.
+func newUserPageResolver(ctx context.Context, page *domain.UserPage) *userPageResolver {
+	state := stateFrom(ctx)
+	users := make([]*userResolver, len(page.Users))
+	for i, user := range page.Users {
+		state.tasksByOwner.want(user.ID)
+		users[i] = &userResolver{user: user}
+	}
+	return &userPageResolver{page: page, users: users}
+}
+
+func (p *userPageResolver) Users() []*userResolver { return p.users }
+func (p *userPageResolver) Page() int32            { return int32(p.page.Page) }
+func (p *userPageResolver) PerPage() int32         { return int32(p.page.PerPage) }
+func (p *userPageResolver) Total() int32           { return int32(p.page.Total) }
+
+func deref[T any](v *T) T {
+	var zero T
+	if v == nil {
+		return zero
+	}
+	return *v
+}
+
+func optionalString(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
+
+func optionalTime(t time.Time) *graphql.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &graphql.Time{Time: t}
+}
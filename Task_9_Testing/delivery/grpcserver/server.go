@@ -0,0 +1,68 @@
+// Package grpcserver serves tasks and users over gRPC for internal services.
+// It calls the same usecases as the REST controllers and guards each method
+// like the matching REST route.
+package grpcserver
+
+//go:generate protoc -I pb --go_out=pb --go_opt=paths=source_relative --go-grpc_out=pb --go-grpc_opt=paths=source_relative pb/task_manager.proto
+
+import (
+	"errors"
+	"log/slog"
+	"task_manager/delivery/grpcserver/pb"
+	"task_manager/domain"
+	"task_manager/infrastructure"
+
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Policies lists every RPC with the guard of its REST route.
+var Policies = map[string]infrastructure.GRPCMethodPolicy{
+	pb.TaskService_ListTasks_FullMethodName:  {Scope: domain.ScopeTasksRead, RateLimit: infrastructure.AuthLimit},
+	pb.TaskService_GetTask_FullMethodName:    {Scope: domain.ScopeTasksRead, RateLimit: infrastructure.AuthLimit},
+	pb.TaskService_CreateTask_FullMethodName: {Scope: domain.ScopeTasksWrite, RateLimit: infrastructure.AuthLimit},
+	pb.TaskService_DeleteTask_FullMethodName: {Scope: domain.ScopeTasksWrite, RateLimit: infrastructure.AuthLimit},
+	pb.TaskService_UpdateTask_FullMethodName: {Admin: true, RateLimit: infrastructure.AdminLimit},
+
+	pb.UserService_Register_FullMethodName:      {Public: true, RateLimit: infrastructure.CredentialsLimit},
+	pb.UserService_Login_FullMethodName:         {Public: true, RateLimit: infrastructure.CredentialsLimit},
+	pb.UserService_CompleteLogin_FullMethodName: {Public: true, RateLimit: infrastructure.CredentialsLimit},
+	pb.UserService_PromoteUser_FullMethodName:   {Admin: true, RateLimit: infrastructure.AdminLimit},
+	pb.UserService_UnlockLogin_FullMethodName:   {Admin: true, RateLimit: infrastructure.AdminLimit},
+}
+
+// NewServer registers both services behind the logging and auth interceptors.
+// The otelgrpc handler starts a span per call, or continues the caller's, so
+// the usecase and repository spans nest below it as they do for REST.
+func NewServer(taskUsecase domain.TaskUsecase, userUsecase domain.UserUsecase, mfaUsecase domain.MFAUsecase, authorizer *infrastructure.GRPCAuthorizer, logger *slog.Logger) *grpc.Server {
+	logUnary, logStream := infrastructure.GRPCRequestLogger(logger)
+	server := grpc.NewServer(
+		grpc.StatsHandler(otelgrpc.NewServerHandler()),
+		grpc.ChainUnaryInterceptor(logUnary, authorizer.UnaryInterceptor()),
+		grpc.ChainStreamInterceptor(logStream, authorizer.StreamInterceptor()),
+	)
+	pb.RegisterTaskServiceServer(server, NewTaskServer(taskUsecase))
+	pb.RegisterUserServiceServer(server, NewUserServer(userUsecase, mfaUsecase))
+	return server
+}
+
+// invalidArgument reports field errors as InvalidArgument with a BadRequest
+// detail per field, and reports whether err was one.
+func invalidArgument(err error) (error, bool) {
+	var verrs domain.ValidationErrors
+	if !errors.As(err, &verrs) {
+		return nil, false
+	}
+	details := &errdetails.BadRequest{}
+	for _, fe := range verrs {
+		details.FieldViolations = append(details.FieldViolations, &errdetails.BadRequest_FieldViolation{Field: fe.Field, Description: fe.Message})
+	}
+	st, detailErr := status.New(codes.InvalidArgument, "validation failed").WithDetails(details)
+	if detailErr != nil {
+		return status.Error(codes.InvalidArgument, verrs.Error()), true
+	}
+	return st.Err(), true
+}
@@ -0,0 +1,295 @@
+package grpcserver
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net"
+	"task_manager/delivery/grpcserver/pb"
+	"task_manager/domain"
+	"task_manager/infrastructure"
+	"task_manager/internal/mocks"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// stubJWTService accepts "admin-token" for user 1, an admin, and "user-token"
+// for user 2.
+type stubJWTService struct{ domain.JWTService }
+
+func (s stubJWTService) ValidateToken(token string) (*domain.Claims, error) {
+	switch token {
+	case "admin-token":
+		return &domain.Claims{Username: "admin", Role: domain.RoleAdmin, RegisteredClaims: jwt.RegisteredClaims{Subject: "1"}}, nil
+	case "user-token":
+		return &domain.Claims{Username: "bob", Role: domain.RoleUser, RegisteredClaims: jwt.RegisteredClaims{Subject: "2"}}, nil
+	}
+	return nil, errors.New("invalid token")
+}
+
+type stubUserRepository struct{ domain.UserRepository }
+
+func (r stubUserRepository) FindUserByID(ctx context.Context, id string) (*domain.User, error) {
+	switch id {
+	case "1":
+		return &domain.User{ID: "1", Username: "admin", Role: domain.RoleAdmin}, nil
+	case "2":
+		return &domain.User{ID: "2", Username: "bob", Role: domain.RoleUser}, nil
+	}
+	return nil, nil
+}
+
+type stubSettingsRepository struct{ domain.SettingsRepository }
+
+func (r stubSettingsRepository) GetSecuritySettings(ctx context.Context) (*domain.SecuritySettings, error) {
+	return &domain.SecuritySettings{}, nil
+}
+
+type ServerTestSuite struct {
+	suite.Suite
+	mockTaskUsecase *mocks.TaskUsecase
+	mockUserUsecase *mocks.UserUsecase
+	mockMFAUsecase  *mocks.MFAUsecase
+	server          *grpc.Server
+	conn            *grpc.ClientConn
+	tasks           pb.TaskServiceClient
+	users           pb.UserServiceClient
+	spans           *tracetest.SpanRecorder
+}
+
+func (s *ServerTestSuite) SetupSuite() {
+	s.spans = tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(s.spans)))
+}
+
+func (s *ServerTestSuite) SetupTest() {
+	s.mockTaskUsecase = &mocks.TaskUsecase{}
+	s.mockUserUsecase = &mocks.UserUsecase{}
+	s.mockMFAUsecase = &mocks.MFAUsecase{}
+	authorizer := infrastructure.NewGRPCAuthorizer(Policies, stubJWTService{}, nil, stubUserRepository{}, stubSettingsRepository{}, infrastructure.NewMemoryRateLimitStore())
+	s.server = NewServer(s.mockTaskUsecase, s.mockUserUsecase, s.mockMFAUsecase, authorizer, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	listener := bufconn.Listen(1 << 20)
+	go s.server.Serve(listener)
+	conn, err := grpc.NewClient("passthrough:///bufconn",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return listener.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	s.Require().NoError(err)
+	s.conn = conn
+	s.tasks = pb.NewTaskServiceClient(conn)
+	s.users = pb.NewUserServiceClient(conn)
+}
+
+func (s *ServerTestSuite) TearDownTest() {
+	s.conn.Close()
+	s.server.Stop()
+	s.mockTaskUsecase.AssertExpectations(s.T())
+	s.mockUserUsecase.AssertExpectations(s.T())
+	s.mockMFAUsecase.AssertExpectations(s.T())
+}
+
+func withToken(token string) context.Context {
+	return metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer "+token)
+}
+
+func (s *ServerTestSuite) TestPoliciesCoverEveryMethod() {
+	for _, desc := range []grpc.ServiceDesc{pb.TaskService_ServiceDesc, pb.UserService_ServiceDesc} {
+		for _, method := range desc.Methods {
+			s.Contains(Policies, "/"+desc.ServiceName+"/"+method.MethodName)
+		}
+		for _, stream := range desc.Streams {
+			s.Contains(Policies, "/"+desc.ServiceName+"/"+stream.StreamName)
+		}
+	}
+}
+
+func (s *ServerTestSuite) TestListTasks() {
+	s.Run("Streams every task", func() {
+		due := time.Date(2025, 4, 3, 0, 0, 0, 0, time.UTC)
+		s.mockTaskUsecase.On("GetAllTasks", mock.Anything).Return([]domain.Task{
+			{ID: "1", Title: "Write docs", Status: domain.TaskStatusPending, DueDate: due, OwnerID: "2"},
+			{ID: "2", Title: "Ship it", Status: domain.TaskStatusPending},
+		}, nil).Once()
+
+		stream, err := s.tasks.ListTasks(withToken("user-token"), &pb.ListTasksRequest{})
+		s.Require().NoError(err)
+		var got []*pb.Task
+		for {
+			task, err := stream.Recv()
+			if err == io.EOF {
+				break
+			}
+			s.Require().NoError(err)
+			got = append(got, task)
+		}
+		s.Require().Len(got, 2)
+		s.Equal("Write docs", got[0].GetTitle())
+		s.True(due.Equal(got[0].GetDueDate().AsTime()))
+		s.Equal("2", got[0].GetOwnerId())
+		s.Nil(got[1].GetDueDate(), "An unset due date should stay unset")
+	})
+
+	s.Run("Needs a token", func() {
+		stream, err := s.tasks.ListTasks(context.Background(), &pb.ListTasksRequest{})
+		s.Require().NoError(err)
+		_, err = stream.Recv()
+		s.Equal(codes.Unauthenticated, status.Code(err))
+	})
+}
+
+func (s *ServerTestSuite) TestGetTask() {
+	s.Run("Not found", func() {
+		s.mockTaskUsecase.On("GetTaskByID", mock.Anything, "9").Return((*domain.Task)(nil), domain.ErrTaskNotFound).Once()
+
+		_, err := s.tasks.GetTask(withToken("user-token"), &pb.GetTaskRequest{Id: "9"})
+		s.Equal(codes.NotFound, status.Code(err))
+	})
+
+	s.Run("Invalid token", func() {
+		_, err := s.tasks.GetTask(withToken("forged"), &pb.GetTaskRequest{Id: "1"})
+		s.Equal(codes.Unauthenticated, status.Code(err))
+		s.Equal("Invalid token", status.Convert(err).Message())
+	})
+}
+
+func (s *ServerTestSuite) TestCreateTask() {
+	s.Run("Passes the caller to the usecase", func() {
+		due := time.Date(2025, 4, 3, 0, 0, 0, 0, time.UTC)
+		s.mockTaskUsecase.On("AddTask", mock.MatchedBy(func(ctx context.Context) bool {
+			p, ok := domain.PrincipalFrom(ctx)
+			return ok && p.UserID == "2"
+		}), domain.Task{Title: "Write docs", DueDate: due, Status: "pending"}).Return("7", nil).Once()
+
+		resp, err := s.tasks.CreateTask(withToken("user-token"), &pb.CreateTaskRequest{Title: "Write docs", DueDate: timestamppb.New(due), Status: "pending"})
+		s.Require().NoError(err)
+		s.Equal("7", resp.GetId())
+	})
+
+	s.Run("Validation error", func() {
+		s.mockTaskUsecase.On("AddTask", mock.Anything, mock.AnythingOfType("domain.Task")).
+			Return("", domain.ValidationErrors{{Field: "title", Message: "is required"}}).Once()
+
+		_, err := s.tasks.CreateTask(withToken("user-token"), &pb.CreateTaskRequest{})
+		st := status.Convert(err)
+		s.Equal(codes.InvalidArgument, st.Code())
+		s.Require().Len(st.Details(), 1)
+		violations := st.Details()[0].(*errdetails.BadRequest).GetFieldViolations()
+		s.Require().Len(violations, 1)
+		s.Equal("title", violations[0].GetField())
+	})
+}
+
+func (s *ServerTestSuite) TestUpdateTaskIsAdminOnly() {
+	s.Run("User", func() {
+		_, err := s.tasks.UpdateTask(withToken("user-token"), &pb.UpdateTaskRequest{Id: "1", Title: "Renamed"})
+		s.Equal(codes.PermissionDenied, status.Code(err))
+		s.Equal("Unauthorized access", status.Convert(err).Message())
+	})
+
+	s.Run("Admin", func() {
+		s.mockTaskUsecase.On("UpdateTask", mock.Anything, "1", domain.Task{Title: "Renamed"}).Return(nil).Once()
+
+		_, err := s.tasks.UpdateTask(withToken("admin-token"), &pb.UpdateTaskRequest{Id: "1", Title: "Renamed"})
+		s.NoError(err)
+	})
+}
+
+func (s *ServerTestSuite) TestLogin() {
+	s.Run("Success without a token", func() {
+		s.mockUserUsecase.On("Login", mock.Anything, "bob", "secret", mock.AnythingOfType("string")).Return("jwt", nil).Once()
+
+		resp, err := s.users.Login(context.Background(), &pb.LoginRequest{Username: "bob", Password: "secret"})
+		s.Require().NoError(err)
+		s.Equal("jwt", resp.GetToken())
+	})
+
+	s.Run("Two-factor challenge", func() {
+		s.mockUserUsecase.On("Login", mock.Anything, "alice", "secret", mock.AnythingOfType("string")).
+			Return("", &domain.MFARequiredError{Token: "mfa"}).Once()
+
+		resp, err := s.users.Login(context.Background(), &pb.LoginRequest{Username: "alice", Password: "secret"})
+		s.Require().NoError(err)
+		s.Empty(resp.GetToken())
+		s.Equal("mfa", resp.GetMfaToken())
+	})
+
+	s.Run("Locked out", func() {
+		s.mockUserUsecase.On("Login", mock.Anything, "bob", "wrong", mock.AnythingOfType("string")).
+			Return("", &domain.LoginBlockedError{Until: time.Now().Add(time.Minute)}).Once()
+
+		_, err := s.users.Login(context.Background(), &pb.LoginRequest{Username: "bob", Password: "wrong"})
+		st := status.Convert(err)
+		s.Equal(codes.ResourceExhausted, st.Code())
+		s.Require().Len(st.Details(), 1)
+		s.IsType(&errdetails.RetryInfo{}, st.Details()[0])
+	})
+}
+
+func (s *ServerTestSuite) TestCallsAreTraced() {
+	inSpan := mock.MatchedBy(func(ctx context.Context) bool { return trace.SpanContextFromContext(ctx).IsValid() })
+	s.mockUserUsecase.On("Login", inSpan, "bob", "secret", mock.AnythingOfType("string")).Return("jwt", nil).Once()
+
+	_, err := s.users.Login(context.Background(), &pb.LoginRequest{Username: "bob", Password: "secret"})
+	s.Require().NoError(err)
+
+	var names []string
+	for _, span := range s.spans.Ended() {
+		names = append(names, span.Name())
+	}
+	s.Contains(names, "taskmanager.v1.UserService/Login")
+}
+
+func (s *ServerTestSuite) TestCompleteLogin() {
+	s.Run("Success without a token", func() {
+		s.mockMFAUsecase.On("CompleteLogin", mock.Anything, "mfa", "123456", mock.AnythingOfType("string")).Return("jwt", nil).Once()
+
+		resp, err := s.users.CompleteLogin(context.Background(), &pb.CompleteLoginRequest{MfaToken: "mfa", Code: "123456"})
+		s.Require().NoError(err)
+		s.Equal("jwt", resp.GetToken())
+	})
+
+	s.Run("Wrong code", func() {
+		s.mockMFAUsecase.On("CompleteLogin", mock.Anything, "mfa", "000000", mock.AnythingOfType("string")).Return("", domain.ErrInvalidMFACode).Once()
+
+		_, err := s.users.CompleteLogin(context.Background(), &pb.CompleteLoginRequest{MfaToken: "mfa", Code: "000000"})
+		s.Equal(codes.Unauthenticated, status.Code(err))
+	})
+
+	s.Run("Locked out", func() {
+		s.mockMFAUsecase.On("CompleteLogin", mock.Anything, "mfa", "000000", mock.AnythingOfType("string")).
+			Return("", &domain.LoginBlockedError{Until: time.Now().Add(time.Minute)}).Once()
+
+		_, err := s.users.CompleteLogin(context.Background(), &pb.CompleteLoginRequest{MfaToken: "mfa", Code: "000000"})
+		st := status.Convert(err)
+		s.Equal(codes.ResourceExhausted, st.Code())
+		s.Require().Len(st.Details(), 1)
+	})
+}
+
+func (s *ServerTestSuite) TestPromoteUser() {
+	s.mockUserUsecase.On("PromoteUser", mock.Anything, "ghost").Return(domain.ErrUserNotFound).Once()
+
+	_, err := s.users.PromoteUser(withToken("admin-token"), &pb.PromoteUserRequest{Username: "ghost"})
+	s.Equal(codes.NotFound, status.Code(err))
+}
+
+func TestServerSuite(t *testing.T) {
+	suite.Run(t, new(ServerTestSuite))
+}
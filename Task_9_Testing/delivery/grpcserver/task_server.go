@@ -0,0 +1,113 @@
+package grpcserver
+
+import (
+	"context"
+	"errors"
+	"task_manager/delivery/grpcserver/pb"
+	"task_manager/domain"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+type TaskServer struct {
+	pb.UnimplementedTaskServiceServer
+	taskUsecase domain.TaskUsecase
+}
+
+func NewTaskServer(taskUsecase domain.TaskUsecase) *TaskServer {
+	return &TaskServer{taskUsecase: taskUsecase}
+}
+
+func (s *TaskServer) ListTasks(req *pb.ListTasksRequest, stream grpc.ServerStreamingServer[pb.Task]) error {
+	tasks, err := s.taskUsecase.GetAllTasks(stream.Context())
+	if err != nil {
+		return status.Error(codes.Internal, "Error fetching tasks")
+	}
+	for _, task := range tasks {
+		if err := stream.Send(taskToProto(task)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *TaskServer) GetTask(ctx context.Context, req *pb.GetTaskRequest) (*pb.Task, error) {
+	task, err := s.taskUsecase.GetTaskByID(ctx, req.GetId())
+	if errors.Is(err, domain.ErrTaskNotFound) {
+		return nil, status.Error(codes.NotFound, "Task not found")
+	}
+	if err != nil {
+		return nil, status.Error(codes.Internal, "Error fetching task")
+	}
+	return taskToProto(*task), nil
+}
+
+func (s *TaskServer) CreateTask(ctx context.Context, req *pb.CreateTaskRequest) (*pb.CreateTaskResponse, error) {
+	id, err := s.taskUsecase.AddTask(ctx, domain.Task{
+		Title:       req.GetTitle(),
+		Description: req.GetDescription(),
+		DueDate:     timeFromProto(req.GetDueDate()),
+		Status:      req.GetStatus(),
+	})
+	if st, ok := invalidArgument(err); ok {
+		return nil, st
+	}
+	if err != nil {
+		return nil, status.Error(codes.Internal, "Error creating task")
+	}
+	return &pb.CreateTaskResponse{Id: id}, nil
+}
+
+func (s *TaskServer) UpdateTask(ctx context.Context, req *pb.UpdateTaskRequest) (*emptypb.Empty, error) {
+	err := s.taskUsecase.UpdateTask(ctx, req.GetId(), domain.Task{
+		Title:       req.GetTitle(),
+		Description: req.GetDescription(),
+		DueDate:     timeFromProto(req.GetDueDate()),
+		Status:      req.GetStatus(),
+	})
+	if st, ok := invalidArgument(err); ok {
+		return nil, st
+	}
+	if errors.Is(err, domain.ErrTaskNotFound) {
+		return nil, status.Error(codes.NotFound, "Task not found")
+	}
+	if err != nil {
+		return nil, status.Error(codes.Internal, "Error updating task")
+	}
+	return &emptypb.Empty{}, nil
+}
+
+func (s *TaskServer) DeleteTask(ctx context.Context, req *pb.DeleteTaskRequest) (*emptypb.Empty, error) {
+	if err := s.taskUsecase.DeleteTask(ctx, req.GetId()); err != nil {
+		return nil, status.Error(codes.Internal, "Error deleting task")
+	}
+	return &emptypb.Empty{}, nil
+}
+
+func taskToProto(task domain.Task) *pb.Task {
+	out := &pb.Task{
+		Id:          task.ID,
+		Title:       task.Title,
+		Description: task.Description,
+		Status:      task.Status,
+		OwnerId:     task.OwnerID,
+	}
+	if !task.DueDate.IsZero() {
+		out.DueDate = timestamppb.New(task.DueDate)
+	}
+	return out
+}
+
+// timeFromProto keeps an unset due date as the zero time, which is what the
+// REST API binds when due_date is left out.
+func timeFromProto(ts *timestamppb.Timestamp) time.Time {
+	if ts == nil {
+		return time.Time{}
+	}
+	return ts.AsTime()
+}
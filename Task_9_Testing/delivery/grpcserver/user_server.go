@@ -0,0 +1,112 @@
+package grpcserver
+
+import (
+	"context"
+	"errors"
+	"task_manager/delivery/grpcserver/pb"
+	"task_manager/domain"
+	"task_manager/infrastructure"
+	"time"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+type UserServer struct {
+	pb.UnimplementedUserServiceServer
+	userUsecase domain.UserUsecase
+	mfaUsecase  domain.MFAUsecase
+}
+
+func NewUserServer(userUsecase domain.UserUsecase, mfaUsecase domain.MFAUsecase) *UserServer {
+	return &UserServer{userUsecase: userUsecase, mfaUsecase: mfaUsecase}
+}
+
+func (s *UserServer) Register(ctx context.Context, req *pb.RegisterRequest) (*emptypb.Empty, error) {
+	err := s.userUsecase.Register(ctx, domain.User{Username: req.GetUsername(), Password: req.GetPassword(), Email: req.GetEmail()})
+	if st, ok := invalidArgument(err); ok {
+		return nil, st
+	}
+	if errors.Is(err, domain.ErrUsernameTaken) || errors.Is(err, domain.ErrEmailInUse) {
+		return nil, status.Error(codes.AlreadyExists, err.Error())
+	}
+	if err != nil {
+		return nil, status.Error(codes.Internal, "Error registering user")
+	}
+	return &emptypb.Empty{}, nil
+}
+
+// Login answers like POST /v1/login: a locked out caller gets
+// ResourceExhausted with a RetryInfo detail, and an account with two-factor
+// authentication gets an mfa_token instead of a token.
+func (s *UserServer) Login(ctx context.Context, req *pb.LoginRequest) (*pb.LoginResponse, error) {
+	token, err := s.userUsecase.Login(ctx, req.GetUsername(), req.GetPassword(), infrastructure.GRPCPeerIP(ctx))
+	var blocked *domain.LoginBlockedError
+	if errors.As(err, &blocked) {
+		return nil, loginBlocked(blocked)
+	}
+	var mfaRequired *domain.MFARequiredError
+	if errors.As(err, &mfaRequired) {
+		return &pb.LoginResponse{MfaToken: mfaRequired.Token}, nil
+	}
+	if errors.Is(err, domain.ErrEmailNotVerified) || errors.Is(err, domain.ErrAccountDisabled) {
+		return nil, status.Error(codes.PermissionDenied, err.Error())
+	}
+	if err != nil {
+		return nil, status.Error(codes.Unauthenticated, err.Error())
+	}
+	return &pb.LoginResponse{Token: token}, nil
+}
+
+// CompleteLogin answers like POST /v1/login/2fa and counts wrong codes
+// against the same lockout as Login.
+func (s *UserServer) CompleteLogin(ctx context.Context, req *pb.CompleteLoginRequest) (*pb.CompleteLoginResponse, error) {
+	token, err := s.mfaUsecase.CompleteLogin(ctx, req.GetMfaToken(), req.GetCode(), infrastructure.GRPCPeerIP(ctx))
+	var blocked *domain.LoginBlockedError
+	switch {
+	case errors.As(err, &blocked):
+		return nil, loginBlocked(blocked)
+	case errors.Is(err, domain.ErrInvalidMFACode), errors.Is(err, domain.ErrInvalidMFAToken):
+		return nil, status.Error(codes.Unauthenticated, err.Error())
+	case errors.Is(err, domain.ErrAccountDisabled):
+		return nil, status.Error(codes.PermissionDenied, err.Error())
+	case err != nil:
+		return nil, status.Error(codes.Internal, "Error processing two-factor request")
+	}
+	return &pb.CompleteLoginResponse{Token: token}, nil
+}
+
+// loginBlocked is ResourceExhausted with a RetryInfo detail saying when to
+// try again.
+func loginBlocked(blocked *domain.LoginBlockedError) error {
+	st, detailErr := status.New(codes.ResourceExhausted, blocked.Error()).WithDetails(&errdetails.RetryInfo{RetryDelay: durationpb.New(time.Until(blocked.Until))})
+	if detailErr != nil {
+		return status.Error(codes.ResourceExhausted, blocked.Error())
+	}
+	return st.Err()
+}
+
+func (s *UserServer) PromoteUser(ctx context.Context, req *pb.PromoteUserRequest) (*emptypb.Empty, error) {
+	err := s.userUsecase.PromoteUser(ctx, req.GetUsername())
+	if errors.Is(err, domain.ErrUserNotFound) {
+		return nil, status.Error(codes.NotFound, err.Error())
+	}
+	if err != nil {
+		return nil, status.Error(codes.Internal, "Error promoting user")
+	}
+	return &emptypb.Empty{}, nil
+}
+
+func (s *UserServer) UnlockLogin(ctx context.Context, req *pb.UnlockLoginRequest) (*emptypb.Empty, error) {
+	err := s.userUsecase.UnlockLogin(ctx, req.GetUsername(), req.GetIp())
+	if st, ok := invalidArgument(err); ok {
+		return nil, st
+	}
+	if err != nil {
+		return nil, status.Error(codes.Internal, "Error unlocking login")
+	}
+	return &emptypb.Empty{}, nil
+}
@@ -3,41 +3,265 @@ package main
 import (
 	"context"
 	"log"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"strings"
 	"task_manager/delivery/controllers"
+	"task_manager/delivery/graph"
+	"task_manager/delivery/grpcserver"
 	"task_manager/delivery/routers"
+	"task_manager/domain"
 	"task_manager/infrastructure"
 	"task_manager/repositories"
 	"task_manager/usecases"
+	"time"
 
+	"github.com/gin-gonic/gin"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 func main() {
 	
-	client, err := mongo.Connect(context.Background(), options.Client().ApplyURI("mongodb://localhost:27017"))
+	logger := newLogger()
+	slog.SetDefault(logger)
+	// OTEL_TRACES_EXPORTER picks otlp, stdout or none (the default).
+	exporter, err := infrastructure.NewSpanExporter(context.Background(), envOr("OTEL_TRACES_EXPORTER", infrastructure.TraceExporterNone))
+	if err != nil {
+		log.Fatal("Tracing setup failed:", err)
+	}
+	shutdownTracing := infrastructure.SetupTracing(infrastructure.ServiceName, exporter)
+	defer shutdownTracing(context.Background())
+	// MONGO_URI and MONGO_DB are shared with the taskadmin tool.
+	client, err := mongo.Connect(context.Background(), options.Client().ApplyURI(envOr("MONGO_URI", "mongodb://localhost:27017")).SetMonitor(repositories.NewCommandMonitor()))
 	if err != nil {
 		log.Fatal("MongoDB connection failed:", err)
 	}
-	db := client.Database("task_maanager")
+	db := client.Database(envOr("MONGO_DB", "task_maanager"))
+	migrate(db)
 	taskCollection := db.Collection("tasks")
 	userCollection := db.Collection("users")
+	settingsCollection := db.Collection("settings")
+	apiKeyCollection := db.Collection("api_keys")
+	sessionCollection := db.Collection("sessions")
 
 	
-	taskRepo := repositories.NewTaskRepository(taskCollection)
-	userRepo := repositories.NewUserRepository(userCollection)
+	metrics := infrastructure.NewPrometheusMetrics()
+	taskRepo := repositories.InstrumentTaskRepository(repositories.NewTaskRepository(taskCollection), metrics)
+	userRepo := repositories.InstrumentUserRepository(repositories.NewUserRepository(userCollection), metrics)
+	settingsRepo := repositories.InstrumentSettingsRepository(repositories.NewSettingsRepository(settingsCollection), metrics)
+	apiKeyRepo := repositories.InstrumentAPIKeyRepository(repositories.NewAPIKeyRepository(apiKeyCollection), metrics)
+	sessionRepo := repositories.InstrumentSessionRepository(repositories.NewSessionRepository(sessionCollection), metrics)
 	passwordSvc := infrastructure.NewPasswordService()
-	jwtSvc := infrastructure.NewJWTService("oliyads-secrete-jwt")
-	taskUsecase := usecases.NewTaskUsecase(taskRepo)
-	userUsecase := usecases.NewUserUsecase(userRepo, passwordSvc, jwtSvc)
+	jwtSvc := newJWTService()
+	limiter := infrastructure.NewMemoryRateLimitStore()
+	mailer := newMailer()
+	taskUsecase := usecases.InstrumentTaskUsecase(usecases.NewTaskUsecase(taskRepo), metrics)
+	baseURL := envOr("APP_BASE_URL", "http://localhost:8080")
+	// VERIFY_SECRET signs the email verification links. It has no default:
+	// whoever knows it can mark any address as verified.
+	verifySecret := os.Getenv("VERIFY_SECRET")
+	if verifySecret == "" {
+		log.Fatal("VERIFY_SECRET must be set to sign email verification links")
+	}
+	verificationUsecase := usecases.InstrumentVerificationUsecase(usecases.NewVerificationUsecase(userRepo, mailer, verifySecret, baseURL), metrics)
+	requireVerification := os.Getenv("REQUIRE_EMAIL_VERIFICATION") == "true"
+	userUsecase := usecases.InstrumentUserUsecase(usecases.NewUserUsecase(userRepo, passwordSvc, jwtSvc, usecases.WithEmailVerification(verificationUsecase, requireVerification)), metrics)
+	profileUsecase := usecases.InstrumentProfileUsecase(usecases.NewProfileUsecase(userRepo, verificationUsecase), metrics)
+	passwordUsecase := usecases.InstrumentPasswordUsecase(usecases.NewPasswordUsecase(userRepo, passwordSvc, mailer), metrics)
+	mfaUsecase := usecases.InstrumentMFAUsecase(usecases.NewMFAUsecase(userRepo, settingsRepo, infrastructure.NewTOTPService("Task Manager"), jwtSvc), metrics)
+	apiKeyUsecase := usecases.InstrumentAPIKeyUsecase(usecases.NewAPIKeyUsecase(apiKeyRepo, userRepo), metrics)
+	userAdminUsecase := usecases.InstrumentUserAdminUsecase(usecases.NewUserAdminUsecase(userRepo, taskRepo), metrics)
+	sessionUsecase := usecases.InstrumentSessionUsecase(usecases.NewSessionUsecase(sessionRepo), metrics)
+	secureCookies := strings.HasPrefix(baseURL, "https://")
 
 
 	taskCtrl := controllers.NewTaskController(taskUsecase)
 	userCtrl := controllers.NewUserController(userUsecase)
+	userAdminCtrl := controllers.NewUserAdminController(userAdminUsecase)
+	passwordCtrl := controllers.NewPasswordController(passwordUsecase)
+	verificationCtrl := controllers.NewVerificationController(verificationUsecase)
+	profileCtrl := controllers.NewProfileController(profileUsecase)
+	mfaCtrl := controllers.NewMFAController(mfaUsecase)
+	apiKeyCtrl := controllers.NewAPIKeyController(apiKeyUsecase)
+	jwksCtrl := controllers.NewJWKSController(jwtSvc)
+	oidcCtrl := newOIDCController(userRepo, jwtSvc, metrics, baseURL, secureCookies)
+	sessionCtrl := controllers.NewSessionController(sessionUsecase, secureCookies)
+	graphHandler := graph.NewHandler(taskUsecase, userUsecase, userAdminUsecase, settingsRepo, limiter)
+
+	// gRPC gets its own port, GRPC_ADDR, next to the REST API.
+	grpcAuthorizer := infrastructure.NewGRPCAuthorizer(grpcserver.Policies, jwtSvc, apiKeyUsecase, userRepo, settingsRepo, limiter)
+	grpcServer := grpcserver.NewServer(taskUsecase, userUsecase, mfaUsecase, grpcAuthorizer, logger)
+	grpcListener, err := net.Listen("tcp", envOr("GRPC_ADDR", ":9090"))
+	if err != nil {
+		log.Fatal("gRPC listen failed:", err)
+	}
+	go func() {
+		if err := grpcServer.Serve(grpcListener); err != nil {
+			log.Fatal("gRPC server failed:", err)
+		}
+	}()
+	defer grpcServer.GracefulStop()
+
+	// Metrics get their own port, METRICS_ADDR, so route and usecase
+	// statistics stay off the public API. The default only listens locally.
+	go func() {
+		if err := http.ListenAndServe(envOr("METRICS_ADDR", "localhost:9091"), metrics.Handler()); err != nil {
+			log.Fatal("Metrics server failed:", err)
+		}
+	}()
 
-	router := routers.SetupRouter(taskCtrl, userCtrl, jwtSvc)
+	router := routers.SetupRouter(taskCtrl, userCtrl, userAdminCtrl, passwordCtrl, verificationCtrl, profileCtrl, mfaCtrl, apiKeyCtrl, jwksCtrl, oidcCtrl, sessionCtrl, graphHandler, jwtSvc, apiKeyUsecase, sessionUsecase, userRepo, settingsRepo, limiter, logger, metrics)
+	trustProxies(router)
 
 	if err := router.Run(":8080"); err != nil {
 		log.Fatal("Server failed to start:", err)
 	}
 }
+
+// newLogger writes JSON logs to stdout at LOG_LEVEL (debug, info, warn or
+// error; default info). Debug also logs every MongoDB command.
+func newLogger() *slog.Logger {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(envOr("LOG_LEVEL", "info"))); err != nil {
+		log.Fatal("Invalid LOG_LEVEL:", err)
+	}
+	return slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: level}))
+}
+
+// migrate applies pending schema migrations before serving, unless
+// MIGRATE_ON_START is false, for deployments that run taskadmin migrate as
+// a separate step. Instances starting together wait for whichever one takes
+// the migration lock.
+func migrate(db *mongo.Database) {
+	if envOr("MIGRATE_ON_START", "true") == "false" {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Minute)
+	defer cancel()
+	if _, err := repositories.NewMigrator(db).Up(ctx, false); err != nil {
+		log.Fatal("Migrations failed:", err)
+	}
+}
+
+// trustProxies lets the client IP come from X-Forwarded-For when the request
+// arrives from one of the comma separated addresses or CIDRs in
+// TRUSTED_PROXIES, or from the header named by TRUSTED_PLATFORM (such as
+// CF-Connecting-IP). Only list proxies that overwrite the header: the client
+// IP keys the rate limits and login lockouts.
+func trustProxies(router *gin.Engine) {
+	if proxies := os.Getenv("TRUSTED_PROXIES"); proxies != "" {
+		list := strings.Split(proxies, ",")
+		for i := range list {
+			list[i] = strings.TrimSpace(list[i])
+		}
+		if err := router.SetTrustedProxies(list); err != nil {
+			log.Fatal("Invalid TRUSTED_PROXIES:", err)
+		}
+	}
+	router.TrustedPlatform = os.Getenv("TRUSTED_PLATFORM")
+}
+
+func envOr(key, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return fallback
+}
+
+// newJWTService picks the token signing setup. JWT_KEY_DIR loads PEM keys
+// shared by every instance. Without it tokens are signed with the shared
+// JWT_SECRET (HS256) as before, unless JWT_ALG asks for RS256 or EdDSA keys
+// generated in memory and rotated every JWT_ROTATE_EVERY. Those keys die
+// with the process, so a restart logs everyone out and replicas reject each
+// other's tokens; they are for development and single instances only.
+// JWT_ISSUER and JWT_AUDIENCE set the iss and aud claims.
+func newJWTService() domain.JWTService {
+	opts := []infrastructure.JWTOption{
+		infrastructure.WithIssuer(envOr("JWT_ISSUER", infrastructure.DefaultIssuer)),
+		infrastructure.WithAudience(envOr("JWT_AUDIENCE", infrastructure.DefaultAudience)),
+	}
+	if dir := os.Getenv("JWT_KEY_DIR"); dir != "" {
+		ring, err := infrastructure.LoadKeyRing(dir)
+		if err != nil {
+			log.Fatal("Loading signing keys failed:", err)
+		}
+		return infrastructure.NewKeyRingJWTService(ring, opts...)
+	}
+
+	alg := envOr("JWT_ALG", "HS256")
+	if alg == "HS256" {
+		secret := os.Getenv("JWT_SECRET")
+		if secret == "" {
+			log.Fatal("JWT_SECRET must be set, or JWT_KEY_DIR for RS256 or EdDSA keys")
+		}
+		return infrastructure.NewJWTService(secret, opts...)
+	}
+	slog.Warn("signing keys are generated in memory, set JWT_KEY_DIR to share them between restarts and replicas", "alg", alg)
+	rotateEvery, err := time.ParseDuration(envOr("JWT_ROTATE_EVERY", "168h"))
+	if err != nil {
+		log.Fatal("Invalid JWT_ROTATE_EVERY:", err)
+	}
+	ring, err := infrastructure.NewKeyRing(alg, infrastructure.TokenTTL)
+	if err != nil {
+		log.Fatal("Generating signing key failed:", err)
+	}
+	go ring.RotateEvery(context.Background(), rotateEvery, func(err error) {
+		slog.Error("signing key rotation failed", "err", err)
+	})
+	return infrastructure.NewKeyRingJWTService(ring, opts...)
+}
+
+// newOIDCController enables single sign-on when OIDC_ISSUER is set, using
+// OIDC_CLIENT_ID, OIDC_CLIENT_SECRET and OIDC_REDIRECT_URL for the client
+// registration. Users whose OIDC_ROLE_CLAIM (default "groups") contains one of
+// the comma separated OIDC_ADMIN_ROLES become admins. OIDC_TRUST_AMR=true
+// lets MFA done at the provider count for the admin two-factor policy. It
+// returns nil when single sign-on is off.
+func newOIDCController(userRepo domain.UserRepository, jwtSvc domain.JWTService, metrics domain.Metrics, baseURL string, secureCookies bool) *controllers.OIDCController {
+	issuer := os.Getenv("OIDC_ISSUER")
+	if issuer == "" {
+		return nil
+	}
+	provider, err := infrastructure.NewOIDCProvider(context.Background(), infrastructure.OIDCConfig{
+		IssuerURL:    issuer,
+		ClientID:     os.Getenv("OIDC_CLIENT_ID"),
+		ClientSecret: os.Getenv("OIDC_CLIENT_SECRET"),
+		RedirectURL:  envOr("OIDC_REDIRECT_URL", baseURL+"/auth/oidc/callback"),
+		RoleClaim:    envOr("OIDC_ROLE_CLAIM", "groups"),
+	})
+	if err != nil {
+		log.Fatal("OIDC setup failed:", err)
+	}
+	var adminRoles []string
+	for _, role := range strings.Split(os.Getenv("OIDC_ADMIN_ROLES"), ",") {
+		if role = strings.TrimSpace(role); role != "" {
+			adminRoles = append(adminRoles, role)
+		}
+	}
+	var opts []usecases.OIDCUsecaseOption
+	if os.Getenv("OIDC_TRUST_AMR") == "true" {
+		opts = append(opts, usecases.WithTrustedAMR())
+	}
+	oidcUsecase := usecases.InstrumentOIDCUsecase(usecases.NewOIDCUsecase(provider, userRepo, jwtSvc, adminRoles, opts...), metrics)
+	return controllers.NewOIDCController(oidcUsecase, secureCookies)
+}
+
+// newMailer picks the mail transport from MAILER: "file" writes messages to
+// MAIL_DIR, "smtp" relays through SMTP_ADDR, anything else logs them.
+func newMailer() domain.Mailer {
+	switch os.Getenv("MAILER") {
+	case "file":
+		mailer, err := infrastructure.NewFileMailer(envOr("MAIL_DIR", "mail"))
+		if err != nil {
+			log.Fatal("Mailer setup failed:", err)
+		}
+		return mailer
+	case "smtp":
+		return infrastructure.NewSMTPMailer(envOr("SMTP_ADDR", "localhost:1025"), envOr("MAIL_FROM", "no-reply@task-manager.local"), nil)
+	default:
+		return infrastructure.NewLogMailer(nil)
+	}
+}
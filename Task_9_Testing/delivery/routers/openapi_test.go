@@ -0,0 +1,261 @@
+package routers
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"task_manager/delivery/controllers"
+	"task_manager/delivery/graph"
+	"task_manager/domain"
+	"task_manager/infrastructure"
+	"testing"
+	"time"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/getkin/kin-openapi/routers/gorillamux"
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// The stubs embed the interfaces they stand in for; methods the scenarios do
+// not need panic, which the router turns into a 500.
+
+type stubJWTService struct{ domain.JWTService }
+
+func (s stubJWTService) ValidateToken(token string) (*domain.Claims, error) {
+	if token != "admin-token" {
+		return nil, errors.New("invalid token")
+	}
+	return &domain.Claims{Username: "admin", Role: domain.RoleAdmin, RegisteredClaims: jwt.RegisteredClaims{Subject: "1"}}, nil
+}
+
+func (s stubJWTService) JWKS() domain.JSONWebKeySet {
+	return domain.JSONWebKeySet{Keys: []domain.JSONWebKey{{Kty: "OKP", Kid: "k1", Use: "sig", Alg: "EdDSA", Crv: "Ed25519", X: "abc"}}}
+}
+
+type stubUserRepository struct{ domain.UserRepository }
+
+func (r stubUserRepository) FindUserByID(ctx context.Context, id string) (*domain.User, error) {
+	return &domain.User{ID: id, Username: "admin", Role: domain.RoleAdmin}, nil
+}
+
+type stubSettingsRepository struct{ domain.SettingsRepository }
+
+func (r stubSettingsRepository) GetSecuritySettings(ctx context.Context) (*domain.SecuritySettings, error) {
+	return &domain.SecuritySettings{}, nil
+}
+
+type stubTaskUsecase struct{ domain.TaskUsecase }
+
+func (u stubTaskUsecase) GetAllTasks(ctx context.Context) ([]domain.Task, error) {
+	return []domain.Task{{ID: "1", Title: "Write docs", Status: domain.TaskStatusPending, DueDate: time.Now(), OwnerID: "1"}}, nil
+}
+
+func (u stubTaskUsecase) GetTaskByID(ctx context.Context, id string) (*domain.Task, error) {
+	return nil, domain.ErrTaskNotFound
+}
+
+func (u stubTaskUsecase) AddTask(ctx context.Context, task domain.Task) (string, error) {
+	if task.Title == "" {
+		return "", domain.ValidationErrors{{Field: "title", Message: "is required"}}
+	}
+	return "2", nil
+}
+
+type stubUserUsecase struct{ domain.UserUsecase }
+
+func (u stubUserUsecase) Login(ctx context.Context, username, password, ip string) (string, error) {
+	if username == "alice" {
+		return "", &domain.MFARequiredError{Token: "challenge"}
+	}
+	return "", errors.New("invalid username or password")
+}
+
+type stubUserAdminUsecase struct{ domain.UserAdminUsecase }
+
+func (u stubUserAdminUsecase) ListUsers(ctx context.Context, query domain.UserQuery) (*domain.UserPage, error) {
+	last := time.Now()
+	users := []domain.User{{ID: "2", Username: "alice", Role: domain.RoleUser, Email: "alice@example.com", CreatedAt: time.Now(), LastLoginAt: &last}}
+	return &domain.UserPage{Users: users, Page: 1, PerPage: 20, Total: 1}, nil
+}
+
+func (u stubUserAdminUsecase) SetRole(ctx context.Context, id, role string) error {
+	return domain.ErrCannotModifySelf
+}
+
+type stubProfileUsecase struct{ domain.ProfileUsecase }
+
+func (u stubProfileUsecase) GetProfile(ctx context.Context) (*domain.User, error) {
+	return &domain.User{ID: "1", Username: "admin", Role: domain.RoleAdmin, Verified: true, CreatedAt: time.Now()}, nil
+}
+
+func newContractRouter() *gin.Engine {
+	return newTestRouter(stubUserUsecase{}, nil)
+}
+
+func newTestRouter(userUsecase domain.UserUsecase, mfaUsecase domain.MFAUsecase) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	return SetupRouter(
+		controllers.NewTaskController(stubTaskUsecase{}),
+		controllers.NewUserController(userUsecase),
+		controllers.NewUserAdminController(stubUserAdminUsecase{}),
+		controllers.NewPasswordController(nil),
+		controllers.NewVerificationController(nil),
+		controllers.NewProfileController(stubProfileUsecase{}),
+		controllers.NewMFAController(mfaUsecase),
+		controllers.NewAPIKeyController(nil),
+		controllers.NewJWKSController(stubJWTService{}),
+		controllers.NewOIDCController(nil, false),
+		controllers.NewSessionController(nil, false),
+		graph.NewHandler(stubTaskUsecase{}, stubUserUsecase{}, stubUserAdminUsecase{}, stubSettingsRepository{}, nil),
+		stubJWTService{}, nil, nil, stubUserRepository{}, stubSettingsRepository{},
+		infrastructure.NewMemoryRateLimitStore(),
+		slog.New(slog.NewTextHandler(io.Discard, nil)),
+		infrastructure.NewPrometheusMetrics(),
+	)
+}
+
+func loadSpec(t *testing.T) *openapi3.T {
+	doc, err := openapi3.NewLoader().LoadFromData(OpenAPISpec)
+	require.NoError(t, err)
+	require.NoError(t, doc.Validate(context.Background()), "The spec itself should be valid OpenAPI 3")
+	return doc
+}
+
+var ginParam = regexp.MustCompile(`:(\w+)`)
+
+// TestSpecCoversRoutes fails when a route is added without documenting it, or
+// when the spec documents a route that no longer exists.
+func TestSpecCoversRoutes(t *testing.T) {
+	doc := loadSpec(t)
+	router := newContractRouter()
+
+	registered := map[string]bool{}
+	for _, route := range router.Routes() {
+		registered[route.Method+" "+ginParam.ReplaceAllString(route.Path, "{$1}")] = true
+	}
+	for _, route := range router.Routes() {
+		key := route.Method + " " + ginParam.ReplaceAllString(route.Path, "{$1}")
+		// Deprecated unversioned aliases are only documented under /v1.
+		if registered[route.Method+" /v1"+ginParam.ReplaceAllString(route.Path, "{$1}")] {
+			continue
+		}
+		path := doc.Paths.Value(ginParam.ReplaceAllString(route.Path, "{$1}"))
+		if assert.NotNil(t, path, "%s is not in the spec", key) {
+			assert.NotNil(t, path.GetOperation(route.Method), "%s is not in the spec", key)
+		}
+	}
+	for path, item := range doc.Paths.Map() {
+		for method := range item.Operations() {
+			assert.True(t, registered[method+" "+path], "%s %s is documented but not routed", method, path)
+		}
+	}
+}
+
+// TestResponsesMatchSpec drives the real handlers and validates both sides of
+// each exchange against the spec.
+func TestResponsesMatchSpec(t *testing.T) {
+	doc := loadSpec(t)
+	doc.Servers = nil
+	specRouter, err := gorillamux.NewRouter(doc)
+	require.NoError(t, err)
+	router := newContractRouter()
+
+	cases := []struct {
+		name, method, path, body string
+		token                    string
+		status                   int
+	}{
+		{"ListTasks", "GET", "/v1/tasks", "", "admin-token", http.StatusOK},
+		{"TaskNotFound", "GET", "/v1/tasks/9", "", "admin-token", http.StatusNotFound},
+		{"CreateTask", "POST", "/v1/tasks", `{"title":"Write docs","status":"pending"}`, "admin-token", http.StatusCreated},
+		{"CreateTaskInvalid", "POST", "/v1/tasks", `{"status":"pending"}`, "admin-token", http.StatusUnprocessableEntity},
+		{"NoToken", "GET", "/v1/tasks", "", "", http.StatusUnauthorized},
+		{"LoginFailed", "POST", "/v1/login", `{"username":"bob","password":"wrong"}`, "", http.StatusUnauthorized},
+		{"LoginNeedsMFA", "POST", "/v1/login", `{"username":"alice","password":"secret"}`, "", http.StatusOK},
+		{"Profile", "GET", "/v1/me", "", "admin-token", http.StatusOK},
+		{"ListUsers", "GET", "/v1/users?per_page=20", "", "admin-token", http.StatusOK},
+		{"PromoteSelf", "POST", "/v1/users/1/promote", "", "admin-token", http.StatusConflict},
+		{"JWKS", "GET", "/.well-known/jwks.json", "", "", http.StatusOK},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(tc.method, tc.path, strings.NewReader(tc.body))
+			if tc.body != "" {
+				req.Header.Set("Content-Type", "application/json")
+			}
+			if tc.token != "" {
+				req.Header.Set("Authorization", "Bearer "+tc.token)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			require.Equal(t, tc.status, w.Code, w.Body.String())
+
+			route, pathParams, err := specRouter.FindRoute(req)
+			require.NoError(t, err)
+			input := &openapi3filter.RequestValidationInput{
+				Request:    req,
+				PathParams: pathParams,
+				Route:      route,
+				Options:    &openapi3filter.Options{AuthenticationFunc: openapi3filter.NoopAuthenticationFunc},
+			}
+			if tc.body != "" {
+				req.Body = io.NopCloser(strings.NewReader(tc.body))
+			}
+			assert.NoError(t, openapi3filter.ValidateRequest(context.Background(), input))
+			assert.NoError(t, openapi3filter.ValidateResponse(context.Background(), &openapi3filter.ResponseValidationInput{
+				RequestValidationInput: input,
+				Status:                 w.Code,
+				Header:                 w.Header(),
+				Body:                   io.NopCloser(bytes.NewReader(w.Body.Bytes())),
+			}))
+		})
+	}
+}
+
+// TestUnversionedAliasesAreDeprecated checks that the old paths still answer
+// like /v1 but announce their sunset, while /v1 itself does not.
+func TestUnversionedAliasesAreDeprecated(t *testing.T) {
+	router := newContractRouter()
+
+	legacy := httptest.NewRequest("GET", "/tasks", nil)
+	legacy.Header.Set("Authorization", "Bearer admin-token")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, legacy)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "@1792368000", w.Header().Get("Deprecation"))
+	assert.Equal(t, "Mon, 19 Apr 2027 00:00:00 GMT", w.Header().Get("Sunset"))
+	assert.Equal(t, `</v1/tasks>; rel="successor-version"`, w.Header().Get("Link"))
+
+	current := httptest.NewRequest("GET", "/v1/tasks", nil)
+	current.Header.Set("Authorization", "Bearer admin-token")
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, current)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Empty(t, w.Header().Get("Deprecation"))
+	assert.Empty(t, w.Header().Get("Sunset"))
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/.well-known/jwks.json", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Empty(t, w.Header().Get("Deprecation"), "Unversioned-only routes are not aliases")
+}
+
+func TestServeOpenAPI(t *testing.T) {
+	router := newContractRouter()
+	for path, contentType := range map[string]string{"/openapi.json": "application/json", "/docs": "text/html"} {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Header().Get("Content-Type"), contentType)
+	}
+}
@@ -1,40 +1,125 @@
 package routers
 
 import (
+	"log/slog"
+	"time"
 	"task_manager/delivery/controllers"
+	"task_manager/delivery/graph"
 	"task_manager/domain" 
 
 	"task_manager/infrastructure"
 
 	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
 )
 
-func SetupRouter(taskCtrl *controllers.TaskController, userCtrl *controllers.UserController, jwtSvc domain.JWTService) *gin.Engine {
-	router := gin.Default()
+// The unversioned API paths are deprecated aliases of /v1 as of
+// unversionedDeprecated and are removed after unversionedSunset.
+var (
+	unversionedDeprecated = time.Date(2026, time.October, 19, 0, 0, 0, 0, time.UTC)
+	unversionedSunset     = time.Date(2027, time.April, 19, 0, 0, 0, 0, time.UTC)
+)
 
+func SetupRouter(taskCtrl *controllers.TaskController, userCtrl *controllers.UserController, userAdminCtrl *controllers.UserAdminController, passwordCtrl *controllers.PasswordController, verificationCtrl *controllers.VerificationController, profileCtrl *controllers.ProfileController, mfaCtrl *controllers.MFAController, apiKeyCtrl *controllers.APIKeyController, jwksCtrl *controllers.JWKSController, oidcCtrl *controllers.OIDCController, sessionCtrl *controllers.SessionController, graphHandler *graph.Handler, jwtSvc domain.JWTService, apiKeys domain.APIKeyUsecase, sessions domain.SessionUsecase, userRepo domain.UserRepository, settingsRepo domain.SettingsRepository, limiter domain.RateLimitStore, logger *slog.Logger, metrics *infrastructure.PrometheusMetrics) *gin.Engine {
+	router := gin.New()
+	// Trust no proxy until the caller says otherwise, so c.ClientIP() is the
+	// peer address and clients cannot pick their own rate limit and login
+	// lockout keys with X-Forwarded-For. delivery/main.go reads
+	// TRUSTED_PROXIES.
+	router.SetTrustedProxies(nil)
+	// Tracing, logging and metrics come first so recovered panics are counted
+	// as 500s. Handlers pass c.Request.Context() on, which carries the span, the
+	// request logger and the caller stored by AuthMiddleware.
+	router.Use(otelgin.Middleware(infrastructure.ServiceName), infrastructure.RequestLogger(logger), metrics.Middleware(), gin.Recovery())
+	router.GET("/openapi.json", serveOpenAPI)
+	router.GET("/docs", serveDocs)
 
-	router.POST("/register", userCtrl.Register)
-	router.POST("/login", userCtrl.Login)
-	router.GET("/users", userCtrl.GetAllUsers)
 
+	// Single sign-on stays unversioned: its callback URL is registered with the
+	// identity provider. oidcCtrl is nil when no provider is configured.
+	if oidcCtrl != nil {
+		sso := router.Group("/auth/oidc", infrastructure.RateLimitMiddleware(limiter, infrastructure.CredentialsLimit))
+		sso.GET("/login", oidcCtrl.Login)
+		sso.GET("/callback", oidcCtrl.Callback)
+	}
+	router.GET("/.well-known/jwks.json", infrastructure.RateLimitMiddleware(limiter, infrastructure.PublicLimit), jwksCtrl.GetJWKS)
 
-	auth := router.Group("/").Use(infrastructure.AuthMiddleware(jwtSvc))
-	{
-		auth.GET("/tasks", taskCtrl.GetTasks)
-		auth.GET("/tasks/:id", taskCtrl.GetTask)
-		auth.DELETE("/tasks/:id", taskCtrl.RemoveTask)
-		auth.POST("/tasks", taskCtrl.AddTask)
+	credentials := []gin.HandlerFunc{infrastructure.RateLimitMiddleware(limiter, infrastructure.CredentialsLimit)}
+	public := []gin.HandlerFunc{infrastructure.RateLimitMiddleware(limiter, infrastructure.PublicLimit)}
+	auth := []gin.HandlerFunc{infrastructure.AuthMiddleware(jwtSvc, apiKeys, sessions), infrastructure.RevocationMiddleware(userRepo), infrastructure.RateLimitMiddleware(limiter, infrastructure.AuthLimit)}
+	// Account management needs a real session; API keys are turned away.
+	account := []gin.HandlerFunc{infrastructure.AuthMiddleware(jwtSvc, apiKeys, sessions), infrastructure.RevocationMiddleware(userRepo), infrastructure.SessionOnlyMiddleware(), infrastructure.RateLimitMiddleware(limiter, infrastructure.AuthLimit)}
+	admin := []gin.HandlerFunc{infrastructure.AuthMiddleware(jwtSvc, apiKeys, sessions), infrastructure.RevocationMiddleware(userRepo), infrastructure.AdminMiddleware(), infrastructure.RequireScope(domain.ScopeAdmin), infrastructure.MFAPolicyMiddleware(settingsRepo), infrastructure.RateLimitMiddleware(limiter, infrastructure.AdminLimit)}
 
-	}
+	// GraphQL evolves its schema in place instead of by version, so it stays
+	// at the root. Resolvers check scopes and admin rights per field.
+	router.Group("/graphql", auth...).POST("", graphHandler.Serve)
 
+	registerV1 := func(api *gin.RouterGroup) {
+		v1Credentials := api.Group("", credentials...)
+		{
+			v1Credentials.POST("/register", userCtrl.Register)
+			v1Credentials.POST("/login", userCtrl.Login)
+			v1Credentials.POST("/login/2fa", mfaCtrl.CompleteLogin)
+			v1Credentials.POST("/password/forgot", passwordCtrl.ForgotPassword)
+			v1Credentials.POST("/password/reset", passwordCtrl.ResetPassword)
+			v1Credentials.POST("/verify/resend", verificationCtrl.ResendVerification)
+		}
 
-	admin := router.Group("/").Use(infrastructure.AuthMiddleware(jwtSvc), infrastructure.AdminMiddleware())
-	{
+		v1Public := api.Group("", public...)
+		{
+			v1Public.GET("/verify", verificationCtrl.VerifyEmail)
+		}
 
-		admin.PUT("/tasks/:id", taskCtrl.UpdateTask)
+		v1Auth := api.Group("", auth...)
+		{
+			v1Auth.GET("/tasks", infrastructure.RequireScope(domain.ScopeTasksRead), taskCtrl.GetTasks)
+			v1Auth.GET("/tasks/:id", infrastructure.RequireScope(domain.ScopeTasksRead), taskCtrl.GetTask)
+			v1Auth.DELETE("/tasks/:id", infrastructure.RequireScope(domain.ScopeTasksWrite), taskCtrl.RemoveTask)
+			v1Auth.POST("/tasks", infrastructure.RequireScope(domain.ScopeTasksWrite), taskCtrl.AddTask)
+		}
 
-		admin.POST("/promote", userCtrl.PromoteUser)
+		v1Account := api.Group("/me", account...)
+		{
+			v1Account.GET("", profileCtrl.GetProfile)
+			v1Account.PATCH("", profileCtrl.UpdateProfile)
+			v1Account.POST("/session", sessionCtrl.StartSession)
+			v1Account.DELETE("/session", sessionCtrl.EndSession)
+			v1Account.POST("/password", passwordCtrl.ChangePassword)
+			v1Account.POST("/2fa/enroll", mfaCtrl.EnrollTOTP)
+			v1Account.POST("/2fa/confirm", mfaCtrl.ConfirmTOTP)
+			v1Account.POST("/2fa/disable", mfaCtrl.DisableTOTP)
+			v1Account.GET("/api-keys", apiKeyCtrl.ListAPIKeys)
+			v1Account.POST("/api-keys", apiKeyCtrl.CreateAPIKey)
+			v1Account.DELETE("/api-keys/:id", apiKeyCtrl.RevokeAPIKey)
+		}
+
+		v1Admin := api.Group("", admin...)
+		{
+			v1Admin.PUT("/tasks/:id", taskCtrl.UpdateTask)
+			v1Admin.PATCH("/tasks/:id", taskCtrl.PatchTask)
+
+			v1Admin.POST("/promote", userCtrl.PromoteUser)
+			v1Admin.GET("/users", userAdminCtrl.ListUsers)
+			v1Admin.GET("/users/:id", userAdminCtrl.GetUser)
+			v1Admin.POST("/users/:id/promote", userAdminCtrl.Promote)
+			v1Admin.POST("/users/:id/demote", userAdminCtrl.Demote)
+			v1Admin.POST("/users/:id/disable", userAdminCtrl.Disable)
+			v1Admin.POST("/users/:id/enable", userAdminCtrl.Enable)
+			v1Admin.DELETE("/users/:id", userAdminCtrl.DeleteUser)
+			v1Admin.POST("/unlock", userCtrl.UnlockLogin)
+			v1Admin.GET("/settings/security", mfaCtrl.GetSecuritySettings)
+			v1Admin.PUT("/settings/security", mfaCtrl.UpdateSecuritySettings)
+		}
 	}
 
+	// A later version gets its own register function next to registerV1 and
+	// its own group, e.g. router.Group("/v2"). It reuses the middleware chains
+	// and usecases above and only swaps the controllers whose payloads changed.
+	registerV1(router.Group("/v1"))
+	// The unversioned paths predate /v1 and stay until unversionedSunset for
+	// clients that cannot update quickly, such as older mobile releases.
+	registerV1(router.Group("/", infrastructure.DeprecatedMiddleware(unversionedDeprecated, unversionedSunset, "/v1")))
+
 	return router
 }
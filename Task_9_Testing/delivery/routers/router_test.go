@@ -0,0 +1,96 @@
+package routers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"task_manager/domain"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// ipLockout blocks an IP after three failed logins, like the per-IP guard of
+// the login usecases, and shares the count between both login steps.
+type ipLockout struct {
+	failures map[string]int
+}
+
+func (l *ipLockout) fail(ip string) error {
+	if l.failures[ip] >= 3 {
+		return &domain.LoginBlockedError{Until: time.Now().Add(time.Minute), Locked: true}
+	}
+	l.failures[ip]++
+	return errors.New("invalid username or password")
+}
+
+type lockoutUserUsecase struct {
+	domain.UserUsecase
+	*ipLockout
+}
+
+func (u lockoutUserUsecase) Login(ctx context.Context, username, password, ip string) (string, error) {
+	return "", u.fail(ip)
+}
+
+type lockoutMFAUsecase struct {
+	domain.MFAUsecase
+	*ipLockout
+}
+
+func (u lockoutMFAUsecase) CompleteLogin(ctx context.Context, mfaToken, code, ip string) (string, error) {
+	return "", u.fail(ip)
+}
+
+func post(router http.Handler, path, body, forwardedFor string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Forwarded-For", forwardedFor)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func postLogin(router http.Handler, username, forwardedFor string) *httptest.ResponseRecorder {
+	return post(router, "/v1/login", `{"username":"`+username+`","password":"wrong"}`, forwardedFor)
+}
+
+// TestForwardedForDoesNotResetRateLimit sends every request from the same
+// peer with a new X-Forwarded-For, which must not give it a new bucket.
+func TestForwardedForDoesNotResetRateLimit(t *testing.T) {
+	router := newContractRouter()
+
+	for i := 0; i < 5; i++ {
+		w := postLogin(router, "bob", fmt.Sprintf("203.0.113.%d", i))
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	}
+	w := postLogin(router, "bob", "203.0.113.99")
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+}
+
+// TestForwardedForDoesNotDodgeLoginLockout rotates X-Forwarded-For across
+// both login steps; the usecases must still see one IP.
+func TestForwardedForDoesNotDodgeLoginLockout(t *testing.T) {
+	lockout := &ipLockout{failures: map[string]int{}}
+	router := newTestRouter(lockoutUserUsecase{ipLockout: lockout}, lockoutMFAUsecase{ipLockout: lockout})
+
+	for i := 0; i < 2; i++ {
+		w := postLogin(router, "bob", fmt.Sprintf("203.0.113.%d", i))
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	}
+	w := post(router, "/v1/login/2fa", `{"mfa_token":"challenge","code":"000000"}`, "203.0.113.50")
+	assert.NotEqual(t, http.StatusOK, w.Code)
+	w = postLogin(router, "bob", "203.0.113.99")
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Len(t, lockout.failures, 1, "Every attempt counts against the peer address")
+}
+
+func TestMetricsAreNotPublic(t *testing.T) {
+	w := httptest.NewRecorder()
+	newContractRouter().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	assert.Equal(t, http.StatusNotFound, w.Code, "Metrics are served on METRICS_ADDR")
+}
@@ -2,9 +2,81 @@ package domain
 
 import (
 	"context"
+	"errors"
 	"time"
+)
+
+
+const (
+	MergePatchMediaType = "application/merge-patch+json"
+	JSONPatchMediaType  = "application/json-patch+json"
+)
+
+
+var (
+	ErrTaskNotFound     = errors.New("task not found")
+	ErrUnsupportedPatch = errors.New("unsupported patch media type")
+	ErrInvalidPatch     = errors.New("invalid patch")
+	ErrTaskConflict     = errors.New("task was changed by another request, fetch it and try again")
+
+	ErrIncorrectPassword = errors.New("current password is incorrect")
+	ErrInvalidResetToken = errors.New("invalid or expired reset token")
+
+	ErrEmailNotVerified   = errors.New("email address not verified")
+	ErrInvalidVerifyToken = errors.New("invalid or expired verification link")
+
+	ErrInvalidMFACode    = errors.New("invalid two-factor code")
+	ErrInvalidMFAToken   = errors.New("invalid or expired two-factor login token")
+	ErrMFANotEnrolled    = errors.New("two-factor authentication is not set up")
+	ErrMFAAlreadyEnabled = errors.New("two-factor authentication is already enabled")
+
+	ErrAPIKeyNotFound = errors.New("api key not found")
+	ErrInvalidAPIKey  = errors.New("invalid or expired api key")
+
+	ErrInvalidSession = errors.New("invalid or expired session")
+	ErrSessionExists  = errors.New("already signed in with a session cookie")
+
+	ErrUsernameTaken    = errors.New("username already exists")
+	ErrEmailInUse       = errors.New("email already in use")
+	ErrUserNotFound     = errors.New("user not found")
+	ErrAccountDisabled  = errors.New("account is disabled")
+	ErrCannotModifySelf = errors.New("admins cannot demote, disable or delete themselves")
+
+	ErrOIDCLoginFailed   = errors.New("single sign-on failed")
+	ErrOIDCEmailConflict = errors.New("an account with this email exists but the address is not verified")
+)
+
+
+const (
+	RoleAdmin = "admin"
+	RoleUser  = "user"
+)
+
 
-	"github.com/golang-jwt/jwt/v5"
+// APIKeyPrefix marks personal access tokens so they can be told apart from JWTs.
+const APIKeyPrefix = "tm_"
+
+
+// Browser sessions: the cookie carries the session token, and unsafe requests
+// must echo the session's CSRF token in the header.
+const (
+	SessionCookieName = "session"
+	CSRFHeader        = "X-CSRF-Token"
+)
+
+
+// API key scopes. Session tokens are not scoped and may do anything the role allows.
+const (
+	ScopeTasksRead  = "tasks:read"
+	ScopeTasksWrite = "tasks:write"
+	ScopeAdmin      = "admin"
+)
+
+
+const (
+	TaskStatusPending    = "pending"
+	TaskStatusInProgress = "in_progress"
+	TaskStatusDone       = "done"
 )
 
 
@@ -14,23 +86,254 @@ type Task struct {
 	Description string    `json:"description" bson:"description"`
 	DueDate     time.Time `json:"due_date" bson:"due_date"`
 	Status      string    `json:"status" bson:"status"`
+	// OwnerID is the user who created the task. It is empty for tasks created
+	// before ownership was recorded and for tasks orphaned by a user deletion.
+	OwnerID string `json:"owner_id,omitempty" bson:"owner_id,omitempty"`
+	// Version counts the writes to the task, starting at 1. The repository
+	// increments it; values sent by clients are ignored.
+	Version int `json:"version" bson:"version,omitempty"`
+}
+
+
+// TaskFilter narrows FindTasks. Zero fields match every task.
+type TaskFilter struct {
+	Status    string
+	OwnerIDs  []string
+	DueAfter  *time.Time
+	DueBefore *time.Time
+	// Search matches the title or description, ignoring case.
+	Search string
 }
 
 
 type User struct {
 	ID       string `json:"id" bson:"_id"`
 	Username string `json:"username" bson:"username"`
-	Password string `json:"password" bson:"password"` 
+	// Password holds the bcrypt hash and is never serialized.
+	Password string `json:"-" bson:"password"`
 	Role     string `json:"role" bson:"role"`
+	Email    string `json:"email,omitempty" bson:"email,omitempty"`
+	Verified bool   `json:"verified" bson:"verified"`
+	Disabled bool   `json:"disabled" bson:"disabled"`
+
+	DisplayName string `json:"display_name,omitempty" bson:"display_name,omitempty"`
+	AvatarURL   string `json:"avatar_url,omitempty" bson:"avatar_url,omitempty"`
+	// TimeZone is an IANA name such as "Europe/Berlin"; Locale is a BCP 47 tag.
+	TimeZone    string     `json:"time_zone,omitempty" bson:"time_zone,omitempty"`
+	Locale      string     `json:"locale,omitempty" bson:"locale,omitempty"`
+	CreatedAt   time.Time  `json:"created_at" bson:"created_at"`
+	LastLoginAt *time.Time `json:"last_login_at,omitempty" bson:"last_login_at,omitempty"`
+	// Tokens issued before this instant are rejected, so changing the
+	// password logs out every existing session.
+	PasswordChangedAt time.Time `json:"-" bson:"password_changed_at,omitempty"`
+
+	// OIDCIssuer and OIDCSubject link the account to a single sign-on identity.
+	OIDCIssuer  string `json:"-" bson:"oidc_issuer,omitempty"`
+	OIDCSubject string `json:"-" bson:"oidc_subject,omitempty"`
+
+	TOTPEnabled bool   `json:"totp_enabled" bson:"totp_enabled"`
+	TOTPSecret  string `json:"-" bson:"totp_secret,omitempty"`
+	// TOTPLastStep is the last accepted time step, so a code cannot be replayed.
+	TOTPLastStep  int64    `json:"-" bson:"totp_last_step,omitempty"`
+	RecoveryCodes []string `json:"-" bson:"recovery_codes,omitempty"`
+}
+
+
+// OIDCIdentity is what an OpenID Connect provider vouched for in a verified ID token.
+type OIDCIdentity struct {
+	Issuer            string
+	Subject           string
+	Email             string
+	EmailVerified     bool
+	Name              string
+	PreferredUsername string
+	// Roles holds the values of the claim configured for role mapping, such as groups.
+	Roles []string
+	AMR   []string
+}
+
+
+// OIDCAuthRequest is the per-login secret state of the authorization code
+// flow. It is kept by the browser between the redirect and the callback.
+type OIDCAuthRequest struct {
+	State    string
+	Nonce    string
+	Verifier string
+}
+
+
+// ProfileUpdate is the body of PATCH /me. Nil fields are left unchanged and an
+// empty string clears the field, except for email which cannot be removed.
+type ProfileUpdate struct {
+	DisplayName *string `json:"display_name"`
+	Email       *string `json:"email"`
+	AvatarURL   *string `json:"avatar_url"`
+	TimeZone    *string `json:"time_zone"`
+	Locale      *string `json:"locale"`
+}
+
+
+// UserQuery selects a page of users. Search matches username or email,
+// case-insensitively.
+type UserQuery struct {
+	Search   string `form:"q"`
+	Role     string `form:"role"`
+	Disabled *bool  `form:"disabled"`
+	Page     int    `form:"page"`
+	PerPage  int    `form:"per_page"`
+}
+
+
+type UserPage struct {
+	Users   []User `json:"users"`
+	Page    int    `json:"page"`
+	PerPage int    `json:"per_page"`
+	Total   int64  `json:"total"`
+}
+
+
+// PasswordReset is a pending reset. Only the SHA-256 of the emailed token is stored.
+type PasswordReset struct {
+	TokenHash string    `bson:"_id"`
+	UserID    string    `bson:"user_id"`
+	ExpiresAt time.Time `bson:"expires_at"`
+}
+
+
+// MFAChallenge is the server side of the intermediate token handed out after a
+// correct password when the user has two-factor authentication enabled.
+type MFAChallenge struct {
+	TokenHash string    `bson:"_id"`
+	UserID    string    `bson:"user_id"`
+	ExpiresAt time.Time `bson:"expires_at"`
+	// AMR records how the first factor was proven; empty means a password.
+	AMR []string `bson:"amr,omitempty"`
+}
+
+
+// TOTPEnrollment is returned once, when a user starts enrolling an authenticator.
+type TOTPEnrollment struct {
+	Secret        string   `json:"secret"`
+	URI           string   `json:"otpauth_uri"`
+	QRCodePNG     []byte   `json:"qr_png"`
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+
+// APIKey is a personal access token. Only the SHA-256 of the key is stored;
+// Prefix is kept so users can tell their keys apart.
+type APIKey struct {
+	ID         string     `json:"id" bson:"_id"`
+	UserID     string     `json:"-" bson:"user_id"`
+	Name       string     `json:"name" bson:"name"`
+	Prefix     string     `json:"prefix" bson:"prefix"`
+	KeyHash    string     `json:"-" bson:"key_hash"`
+	Scopes     []string   `json:"scopes" bson:"scopes"`
+	CreatedAt  time.Time  `json:"created_at" bson:"created_at"`
+	ExpiresAt  time.Time  `json:"expires_at" bson:"expires_at"`
+	LastUsedAt *time.Time `json:"last_used_at" bson:"last_used_at,omitempty"`
+}
+
+
+// Session is a server-side browser session. Neither the cookie value nor the
+// CSRF token is stored, only their SHA-256, so a database leak cannot be
+// replayed.
+type Session struct {
+	ID          string    `json:"-" bson:"_id"`
+	UserID      string    `json:"-" bson:"user_id"`
+	Username    string    `json:"-" bson:"username"`
+	Role        string    `json:"-" bson:"role"`
+	AuthMethods []string  `json:"-" bson:"auth_methods"`
+	CSRFHash    string    `json:"-" bson:"csrf_hash"`
+	CreatedAt   time.Time `json:"created_at" bson:"created_at"`
+	ExpiresAt   time.Time `json:"expires_at" bson:"expires_at"`
+}
+
+
+// JSONWebKey is the public half of a token signing key (RFC 7517). RSA keys
+// fill N and E, Ed25519 keys fill Crv and X.
+type JSONWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+
+type JSONWebKeySet struct {
+	Keys []JSONWebKey `json:"keys"`
+}
+
+
+type SecuritySettings struct {
+	RequireAdminMFA bool `json:"require_admin_mfa" bson:"require_admin_mfa"`
+}
+
+
+// MFARequiredError is returned by Login when the password was right but a
+// second factor is still needed. Token identifies the pending login.
+type MFARequiredError struct {
+	Token string
+}
+
+func (e *MFARequiredError) Error() string {
+	return "two-factor authentication required"
+}
+
+
+// LoginAttempts tracks failed logins for one key, either "user:<username>" or "ip:<address>".
+type LoginAttempts struct {
+	Key         string    `json:"key" bson:"_id"`
+	Failures    int       `json:"failures" bson:"failures"`
+	LastFailure time.Time `json:"last_failure" bson:"last_failure"`
+	LockedUntil time.Time `json:"locked_until" bson:"locked_until"`
+}
+
+
+// LockEvent is written every time a username or IP gets locked out.
+type LockEvent struct {
+	ID          string    `json:"id" bson:"_id"`
+	Key         string    `json:"key" bson:"key"`
+	Username    string    `json:"username" bson:"username"`
+	IP          string    `json:"ip" bson:"ip"`
+	Failures    int       `json:"failures" bson:"failures"`
+	LockedAt    time.Time `json:"locked_at" bson:"locked_at"`
+	LockedUntil time.Time `json:"locked_until" bson:"locked_until"`
+}
+
+
+// LoginBlockedError is returned by Login while a caller must wait before trying
+// again, either because of a progressive delay or because of a lock.
+type LoginBlockedError struct {
+	Until  time.Time
+	Locked bool
+}
+
+func (e *LoginBlockedError) Error() string {
+	if e.Locked {
+		return "too many failed login attempts, account temporarily locked"
+	}
+	return "too many failed login attempts, try again later"
 }
 
 
 type TaskRepository interface {
 	AddTask(ctx context.Context, task Task) (string, error)
 	GetAllTasks(ctx context.Context) ([]Task, error)
+	FindTasks(ctx context.Context, filter TaskFilter) ([]Task, error)
 	GetTaskByID(ctx context.Context, id string) (*Task, error)
 	UpdateTask(ctx context.Context, id string, task Task) error
+	// PatchTask only writes while the stored version is still version and
+	// returns ErrTaskConflict when another write came first.
+	PatchTask(ctx context.Context, id string, version int, fields map[string]interface{}) error
 	DeleteTask(ctx context.Context, id string) error
+	// ReassignTasks moves every task of one owner to another; an empty
+	// toOwnerID leaves them without an owner.
+	ReassignTasks(ctx context.Context, fromOwnerID, toOwnerID string) (int64, error)
 
 }
 
@@ -38,27 +341,167 @@ type TaskRepository interface {
 type UserRepository interface {
 	CreateUser(ctx context.Context, user User) error
 	FindUserByUsername(ctx context.Context, username string) (*User, error)
+	FindUserByID(ctx context.Context, id string) (*User, error)
+	// FindUsersByIDs skips ids that do not exist.
+	FindUsersByIDs(ctx context.Context, ids []string) ([]User, error)
+	FindUserByEmail(ctx context.Context, email string) (*User, error)
+	UpdatePassword(ctx context.Context, id, hashed string, changedAt time.Time) error
+	MarkEmailVerified(ctx context.Context, id, email string) (bool, error)
+	FindUserByOIDCSubject(ctx context.Context, issuer, subject string) (*User, error)
+	LinkOIDCIdentity(ctx context.Context, id, issuer, subject string) error
+	// UpdateProfile $sets the given bson fields.
+	UpdateProfile(ctx context.Context, id string, fields map[string]interface{}) error
+	RecordLogin(ctx context.Context, id string, at time.Time) error
+	SetTOTPSecret(ctx context.Context, id, secret string, recoveryCodes []string) error
+	EnableTOTP(ctx context.Context, id string) error
+	DisableTOTP(ctx context.Context, id string) error
+	AdvanceTOTPStep(ctx context.Context, id string, step int64) (bool, error)
+	ConsumeRecoveryCode(ctx context.Context, id, codeHash string) (bool, error)
 	PromoteUser(ctx context.Context, username string) error
+	SetRole(ctx context.Context, id, role string) error
+	SetDisabled(ctx context.Context, id string, disabled bool) error
+	DeleteUser(ctx context.Context, id string) error
 	IsFirstUser(ctx context.Context) (bool, error)
 	GetAllUsers(ctx context.Context) ([]*User, error)
-	
+	ListUsers(ctx context.Context, query UserQuery) ([]User, int64, error)
+	GetLoginAttempts(ctx context.Context, key string) (*LoginAttempts, error)
+	RecordFailedLogin(ctx context.Context, key string, at time.Time) (*LoginAttempts, error)
+	LockLogin(ctx context.Context, key string, until time.Time) error
+	ResetLoginAttempts(ctx context.Context, key string) error
+	RecordLockEvent(ctx context.Context, event LockEvent) error
+	CreatePasswordReset(ctx context.Context, reset PasswordReset) error
+	FindPasswordReset(ctx context.Context, tokenHash string) (*PasswordReset, error)
+	ConsumePasswordReset(ctx context.Context, tokenHash string, now time.Time) (*PasswordReset, error)
+	DeletePasswordResets(ctx context.Context, userID string) error
+	CreateMFAChallenge(ctx context.Context, challenge MFAChallenge) error
+	FindMFAChallenge(ctx context.Context, tokenHash string) (*MFAChallenge, error)
+	ConsumeMFAChallenge(ctx context.Context, tokenHash string, now time.Time) (*MFAChallenge, error)
+}
+
+
+type APIKeyRepository interface {
+	CreateAPIKey(ctx context.Context, key APIKey) error
+	FindAPIKeyByHash(ctx context.Context, keyHash string) (*APIKey, error)
+	ListAPIKeys(ctx context.Context, userID string) ([]APIKey, error)
+	DeleteAPIKey(ctx context.Context, userID, id string) (bool, error)
+	TouchAPIKey(ctx context.Context, id string, at time.Time) error
+}
+
+
+type SessionRepository interface {
+	CreateSession(ctx context.Context, session Session) error
+	FindSession(ctx context.Context, id string) (*Session, error)
+	DeleteSession(ctx context.Context, id string) error
+}
+
+
+type SettingsRepository interface {
+	GetSecuritySettings(ctx context.Context) (*SecuritySettings, error)
+	SaveSecuritySettings(ctx context.Context, settings SecuritySettings) error
 }
 
 
 type TaskUsecase interface {
 	AddTask(ctx context.Context, task Task) (string, error)
 	GetAllTasks(ctx context.Context) ([]Task, error)
+	FindTasks(ctx context.Context, filter TaskFilter) ([]Task, error)
 	GetTaskByID(ctx context.Context, id string) (*Task, error)
 	UpdateTask(ctx context.Context, id string, task Task) error
+	PatchTask(ctx context.Context, id, mediaType string, patch []byte) (*Task, error)
 	DeleteTask(ctx context.Context, id string) error
 }
 
 
 type UserUsecase interface {
 	Register(ctx context.Context, user User) error
-	Login(ctx context.Context, username, password string) (string, error)
+	Login(ctx context.Context, username, password, ip string) (string, error)
 	PromoteUser(ctx context.Context, username string) error
-	GetAllUsers(ctx context.Context) ([]*User, error)
+	UnlockLogin(ctx context.Context, username, ip string) error
+	// FindUsersByIDs looks up several users at once, for batched lookups.
+	FindUsersByIDs(ctx context.Context, ids []string) ([]User, error)
+}
+
+
+// OIDCProvider runs the authorization code flow with PKCE against one
+// external identity provider.
+type OIDCProvider interface {
+	AuthCodeURL(req OIDCAuthRequest) string
+	// Exchange redeems code and returns the identity from the verified ID token.
+	Exchange(ctx context.Context, code string, req OIDCAuthRequest) (*OIDCIdentity, error)
+}
+
+
+// OIDCUsecase signs users in through an OIDCProvider and issues our own token.
+type OIDCUsecase interface {
+	BeginLogin(ctx context.Context) (*OIDCAuthRequest, string, error)
+	CompleteLogin(ctx context.Context, req OIDCAuthRequest, state, code string) (string, error)
+}
+
+
+// ProfileUsecase reads and edits the caller's own account. A new email address
+// is stored unverified and a verification link is sent to it.
+type ProfileUsecase interface {
+	GetProfile(ctx context.Context) (*User, error)
+	UpdateProfile(ctx context.Context, update ProfileUpdate) (*User, error)
+}
+
+
+// UserAdminUsecase backs the admin user endpoints. The acting admin is taken
+// from the context and may not demote, disable or delete their own account.
+type UserAdminUsecase interface {
+	ListUsers(ctx context.Context, query UserQuery) (*UserPage, error)
+	GetUser(ctx context.Context, id string) (*User, error)
+	SetRole(ctx context.Context, id, role string) error
+	SetDisabled(ctx context.Context, id string, disabled bool) error
+	// DeleteUser hands the user's tasks to reassignTo, or orphans them when it is empty.
+	DeleteUser(ctx context.Context, id, reassignTo string) error
+}
+
+
+// PasswordUsecase, MFAUsecase and APIKeyUsecase act on the caller found in
+// the context (see WithPrincipal) rather than on a user id argument.
+type PasswordUsecase interface {
+	ChangePassword(ctx context.Context, currentPassword, newPassword string) error
+	ForgotPassword(ctx context.Context, email string) error
+	ResetPassword(ctx context.Context, token, newPassword string) error
+}
+
+
+type VerificationUsecase interface {
+	SendVerification(ctx context.Context, user User) error
+	VerifyEmail(ctx context.Context, token string) error
+	ResendVerification(ctx context.Context, email string) error
+}
+
+
+type MFAUsecase interface {
+	EnrollTOTP(ctx context.Context) (*TOTPEnrollment, error)
+	ConfirmTOTP(ctx context.Context, code string) error
+	DisableTOTP(ctx context.Context, code string) error
+	CompleteLogin(ctx context.Context, mfaToken, code, ip string) (string, error)
+	GetSecuritySettings(ctx context.Context) (*SecuritySettings, error)
+	UpdateSecuritySettings(ctx context.Context, settings SecuritySettings) error
+}
+
+
+// APIKeyUsecase manages personal access tokens. CreateAPIKey returns the plain
+// key alongside its record; it cannot be retrieved again afterwards.
+type APIKeyUsecase interface {
+	CreateAPIKey(ctx context.Context, name string, scopes []string, ttl time.Duration) (*APIKey, string, error)
+	ListAPIKeys(ctx context.Context) ([]APIKey, error)
+	RevokeAPIKey(ctx context.Context, id string) error
+	Authenticate(ctx context.Context, key string) (*User, *APIKey, error)
+}
+
+
+// SessionUsecase turns the caller's bearer session into a cookie session and
+// checks the cookie on later requests. StartSession returns the cookie value
+// and CSRF token, neither of which can be retrieved again.
+type SessionUsecase interface {
+	StartSession(ctx context.Context) (*Session, string, string, error)
+	Authenticate(ctx context.Context, token string) (*Session, error)
+	VerifyCSRF(session *Session, csrfToken string) bool
+	EndSession(ctx context.Context) error
 }
 
 
@@ -68,7 +511,59 @@ type PasswordService interface {
 }
 
 
+// TOTPService wraps RFC 6238. MatchStep reports which time step a code belongs
+// to, so callers can refuse to accept the same step twice.
+type TOTPService interface {
+	GenerateSecret(accountName string) (secret, uri string, err error)
+	QRCode(uri string) ([]byte, error)
+	MatchStep(secret, code string, at time.Time) (int64, bool)
+}
+
+
+// JWTService issues and parses session tokens. amr lists the authentication
+// methods used (RFC 8176), e.g. "pwd" and "otp". JWKS publishes the public verification
+// keys; it is empty when tokens are signed with a shared secret.
 type JWTService interface {
-	GenerateToken(userID, username, role string) (string, error)
-	ValidateToken(tokenString string) (*jwt.Token, error)
+	GenerateToken(userID, username, role string, amr ...string) (string, error)
+	ValidateToken(tokenString string) (*Claims, error)
+	JWKS() JSONWebKeySet
+}
+
+
+type Email struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+
+// Mailer delivers outgoing email. Local runs use the log or file mailer.
+type Mailer interface {
+	Send(ctx context.Context, email Email) error
+}
+
+
+// RateLimitPolicy describes a token bucket that holds Limit tokens and refills
+// completely over Window.
+type RateLimitPolicy struct {
+	Name   string
+	Limit  int
+	Window time.Duration
+}
+
+
+// RateLimitResult is the state of a bucket after a request tried to take a token.
+type RateLimitResult struct {
+	Allowed    bool
+	Limit      int
+	Remaining  int
+	Reset      time.Duration
+	RetryAfter time.Duration
+}
+
+
+// RateLimitStore keeps token buckets. The in-memory store suits a single
+// instance; replicas behind a load balancer need a shared implementation.
+type RateLimitStore interface {
+	Take(ctx context.Context, key string, policy RateLimitPolicy) (RateLimitResult, error)
 }
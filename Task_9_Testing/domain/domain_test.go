@@ -69,14 +69,15 @@ func TestUserSerialization(t *testing.T) {
 		assert.NoError(t, err, "User should marshal to JSON without error")
 		assert.Contains(t, string(jsonData), `"id":"1"`, "JSON should include id")
 		assert.Contains(t, string(jsonData), `"username":"testuser"`, "JSON should include username")
-		assert.Contains(t, string(jsonData), `"password":"hashedpass"`, "JSON should include password")
+		assert.NotContains(t, string(jsonData), "hashedpass", "JSON should never include the password hash")
 		assert.Contains(t, string(jsonData), `"role":"user"`, "JSON should include role")
 
 		
 		var unmarshaled User
 		err = json.Unmarshal(jsonData, &unmarshaled)
 		assert.NoError(t, err, "User should unmarshal from JSON without error")
-		assert.Equal(t, user, unmarshaled, "Unmarshaled user should match original")
+		user.Password = ""
+		assert.Equal(t, user, unmarshaled, "Unmarshaled user should match original without the password")
 	})
 
 	t.Run("BSON", func(t *testing.T) {
@@ -0,0 +1,25 @@
+package domain
+
+import (
+	"context"
+	"log/slog"
+)
+
+// RequestIDHeader carries the id that ties together every log line of a request.
+const RequestIDHeader = "X-Request-ID"
+
+type loggerKey struct{}
+
+// WithLogger stores the request-scoped logger in ctx.
+func WithLogger(ctx context.Context, logger *slog.Logger) context.Context {
+	return context.WithValue(ctx, loggerKey{}, logger)
+}
+
+// Logger returns the logger stored by WithLogger, or the default logger when
+// ctx did not come from a request, such as in background jobs and tests.
+func Logger(ctx context.Context) *slog.Logger {
+	if logger, ok := ctx.Value(loggerKey{}).(*slog.Logger); ok && logger != nil {
+		return logger
+	}
+	return slog.Default()
+}
@@ -0,0 +1,44 @@
+package domain
+
+import (
+	"errors"
+	"time"
+)
+
+// Login methods and results reported to Metrics.CountLogin.
+const (
+	LoginMethodPassword = "password"
+	LoginMethodMFA      = "mfa"
+	LoginMethodOIDC     = "oidc"
+
+	LoginSucceeded  = "success"
+	LoginFailed     = "failure"
+	LoginNeedsMFA   = "mfa_required"
+	LoginWasBlocked = "blocked"
+)
+
+// Metrics records what the service is doing for monitoring. Implementations
+// must be safe for concurrent use.
+type Metrics interface {
+	// ObserveRepositoryCall records the latency and outcome of one repository method.
+	ObserveRepositoryCall(repository, method string, duration time.Duration, err error)
+	// CountOperation records one usecase call and whether it failed.
+	CountOperation(usecase, operation string, err error)
+	CountLogin(method, result string)
+}
+
+// LoginResult classifies the error of a login attempt for CountLogin.
+func LoginResult(err error) string {
+	var mfa *MFARequiredError
+	var blocked *LoginBlockedError
+	switch {
+	case err == nil:
+		return LoginSucceeded
+	case errors.As(err, &mfa):
+		return LoginNeedsMFA
+	case errors.As(err, &blocked):
+		return LoginWasBlocked
+	default:
+		return LoginFailed
+	}
+}
@@ -0,0 +1,94 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+var ErrUnauthenticated = errors.New("not authenticated")
+
+// Claims is the payload of a session token.
+type Claims struct {
+	Username string   `json:"name"`
+	Role     string   `json:"role"`
+	AMR      []string `json:"amr,omitempty"`
+	jwt.RegisteredClaims
+}
+
+// Principal returns the caller described by the token.
+func (c *Claims) Principal() *Principal {
+	p := &Principal{UserID: c.Subject, Username: c.Username, Role: c.Role, AuthMethods: c.AMR}
+	if c.IssuedAt != nil {
+		p.IssuedAt = c.IssuedAt.Time
+	}
+	return p
+}
+
+// Principal is the authenticated caller of a request, whether it came with a
+// session token, a session cookie or an API key.
+type Principal struct {
+	UserID      string
+	Username    string
+	Role        string
+	AuthMethods []string
+	IssuedAt    time.Time
+	// APIKeyID and Scopes are only set for API keys. Sessions are unscoped.
+	APIKeyID string
+	Scopes   []string
+	// SessionID is set when the caller came in with a session cookie.
+	SessionID string
+}
+
+func (p *Principal) IsAdmin() bool {
+	return p.Role == "admin"
+}
+
+func (p *Principal) IsAPIKey() bool {
+	return p.APIKeyID != ""
+}
+
+func (p *Principal) HasAuthMethod(method string) bool {
+	for _, m := range p.AuthMethods {
+		if m == method {
+			return true
+		}
+	}
+	return false
+}
+
+// HasScope reports whether the caller may act within scope.
+func (p *Principal) HasScope(scope string) bool {
+	if !p.IsAPIKey() {
+		return true
+	}
+	for _, s := range p.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+type principalKey struct{}
+
+func WithPrincipal(ctx context.Context, p *Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, p)
+}
+
+// PrincipalFrom returns the caller stored by WithPrincipal.
+func PrincipalFrom(ctx context.Context) (*Principal, bool) {
+	p, ok := ctx.Value(principalKey{}).(*Principal)
+	return p, ok && p != nil
+}
+
+// CallerID returns the id of the caller or ErrUnauthenticated.
+func CallerID(ctx context.Context) (string, error) {
+	p, ok := PrincipalFrom(ctx)
+	if !ok {
+		return "", ErrUnauthenticated
+	}
+	return p.UserID, nil
+}
@@ -0,0 +1,55 @@
+package domain
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPrincipalContext(t *testing.T) {
+	t.Run("RoundTrip", func(t *testing.T) {
+		ctx := WithPrincipal(context.Background(), &Principal{UserID: "1"})
+		p, ok := PrincipalFrom(ctx)
+		assert.True(t, ok)
+		assert.Equal(t, "1", p.UserID)
+
+		id, err := CallerID(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, "1", id)
+	})
+
+	t.Run("Missing", func(t *testing.T) {
+		_, ok := PrincipalFrom(context.Background())
+		assert.False(t, ok)
+		_, err := CallerID(context.Background())
+		assert.ErrorIs(t, err, ErrUnauthenticated)
+	})
+}
+
+func TestClaimsPrincipal(t *testing.T) {
+	issuedAt := time.Date(2025, 4, 3, 12, 0, 0, 0, time.UTC)
+	claims := Claims{
+		Username:         "testuser",
+		Role:             "admin",
+		AMR:              []string{"pwd", "otp"},
+		RegisteredClaims: jwt.RegisteredClaims{Subject: "1", IssuedAt: jwt.NewNumericDate(issuedAt)},
+	}
+
+	p := claims.Principal()
+	assert.Equal(t, "1", p.UserID)
+	assert.True(t, p.IsAdmin())
+	assert.True(t, p.HasAuthMethod("otp"))
+	assert.True(t, p.IssuedAt.Equal(issuedAt))
+	assert.False(t, p.IsAPIKey())
+	assert.True(t, p.HasScope(ScopeAdmin), "Sessions should not be limited by scopes")
+}
+
+func TestPrincipalScopes(t *testing.T) {
+	p := &Principal{UserID: "1", APIKeyID: "k1", Scopes: []string{ScopeTasksRead}}
+	assert.True(t, p.IsAPIKey())
+	assert.True(t, p.HasScope(ScopeTasksRead))
+	assert.False(t, p.HasScope(ScopeTasksWrite))
+}
@@ -0,0 +1,37 @@
+package domain
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidationErrors(t *testing.T) {
+	t.Run("Empty", func(t *testing.T) {
+		var errs ValidationErrors
+		assert.NoError(t, errs.Err(), "No field errors should mean no error")
+	})
+
+	t.Run("Collects", func(t *testing.T) {
+		var errs ValidationErrors
+		errs.Add("title", "is required")
+		errs.Add("status", "must be one of pending, in_progress, done")
+
+		err := errs.Err()
+		assert.Error(t, err, "Field errors should produce an error")
+		assert.Equal(t, "validation failed: title: is required; status: must be one of pending, in_progress, done", err.Error())
+
+		var target ValidationErrors
+		assert.True(t, errors.As(err, &target), "Error should unwrap to ValidationErrors")
+		assert.Len(t, target, 2, "Both field errors should be kept")
+	})
+
+	t.Run("JSON", func(t *testing.T) {
+		errs := ValidationErrors{{Field: "username", Message: "is required"}}
+		jsonData, err := json.Marshal(errs)
+		assert.NoError(t, err, "ValidationErrors should marshal to JSON without error")
+		assert.Equal(t, `[{"field":"username","message":"is required"}]`, string(jsonData))
+	})
+}
@@ -1,49 +1,280 @@
 package infrastructure
 
 import (
+	"context"
+	"errors"
 	"net/http"
 	"strings"
 	"task_manager/domain"
 
 	"github.com/gin-gonic/gin"
-	"github.com/golang-jwt/jwt/v5"
 )
 
-func AuthMiddleware(jwtSvc domain.JWTService) gin.HandlerFunc {
+// AuthMiddleware accepts either a session JWT or, when apiKeys is set, a
+// personal access token in the Authorization header. Without the header it
+// falls back to the session cookie when sessions is set. All of them store a
+// domain.Principal in the request context, where the middlewares below,
+// handlers and usecases pick it up with domain.PrincipalFrom.
+func AuthMiddleware(jwtSvc domain.JWTService, apiKeys domain.APIKeyUsecase, sessions domain.SessionUsecase) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		tokenString := c.GetHeader("Authorization")
 		if tokenString == "" {
+			if cookie, err := c.Cookie(domain.SessionCookieName); err == nil && sessions != nil {
+				authenticateSession(c, sessions, cookie)
+				return
+			}
 			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Authorization header required"})
 			return
 		}
 
-		tokenString = strings.TrimPrefix(tokenString, "Bearer ")
-		token, err := jwtSvc.ValidateToken(tokenString)
-		if err != nil || !token.Valid {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
+		p, err := authenticateBearer(c.Request.Context(), jwtSvc, apiKeys, strings.TrimPrefix(tokenString, "Bearer "))
+		if err != nil {
+			abortWithAuthError(c, err)
 			return
 		}
+		setPrincipal(c, p)
+		c.Next()
+	}
+}
+
+// authError is a rejected request together with the HTTP status and message
+// the client gets. The gRPC interceptors map the status to a gRPC code, so
+// both front ends refuse the same requests for the same reasons.
+type authError struct {
+	status  int
+	message string
+}
+
+func (e *authError) Error() string { return e.message }
+
+func abortWithAuthError(c *gin.Context, err error) {
+	var authErr *authError
+	if !errors.As(err, &authErr) {
+		authErr = &authError{status: http.StatusInternalServerError, message: "Internal server error"}
+	}
+	c.AbortWithStatusJSON(authErr.status, gin.H{"error": authErr.message})
+}
+
+// authenticateBearer resolves a session JWT or, when apiKeys is set, a
+// personal access token to the caller.
+func authenticateBearer(ctx context.Context, jwtSvc domain.JWTService, apiKeys domain.APIKeyUsecase, token string) (*domain.Principal, error) {
+	if apiKeys != nil && strings.HasPrefix(token, domain.APIKeyPrefix) {
+		return authenticateAPIKey(ctx, apiKeys, token)
+	}
+	claims, err := jwtSvc.ValidateToken(token)
+	if err != nil {
+		return nil, &authError{status: http.StatusUnauthorized, message: "Invalid token"}
+	}
+	if claims.Subject == "" {
+		return nil, &authError{status: http.StatusUnauthorized, message: "Invalid token claims"}
+	}
+	return claims.Principal(), nil
+}
+
+// authenticateAPIKey uses the key's creation time as IssuedAt, so a password
+// change revokes keys created before it just like sessions.
+func authenticateAPIKey(ctx context.Context, apiKeys domain.APIKeyUsecase, plain string) (*domain.Principal, error) {
+	user, key, err := apiKeys.Authenticate(ctx, plain)
+	if errors.Is(err, domain.ErrInvalidAPIKey) {
+		return nil, &authError{status: http.StatusUnauthorized, message: "Invalid token"}
+	}
+	if err != nil {
+		return nil, &authError{status: http.StatusInternalServerError, message: "Error checking API key"}
+	}
+
+	return &domain.Principal{
+		UserID:   user.ID,
+		Username: user.Username,
+		Role:     user.Role,
+		IssuedAt: key.CreatedAt,
+		APIKeyID: key.ID,
+		Scopes:   key.Scopes,
+	}, nil
+}
+
+// authenticateSession checks the session cookie and, since browsers attach
+// cookies to cross-site requests too, the CSRF token on every unsafe method.
+func authenticateSession(c *gin.Context, sessions domain.SessionUsecase, token string) {
+	session, err := sessions.Authenticate(c.Request.Context(), token)
+	if errors.Is(err, domain.ErrInvalidSession) {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid session"})
+		return
+	}
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Error checking session"})
+		return
+	}
+	if !safeMethod(c.Request.Method) && !sessions.VerifyCSRF(session, c.GetHeader(domain.CSRFHeader)) {
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "CSRF token missing or invalid"})
+		return
+	}
+
+	setPrincipal(c, &domain.Principal{
+		UserID:      session.UserID,
+		Username:    session.Username,
+		Role:        session.Role,
+		AuthMethods: session.AuthMethods,
+		IssuedAt:    session.CreatedAt,
+		SessionID:   session.ID,
+	})
+	c.Next()
+}
+
+func safeMethod(method string) bool {
+	return method == http.MethodGet || method == http.MethodHead || method == http.MethodOptions
+}
 
-		claims, ok := token.Claims.(jwt.MapClaims)
+// setPrincipal also tags the request logger with the caller, so every later
+// log line of the request names the user.
+func setPrincipal(c *gin.Context, p *domain.Principal) {
+	c.Request = c.Request.WithContext(contextWithPrincipal(c.Request.Context(), p))
+}
+
+func contextWithPrincipal(ctx context.Context, p *domain.Principal) context.Context {
+	ctx = domain.WithLogger(ctx, domain.Logger(ctx).With("user_id", p.UserID))
+	return domain.WithPrincipal(ctx, p)
+}
+
+// callerOrAbort returns the principal set by AuthMiddleware and answers 401
+// when there is none, which only happens if a route is wired without it.
+func callerOrAbort(c *gin.Context) (*domain.Principal, bool) {
+	p, ok := domain.PrincipalFrom(c.Request.Context())
+	if !ok {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Authorization header required"})
+	}
+	return p, ok
+}
+
+// RequireScope limits API keys to the routes their scopes cover. Session
+// tokens carry no scopes and pass through.
+func RequireScope(scope string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		p, ok := callerOrAbort(c)
 		if !ok {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid token claims"})
 			return
 		}
+		if !p.HasScope(scope) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "API key is missing the " + scope + " scope"})
+			return
+		}
+		c.Next()
+	}
+}
 
-		c.Set("userID", claims["sub"])
-		c.Set("username", claims["name"])
-		c.Set("role", claims["role"])
+// SessionOnlyMiddleware keeps API keys away from account management, so a
+// leaked key cannot be used to mint more keys or change the password.
+func SessionOnlyMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		p, ok := callerOrAbort(c)
+		if !ok {
+			return
+		}
+		if p.IsAPIKey() {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "API keys cannot be used for this endpoint"})
+			return
+		}
 		c.Next()
 	}
 }
 
+// RevocationMiddleware rejects tokens of users that no longer exist, that were
+// disabled, or that were issued before the user's last password change. It
+// runs after AuthMiddleware.
+func RevocationMiddleware(userRepo domain.UserRepository) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		p, ok := callerOrAbort(c)
+		if !ok {
+			return
+		}
+		current, err := checkRevocation(c.Request.Context(), userRepo, p)
+		if err != nil {
+			abortWithAuthError(c, err)
+			return
+		}
+		if current != p {
+			setPrincipal(c, current)
+		}
+		c.Next()
+	}
+}
+
+// checkRevocation returns the caller with the role currently stored for the
+// user, or an error when the token no longer counts.
+func checkRevocation(ctx context.Context, userRepo domain.UserRepository, p *domain.Principal) (*domain.Principal, error) {
+	user, err := userRepo.FindUserByID(ctx, p.UserID)
+	if err != nil {
+		return nil, &authError{status: http.StatusInternalServerError, message: "Error checking session"}
+	}
+	if user == nil {
+		return nil, &authError{status: http.StatusUnauthorized, message: "Invalid token"}
+	}
+
+	if !user.PasswordChangedAt.IsZero() && p.IssuedAt.Unix() < user.PasswordChangedAt.Unix() {
+		return nil, &authError{status: http.StatusUnauthorized, message: "Session has been revoked"}
+	}
+	if user.Disabled {
+		return nil, &authError{status: http.StatusForbidden, message: "Account disabled"}
+	}
+	// The role in a token is a snapshot from login; a demotion applies to
+	// sessions that are already open.
+	if user.Role != p.Role {
+		current := *p
+		current.Role = user.Role
+		return &current, nil
+	}
+	return p, nil
+}
+
 func AdminMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		role, exists := c.Get("role")
-		if !exists || role != "admin" {
+		p, ok := domain.PrincipalFrom(c.Request.Context())
+		if !ok || !p.IsAdmin() {
 			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Unauthorized access"})
 			return
 		}
 		c.Next()
 	}
 }
+
+// MFAPolicyMiddleware enforces the admin two-factor setting. When it is on,
+// admin routes only accept tokens whose amr claim includes "otp"; the rest of
+// the API, including enrolment, stays reachable with a password-only token.
+func MFAPolicyMiddleware(settingsRepo domain.SettingsRepository) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		p, ok := callerOrAbort(c)
+		if !ok {
+			return
+		}
+		if err := checkMFAPolicy(c.Request.Context(), settingsRepo, p); err != nil {
+			abortWithAuthError(c, err)
+			return
+		}
+		c.Next()
+	}
+}
+
+// CheckAdmin runs the checks of the admin routes, AdminMiddleware,
+// RequireScope(domain.ScopeAdmin) and MFAPolicyMiddleware, on the caller in
+// ctx. It is for handlers that authorize per field, like the GraphQL
+// resolvers, and returns an error whose message is safe to show the client.
+func CheckAdmin(ctx context.Context, settingsRepo domain.SettingsRepository) error {
+	p, ok := domain.PrincipalFrom(ctx)
+	if !ok || !p.IsAdmin() {
+		return &authError{status: http.StatusForbidden, message: "Unauthorized access"}
+	}
+	if !p.HasScope(domain.ScopeAdmin) {
+		return &authError{status: http.StatusForbidden, message: "API key is missing the " + domain.ScopeAdmin + " scope"}
+	}
+	return checkMFAPolicy(ctx, settingsRepo, p)
+}
+
+func checkMFAPolicy(ctx context.Context, settingsRepo domain.SettingsRepository, p *domain.Principal) error {
+	settings, err := settingsRepo.GetSecuritySettings(ctx)
+	if err != nil {
+		return &authError{status: http.StatusInternalServerError, message: "Error loading security settings"}
+	}
+	if settings.RequireAdminMFA && !p.HasAuthMethod("otp") {
+		return &authError{status: http.StatusForbidden, message: "Two-factor authentication required for admin access"}
+	}
+	return nil
+}
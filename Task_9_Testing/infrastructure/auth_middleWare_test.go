@@ -1,11 +1,16 @@
 package infrastructure
 
 import (
+	"context"
+	"errors"
 	"net/http"
 	"net/http/httptest"
+	"task_manager/domain"
 	"testing"
+	"time"
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/suite"
 )
@@ -14,27 +19,80 @@ type MockJWTService struct {
 	mock.Mock
 }
 
-func (m *MockJWTService) GenerateToken(userID, username, role string) (string, error) {
+func (m *MockJWTService) GenerateToken(userID, username, role string, amr ...string) (string, error) {
 	return "", nil 
 }
 
-func (m *MockJWTService) ValidateToken(tokenString string) (*jwt.Token, error) {
+func (m *MockJWTService) ValidateToken(tokenString string) (*domain.Claims, error) {
 	args := m.Called(tokenString)
-	return args.Get(0).(*jwt.Token), args.Error(1)
+	return args.Get(0).(*domain.Claims), args.Error(1)
+}
+
+func (m *MockJWTService) JWKS() domain.JSONWebKeySet {
+	return domain.JSONWebKeySet{}
+}
+
+type MockAPIKeyUsecase struct {
+	mock.Mock
+}
+
+func (m *MockAPIKeyUsecase) CreateAPIKey(ctx context.Context, name string, scopes []string, ttl time.Duration) (*domain.APIKey, string, error) {
+	args := m.Called(ctx, name, scopes, ttl)
+	return args.Get(0).(*domain.APIKey), args.String(1), args.Error(2)
+}
+
+func (m *MockAPIKeyUsecase) ListAPIKeys(ctx context.Context) ([]domain.APIKey, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]domain.APIKey), args.Error(1)
+}
+
+func (m *MockAPIKeyUsecase) RevokeAPIKey(ctx context.Context, id string) error {
+	return m.Called(ctx, id).Error(0)
+}
+
+func (m *MockAPIKeyUsecase) Authenticate(ctx context.Context, key string) (*domain.User, *domain.APIKey, error) {
+	args := m.Called(ctx, key)
+	return args.Get(0).(*domain.User), args.Get(1).(*domain.APIKey), args.Error(2)
+}
+
+type MockSessionUsecase struct {
+	mock.Mock
+}
+
+func (m *MockSessionUsecase) StartSession(ctx context.Context) (*domain.Session, string, string, error) {
+	args := m.Called(ctx)
+	return args.Get(0).(*domain.Session), args.String(1), args.String(2), args.Error(3)
+}
+
+func (m *MockSessionUsecase) Authenticate(ctx context.Context, token string) (*domain.Session, error) {
+	args := m.Called(ctx, token)
+	return args.Get(0).(*domain.Session), args.Error(1)
+}
+
+func (m *MockSessionUsecase) VerifyCSRF(session *domain.Session, csrfToken string) bool {
+	return m.Called(session, csrfToken).Bool(0)
+}
+
+func (m *MockSessionUsecase) EndSession(ctx context.Context) error {
+	return m.Called(ctx).Error(0)
 }
 
 type AuthMiddlewareTestSuite struct {
 	suite.Suite
-	mockJWT    *MockJWTService
-	router     *gin.Engine
-	authMw     gin.HandlerFunc
-	adminMw    gin.HandlerFunc
+	mockJWT      *MockJWTService
+	mockKeys     *MockAPIKeyUsecase
+	mockSessions *MockSessionUsecase
+	router       *gin.Engine
+	authMw       gin.HandlerFunc
+	adminMw      gin.HandlerFunc
 }
 
 func (s *AuthMiddlewareTestSuite) SetupTest() {
 	gin.SetMode(gin.TestMode)
 	s.mockJWT = &MockJWTService{}
-	s.authMw = AuthMiddleware(s.mockJWT)
+	s.mockKeys = &MockAPIKeyUsecase{}
+	s.mockSessions = &MockSessionUsecase{}
+	s.authMw = AuthMiddleware(s.mockJWT, s.mockKeys, s.mockSessions)
 	s.adminMw = AdminMiddleware()
 	s.router = gin.New()
 	
@@ -45,22 +103,138 @@ func (s *AuthMiddlewareTestSuite) SetupTest() {
 	s.router.GET("/admin", s.authMw, s.adminMw, func(c *gin.Context) {
 		c.String(http.StatusOK, "Admin OK")
 	})
+
+	s.router.POST("/tasks", s.authMw, RequireScope(domain.ScopeTasksWrite), func(c *gin.Context) {
+		p, _ := domain.PrincipalFrom(c.Request.Context())
+		c.String(http.StatusOK, p.UserID)
+	})
+
+	s.router.POST("/me/password", s.authMw, SessionOnlyMiddleware(), func(c *gin.Context) {
+		c.String(http.StatusOK, "Account OK")
+	})
 }
 
 func (s *AuthMiddlewareTestSuite) TearDownTest() {
 	s.mockJWT.AssertExpectations(s.T())
+	s.mockKeys.AssertExpectations(s.T())
+	s.mockSessions.AssertExpectations(s.T())
+}
+
+func (s *AuthMiddlewareTestSuite) TestAPIKeys() {
+	user := &domain.User{ID: "1", Username: "ci", Role: "user"}
+	send := func(method, path, key string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(method, path, nil)
+		req.Header.Set("Authorization", "Bearer "+key)
+		w := httptest.NewRecorder()
+		s.router.ServeHTTP(w, req)
+		return w
+	}
+
+	s.Run("ScopeGranted", func() {
+		key := &domain.APIKey{ID: "k1", Scopes: []string{domain.ScopeTasksRead, domain.ScopeTasksWrite}}
+		s.mockKeys.On("Authenticate", mock.Anything, "tm_write").Return(user, key, nil).Once()
+
+		w := send("POST", "/tasks", "tm_write")
+		s.Equal(http.StatusOK, w.Code)
+		s.Equal("1", w.Body.String(), "The key should carry its owner's identity")
+	})
+
+	s.Run("ScopeMissing", func() {
+		key := &domain.APIKey{ID: "k2", Scopes: []string{domain.ScopeTasksRead}}
+		s.mockKeys.On("Authenticate", mock.Anything, "tm_read").Return(user, key, nil).Once()
+
+		w := send("POST", "/tasks", "tm_read")
+		s.Equal(http.StatusForbidden, w.Code)
+		s.Contains(w.Body.String(), "tasks:write")
+	})
+
+	s.Run("AccountRoutesNeedSession", func() {
+		key := &domain.APIKey{ID: "k1", Scopes: []string{domain.ScopeTasksWrite}}
+		s.mockKeys.On("Authenticate", mock.Anything, "tm_write").Return(user, key, nil).Once()
+
+		w := send("POST", "/me/password", "tm_write")
+		s.Equal(http.StatusForbidden, w.Code)
+	})
+
+	s.Run("InvalidKey", func() {
+		s.mockKeys.On("Authenticate", mock.Anything, "tm_expired").Return((*domain.User)(nil), (*domain.APIKey)(nil), domain.ErrInvalidAPIKey).Once()
+
+		w := send("GET", "/protected", "tm_expired")
+		s.Equal(http.StatusUnauthorized, w.Code)
+		s.Contains(w.Body.String(), `"error":"Invalid token"`)
+	})
+
+	s.Run("SessionsAreUnscoped", func() {
+		token := &domain.Claims{Username: "testuser", Role: "user", RegisteredClaims: jwt.RegisteredClaims{Subject: "1"}}
+		s.mockJWT.On("ValidateToken", "valid-token").Return(token, nil).Twice()
+
+		s.Equal(http.StatusOK, send("POST", "/tasks", "valid-token").Code)
+		s.Equal(http.StatusOK, send("POST", "/me/password", "valid-token").Code)
+	})
+}
+
+func (s *AuthMiddlewareTestSuite) TestSessions() {
+	session := &domain.Session{ID: "hash", UserID: "1", Username: "testuser", Role: "user", AuthMethods: []string{"pwd"}}
+	send := func(method, path, csrf string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(method, path, nil)
+		req.AddCookie(&http.Cookie{Name: domain.SessionCookieName, Value: "cookie"})
+		if csrf != "" {
+			req.Header.Set(domain.CSRFHeader, csrf)
+		}
+		w := httptest.NewRecorder()
+		s.router.ServeHTTP(w, req)
+		return w
+	}
+
+	s.Run("SafeMethod", func() {
+		s.mockSessions.On("Authenticate", mock.Anything, "cookie").Return(session, nil).Once()
+
+		w := send("GET", "/protected", "")
+		s.Equal(http.StatusOK, w.Code, "Reads need no CSRF token")
+	})
+
+	s.Run("MissingCSRF", func() {
+		s.mockSessions.On("Authenticate", mock.Anything, "cookie").Return(session, nil).Once()
+		s.mockSessions.On("VerifyCSRF", session, "").Return(false).Once()
+
+		w := send("POST", "/tasks", "")
+		s.Equal(http.StatusForbidden, w.Code)
+		s.Contains(w.Body.String(), "CSRF token missing or invalid")
+	})
+
+	s.Run("WithCSRF", func() {
+		s.mockSessions.On("Authenticate", mock.Anything, "cookie").Return(session, nil).Once()
+		s.mockSessions.On("VerifyCSRF", session, "csrf").Return(true).Once()
+
+		w := send("POST", "/tasks", "csrf")
+		s.Equal(http.StatusOK, w.Code)
+		s.Equal("1", w.Body.String())
+	})
+
+	s.Run("InvalidSession", func() {
+		s.mockSessions.On("Authenticate", mock.Anything, "cookie").Return((*domain.Session)(nil), domain.ErrInvalidSession).Once()
+
+		w := send("GET", "/protected", "")
+		s.Equal(http.StatusUnauthorized, w.Code)
+		s.Contains(w.Body.String(), "Invalid session")
+	})
+
+	s.Run("BearerWins", func() {
+		token := &domain.Claims{Username: "testuser", Role: "user", RegisteredClaims: jwt.RegisteredClaims{Subject: "1"}}
+		s.mockJWT.On("ValidateToken", "valid-token").Return(token, nil).Once()
+
+		req, _ := http.NewRequest("POST", "/tasks", nil)
+		req.Header.Set("Authorization", "Bearer valid-token")
+		req.AddCookie(&http.Cookie{Name: domain.SessionCookieName, Value: "cookie"})
+		w := httptest.NewRecorder()
+		s.router.ServeHTTP(w, req)
+		s.Equal(http.StatusOK, w.Code, "Bearer callers are not subject to CSRF checks")
+	})
 }
 
 func (s *AuthMiddlewareTestSuite) TestAuthMiddleware() {
 	s.Run("Success", func() {
-		token := &jwt.Token{
-			Claims: jwt.MapClaims{
-				"sub":  "1",
-				"name": "testuser",
-				"role": "user",
-			},
-			Valid: true,
-		}
+		token := &domain.Claims{Username: "testuser", Role: "user", RegisteredClaims: jwt.RegisteredClaims{Subject: "1"}}
 		s.mockJWT.On("ValidateToken", "valid-token").Return(token, nil).Once()
 
 		req, _ := http.NewRequest("GET", "/protected", nil)
@@ -82,7 +256,7 @@ func (s *AuthMiddlewareTestSuite) TestAuthMiddleware() {
 	})
 
 	s.Run("InvalidToken", func() {
-		s.mockJWT.On("ValidateToken", "invalid-token").Return((*jwt.Token)(nil), jwt.ErrSignatureInvalid).Once()
+		s.mockJWT.On("ValidateToken", "invalid-token").Return((*domain.Claims)(nil), jwt.ErrSignatureInvalid).Once()
 
 		req, _ := http.NewRequest("GET", "/protected", nil)
 		req.Header.Set("Authorization", "Bearer invalid-token")
@@ -94,10 +268,7 @@ func (s *AuthMiddlewareTestSuite) TestAuthMiddleware() {
 	})
 
 	s.Run("InvalidClaims", func() {
-		token := &jwt.Token{
-			Claims: nil, 
-			Valid:  true,
-		}
+		token := &domain.Claims{Username: "testuser"}
 		s.mockJWT.On("ValidateToken", "bad-claims-token").Return(token, nil).Once()
 
 		req, _ := http.NewRequest("GET", "/protected", nil)
@@ -112,14 +283,7 @@ func (s *AuthMiddlewareTestSuite) TestAuthMiddleware() {
 
 func (s *AuthMiddlewareTestSuite) TestAdminMiddleware() {
 	s.Run("SuccessAdmin", func() {
-		token := &jwt.Token{
-			Claims: jwt.MapClaims{
-				"sub":  "1",
-				"name": "adminuser",
-				"role": "admin",
-			},
-			Valid: true,
-		}
+		token := &domain.Claims{Username: "adminuser", Role: "admin", RegisteredClaims: jwt.RegisteredClaims{Subject: "1"}}
 		s.mockJWT.On("ValidateToken", "admin-token").Return(token, nil).Once()
 
 		req, _ := http.NewRequest("GET", "/admin", nil)
@@ -132,14 +296,7 @@ func (s *AuthMiddlewareTestSuite) TestAdminMiddleware() {
 	})
 
 	s.Run("NonAdmin", func() {
-		token := &jwt.Token{
-			Claims: jwt.MapClaims{
-				"sub":  "1",
-				"name": "testuser",
-				"role": "user",
-			},
-			Valid: true,
-		}
+		token := &domain.Claims{Username: "testuser", Role: "user", RegisteredClaims: jwt.RegisteredClaims{Subject: "1"}}
 		s.mockJWT.On("ValidateToken", "user-token").Return(token, nil).Once()
 
 		req, _ := http.NewRequest("GET", "/admin", nil)
@@ -152,14 +309,7 @@ func (s *AuthMiddlewareTestSuite) TestAdminMiddleware() {
 	})
 
 	s.Run("NoRole", func() {
-		token := &jwt.Token{
-			Claims: jwt.MapClaims{
-				"sub":  "1",
-				"name": "testuser",
-				
-			},
-			Valid: true,
-		}
+		token := &domain.Claims{Username: "testuser", RegisteredClaims: jwt.RegisteredClaims{Subject: "1"}}
 		s.mockJWT.On("ValidateToken", "no-role-token").Return(token, nil).Once()
 
 		req, _ := http.NewRequest("GET", "/admin", nil)
@@ -174,4 +324,140 @@ func (s *AuthMiddlewareTestSuite) TestAdminMiddleware() {
 
 func TestAuthMiddlewareSuite(t *testing.T) {
 	suite.Run(t, new(AuthMiddlewareTestSuite))
-}
\ No newline at end of file
+}
+// withPrincipal stands in for AuthMiddleware in tests of the middlewares after it.
+func withPrincipal(p *domain.Principal) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		setPrincipal(c, p)
+	}
+}
+
+type stubUserRepository struct {
+	domain.UserRepository
+	user *domain.User
+	err  error
+}
+
+func (r *stubUserRepository) FindUserByID(ctx context.Context, id string) (*domain.User, error) {
+	if r.user == nil || r.user.ID != id {
+		return nil, r.err
+	}
+	return r.user, r.err
+}
+
+func TestRevocationMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	changedAt := time.Date(2025, 4, 3, 12, 0, 0, 0, time.UTC)
+
+	run := func(repo domain.UserRepository, userID string, iat int64) *httptest.ResponseRecorder {
+		router := gin.New()
+		router.GET("/", withPrincipal(&domain.Principal{UserID: userID, IssuedAt: time.Unix(iat, 0)}), RevocationMiddleware(repo), func(c *gin.Context) {
+			c.String(http.StatusOK, "OK")
+		})
+		req, _ := http.NewRequest("GET", "/", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("Valid", func(t *testing.T) {
+		repo := &stubUserRepository{user: &domain.User{ID: "1", PasswordChangedAt: changedAt}}
+		w := run(repo, "1", changedAt.Unix())
+		assert.Equal(t, http.StatusOK, w.Code, "Tokens issued after the change should pass")
+	})
+
+	t.Run("IssuedBeforePasswordChange", func(t *testing.T) {
+		repo := &stubUserRepository{user: &domain.User{ID: "1", PasswordChangedAt: changedAt}}
+		w := run(repo, "1", changedAt.Add(-time.Hour).Unix())
+		assert.Equal(t, http.StatusUnauthorized, w.Code, "Older tokens should be revoked")
+		assert.Contains(t, w.Body.String(), "Session has been revoked")
+	})
+
+	t.Run("NeverChanged", func(t *testing.T) {
+		repo := &stubUserRepository{user: &domain.User{ID: "1"}}
+		w := run(repo, "1", changedAt.Unix())
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("Disabled", func(t *testing.T) {
+		repo := &stubUserRepository{user: &domain.User{ID: "1", Disabled: true}}
+		w := run(repo, "1", changedAt.Unix())
+		assert.Equal(t, http.StatusForbidden, w.Code, "Disabled accounts should lose access immediately")
+		assert.Contains(t, w.Body.String(), "Account disabled")
+	})
+
+	t.Run("Demoted", func(t *testing.T) {
+		router := gin.New()
+		p := &domain.Principal{UserID: "1", Role: domain.RoleAdmin, IssuedAt: changedAt}
+		repo := &stubUserRepository{user: &domain.User{ID: "1", Role: domain.RoleUser}}
+		router.GET("/", withPrincipal(p), RevocationMiddleware(repo), AdminMiddleware(), func(c *gin.Context) {
+			c.String(http.StatusOK, "OK")
+		})
+		req, _ := http.NewRequest("GET", "/", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusForbidden, w.Code, "A demoted admin's open session should lose admin access")
+	})
+
+	t.Run("UnknownUser", func(t *testing.T) {
+		w := run(&stubUserRepository{}, "2", changedAt.Unix())
+		assert.Equal(t, http.StatusUnauthorized, w.Code, "Tokens of deleted users should be rejected")
+	})
+
+	t.Run("RepositoryError", func(t *testing.T) {
+		w := run(&stubUserRepository{err: errors.New("db down")}, "1", changedAt.Unix())
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+	})
+}
+
+type stubSettingsRepository struct {
+	settings domain.SecuritySettings
+	err      error
+}
+
+func (r *stubSettingsRepository) GetSecuritySettings(ctx context.Context) (*domain.SecuritySettings, error) {
+	return &r.settings, r.err
+}
+
+func (r *stubSettingsRepository) SaveSecuritySettings(ctx context.Context, settings domain.SecuritySettings) error {
+	r.settings = settings
+	return r.err
+}
+
+func TestMFAPolicyMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	run := func(repo domain.SettingsRepository, amr []string) *httptest.ResponseRecorder {
+		router := gin.New()
+		router.GET("/", withPrincipal(&domain.Principal{UserID: "1", Role: "admin", AuthMethods: amr}), MFAPolicyMiddleware(repo), func(c *gin.Context) {
+			c.String(http.StatusOK, "OK")
+		})
+		req, _ := http.NewRequest("GET", "/", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("PolicyOff", func(t *testing.T) {
+		w := run(&stubSettingsRepository{}, []string{"pwd"})
+		assert.Equal(t, http.StatusOK, w.Code, "Password-only tokens pass when the policy is off")
+	})
+
+	t.Run("PasswordOnly", func(t *testing.T) {
+		repo := &stubSettingsRepository{settings: domain.SecuritySettings{RequireAdminMFA: true}}
+		w := run(repo, []string{"pwd"})
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		assert.Contains(t, w.Body.String(), "Two-factor authentication required")
+	})
+
+	t.Run("WithOTP", func(t *testing.T) {
+		repo := &stubSettingsRepository{settings: domain.SecuritySettings{RequireAdminMFA: true}}
+		w := run(repo, []string{"pwd", "otp"})
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("RepositoryError", func(t *testing.T) {
+		w := run(&stubSettingsRepository{err: errors.New("db down")}, nil)
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+	})
+}
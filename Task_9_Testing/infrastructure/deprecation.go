@@ -0,0 +1,26 @@
+package infrastructure
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DeprecatedMiddleware marks the routes behind it as deprecated aliases of
+// the same routes under successorPrefix. Responses carry the Deprecation
+// (RFC 9745) and Sunset (RFC 8594) headers and a successor-version link, so
+// clients can find out before the aliases go away. Their remaining traffic
+// shows up in the HTTP metrics under the unversioned routes.
+func DeprecatedMiddleware(since, sunset time.Time, successorPrefix string) gin.HandlerFunc {
+	deprecation := "@" + strconv.FormatInt(since.Unix(), 10)
+	sunsetDate := sunset.UTC().Format(http.TimeFormat)
+	return func(c *gin.Context) {
+		c.Header("Deprecation", deprecation)
+		c.Header("Sunset", sunsetDate)
+		c.Header("Link", "<"+successorPrefix+strings.TrimSuffix(c.Request.URL.Path, "/")+`>; rel="successor-version"`)
+		c.Next()
+	}
+}
@@ -0,0 +1,230 @@
+package infrastructure
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net"
+	"net/http"
+	"strings"
+	"task_manager/domain"
+	"time"
+
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// GRPCMethodPolicy is the gRPC counterpart of the middleware chain in front of
+// a REST route.
+type GRPCMethodPolicy struct {
+	// Public methods take no credentials, like registration and login.
+	Public bool
+	// Scope is the API key scope the method needs, if any.
+	Scope string
+	// Admin methods also need the admin scope and pass the admin two-factor
+	// policy, like AdminMiddleware and MFAPolicyMiddleware.
+	Admin bool
+	// RateLimit shares its budget with the REST routes using the same policy.
+	RateLimit domain.RateLimitPolicy
+}
+
+// GRPCAuthorizer checks every call against the policy of its method, with the
+// same checks and messages as AuthMiddleware, RevocationMiddleware,
+// AdminMiddleware, RequireScope and RateLimitMiddleware. Methods without a
+// policy are refused, so a new RPC cannot go out unprotected by accident.
+type GRPCAuthorizer struct {
+	policies     map[string]GRPCMethodPolicy
+	jwtSvc       domain.JWTService
+	apiKeys      domain.APIKeyUsecase
+	userRepo     domain.UserRepository
+	settingsRepo domain.SettingsRepository
+	limiter      domain.RateLimitStore
+}
+
+// NewGRPCAuthorizer takes policies keyed by full method name, such as
+// "/taskmanager.v1.TaskService/GetTask".
+func NewGRPCAuthorizer(policies map[string]GRPCMethodPolicy, jwtSvc domain.JWTService, apiKeys domain.APIKeyUsecase, userRepo domain.UserRepository, settingsRepo domain.SettingsRepository, limiter domain.RateLimitStore) *GRPCAuthorizer {
+	return &GRPCAuthorizer{policies: policies, jwtSvc: jwtSvc, apiKeys: apiKeys, userRepo: userRepo, settingsRepo: settingsRepo, limiter: limiter}
+}
+
+func (a *GRPCAuthorizer) UnaryInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		ctx, err := a.authorize(ctx, info.FullMethod)
+		if err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+func (a *GRPCAuthorizer) StreamInterceptor() grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, err := a.authorize(ss.Context(), info.FullMethod)
+		if err != nil {
+			return err
+		}
+		return handler(srv, &contextStream{ServerStream: ss, ctx: ctx})
+	}
+}
+
+// authorize returns ctx with the caller stored in it, or a status error.
+func (a *GRPCAuthorizer) authorize(ctx context.Context, method string) (context.Context, error) {
+	policy, ok := a.policies[method]
+	if !ok {
+		return nil, status.Error(codes.PermissionDenied, "Unknown method")
+	}
+
+	if !policy.Public {
+		token := bearerFromMetadata(ctx)
+		if token == "" {
+			return nil, status.Error(codes.Unauthenticated, "Authorization header required")
+		}
+		p, err := authenticateBearer(ctx, a.jwtSvc, a.apiKeys, token)
+		if err != nil {
+			return nil, grpcAuthError(err)
+		}
+		if p, err = checkRevocation(ctx, a.userRepo, p); err != nil {
+			return nil, grpcAuthError(err)
+		}
+		ctx = contextWithPrincipal(ctx, p)
+
+		if policy.Admin {
+			if !p.IsAdmin() {
+				return nil, status.Error(codes.PermissionDenied, "Unauthorized access")
+			}
+			if !p.HasScope(domain.ScopeAdmin) {
+				return nil, status.Error(codes.PermissionDenied, "API key is missing the "+domain.ScopeAdmin+" scope")
+			}
+			if err := checkMFAPolicy(ctx, a.settingsRepo, p); err != nil {
+				return nil, grpcAuthError(err)
+			}
+		}
+		if policy.Scope != "" && !p.HasScope(policy.Scope) {
+			return nil, status.Error(codes.PermissionDenied, "API key is missing the "+policy.Scope+" scope")
+		}
+	}
+
+	if policy.RateLimit.Name != "" && a.limiter != nil {
+		key := policy.RateLimit.Name + ":ip:" + GRPCPeerIP(ctx)
+		if p, ok := domain.PrincipalFrom(ctx); ok {
+			key = policy.RateLimit.Name + ":user:" + p.UserID
+		}
+		result, err := a.limiter.Take(ctx, key, policy.RateLimit)
+		if err != nil {
+			// A broken limiter should not take the whole API down with it.
+			domain.Logger(ctx).Error("rate limiter", "policy", policy.RateLimit.Name, "error", err)
+		} else if !result.Allowed {
+			return nil, status.Error(codes.ResourceExhausted, "Too many requests")
+		}
+	}
+	return ctx, nil
+}
+
+// bearerFromMetadata reads the "authorization" metadata, with or without the
+// "Bearer " prefix, like the Authorization header.
+func bearerFromMetadata(ctx context.Context) string {
+	values := metadata.ValueFromIncomingContext(ctx, "authorization")
+	if len(values) == 0 {
+		return ""
+	}
+	return strings.TrimPrefix(values[0], "Bearer ")
+}
+
+func grpcAuthError(err error) error {
+	var authErr *authError
+	if !errors.As(err, &authErr) {
+		return status.Error(codes.Internal, "Internal server error")
+	}
+	code := codes.Internal
+	switch authErr.status {
+	case http.StatusUnauthorized:
+		code = codes.Unauthenticated
+	case http.StatusForbidden:
+		code = codes.PermissionDenied
+	}
+	return status.Error(code, authErr.message)
+}
+
+// GRPCPeerIP is the address of the caller without the port, the gRPC
+// counterpart of gin's ClientIP.
+func GRPCPeerIP(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.Addr == nil {
+		return ""
+	}
+	host, _, err := net.SplitHostPort(p.Addr.String())
+	if err != nil {
+		return p.Addr.String()
+	}
+	return host
+}
+
+// GRPCRequestLogger is the gRPC counterpart of RequestLogger: it stores a
+// logger carrying the x-request-id metadata, or a new id, in the call context,
+// sends the id back in the response header and logs one line per call.
+func GRPCRequestLogger(base *slog.Logger) (grpc.UnaryServerInterceptor, grpc.StreamServerInterceptor) {
+	begin := func(ctx context.Context) (context.Context, *slog.Logger) {
+		var requestID string
+		if values := metadata.ValueFromIncomingContext(ctx, strings.ToLower(domain.RequestIDHeader)); len(values) > 0 {
+			requestID = values[0]
+		}
+		if !validRequestID(requestID) {
+			requestID = newRequestID()
+		}
+		_ = grpc.SetHeader(ctx, metadata.Pairs(strings.ToLower(domain.RequestIDHeader), requestID))
+		logger := base.With("request_id", requestID)
+		if span := trace.SpanContextFromContext(ctx); span.IsValid() {
+			logger = logger.With("trace_id", span.TraceID().String(), "span_id", span.SpanID().String())
+		}
+		return domain.WithLogger(ctx, logger), logger
+	}
+	end := func(ctx context.Context, logger *slog.Logger, method string, start time.Time, err error) {
+		code := status.Code(err)
+		level := slog.LevelInfo
+		switch code {
+		case codes.OK:
+		case codes.Internal, codes.Unknown, codes.DataLoss, codes.Unavailable:
+			level = slog.LevelError
+		default:
+			level = slog.LevelWarn
+		}
+		attrs := []slog.Attr{
+			slog.String("rpc_method", method),
+			slog.String("code", code.String()),
+			slog.Duration("latency", time.Since(start)),
+		}
+		if err != nil {
+			attrs = append(attrs, slog.String("error", status.Convert(err).Message()))
+		}
+		logger.LogAttrs(ctx, level, "rpc", attrs...)
+	}
+
+	unary := func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		start := time.Now()
+		ctx, logger := begin(ctx)
+		resp, err := handler(ctx, req)
+		end(ctx, logger, info.FullMethod, start, err)
+		return resp, err
+	}
+	stream := func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		start := time.Now()
+		ctx, logger := begin(ss.Context())
+		err := handler(srv, &contextStream{ServerStream: ss, ctx: ctx})
+		end(ctx, logger, info.FullMethod, start, err)
+		return err
+	}
+	return unary, stream
+}
+
+// contextStream replaces the context of a server stream, which interceptors
+// cannot do otherwise.
+type contextStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *contextStream) Context() context.Context { return s.ctx }
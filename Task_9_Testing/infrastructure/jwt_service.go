@@ -1,32 +1,111 @@
 package infrastructure
 
 import (
+	"errors"
 	"task_manager/domain"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
 )
 
+// TokenTTL is how long a session token stays valid. Key rotation grace periods
+// should not be shorter.
+const TokenTTL = 24 * time.Hour
+
+// Defaults for the iss and aud claims. Services verifying our tokens through
+// the JWKS should check both.
+const (
+	DefaultIssuer   = "task_manager"
+	DefaultAudience = "task_manager"
+)
+
 type JWTServiceImpl struct {
-	secret string
+	keys     *KeyRing
+	issuer   string
+	audience string
+	now      func() time.Time
+}
+
+type JWTOption func(*JWTServiceImpl)
+
+func WithIssuer(issuer string) JWTOption {
+	return func(s *JWTServiceImpl) { s.issuer = issuer }
+}
+
+func WithAudience(audience string) JWTOption {
+	return func(s *JWTServiceImpl) { s.audience = audience }
+}
+
+// NewJWTService signs with a shared HMAC secret. Only HS256 tokens are accepted.
+func NewJWTService(secret string, opts ...JWTOption) domain.JWTService {
+	return newJWTService(newHMACKeyRing(secret), opts)
+}
+
+// NewKeyRingJWTService signs with the current key of ring and stamps its kid
+// into the token header.
+func NewKeyRingJWTService(ring *KeyRing, opts ...JWTOption) domain.JWTService {
+	return newJWTService(ring, opts)
+}
+
+func newJWTService(ring *KeyRing, opts []JWTOption) *JWTServiceImpl {
+	s := &JWTServiceImpl{keys: ring, issuer: DefaultIssuer, audience: DefaultAudience, now: time.Now}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
 }
 
-func NewJWTService(secret string) domain.JWTService {
-	return &JWTServiceImpl{secret: secret}
+func (s *JWTServiceImpl) GenerateToken(userID, username, role string, amr ...string) (string, error) {
+	now := s.now()
+	claims := domain.Claims{
+		Username: username,
+		Role:     role,
+		AMR:      amr,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    s.issuer,
+			Subject:   userID,
+			Audience:  jwt.ClaimStrings{s.audience},
+			IssuedAt:  jwt.NewNumericDate(now),
+			NotBefore: jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(TokenTTL)),
+		},
+	}
+	key := s.keys.signingKey()
+	token := jwt.NewWithClaims(key.method, claims)
+	if key.id != "" {
+		token.Header["kid"] = key.id
+	}
+	return token.SignedString(key.secret)
 }
 
-func (s *JWTServiceImpl) GenerateToken(userID, username, role string) (string, error) {
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
-		"sub":  userID,
-		"name": username,
-		"role": role,
-		"exp":  time.Now().Add(24 * time.Hour).Unix(),
-	})
-	return token.SignedString([]byte(s.secret))
+// ValidateToken pins the algorithm to the one of the key named by kid, so a
+// token cannot pick its own verification method through the alg header. The
+// issuer, audience, expiry and not-before claims must all check out.
+func (s *JWTServiceImpl) ValidateToken(tokenString string) (*domain.Claims, error) {
+	var claims domain.Claims
+	_, err := jwt.ParseWithClaims(tokenString, &claims, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		key := s.keys.verificationKey(kid)
+		if key == nil {
+			return nil, errors.New("unknown signing key")
+		}
+		if token.Method.Alg() != key.method.Alg() {
+			return nil, errors.New("unexpected signing method")
+		}
+		return key.verifyKey(), nil
+	},
+		jwt.WithValidMethods(s.keys.methods()),
+		jwt.WithIssuer(s.issuer),
+		jwt.WithAudience(s.audience),
+		jwt.WithExpirationRequired(),
+		jwt.WithTimeFunc(s.now),
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &claims, nil
 }
 
-func (s *JWTServiceImpl) ValidateToken(tokenString string) (*jwt.Token, error) {
-	return jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-		return []byte(s.secret), nil
-	})
+func (s *JWTServiceImpl) JWKS() domain.JSONWebKeySet {
+	return s.keys.JWKS()
 }
@@ -1,6 +1,14 @@
 package infrastructure
 
 import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"task_manager/domain"
 	"testing"
 	"time"
 	"github.com/golang-jwt/jwt/v5"
@@ -32,6 +40,10 @@ func TestGenerateToken(t *testing.T) {
 		exp, ok := claims["exp"].(float64)
 		assert.True(t, ok, "Expiration should be a number")
 		assert.InDelta(t, time.Now().Add(24*time.Hour).Unix(), int64(exp), 2, "Expiration should be ~24 hours from now")
+
+		iat, ok := claims["iat"].(float64)
+		assert.True(t, ok, "Issued-at should be a number")
+		assert.InDelta(t, time.Now().Unix(), int64(iat), 2, "Issued-at should be now")
 	})
 }
 
@@ -45,15 +57,12 @@ func TestValidateToken(t *testing.T) {
 		assert.NoError(t, err)
 
 		
-		token, err := jwtService.ValidateToken(tokenString)
+		claims, err := jwtService.ValidateToken(tokenString)
 		assert.NoError(t, err, "ValidateToken should not return an error")
-		assert.True(t, token.Valid, "Token should be valid")
-
-		claims, ok := token.Claims.(jwt.MapClaims)
-		assert.True(t, ok)
-		assert.Equal(t, "1", claims["sub"])
-		assert.Equal(t, "testuser", claims["name"])
-		assert.Equal(t, "user", claims["role"])
+		assert.Equal(t, "1", claims.Subject)
+		assert.Equal(t, "testuser", claims.Username)
+		assert.Equal(t, "user", claims.Role)
+		assert.Equal(t, DefaultIssuer, claims.Issuer)
 	})
 
 	t.Run("InvalidToken", func(t *testing.T) {
@@ -91,3 +100,184 @@ func TestValidateToken(t *testing.T) {
 		assert.Contains(t, err.Error(), "token is expired", "Error should indicate expiration")
 	})
 }
+
+func TestAlgorithmPinning(t *testing.T) {
+	secret := "test-secret"
+	jwtService := NewJWTService(secret)
+
+	t.Run("NoneAlgorithm", func(t *testing.T) {
+		token := jwt.NewWithClaims(jwt.SigningMethodNone, jwt.MapClaims{"sub": "1", "role": "admin"})
+		tokenString, err := token.SignedString(jwt.UnsafeAllowNoneSignatureType)
+		assert.NoError(t, err)
+
+		_, err = jwtService.ValidateToken(tokenString)
+		assert.Error(t, err, "Unsigned tokens should be rejected")
+	})
+
+	t.Run("HMACWithPublicKey", func(t *testing.T) {
+		ring, err := NewKeyRing(AlgRS256, time.Hour)
+		assert.NoError(t, err)
+		rsaService := NewKeyRingJWTService(ring)
+		key := ring.signingKey()
+		publicDER, err := x509.MarshalPKIXPublicKey(key.verifyKey())
+		assert.NoError(t, err)
+
+		// The classic confusion attack: sign with HS256 using the public key as the secret.
+		token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"sub": "1", "role": "admin"})
+		token.Header["kid"] = key.id
+		tokenString, err := token.SignedString(publicDER)
+		assert.NoError(t, err)
+
+		_, err = rsaService.ValidateToken(tokenString)
+		assert.Error(t, err, "HS256 tokens should not be accepted by an RS256 ring")
+	})
+}
+
+func TestKeyRingJWTService(t *testing.T) {
+	for _, alg := range []string{AlgRS256, AlgEdDSA} {
+		t.Run(alg, func(t *testing.T) {
+			ring, err := NewKeyRing(alg, time.Hour)
+			assert.NoError(t, err)
+			jwtService := NewKeyRingJWTService(ring)
+
+			tokenString, err := jwtService.GenerateToken("1", "testuser", "user", "pwd")
+			assert.NoError(t, err)
+
+			claims, err := jwtService.ValidateToken(tokenString)
+			assert.NoError(t, err, "ValidateToken should accept its own tokens")
+			assert.Equal(t, []string{"pwd"}, claims.AMR)
+			token := parseUnverified(t, tokenString)
+			assert.Equal(t, alg, token.Method.Alg())
+			assert.Equal(t, ring.signingKey().id, token.Header["kid"], "Tokens should name their key")
+
+			jwks := jwtService.JWKS()
+			assert.Len(t, jwks.Keys, 1)
+			assert.Equal(t, token.Header["kid"], jwks.Keys[0].Kid)
+			assert.Equal(t, alg, jwks.Keys[0].Alg)
+		})
+	}
+}
+
+func TestKeyRotation(t *testing.T) {
+	now := time.Date(2025, 4, 3, 12, 0, 0, 0, time.UTC)
+	ring, err := NewKeyRing(AlgEdDSA, time.Hour)
+	assert.NoError(t, err)
+	ring.now = func() time.Time { return now }
+	jwtService := NewKeyRingJWTService(ring)
+
+	oldToken, err := jwtService.GenerateToken("1", "testuser", "user")
+	assert.NoError(t, err)
+	oldKid := ring.signingKey().id
+
+	assert.NoError(t, ring.Rotate())
+	newToken, err := jwtService.GenerateToken("1", "testuser", "user")
+	assert.NoError(t, err)
+	assert.NotEqual(t, oldKid, ring.signingKey().id, "Rotation should switch the signing key")
+
+	t.Run("GracePeriod", func(t *testing.T) {
+		_, err := jwtService.ValidateToken(oldToken)
+		assert.NoError(t, err, "Tokens of the previous key should verify during the grace period")
+		assert.Len(t, jwtService.JWKS().Keys, 2, "Both keys should be published")
+	})
+
+	t.Run("Retired", func(t *testing.T) {
+		now = now.Add(time.Hour)
+		_, err := jwtService.ValidateToken(oldToken)
+		assert.Error(t, err, "Tokens of a retired key should be rejected")
+		_, err = jwtService.ValidateToken(newToken)
+		assert.NoError(t, err)
+		assert.Len(t, jwtService.JWKS().Keys, 1, "Retired keys should not be published")
+	})
+}
+
+func TestLoadKeyRing(t *testing.T) {
+	dir := t.TempDir()
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	assert.NoError(t, err)
+	der, err := x509.MarshalPKCS8PrivateKey(edKey)
+	assert.NoError(t, err)
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoError(t, err)
+
+	writePEM := func(name, blockType string, der []byte) {
+		data := pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der})
+		assert.NoError(t, os.WriteFile(filepath.Join(dir, name), data, 0o600))
+	}
+	writePEM("2025-01.pem", "PRIVATE KEY", der)
+	writePEM("2025-02.pem", "RSA PRIVATE KEY", x509.MarshalPKCS1PrivateKey(rsaKey))
+
+	ring, err := LoadKeyRing(dir)
+	assert.NoError(t, err)
+	jwtService := NewKeyRingJWTService(ring)
+
+	tokenString, err := jwtService.GenerateToken("1", "testuser", "user")
+	assert.NoError(t, err)
+	_, err = jwtService.ValidateToken(tokenString)
+	assert.NoError(t, err)
+	token := parseUnverified(t, tokenString)
+	assert.Equal(t, "2025-02", token.Header["kid"], "The last key by name should sign")
+	assert.Equal(t, AlgRS256, token.Method.Alg())
+	assert.Len(t, jwtService.JWKS().Keys, 2)
+
+	_, err = LoadKeyRing(t.TempDir())
+	assert.Error(t, err, "An empty directory should be an error")
+}
+
+func parseUnverified(t *testing.T, tokenString string) *jwt.Token {
+	token, _, err := jwt.NewParser().ParseUnverified(tokenString, &domain.Claims{})
+	assert.NoError(t, err)
+	return token
+}
+
+func TestRegisteredClaims(t *testing.T) {
+	secret := "test-secret"
+	now := time.Date(2025, 4, 3, 12, 0, 0, 0, time.UTC)
+	jwtService := NewJWTService(secret, WithIssuer("issuer-a"), WithAudience("api-a")).(*JWTServiceImpl)
+	jwtService.now = func() time.Time { return now }
+
+	sign := func(claims jwt.RegisteredClaims) string {
+		tokenString, err := jwt.NewWithClaims(jwt.SigningMethodHS256, domain.Claims{RegisteredClaims: claims}).SignedString([]byte(secret))
+		assert.NoError(t, err)
+		return tokenString
+	}
+	valid := jwt.RegisteredClaims{
+		Issuer:    "issuer-a",
+		Subject:   "1",
+		Audience:  jwt.ClaimStrings{"api-a"},
+		ExpiresAt: jwt.NewNumericDate(now.Add(time.Hour)),
+	}
+
+	t.Run("Valid", func(t *testing.T) {
+		claims, err := jwtService.ValidateToken(sign(valid))
+		assert.NoError(t, err)
+		assert.Equal(t, "1", claims.Principal().UserID)
+	})
+
+	t.Run("WrongIssuer", func(t *testing.T) {
+		c := valid
+		c.Issuer = "someone-else"
+		_, err := jwtService.ValidateToken(sign(c))
+		assert.ErrorIs(t, err, jwt.ErrTokenInvalidIssuer)
+	})
+
+	t.Run("WrongAudience", func(t *testing.T) {
+		c := valid
+		c.Audience = jwt.ClaimStrings{"other-api"}
+		_, err := jwtService.ValidateToken(sign(c))
+		assert.ErrorIs(t, err, jwt.ErrTokenInvalidAudience)
+	})
+
+	t.Run("NotYetValid", func(t *testing.T) {
+		c := valid
+		c.NotBefore = jwt.NewNumericDate(now.Add(time.Minute))
+		_, err := jwtService.ValidateToken(sign(c))
+		assert.ErrorIs(t, err, jwt.ErrTokenNotValidYet)
+	})
+
+	t.Run("NoExpiry", func(t *testing.T) {
+		c := valid
+		c.ExpiresAt = nil
+		_, err := jwtService.ValidateToken(sign(c))
+		assert.Error(t, err, "Tokens without exp should be rejected")
+	})
+}
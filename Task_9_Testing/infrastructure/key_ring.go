@@ -0,0 +1,286 @@
+package infrastructure
+
+import (
+	"context"
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"task_manager/domain"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Supported asymmetric signing algorithms.
+const (
+	AlgRS256 = "RS256"
+	AlgEdDSA = "EdDSA"
+)
+
+const rsaKeyBits = 2048
+
+// signingKey is one entry of a KeyRing. secret is either a crypto.Signer for
+// asymmetric keys or the raw bytes of an HMAC secret.
+type signingKey struct {
+	id     string
+	method jwt.SigningMethod
+	secret interface{}
+	// retiresAt is set when a newer key takes over. The key keeps verifying
+	// tokens until then but no longer signs.
+	retiresAt time.Time
+}
+
+func (k *signingKey) verifyKey() interface{} {
+	if signer, ok := k.secret.(crypto.Signer); ok {
+		return signer.Public()
+	}
+	return k.secret
+}
+
+// KeyRing holds the token signing keys. The newest key signs; older keys stay
+// valid for verification until their grace period ends.
+type KeyRing struct {
+	mu    sync.RWMutex
+	alg   string
+	grace time.Duration
+	keys  []*signingKey
+	now   func() time.Time
+}
+
+// NewKeyRing creates a ring with a freshly generated key. grace should be at
+// least the token lifetime, so no token outlives the key that signed it.
+func NewKeyRing(alg string, grace time.Duration) (*KeyRing, error) {
+	ring := &KeyRing{alg: alg, grace: grace, now: time.Now}
+	if err := ring.Rotate(); err != nil {
+		return nil, err
+	}
+	return ring, nil
+}
+
+// LoadKeyRing reads PEM encoded private keys from dir, using each file name
+// (without extension) as the kid. The last file in name order signs, so a
+// rotation is a matter of adding a file that sorts later and restarting.
+// Every instance loading the same directory issues interchangeable tokens.
+func LoadKeyRing(dir string) (*KeyRing, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.pem"))
+	if err != nil {
+		return nil, err
+	}
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("no signing keys found in %s", dir)
+	}
+	sort.Strings(paths)
+
+	ring := &KeyRing{now: time.Now}
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		id := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+		key, err := parseSigningKey(id, data)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		ring.keys = append(ring.keys, key)
+	}
+	ring.alg = ring.keys[len(ring.keys)-1].method.Alg()
+	return ring, nil
+}
+
+// newHMACKeyRing wraps a shared secret. Tokens carry no kid and the key is
+// never published.
+func newHMACKeyRing(secret string) *KeyRing {
+	key := &signingKey{method: jwt.SigningMethodHS256, secret: []byte(secret)}
+	return &KeyRing{alg: key.method.Alg(), keys: []*signingKey{key}, now: time.Now}
+}
+
+// Rotate generates a new signing key and starts the grace period of the
+// current one. Keys whose grace period has ended are dropped.
+func (r *KeyRing) Rotate() error {
+	key, err := generateSigningKey(r.alg)
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := r.now()
+	kept := r.keys[:0]
+	for _, k := range r.keys {
+		if k.retiresAt.IsZero() {
+			k.retiresAt = now.Add(r.grace)
+		}
+		if k.retiresAt.After(now) {
+			kept = append(kept, k)
+		}
+	}
+	r.keys = append(kept, key)
+	return nil
+}
+
+// RotateEvery rotates the ring on a fixed schedule until ctx is done.
+func (r *KeyRing) RotateEvery(ctx context.Context, interval time.Duration, onError func(error)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := r.Rotate(); err != nil && onError != nil {
+				onError(err)
+			}
+		}
+	}
+}
+
+func (r *KeyRing) signingKey() *signingKey {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.keys[len(r.keys)-1]
+}
+
+// verificationKey returns the key with the given kid unless it has retired.
+func (r *KeyRing) verificationKey(kid string) *signingKey {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	now := r.now()
+	for _, k := range r.keys {
+		if k.id == kid && (k.retiresAt.IsZero() || k.retiresAt.After(now)) {
+			return k
+		}
+	}
+	return nil
+}
+
+// methods lists the algorithms the ring accepts; anything else is rejected
+// before a key is even looked up.
+func (r *KeyRing) methods() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var algs []string
+	for _, k := range r.keys {
+		if !containsString(algs, k.method.Alg()) {
+			algs = append(algs, k.method.Alg())
+		}
+	}
+	return algs
+}
+
+// JWKS returns the public keys that can still verify tokens.
+func (r *KeyRing) JWKS() domain.JSONWebKeySet {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	now := r.now()
+	set := domain.JSONWebKeySet{Keys: []domain.JSONWebKey{}}
+	for _, k := range r.keys {
+		if !k.retiresAt.IsZero() && !k.retiresAt.After(now) {
+			continue
+		}
+		if jwk, ok := publicJWK(k); ok {
+			set.Keys = append(set.Keys, jwk)
+		}
+	}
+	return set
+}
+
+func publicJWK(k *signingKey) (domain.JSONWebKey, bool) {
+	jwk := domain.JSONWebKey{Kid: k.id, Use: "sig", Alg: k.method.Alg()}
+	switch pub := k.verifyKey().(type) {
+	case *rsa.PublicKey:
+		jwk.Kty = "RSA"
+		jwk.N = base64.RawURLEncoding.EncodeToString(pub.N.Bytes())
+		jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes())
+	case ed25519.PublicKey:
+		jwk.Kty = "OKP"
+		jwk.Crv = "Ed25519"
+		jwk.X = base64.RawURLEncoding.EncodeToString(pub)
+	default:
+		return domain.JSONWebKey{}, false
+	}
+	return jwk, true
+}
+
+func generateSigningKey(alg string) (*signingKey, error) {
+	id, err := newKeyID()
+	if err != nil {
+		return nil, err
+	}
+	switch alg {
+	case AlgRS256:
+		priv, err := rsa.GenerateKey(rand.Reader, rsaKeyBits)
+		if err != nil {
+			return nil, err
+		}
+		return &signingKey{id: id, method: jwt.SigningMethodRS256, secret: priv}, nil
+	case AlgEdDSA:
+		_, priv, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			return nil, err
+		}
+		return &signingKey{id: id, method: jwt.SigningMethodEdDSA, secret: priv}, nil
+	default:
+		return nil, fmt.Errorf("unsupported signing algorithm %q", alg)
+	}
+}
+
+func parseSigningKey(id string, data []byte) (*signingKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM block found")
+	}
+
+	var parsed interface{}
+	var err error
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PRIVATE KEY":
+		parsed, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("unsupported PEM block %q", block.Type)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	switch priv := parsed.(type) {
+	case *rsa.PrivateKey:
+		return &signingKey{id: id, method: jwt.SigningMethodRS256, secret: priv}, nil
+	case ed25519.PrivateKey:
+		return &signingKey{id: id, method: jwt.SigningMethodEdDSA, secret: priv}, nil
+	default:
+		return nil, fmt.Errorf("unsupported key type %T", parsed)
+	}
+}
+
+func newKeyID() (string, error) {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,97 @@
+package infrastructure
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/smtp"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"task_manager/domain"
+	"time"
+)
+
+// LogMailer writes outgoing email to the standard logger instead of sending it.
+type LogMailer struct {
+	logger *log.Logger
+}
+
+func NewLogMailer(logger *log.Logger) domain.Mailer {
+	if logger == nil {
+		logger = log.Default()
+	}
+	return &LogMailer{logger: logger}
+}
+
+func (m *LogMailer) Send(ctx context.Context, email domain.Email) error {
+	m.logger.Printf("mail to=%q subject=%q\n%s", email.To, email.Subject, email.Body)
+	return nil
+}
+
+// FileMailer drops every message into dir as an .eml file, which is handy for
+// local runs and for reading the reset links tests produce.
+type FileMailer struct {
+	dir string
+	mu  sync.Mutex
+	seq int
+	now func() time.Time
+}
+
+func NewFileMailer(dir string) (domain.Mailer, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &FileMailer{dir: dir, now: time.Now}, nil
+}
+
+func (m *FileMailer) Send(ctx context.Context, email domain.Email) error {
+	m.mu.Lock()
+	m.seq++
+	seq := m.seq
+	m.mu.Unlock()
+
+	now := m.now()
+	name := fmt.Sprintf("%s-%04d-%s.eml", now.UTC().Format("20060102T150405"), seq, safeFileName(email.To))
+	return os.WriteFile(filepath.Join(m.dir, name), formatMessage("", email, now), 0o600)
+}
+
+// SMTPMailer hands messages to an SMTP server. For local runs point it at a
+// catch-all server such as MailHog or Mailpit, usually on localhost:1025.
+type SMTPMailer struct {
+	addr string
+	from string
+	auth smtp.Auth
+}
+
+func NewSMTPMailer(addr, from string, auth smtp.Auth) domain.Mailer {
+	return &SMTPMailer{addr: addr, from: from, auth: auth}
+}
+
+func (m *SMTPMailer) Send(ctx context.Context, email domain.Email) error {
+	return smtp.SendMail(m.addr, m.auth, m.from, []string{email.To}, formatMessage(m.from, email, time.Now()))
+}
+
+func formatMessage(from string, email domain.Email, now time.Time) []byte {
+	var b strings.Builder
+	if from != "" {
+		fmt.Fprintf(&b, "From: %s\r\n", from)
+	}
+	fmt.Fprintf(&b, "Date: %s\r\n", now.Format(time.RFC1123Z))
+	fmt.Fprintf(&b, "To: %s\r\n", email.To)
+	fmt.Fprintf(&b, "Subject: %s\r\n", email.Subject)
+	b.WriteString("Content-Type: text/plain; charset=utf-8\r\n\r\n")
+	b.WriteString(strings.ReplaceAll(email.Body, "\n", "\r\n"))
+	return []byte(b.String())
+}
+
+func safeFileName(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '.', r == '-', r == '_', r == '@':
+			return r
+		}
+		return '_'
+	}, s)
+}
@@ -0,0 +1,112 @@
+package infrastructure
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"log"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"task_manager/domain"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLogMailer(t *testing.T) {
+	var buf bytes.Buffer
+	mailer := NewLogMailer(log.New(&buf, "", 0))
+
+	err := mailer.Send(context.Background(), domain.Email{To: "jane@example.com", Subject: "Hello", Body: "token: abc"})
+	assert.NoError(t, err, "Send should not return an error")
+	assert.Contains(t, buf.String(), `to="jane@example.com"`, "Log should include the recipient")
+	assert.Contains(t, buf.String(), "token: abc", "Log should include the body")
+}
+
+func TestFileMailer(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "mail")
+	mailer, err := NewFileMailer(dir)
+	assert.NoError(t, err, "NewFileMailer should create the directory")
+
+	err = mailer.Send(context.Background(), domain.Email{To: "jane/../@example.com", Subject: "Reset", Body: "token: abc"})
+	assert.NoError(t, err, "Send should not return an error")
+
+	entries, err := os.ReadDir(dir)
+	assert.NoError(t, err)
+	if assert.Len(t, entries, 1, "One message should be written") {
+		assert.NotContains(t, entries[0].Name(), "/", "Recipient should be sanitised in the file name")
+		data, err := os.ReadFile(filepath.Join(dir, entries[0].Name()))
+		assert.NoError(t, err)
+		assert.Contains(t, string(data), "Subject: Reset\r\n")
+		assert.Contains(t, string(data), "\r\n\r\ntoken: abc")
+	}
+}
+
+// fakeSMTPServer accepts one message and sends what it received on the returned channel.
+func fakeSMTPServer(t *testing.T) (string, <-chan string) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	received := make(chan string, 1)
+	go func() {
+		defer ln.Close()
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		r := bufio.NewReader(conn)
+		reply := func(line string) { conn.Write([]byte(line + "\r\n")) }
+
+		reply("220 localhost ESMTP")
+		var transcript strings.Builder
+		inData := false
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+			if inData {
+				if line == ".\r\n" {
+					inData = false
+					reply("250 OK")
+					continue
+				}
+				transcript.WriteString(line)
+				continue
+			}
+			switch cmd := strings.ToUpper(strings.TrimSpace(line)); {
+			case strings.HasPrefix(cmd, "EHLO"), strings.HasPrefix(cmd, "HELO"):
+				reply("250 localhost")
+			case strings.HasPrefix(cmd, "DATA"):
+				inData = true
+				reply("354 End data with <CR><LF>.<CR><LF>")
+			case strings.HasPrefix(cmd, "QUIT"):
+				reply("221 Bye")
+				received <- transcript.String()
+				return
+			default:
+				transcript.WriteString(line)
+				reply("250 OK")
+			}
+		}
+	}()
+	return ln.Addr().String(), received
+}
+
+func TestSMTPMailer(t *testing.T) {
+	addr, received := fakeSMTPServer(t)
+	mailer := NewSMTPMailer(addr, "no-reply@example.com", nil)
+
+	err := mailer.Send(context.Background(), domain.Email{To: "jane@example.com", Subject: "Verify", Body: "line one\nline two"})
+	assert.NoError(t, err, "Send should not return an error")
+
+	transcript := <-received
+	assert.Contains(t, transcript, "MAIL FROM:<no-reply@example.com>")
+	assert.Contains(t, transcript, "RCPT TO:<jane@example.com>")
+	assert.Contains(t, transcript, "Subject: Verify\r\n")
+	assert.Contains(t, transcript, "line one\r\nline two", "Body lines should end in CRLF")
+}
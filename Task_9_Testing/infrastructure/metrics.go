@@ -0,0 +1,103 @@
+package infrastructure
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// PrometheusMetrics implements domain.Metrics and also measures HTTP traffic.
+// It uses its own registry, so tests can create as many as they like.
+type PrometheusMetrics struct {
+	registry     *prometheus.Registry
+	httpRequests *prometheus.CounterVec
+	httpDuration *prometheus.HistogramVec
+	operations   *prometheus.CounterVec
+	repoDuration *prometheus.HistogramVec
+	repoErrors   *prometheus.CounterVec
+	logins       *prometheus.CounterVec
+}
+
+func NewPrometheusMetrics() *PrometheusMetrics {
+	m := &PrometheusMetrics{
+		registry: prometheus.NewRegistry(),
+		httpRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "http_requests_total",
+			Help: "HTTP requests by method, route and status.",
+		}, []string{"method", "route", "status"}),
+		httpDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "http_request_duration_seconds",
+			Help:    "HTTP request latency by method, route and status.",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"method", "route", "status"}),
+		operations: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "task_manager_usecase_operations_total",
+			Help: "Usecase calls by usecase, operation and outcome.",
+		}, []string{"usecase", "operation", "outcome"}),
+		repoDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "task_manager_repository_call_duration_seconds",
+			Help:    "Repository call latency by repository and method.",
+			Buckets: []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1},
+		}, []string{"repository", "method"}),
+		repoErrors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "task_manager_repository_errors_total",
+			Help: "Failed repository calls by repository and method.",
+		}, []string{"repository", "method"}),
+		logins: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "task_manager_logins_total",
+			Help: "Login attempts by method and result.",
+		}, []string{"method", "result"}),
+	}
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.httpRequests, m.httpDuration, m.operations, m.repoDuration, m.repoErrors, m.logins,
+	)
+	return m
+}
+
+func (m *PrometheusMetrics) ObserveRepositoryCall(repository, method string, duration time.Duration, err error) {
+	m.repoDuration.WithLabelValues(repository, method).Observe(duration.Seconds())
+	if err != nil {
+		m.repoErrors.WithLabelValues(repository, method).Inc()
+	}
+}
+
+func (m *PrometheusMetrics) CountOperation(usecase, operation string, err error) {
+	outcome := "ok"
+	if err != nil {
+		outcome = "error"
+	}
+	m.operations.WithLabelValues(usecase, operation, outcome).Inc()
+}
+
+func (m *PrometheusMetrics) CountLogin(method, result string) {
+	m.logins.WithLabelValues(method, result).Inc()
+}
+
+// Middleware measures every request. Requests that match no route share one
+// label, so scanners cannot blow up the number of series.
+func (m *PrometheusMetrics) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		status := strconv.Itoa(c.Writer.Status())
+		m.httpRequests.WithLabelValues(c.Request.Method, route, status).Inc()
+		m.httpDuration.WithLabelValues(c.Request.Method, route, status).Observe(time.Since(start).Seconds())
+	}
+}
+
+// Handler serves the metrics in the Prometheus text format.
+func (m *PrometheusMetrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+}
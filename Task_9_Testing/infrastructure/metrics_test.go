@@ -0,0 +1,61 @@
+package infrastructure
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPrometheusMetrics(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	m := NewPrometheusMetrics()
+	router := gin.New()
+	router.Use(m.Middleware())
+	router.GET("/tasks/:id", func(c *gin.Context) { c.Status(http.StatusNotFound) })
+	router.GET("/metrics", gin.WrapH(m.Handler()))
+
+	get := func(path string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("GET", path, nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("HTTP", func(t *testing.T) {
+		get("/tasks/1")
+		get("/tasks/2")
+		get("/wp-login.php")
+
+		assert.Equal(t, 2.0, testutil.ToFloat64(m.httpRequests.WithLabelValues("GET", "/tasks/:id", "404")), "Requests should be counted per route, not per path")
+		assert.Equal(t, 1.0, testutil.ToFloat64(m.httpRequests.WithLabelValues("GET", "unmatched", "404")))
+	})
+
+	t.Run("Repositories", func(t *testing.T) {
+		m.ObserveRepositoryCall("task", "AddTask", time.Millisecond, nil)
+		m.ObserveRepositoryCall("task", "AddTask", time.Millisecond, errors.New("db down"))
+
+		assert.Equal(t, 1.0, testutil.ToFloat64(m.repoErrors.WithLabelValues("task", "AddTask")))
+	})
+
+	t.Run("LoginsAndOperations", func(t *testing.T) {
+		m.CountLogin("password", "failure")
+		m.CountOperation("task", "DeleteTask", errors.New("not found"))
+
+		assert.Equal(t, 1.0, testutil.ToFloat64(m.logins.WithLabelValues("password", "failure")))
+		assert.Equal(t, 1.0, testutil.ToFloat64(m.operations.WithLabelValues("task", "DeleteTask", "error")))
+	})
+
+	t.Run("Exposition", func(t *testing.T) {
+		body := get("/metrics").Body.String()
+		for _, name := range []string{"http_request_duration_seconds_bucket", "task_manager_repository_call_duration_seconds", "task_manager_logins_total", "go_goroutines"} {
+			assert.True(t, strings.Contains(body, name), "Expected %s in the scrape", name)
+		}
+	})
+}
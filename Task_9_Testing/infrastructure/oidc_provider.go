@@ -0,0 +1,127 @@
+package infrastructure
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"task_manager/domain"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"golang.org/x/oauth2"
+)
+
+// OIDCConfig describes the client registration with an OpenID Connect provider.
+type OIDCConfig struct {
+	IssuerURL    string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	// RoleClaim names the ID token claim that carries roles or groups, for
+	// example "groups". It may hold a string or a list of strings.
+	RoleClaim string
+}
+
+type OIDCProviderImpl struct {
+	client    *http.Client
+	oauth     oauth2.Config
+	verifier  *oidc.IDTokenVerifier
+	roleClaim string
+}
+
+// NewOIDCProvider fetches the provider's discovery document, so the issuer
+// must be reachable at startup.
+//
+// Calls to the provider are traced and carry the trace context of the login.
+func NewOIDCProvider(ctx context.Context, cfg OIDCConfig) (domain.OIDCProvider, error) {
+	client := &http.Client{Transport: otelhttp.NewTransport(http.DefaultTransport)}
+	// The key set keeps using this context's client to fetch rotated keys.
+	provider, err := oidc.NewProvider(oidc.ClientContext(ctx, client), cfg.IssuerURL)
+	if err != nil {
+		return nil, fmt.Errorf("oidc discovery: %w", err)
+	}
+	return &OIDCProviderImpl{
+		client: client,
+		oauth: oauth2.Config{
+			ClientID:     cfg.ClientID,
+			ClientSecret: cfg.ClientSecret,
+			RedirectURL:  cfg.RedirectURL,
+			Endpoint:     provider.Endpoint(),
+			Scopes:       []string{oidc.ScopeOpenID, "email", "profile"},
+		},
+		verifier:  provider.Verifier(&oidc.Config{ClientID: cfg.ClientID}),
+		roleClaim: cfg.RoleClaim,
+	}, nil
+}
+
+func (p *OIDCProviderImpl) AuthCodeURL(req domain.OIDCAuthRequest) string {
+	return p.oauth.AuthCodeURL(req.State, oidc.Nonce(req.Nonce), oauth2.S256ChallengeOption(req.Verifier))
+}
+
+// Exchange redeems the code with the PKCE verifier, then checks the ID token's
+// signature, issuer, audience, expiry and nonce before reading any claim.
+func (p *OIDCProviderImpl) Exchange(ctx context.Context, code string, req domain.OIDCAuthRequest) (*domain.OIDCIdentity, error) {
+	ctx = oidc.ClientContext(ctx, p.client)
+	token, err := p.oauth.Exchange(ctx, code, oauth2.VerifierOption(req.Verifier))
+	if err != nil {
+		return nil, fmt.Errorf("oidc token exchange: %w", err)
+	}
+	rawIDToken, ok := token.Extra("id_token").(string)
+	if !ok {
+		return nil, errors.New("oidc token response has no id_token")
+	}
+	idToken, err := p.verifier.Verify(ctx, rawIDToken)
+	if err != nil {
+		return nil, fmt.Errorf("oidc id token: %w", err)
+	}
+	if idToken.Nonce != req.Nonce {
+		return nil, errors.New("oidc id token nonce mismatch")
+	}
+
+	var claims struct {
+		Email             string   `json:"email"`
+		EmailVerified     bool     `json:"email_verified"`
+		Name              string   `json:"name"`
+		PreferredUsername string   `json:"preferred_username"`
+		AMR               []string `json:"amr"`
+	}
+	if err := idToken.Claims(&claims); err != nil {
+		return nil, fmt.Errorf("oidc id token claims: %w", err)
+	}
+	identity := &domain.OIDCIdentity{
+		Issuer:            idToken.Issuer,
+		Subject:           idToken.Subject,
+		Email:             claims.Email,
+		EmailVerified:     claims.EmailVerified,
+		Name:              claims.Name,
+		PreferredUsername: claims.PreferredUsername,
+		AMR:               claims.AMR,
+	}
+	if p.roleClaim != "" {
+		var all map[string]interface{}
+		if err := idToken.Claims(&all); err != nil {
+			return nil, fmt.Errorf("oidc id token claims: %w", err)
+		}
+		identity.Roles = stringValues(all[p.roleClaim])
+	}
+	return identity, nil
+}
+
+// stringValues accepts a claim holding either one string or a list of them.
+func stringValues(claim interface{}) []string {
+	switch v := claim.(type) {
+	case string:
+		return []string{v}
+	case []interface{}:
+		values := make([]string, 0, len(v))
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				values = append(values, s)
+			}
+		}
+		return values
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,108 @@
+package infrastructure
+
+import (
+	"context"
+	"net/url"
+	"task_manager/domain"
+	"task_manager/infrastructure/oidctest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOIDCProvider(t *testing.T) {
+	idp := oidctest.NewServer()
+	defer idp.Close()
+	ctx := context.Background()
+
+	provider, err := NewOIDCProvider(ctx, OIDCConfig{
+		IssuerURL:    idp.URL,
+		ClientID:     idp.ClientID,
+		ClientSecret: idp.ClientSecret,
+		RedirectURL:  "http://localhost:8080/auth/oidc/callback",
+		RoleClaim:    "groups",
+	})
+	require.NoError(t, err, "Discovery against the mock provider should succeed")
+
+	newRequest := func() domain.OIDCAuthRequest {
+		return domain.OIDCAuthRequest{
+			State:    "state-" + t.Name(),
+			Nonce:    "nonce-" + t.Name(),
+			Verifier: "verifier-0123456789-0123456789-0123456789-" + t.Name(),
+		}
+	}
+
+	t.Run("Success", func(t *testing.T) {
+		idp.SetClaims(map[string]interface{}{
+			"sub":                "user-1",
+			"email":              "alice@example.com",
+			"email_verified":     true,
+			"name":               "Alice",
+			"preferred_username": "alice",
+			"groups":             []string{"staff", "task-admins"},
+			"amr":                []string{"pwd", "mfa"},
+		})
+		req := newRequest()
+		authURL := provider.AuthCodeURL(req)
+		parsed, _ := url.Parse(authURL)
+		assert.Equal(t, "S256", parsed.Query().Get("code_challenge_method"))
+		assert.NotContains(t, authURL, req.Verifier, "Only the challenge may leave the server")
+
+		code, state, err := idp.Authorize(authURL)
+		require.NoError(t, err)
+		assert.Equal(t, req.State, state)
+
+		identity, err := provider.Exchange(ctx, code, req)
+		require.NoError(t, err)
+		assert.Equal(t, idp.URL, identity.Issuer)
+		assert.Equal(t, "user-1", identity.Subject)
+		assert.Equal(t, "alice@example.com", identity.Email)
+		assert.True(t, identity.EmailVerified)
+		assert.Equal(t, "alice", identity.PreferredUsername)
+		assert.Equal(t, []string{"staff", "task-admins"}, identity.Roles)
+		assert.Equal(t, []string{"pwd", "mfa"}, identity.AMR)
+	})
+
+	t.Run("SingleRoleString", func(t *testing.T) {
+		idp.SetClaims(map[string]interface{}{"sub": "user-2", "groups": "task-admins"})
+		req := newRequest()
+		code, _, err := idp.Authorize(provider.AuthCodeURL(req))
+		require.NoError(t, err)
+
+		identity, err := provider.Exchange(ctx, code, req)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"task-admins"}, identity.Roles)
+	})
+
+	t.Run("WrongVerifier", func(t *testing.T) {
+		req := newRequest()
+		code, _, err := idp.Authorize(provider.AuthCodeURL(req))
+		require.NoError(t, err)
+
+		req.Verifier = "someone-elses-verifier-0123456789-0123456789"
+		_, err = provider.Exchange(ctx, code, req)
+		assert.Error(t, err, "A stolen code is useless without the verifier")
+	})
+
+	t.Run("WrongNonce", func(t *testing.T) {
+		req := newRequest()
+		code, _, err := idp.Authorize(provider.AuthCodeURL(req))
+		require.NoError(t, err)
+
+		req.Nonce = "replayed"
+		_, err = provider.Exchange(ctx, code, req)
+		assert.Error(t, err, "An ID token minted for another login should be rejected")
+	})
+
+	t.Run("CodeReuse", func(t *testing.T) {
+		req := newRequest()
+		code, _, err := idp.Authorize(provider.AuthCodeURL(req))
+		require.NoError(t, err)
+
+		_, err = provider.Exchange(ctx, code, req)
+		require.NoError(t, err)
+		_, err = provider.Exchange(ctx, code, req)
+		assert.Error(t, err, "Codes should only be redeemable once")
+	})
+}
@@ -0,0 +1,128 @@
+package infrastructure
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"task_manager/domain"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Per-group rate limits, shared by the REST routes and the gRPC methods so
+// both draw on one budget. Credential endpoints get the tightest budget
+// because they are the ones worth hammering.
+var (
+	CredentialsLimit = domain.RateLimitPolicy{Name: "credentials", Limit: 5, Window: time.Minute}
+	PublicLimit      = domain.RateLimitPolicy{Name: "public", Limit: 30, Window: time.Minute}
+	AuthLimit        = domain.RateLimitPolicy{Name: "auth", Limit: 120, Window: time.Minute}
+	AdminLimit       = domain.RateLimitPolicy{Name: "admin", Limit: 60, Window: time.Minute}
+)
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+	window time.Duration
+}
+
+// MemoryRateLimitStore keeps buckets in process memory.
+type MemoryRateLimitStore struct {
+	mu      sync.Mutex
+	buckets map[string]*bucket
+	now     func() time.Time
+	takes   int
+}
+
+func NewMemoryRateLimitStore() *MemoryRateLimitStore {
+	return &MemoryRateLimitStore{buckets: map[string]*bucket{}, now: time.Now}
+}
+
+// sweepEvery controls how often idle, fully refilled buckets are dropped.
+const sweepEvery = 1000
+
+func (s *MemoryRateLimitStore) Take(ctx context.Context, key string, policy domain.RateLimitPolicy) (domain.RateLimitResult, error) {
+	if policy.Limit <= 0 || policy.Window <= 0 {
+		return domain.RateLimitResult{}, fmt.Errorf("invalid rate limit policy %q", policy.Name)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	s.takes++
+	if s.takes%sweepEvery == 0 {
+		s.sweep(now)
+	}
+
+	limit := float64(policy.Limit)
+	perToken := policy.Window / time.Duration(policy.Limit)
+
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &bucket{tokens: limit, last: now, window: policy.Window}
+		s.buckets[key] = b
+	} else {
+		elapsed := now.Sub(b.last)
+		b.tokens = math.Min(limit, b.tokens+elapsed.Seconds()/perToken.Seconds())
+		b.last = now
+	}
+
+	result := domain.RateLimitResult{Limit: policy.Limit}
+	if b.tokens >= 1 {
+		b.tokens--
+		result.Allowed = true
+	} else {
+		result.RetryAfter = time.Duration((1 - b.tokens) * float64(perToken))
+	}
+	result.Remaining = int(b.tokens)
+	result.Reset = time.Duration((limit - b.tokens) * float64(perToken))
+	return result, nil
+}
+
+func (s *MemoryRateLimitStore) sweep(now time.Time) {
+	for key, b := range s.buckets {
+		if now.Sub(b.last) >= b.window {
+			delete(s.buckets, key)
+		}
+	}
+}
+
+// RateLimitMiddleware applies policy per caller. Authenticated requests are keyed
+// by userID, so it must run after AuthMiddleware on protected groups; everything
+// else is keyed by client IP.
+func RateLimitMiddleware(store domain.RateLimitStore, policy domain.RateLimitPolicy) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := policy.Name + ":ip:" + c.ClientIP()
+		if p, ok := domain.PrincipalFrom(c.Request.Context()); ok {
+			key = policy.Name + ":user:" + p.UserID
+		}
+
+		result, err := store.Take(c.Request.Context(), key, policy)
+		if err != nil {
+			// A broken limiter should not take the whole API down with it.
+			domain.Logger(c.Request.Context()).Error("rate limiter", "policy", policy.Name, "error", err)
+			c.Next()
+			return
+		}
+
+		c.Header("RateLimit-Policy", fmt.Sprintf("%d;w=%d", policy.Limit, int(policy.Window.Seconds())))
+		c.Header("RateLimit-Limit", strconv.Itoa(result.Limit))
+		c.Header("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+		c.Header("RateLimit-Reset", strconv.Itoa(ceilSeconds(result.Reset)))
+
+		if !result.Allowed {
+			c.Header("Retry-After", strconv.Itoa(ceilSeconds(result.RetryAfter)))
+			c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{"error": "Too many requests"})
+			return
+		}
+		c.Next()
+	}
+}
+
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
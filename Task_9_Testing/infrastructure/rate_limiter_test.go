@@ -0,0 +1,146 @@
+package infrastructure
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"task_manager/domain"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time { return c.now }
+
+func (c *fakeClock) Advance(d time.Duration) { c.now = c.now.Add(d) }
+
+func newTestStore() (*MemoryRateLimitStore, *fakeClock) {
+	clock := &fakeClock{now: time.Date(2025, 4, 3, 12, 0, 0, 0, time.UTC)}
+	store := NewMemoryRateLimitStore()
+	store.now = clock.Now
+	return store, clock
+}
+
+func TestMemoryRateLimitStore(t *testing.T) {
+	policy := domain.RateLimitPolicy{Name: "test", Limit: 3, Window: 3 * time.Second}
+	ctx := context.Background()
+
+	t.Run("ConsumesBurst", func(t *testing.T) {
+		store, _ := newTestStore()
+		for i := 2; i >= 0; i-- {
+			result, err := store.Take(ctx, "k", policy)
+			assert.NoError(t, err)
+			assert.True(t, result.Allowed, "Requests within the limit should pass")
+			assert.Equal(t, i, result.Remaining)
+		}
+
+		result, err := store.Take(ctx, "k", policy)
+		assert.NoError(t, err)
+		assert.False(t, result.Allowed, "Request over the limit should be rejected")
+		assert.Equal(t, time.Second, result.RetryAfter)
+		assert.Equal(t, 3*time.Second, result.Reset)
+	})
+
+	t.Run("Refills", func(t *testing.T) {
+		store, clock := newTestStore()
+		for i := 0; i < 3; i++ {
+			store.Take(ctx, "k", policy)
+		}
+		clock.Advance(time.Second)
+
+		result, _ := store.Take(ctx, "k", policy)
+		assert.True(t, result.Allowed, "One token should have refilled after a second")
+		result, _ = store.Take(ctx, "k", policy)
+		assert.False(t, result.Allowed, "Only one token should have refilled")
+	})
+
+	t.Run("SeparateKeys", func(t *testing.T) {
+		store, _ := newTestStore()
+		for i := 0; i < 3; i++ {
+			store.Take(ctx, "a", policy)
+		}
+		result, _ := store.Take(ctx, "b", policy)
+		assert.True(t, result.Allowed, "Buckets should not be shared between keys")
+	})
+
+	t.Run("InvalidPolicy", func(t *testing.T) {
+		store, _ := newTestStore()
+		_, err := store.Take(ctx, "k", domain.RateLimitPolicy{Name: "broken"})
+		assert.Error(t, err)
+	})
+}
+
+type failingStore struct{}
+
+func (failingStore) Take(ctx context.Context, key string, policy domain.RateLimitPolicy) (domain.RateLimitResult, error) {
+	return domain.RateLimitResult{}, errors.New("store down")
+}
+
+func TestRateLimitMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	policy := domain.RateLimitPolicy{Name: "test", Limit: 2, Window: time.Minute}
+
+	newRouter := func(store domain.RateLimitStore, userID string) *gin.Engine {
+		router := gin.New()
+		router.GET("/", func(c *gin.Context) {
+			if userID != "" {
+				setPrincipal(c, &domain.Principal{UserID: userID})
+			}
+		}, RateLimitMiddleware(store, policy), func(c *gin.Context) {
+			c.String(http.StatusOK, "OK")
+		})
+		return router
+	}
+
+	do := func(router *gin.Engine, ip string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("GET", "/", nil)
+		req.RemoteAddr = ip + ":1234"
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("HeadersAndRejection", func(t *testing.T) {
+		store, _ := newTestStore()
+		router := newRouter(store, "")
+
+		w := do(router, "10.0.0.1")
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "2", w.Header().Get("RateLimit-Limit"))
+		assert.Equal(t, "1", w.Header().Get("RateLimit-Remaining"))
+		assert.Equal(t, "30", w.Header().Get("RateLimit-Reset"))
+		assert.Equal(t, "2;w=60", w.Header().Get("RateLimit-Policy"))
+
+		do(router, "10.0.0.1")
+		w = do(router, "10.0.0.1")
+		assert.Equal(t, http.StatusTooManyRequests, w.Code)
+		assert.Equal(t, "30", w.Header().Get("Retry-After"))
+
+		w = do(router, "10.0.0.2")
+		assert.Equal(t, http.StatusOK, w.Code, "Another IP should have its own bucket")
+	})
+
+	t.Run("KeyedByUser", func(t *testing.T) {
+		store, _ := newTestStore()
+		router := newRouter(store, "user-1")
+
+		do(router, "10.0.0.1")
+		do(router, "10.0.0.2")
+		w := do(router, "10.0.0.3")
+		assert.Equal(t, http.StatusTooManyRequests, w.Code, "The same user should share a bucket across IPs")
+	})
+
+	t.Run("FailsOpen", func(t *testing.T) {
+		router := newRouter(failingStore{}, "")
+
+		w := do(router, "10.0.0.1")
+		assert.Equal(t, http.StatusOK, w.Code, "A failing store should not block requests")
+	})
+}
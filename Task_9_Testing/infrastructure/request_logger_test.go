@@ -0,0 +1,82 @@
+package infrastructure
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"task_manager/domain"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequestLogger(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	run := func(requestID string, handlers ...gin.HandlerFunc) (*httptest.ResponseRecorder, []map[string]interface{}) {
+		var buf bytes.Buffer
+		router := gin.New()
+		router.Use(RequestLogger(slog.New(slog.NewJSONHandler(&buf, nil))))
+		router.GET("/tasks/:id", handlers...)
+		req, _ := http.NewRequest("GET", "/tasks/42", nil)
+		if requestID != "" {
+			req.Header.Set(domain.RequestIDHeader, requestID)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		var lines []map[string]interface{}
+		for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+			var entry map[string]interface{}
+			require.NoError(t, json.Unmarshal([]byte(line), &entry))
+			lines = append(lines, entry)
+		}
+		return w, lines
+	}
+	ok := func(c *gin.Context) { c.Status(http.StatusOK) }
+
+	t.Run("AssignsID", func(t *testing.T) {
+		w, lines := run("", ok)
+		id := w.Header().Get(domain.RequestIDHeader)
+		assert.Len(t, id, 32)
+		require.Len(t, lines, 1)
+		entry := lines[0]
+		assert.Equal(t, "request", entry["msg"])
+		assert.Equal(t, id, entry["request_id"])
+		assert.Equal(t, "GET", entry["method"])
+		assert.Equal(t, "/tasks/:id", entry["route"], "Routes keep log cardinality low")
+		assert.Equal(t, float64(http.StatusOK), entry["status"])
+		assert.Contains(t, entry, "latency")
+	})
+
+	t.Run("PropagatesID", func(t *testing.T) {
+		w, lines := run("upstream-123", ok)
+		assert.Equal(t, "upstream-123", w.Header().Get(domain.RequestIDHeader))
+		assert.Equal(t, "upstream-123", lines[0]["request_id"])
+	})
+
+	t.Run("ReplacesInvalidID", func(t *testing.T) {
+		w, _ := run(strings.Repeat("x", maxRequestIDLength+1), ok)
+		assert.Len(t, w.Header().Get(domain.RequestIDHeader), 32)
+	})
+
+	t.Run("ContextLogger", func(t *testing.T) {
+		p := &domain.Principal{UserID: "7"}
+		w, lines := run("req-1", func(c *gin.Context) { setPrincipal(c, p) }, func(c *gin.Context) {
+			domain.Logger(c.Request.Context()).Info("inside handler")
+			c.Status(http.StatusNotFound)
+		})
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		require.Len(t, lines, 2)
+		assert.Equal(t, "inside handler", lines[0]["msg"])
+		assert.Equal(t, "req-1", lines[0]["request_id"], "Deeper layers should log with the request id")
+		assert.Equal(t, "7", lines[0]["user_id"])
+		assert.Equal(t, "WARN", lines[1]["level"])
+		assert.Equal(t, "7", lines[1]["user_id"])
+	})
+}
@@ -0,0 +1,54 @@
+package infrastructure
+
+import (
+	"bytes"
+	"image/png"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/pquerna/otp/totp"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTOTPService(t *testing.T) {
+	svc := NewTOTPService("Task Manager")
+
+	secret, uri, err := svc.GenerateSecret("testuser")
+	assert.NoError(t, err, "GenerateSecret should not return an error")
+	assert.NotEmpty(t, secret)
+
+	parsed, err := url.Parse(uri)
+	assert.NoError(t, err)
+	assert.Equal(t, "otpauth", parsed.Scheme, "URI should use the otpauth scheme")
+	assert.Equal(t, "totp", parsed.Host)
+	assert.Equal(t, secret, parsed.Query().Get("secret"))
+	assert.Equal(t, "Task Manager", parsed.Query().Get("issuer"))
+
+	t.Run("QRCode", func(t *testing.T) {
+		data, err := svc.QRCode(uri)
+		assert.NoError(t, err, "QRCode should not return an error")
+		img, err := png.Decode(bytes.NewReader(data))
+		assert.NoError(t, err, "QRCode should be a PNG")
+		assert.Equal(t, 256, img.Bounds().Dx())
+	})
+
+	t.Run("MatchStep", func(t *testing.T) {
+		at := time.Date(2025, 4, 3, 12, 0, 0, 0, time.UTC)
+		code, err := totp.GenerateCode(secret, at)
+		assert.NoError(t, err)
+
+		step, ok := svc.MatchStep(secret, code, at)
+		assert.True(t, ok, "Current code should match")
+		assert.Equal(t, at.Unix()/30, step)
+
+		_, ok = svc.MatchStep(secret, code, at.Add(30*time.Second))
+		assert.True(t, ok, "Code from the previous step should still match")
+
+		_, ok = svc.MatchStep(secret, code, at.Add(2*time.Minute))
+		assert.False(t, ok, "Old codes should not match")
+
+		_, ok = svc.MatchStep(secret, "000000x", at)
+		assert.False(t, ok)
+	})
+}
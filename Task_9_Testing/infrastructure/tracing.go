@@ -0,0 +1,77 @@
+package infrastructure
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+)
+
+// ServiceName identifies this service in traces unless OTEL_SERVICE_NAME is set.
+const ServiceName = "task-manager"
+
+// Span exporters accepted by NewSpanExporter.
+const (
+	TraceExporterNone   = "none"
+	TraceExporterOTLP   = "otlp"
+	TraceExporterStdout = "stdout"
+	TraceExporterMemory = "memory"
+)
+
+// NewSpanExporter builds the exporter named by kind. OTLP is configured through
+// the standard OTEL_EXPORTER_OTLP_* variables; the memory exporter keeps spans
+// for tests to inspect. "console" is accepted for stdout, as in the OTel spec.
+// "none" returns a nil exporter.
+func NewSpanExporter(ctx context.Context, kind string) (sdktrace.SpanExporter, error) {
+	switch kind {
+	case TraceExporterNone, "":
+		return nil, nil
+	case TraceExporterOTLP:
+		return otlptracehttp.New(ctx)
+	case TraceExporterStdout, "console":
+		return stdouttrace.New(stdouttrace.WithWriter(os.Stdout))
+	case TraceExporterMemory:
+		return tracetest.NewInMemoryExporter(), nil
+	default:
+		return nil, fmt.Errorf("unknown trace exporter %q", kind)
+	}
+}
+
+// SetupTracing installs a global tracer provider that sends spans to exporter,
+// and the W3C trace-context and baggage propagators. Without an exporter no
+// spans are recorded, but incoming trace context is still passed on. The
+// returned function flushes pending spans and should run on shutdown.
+func SetupTracing(serviceName string, exporter sdktrace.SpanExporter) func(context.Context) error {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+	if exporter == nil {
+		return func(context.Context) error { return nil }
+	}
+
+	// OTEL_SERVICE_NAME and OTEL_RESOURCE_ATTRIBUTES override serviceName.
+	res, err := resource.New(context.Background(),
+		resource.WithAttributes(semconv.ServiceName(serviceName)),
+		resource.WithFromEnv(),
+		resource.WithTelemetrySDK(),
+	)
+	if err != nil {
+		res = resource.Default()
+	}
+	opts := []sdktrace.TracerProviderOption{sdktrace.WithResource(res)}
+	if _, ok := exporter.(*tracetest.InMemoryExporter); ok {
+		// Tests read spans right after the request, so skip the batching delay.
+		opts = append(opts, sdktrace.WithSyncer(exporter))
+	} else {
+		opts = append(opts, sdktrace.WithBatcher(exporter))
+	}
+	provider := sdktrace.NewTracerProvider(opts...)
+	otel.SetTracerProvider(provider)
+	return provider.Shutdown
+}
@@ -0,0 +1,59 @@
+package infrastructure
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestTracing(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	exporter, err := NewSpanExporter(context.Background(), TraceExporterMemory)
+	require.NoError(t, err)
+	shutdown := SetupTracing(ServiceName, exporter)
+	defer shutdown(context.Background())
+	spans := exporter.(*tracetest.InMemoryExporter)
+
+	var logs bytes.Buffer
+	router := gin.New()
+	router.Use(otelgin.Middleware(ServiceName), RequestLogger(slog.New(slog.NewJSONHandler(&logs, nil))))
+	router.GET("/tasks/:id", func(c *gin.Context) {
+		_, span := otel.Tracer("test").Start(c.Request.Context(), "TaskUsecase.GetTaskByID")
+		span.End()
+		c.Status(http.StatusOK)
+	})
+
+	const traceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+	req, _ := http.NewRequest("GET", "/tasks/1", nil)
+	req.Header.Set("traceparent", "00-"+traceID+"-00f067aa0ba902b7-01")
+	router.ServeHTTP(httptest.NewRecorder(), req)
+
+	got := spans.GetSpans()
+	require.Len(t, got, 2)
+	child, server := got[0], got[1]
+	assert.Equal(t, "/tasks/:id", server.Name)
+	assert.Equal(t, trace.SpanKindServer, server.SpanKind)
+	assert.Equal(t, traceID, server.SpanContext.TraceID().String(), "The caller's trace should continue")
+	assert.Equal(t, server.SpanContext.SpanID(), child.Parent.SpanID(), "Usecase spans should nest under the request")
+	assert.Contains(t, logs.String(), `"trace_id":"`+traceID+`"`)
+}
+
+func TestNewSpanExporter(t *testing.T) {
+	exporter, err := NewSpanExporter(context.Background(), TraceExporterNone)
+	assert.NoError(t, err)
+	assert.Nil(t, exporter)
+
+	_, err = NewSpanExporter(context.Background(), "zipkin")
+	assert.Error(t, err)
+}
@@ -0,0 +1,130 @@
+// Package mocks holds testify mocks of the domain usecases that the tests of
+// several delivery packages and the client share.
+package mocks
+
+import (
+	"context"
+	"task_manager/domain"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type TaskUsecase struct {
+	mock.Mock
+}
+
+func (m *TaskUsecase) AddTask(ctx context.Context, task domain.Task) (string, error) {
+	args := m.Called(ctx, task)
+	return args.String(0), args.Error(1)
+}
+
+func (m *TaskUsecase) GetAllTasks(ctx context.Context) ([]domain.Task, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]domain.Task), args.Error(1)
+}
+
+func (m *TaskUsecase) FindTasks(ctx context.Context, filter domain.TaskFilter) ([]domain.Task, error) {
+	args := m.Called(ctx, filter)
+	return args.Get(0).([]domain.Task), args.Error(1)
+}
+
+func (m *TaskUsecase) GetTaskByID(ctx context.Context, id string) (*domain.Task, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(*domain.Task), args.Error(1)
+}
+
+func (m *TaskUsecase) UpdateTask(ctx context.Context, id string, task domain.Task) error {
+	return m.Called(ctx, id, task).Error(0)
+}
+
+func (m *TaskUsecase) PatchTask(ctx context.Context, id, mediaType string, patch []byte) (*domain.Task, error) {
+	args := m.Called(ctx, id, mediaType, patch)
+	return args.Get(0).(*domain.Task), args.Error(1)
+}
+
+func (m *TaskUsecase) DeleteTask(ctx context.Context, id string) error {
+	return m.Called(ctx, id).Error(0)
+}
+
+type UserUsecase struct {
+	mock.Mock
+}
+
+func (m *UserUsecase) Register(ctx context.Context, user domain.User) error {
+	return m.Called(ctx, user).Error(0)
+}
+
+func (m *UserUsecase) Login(ctx context.Context, username, password, ip string) (string, error) {
+	args := m.Called(ctx, username, password, ip)
+	return args.String(0), args.Error(1)
+}
+
+func (m *UserUsecase) UnlockLogin(ctx context.Context, username, ip string) error {
+	return m.Called(ctx, username, ip).Error(0)
+}
+
+func (m *UserUsecase) FindUsersByIDs(ctx context.Context, ids []string) ([]domain.User, error) {
+	args := m.Called(ctx, ids)
+	return args.Get(0).([]domain.User), args.Error(1)
+}
+
+func (m *UserUsecase) PromoteUser(ctx context.Context, username string) error {
+	return m.Called(ctx, username).Error(0)
+}
+
+type UserAdminUsecase struct {
+	mock.Mock
+}
+
+func (m *UserAdminUsecase) ListUsers(ctx context.Context, query domain.UserQuery) (*domain.UserPage, error) {
+	args := m.Called(ctx, query)
+	return args.Get(0).(*domain.UserPage), args.Error(1)
+}
+
+func (m *UserAdminUsecase) GetUser(ctx context.Context, id string) (*domain.User, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(*domain.User), args.Error(1)
+}
+
+func (m *UserAdminUsecase) SetRole(ctx context.Context, id, role string) error {
+	return m.Called(ctx, id, role).Error(0)
+}
+
+func (m *UserAdminUsecase) SetDisabled(ctx context.Context, id string, disabled bool) error {
+	return m.Called(ctx, id, disabled).Error(0)
+}
+
+func (m *UserAdminUsecase) DeleteUser(ctx context.Context, id, reassignTo string) error {
+	return m.Called(ctx, id, reassignTo).Error(0)
+}
+
+type MFAUsecase struct {
+	mock.Mock
+}
+
+func (m *MFAUsecase) EnrollTOTP(ctx context.Context) (*domain.TOTPEnrollment, error) {
+	args := m.Called(ctx)
+	return args.Get(0).(*domain.TOTPEnrollment), args.Error(1)
+}
+
+func (m *MFAUsecase) ConfirmTOTP(ctx context.Context, code string) error {
+	return m.Called(ctx, code).Error(0)
+}
+
+func (m *MFAUsecase) DisableTOTP(ctx context.Context, code string) error {
+	return m.Called(ctx, code).Error(0)
+}
+
+func (m *MFAUsecase) CompleteLogin(ctx context.Context, mfaToken, code, ip string) (string, error) {
+	args := m.Called(ctx, mfaToken, code, ip)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MFAUsecase) GetSecuritySettings(ctx context.Context) (*domain.SecuritySettings, error) {
+	args := m.Called(ctx)
+	return args.Get(0).(*domain.SecuritySettings), args.Error(1)
+}
+
+func (m *MFAUsecase) UpdateSecuritySettings(ctx context.Context, settings domain.SecuritySettings) error {
+	return m.Called(ctx, settings).Error(0)
+}
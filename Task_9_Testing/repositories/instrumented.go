@@ -0,0 +1,416 @@
+package repositories
+
+import (
+	"context"
+	"task_manager/domain"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+)
+
+// The instrumented repositories open a span for every call and report its
+// latency and errors to domain.Metrics, labelled with the method name. They
+// wrap the Mongo implementations in main, so usecases and tests stay unaware
+// of them. The spans of the Mongo commands themselves nest below.
+
+var tracer = otel.Tracer("task_manager/repositories")
+
+// startCall starts the span of one repository call, named like
+// "UserRepository.FindUserByID". The returned function ends it and records
+// the call.
+func startCall(ctx context.Context, metrics domain.Metrics, repository, iface, method string) (context.Context, func(error)) {
+	start := time.Now()
+	ctx, span := tracer.Start(ctx, iface+"."+method)
+	return ctx, func(err error) {
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+		metrics.ObserveRepositoryCall(repository, method, time.Since(start), err)
+	}
+}
+
+type instrumentedTaskRepository struct {
+	next    domain.TaskRepository
+	metrics domain.Metrics
+}
+
+func InstrumentTaskRepository(next domain.TaskRepository, metrics domain.Metrics) domain.TaskRepository {
+	return &instrumentedTaskRepository{next: next, metrics: metrics}
+}
+
+func (r *instrumentedTaskRepository) start(ctx context.Context, name string) (context.Context, func(error)) {
+	return startCall(ctx, r.metrics, "task", "TaskRepository", name)
+}
+
+func (r *instrumentedTaskRepository) AddTask(ctx context.Context, task domain.Task) (_ string, err error) {
+	ctx, end := r.start(ctx, "AddTask")
+	defer func() { end(err) }()
+	return r.next.AddTask(ctx, task)
+}
+
+func (r *instrumentedTaskRepository) GetAllTasks(ctx context.Context) (_ []domain.Task, err error) {
+	ctx, end := r.start(ctx, "GetAllTasks")
+	defer func() { end(err) }()
+	return r.next.GetAllTasks(ctx)
+}
+
+func (r *instrumentedTaskRepository) FindTasks(ctx context.Context, filter domain.TaskFilter) (_ []domain.Task, err error) {
+	ctx, end := r.start(ctx, "FindTasks")
+	defer func() { end(err) }()
+	return r.next.FindTasks(ctx, filter)
+}
+
+func (r *instrumentedTaskRepository) GetTaskByID(ctx context.Context, id string) (_ *domain.Task, err error) {
+	ctx, end := r.start(ctx, "GetTaskByID")
+	defer func() { end(err) }()
+	return r.next.GetTaskByID(ctx, id)
+}
+
+func (r *instrumentedTaskRepository) UpdateTask(ctx context.Context, id string, task domain.Task) (err error) {
+	ctx, end := r.start(ctx, "UpdateTask")
+	defer func() { end(err) }()
+	return r.next.UpdateTask(ctx, id, task)
+}
+
+func (r *instrumentedTaskRepository) PatchTask(ctx context.Context, id string, version int, fields map[string]interface{}) (err error) {
+	ctx, end := r.start(ctx, "PatchTask")
+	defer func() { end(err) }()
+	return r.next.PatchTask(ctx, id, version, fields)
+}
+
+func (r *instrumentedTaskRepository) DeleteTask(ctx context.Context, id string) (err error) {
+	ctx, end := r.start(ctx, "DeleteTask")
+	defer func() { end(err) }()
+	return r.next.DeleteTask(ctx, id)
+}
+
+func (r *instrumentedTaskRepository) ReassignTasks(ctx context.Context, fromOwnerID, toOwnerID string) (_ int64, err error) {
+	ctx, end := r.start(ctx, "ReassignTasks")
+	defer func() { end(err) }()
+	return r.next.ReassignTasks(ctx, fromOwnerID, toOwnerID)
+}
+
+type instrumentedUserRepository struct {
+	next    domain.UserRepository
+	metrics domain.Metrics
+}
+
+func InstrumentUserRepository(next domain.UserRepository, metrics domain.Metrics) domain.UserRepository {
+	return &instrumentedUserRepository{next: next, metrics: metrics}
+}
+
+func (r *instrumentedUserRepository) start(ctx context.Context, name string) (context.Context, func(error)) {
+	return startCall(ctx, r.metrics, "user", "UserRepository", name)
+}
+
+func (r *instrumentedUserRepository) CreateUser(ctx context.Context, user domain.User) (err error) {
+	ctx, end := r.start(ctx, "CreateUser")
+	defer func() { end(err) }()
+	return r.next.CreateUser(ctx, user)
+}
+
+func (r *instrumentedUserRepository) FindUserByUsername(ctx context.Context, username string) (_ *domain.User, err error) {
+	ctx, end := r.start(ctx, "FindUserByUsername")
+	defer func() { end(err) }()
+	return r.next.FindUserByUsername(ctx, username)
+}
+
+func (r *instrumentedUserRepository) FindUserByID(ctx context.Context, id string) (_ *domain.User, err error) {
+	ctx, end := r.start(ctx, "FindUserByID")
+	defer func() { end(err) }()
+	return r.next.FindUserByID(ctx, id)
+}
+
+func (r *instrumentedUserRepository) FindUsersByIDs(ctx context.Context, ids []string) (_ []domain.User, err error) {
+	ctx, end := r.start(ctx, "FindUsersByIDs")
+	defer func() { end(err) }()
+	return r.next.FindUsersByIDs(ctx, ids)
+}
+
+func (r *instrumentedUserRepository) FindUserByEmail(ctx context.Context, email string) (_ *domain.User, err error) {
+	ctx, end := r.start(ctx, "FindUserByEmail")
+	defer func() { end(err) }()
+	return r.next.FindUserByEmail(ctx, email)
+}
+
+func (r *instrumentedUserRepository) UpdatePassword(ctx context.Context, id, hashed string, changedAt time.Time) (err error) {
+	ctx, end := r.start(ctx, "UpdatePassword")
+	defer func() { end(err) }()
+	return r.next.UpdatePassword(ctx, id, hashed, changedAt)
+}
+
+func (r *instrumentedUserRepository) MarkEmailVerified(ctx context.Context, id, email string) (_ bool, err error) {
+	ctx, end := r.start(ctx, "MarkEmailVerified")
+	defer func() { end(err) }()
+	return r.next.MarkEmailVerified(ctx, id, email)
+}
+
+func (r *instrumentedUserRepository) FindUserByOIDCSubject(ctx context.Context, issuer, subject string) (_ *domain.User, err error) {
+	ctx, end := r.start(ctx, "FindUserByOIDCSubject")
+	defer func() { end(err) }()
+	return r.next.FindUserByOIDCSubject(ctx, issuer, subject)
+}
+
+func (r *instrumentedUserRepository) LinkOIDCIdentity(ctx context.Context, id, issuer, subject string) (err error) {
+	ctx, end := r.start(ctx, "LinkOIDCIdentity")
+	defer func() { end(err) }()
+	return r.next.LinkOIDCIdentity(ctx, id, issuer, subject)
+}
+
+func (r *instrumentedUserRepository) UpdateProfile(ctx context.Context, id string, fields map[string]interface{}) (err error) {
+	ctx, end := r.start(ctx, "UpdateProfile")
+	defer func() { end(err) }()
+	return r.next.UpdateProfile(ctx, id, fields)
+}
+
+func (r *instrumentedUserRepository) RecordLogin(ctx context.Context, id string, at time.Time) (err error) {
+	ctx, end := r.start(ctx, "RecordLogin")
+	defer func() { end(err) }()
+	return r.next.RecordLogin(ctx, id, at)
+}
+
+func (r *instrumentedUserRepository) SetTOTPSecret(ctx context.Context, id, secret string, recoveryCodes []string) (err error) {
+	ctx, end := r.start(ctx, "SetTOTPSecret")
+	defer func() { end(err) }()
+	return r.next.SetTOTPSecret(ctx, id, secret, recoveryCodes)
+}
+
+func (r *instrumentedUserRepository) EnableTOTP(ctx context.Context, id string) (err error) {
+	ctx, end := r.start(ctx, "EnableTOTP")
+	defer func() { end(err) }()
+	return r.next.EnableTOTP(ctx, id)
+}
+
+func (r *instrumentedUserRepository) DisableTOTP(ctx context.Context, id string) (err error) {
+	ctx, end := r.start(ctx, "DisableTOTP")
+	defer func() { end(err) }()
+	return r.next.DisableTOTP(ctx, id)
+}
+
+func (r *instrumentedUserRepository) AdvanceTOTPStep(ctx context.Context, id string, step int64) (_ bool, err error) {
+	ctx, end := r.start(ctx, "AdvanceTOTPStep")
+	defer func() { end(err) }()
+	return r.next.AdvanceTOTPStep(ctx, id, step)
+}
+
+func (r *instrumentedUserRepository) ConsumeRecoveryCode(ctx context.Context, id, codeHash string) (_ bool, err error) {
+	ctx, end := r.start(ctx, "ConsumeRecoveryCode")
+	defer func() { end(err) }()
+	return r.next.ConsumeRecoveryCode(ctx, id, codeHash)
+}
+
+func (r *instrumentedUserRepository) PromoteUser(ctx context.Context, username string) (err error) {
+	ctx, end := r.start(ctx, "PromoteUser")
+	defer func() { end(err) }()
+	return r.next.PromoteUser(ctx, username)
+}
+
+func (r *instrumentedUserRepository) SetRole(ctx context.Context, id, role string) (err error) {
+	ctx, end := r.start(ctx, "SetRole")
+	defer func() { end(err) }()
+	return r.next.SetRole(ctx, id, role)
+}
+
+func (r *instrumentedUserRepository) SetDisabled(ctx context.Context, id string, disabled bool) (err error) {
+	ctx, end := r.start(ctx, "SetDisabled")
+	defer func() { end(err) }()
+	return r.next.SetDisabled(ctx, id, disabled)
+}
+
+func (r *instrumentedUserRepository) DeleteUser(ctx context.Context, id string) (err error) {
+	ctx, end := r.start(ctx, "DeleteUser")
+	defer func() { end(err) }()
+	return r.next.DeleteUser(ctx, id)
+}
+
+func (r *instrumentedUserRepository) IsFirstUser(ctx context.Context) (_ bool, err error) {
+	ctx, end := r.start(ctx, "IsFirstUser")
+	defer func() { end(err) }()
+	return r.next.IsFirstUser(ctx)
+}
+
+func (r *instrumentedUserRepository) GetAllUsers(ctx context.Context) (_ []*domain.User, err error) {
+	ctx, end := r.start(ctx, "GetAllUsers")
+	defer func() { end(err) }()
+	return r.next.GetAllUsers(ctx)
+}
+
+func (r *instrumentedUserRepository) ListUsers(ctx context.Context, query domain.UserQuery) (_ []domain.User, _ int64, err error) {
+	ctx, end := r.start(ctx, "ListUsers")
+	defer func() { end(err) }()
+	return r.next.ListUsers(ctx, query)
+}
+
+func (r *instrumentedUserRepository) GetLoginAttempts(ctx context.Context, key string) (_ *domain.LoginAttempts, err error) {
+	ctx, end := r.start(ctx, "GetLoginAttempts")
+	defer func() { end(err) }()
+	return r.next.GetLoginAttempts(ctx, key)
+}
+
+func (r *instrumentedUserRepository) RecordFailedLogin(ctx context.Context, key string, at time.Time) (_ *domain.LoginAttempts, err error) {
+	ctx, end := r.start(ctx, "RecordFailedLogin")
+	defer func() { end(err) }()
+	return r.next.RecordFailedLogin(ctx, key, at)
+}
+
+func (r *instrumentedUserRepository) LockLogin(ctx context.Context, key string, until time.Time) (err error) {
+	ctx, end := r.start(ctx, "LockLogin")
+	defer func() { end(err) }()
+	return r.next.LockLogin(ctx, key, until)
+}
+
+func (r *instrumentedUserRepository) ResetLoginAttempts(ctx context.Context, key string) (err error) {
+	ctx, end := r.start(ctx, "ResetLoginAttempts")
+	defer func() { end(err) }()
+	return r.next.ResetLoginAttempts(ctx, key)
+}
+
+func (r *instrumentedUserRepository) RecordLockEvent(ctx context.Context, event domain.LockEvent) (err error) {
+	ctx, end := r.start(ctx, "RecordLockEvent")
+	defer func() { end(err) }()
+	return r.next.RecordLockEvent(ctx, event)
+}
+
+func (r *instrumentedUserRepository) CreatePasswordReset(ctx context.Context, reset domain.PasswordReset) (err error) {
+	ctx, end := r.start(ctx, "CreatePasswordReset")
+	defer func() { end(err) }()
+	return r.next.CreatePasswordReset(ctx, reset)
+}
+
+func (r *instrumentedUserRepository) FindPasswordReset(ctx context.Context, tokenHash string) (_ *domain.PasswordReset, err error) {
+	ctx, end := r.start(ctx, "FindPasswordReset")
+	defer func() { end(err) }()
+	return r.next.FindPasswordReset(ctx, tokenHash)
+}
+
+func (r *instrumentedUserRepository) ConsumePasswordReset(ctx context.Context, tokenHash string, now time.Time) (_ *domain.PasswordReset, err error) {
+	ctx, end := r.start(ctx, "ConsumePasswordReset")
+	defer func() { end(err) }()
+	return r.next.ConsumePasswordReset(ctx, tokenHash, now)
+}
+
+func (r *instrumentedUserRepository) DeletePasswordResets(ctx context.Context, userID string) (err error) {
+	ctx, end := r.start(ctx, "DeletePasswordResets")
+	defer func() { end(err) }()
+	return r.next.DeletePasswordResets(ctx, userID)
+}
+
+func (r *instrumentedUserRepository) CreateMFAChallenge(ctx context.Context, challenge domain.MFAChallenge) (err error) {
+	ctx, end := r.start(ctx, "CreateMFAChallenge")
+	defer func() { end(err) }()
+	return r.next.CreateMFAChallenge(ctx, challenge)
+}
+
+func (r *instrumentedUserRepository) FindMFAChallenge(ctx context.Context, tokenHash string) (_ *domain.MFAChallenge, err error) {
+	ctx, end := r.start(ctx, "FindMFAChallenge")
+	defer func() { end(err) }()
+	return r.next.FindMFAChallenge(ctx, tokenHash)
+}
+
+func (r *instrumentedUserRepository) ConsumeMFAChallenge(ctx context.Context, tokenHash string, now time.Time) (_ *domain.MFAChallenge, err error) {
+	ctx, end := r.start(ctx, "ConsumeMFAChallenge")
+	defer func() { end(err) }()
+	return r.next.ConsumeMFAChallenge(ctx, tokenHash, now)
+}
+
+type instrumentedSettingsRepository struct {
+	next    domain.SettingsRepository
+	metrics domain.Metrics
+}
+
+func InstrumentSettingsRepository(next domain.SettingsRepository, metrics domain.Metrics) domain.SettingsRepository {
+	return &instrumentedSettingsRepository{next: next, metrics: metrics}
+}
+
+func (r *instrumentedSettingsRepository) start(ctx context.Context, name string) (context.Context, func(error)) {
+	return startCall(ctx, r.metrics, "settings", "SettingsRepository", name)
+}
+
+func (r *instrumentedSettingsRepository) GetSecuritySettings(ctx context.Context) (_ *domain.SecuritySettings, err error) {
+	ctx, end := r.start(ctx, "GetSecuritySettings")
+	defer func() { end(err) }()
+	return r.next.GetSecuritySettings(ctx)
+}
+
+func (r *instrumentedSettingsRepository) SaveSecuritySettings(ctx context.Context, settings domain.SecuritySettings) (err error) {
+	ctx, end := r.start(ctx, "SaveSecuritySettings")
+	defer func() { end(err) }()
+	return r.next.SaveSecuritySettings(ctx, settings)
+}
+
+type instrumentedAPIKeyRepository struct {
+	next    domain.APIKeyRepository
+	metrics domain.Metrics
+}
+
+func InstrumentAPIKeyRepository(next domain.APIKeyRepository, metrics domain.Metrics) domain.APIKeyRepository {
+	return &instrumentedAPIKeyRepository{next: next, metrics: metrics}
+}
+
+func (r *instrumentedAPIKeyRepository) start(ctx context.Context, name string) (context.Context, func(error)) {
+	return startCall(ctx, r.metrics, "api_key", "APIKeyRepository", name)
+}
+
+func (r *instrumentedAPIKeyRepository) CreateAPIKey(ctx context.Context, key domain.APIKey) (err error) {
+	ctx, end := r.start(ctx, "CreateAPIKey")
+	defer func() { end(err) }()
+	return r.next.CreateAPIKey(ctx, key)
+}
+
+func (r *instrumentedAPIKeyRepository) FindAPIKeyByHash(ctx context.Context, keyHash string) (_ *domain.APIKey, err error) {
+	ctx, end := r.start(ctx, "FindAPIKeyByHash")
+	defer func() { end(err) }()
+	return r.next.FindAPIKeyByHash(ctx, keyHash)
+}
+
+func (r *instrumentedAPIKeyRepository) ListAPIKeys(ctx context.Context, userID string) (_ []domain.APIKey, err error) {
+	ctx, end := r.start(ctx, "ListAPIKeys")
+	defer func() { end(err) }()
+	return r.next.ListAPIKeys(ctx, userID)
+}
+
+func (r *instrumentedAPIKeyRepository) DeleteAPIKey(ctx context.Context, userID, id string) (_ bool, err error) {
+	ctx, end := r.start(ctx, "DeleteAPIKey")
+	defer func() { end(err) }()
+	return r.next.DeleteAPIKey(ctx, userID, id)
+}
+
+func (r *instrumentedAPIKeyRepository) TouchAPIKey(ctx context.Context, id string, at time.Time) (err error) {
+	ctx, end := r.start(ctx, "TouchAPIKey")
+	defer func() { end(err) }()
+	return r.next.TouchAPIKey(ctx, id, at)
+}
+
+type instrumentedSessionRepository struct {
+	next    domain.SessionRepository
+	metrics domain.Metrics
+}
+
+func InstrumentSessionRepository(next domain.SessionRepository, metrics domain.Metrics) domain.SessionRepository {
+	return &instrumentedSessionRepository{next: next, metrics: metrics}
+}
+
+func (r *instrumentedSessionRepository) start(ctx context.Context, name string) (context.Context, func(error)) {
+	return startCall(ctx, r.metrics, "session", "SessionRepository", name)
+}
+
+func (r *instrumentedSessionRepository) CreateSession(ctx context.Context, session domain.Session) (err error) {
+	ctx, end := r.start(ctx, "CreateSession")
+	defer func() { end(err) }()
+	return r.next.CreateSession(ctx, session)
+}
+
+func (r *instrumentedSessionRepository) FindSession(ctx context.Context, id string) (_ *domain.Session, err error) {
+	ctx, end := r.start(ctx, "FindSession")
+	defer func() { end(err) }()
+	return r.next.FindSession(ctx, id)
+}
+
+func (r *instrumentedSessionRepository) DeleteSession(ctx context.Context, id string) (err error) {
+	ctx, end := r.start(ctx, "DeleteSession")
+	defer func() { end(err) }()
+	return r.next.DeleteSession(ctx, id)
+}
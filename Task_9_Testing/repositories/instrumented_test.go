@@ -0,0 +1,72 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"task_manager/domain"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type repositoryCall struct {
+	repository, method string
+	err                error
+}
+
+type recordingMetrics struct {
+	domain.Metrics
+	calls []repositoryCall
+}
+
+func (m *recordingMetrics) ObserveRepositoryCall(repository, method string, duration time.Duration, err error) {
+	m.calls = append(m.calls, repositoryCall{repository, method, err})
+}
+
+type stubSettingsRepository struct {
+	domain.SettingsRepository
+}
+
+func (stubSettingsRepository) GetSecuritySettings(ctx context.Context) (*domain.SecuritySettings, error) {
+	return &domain.SecuritySettings{RequireAdminMFA: true}, nil
+}
+
+func TestInstrumentedRepositories(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("PassesThrough", func(t *testing.T) {
+		metrics := &recordingMetrics{}
+		mockRepo := &MockTaskRepository{}
+		mockRepo.On("AddTask", mock.Anything, domain.Task{Title: "Write docs"}).Return("1", nil).Once()
+
+		id, err := InstrumentTaskRepository(mockRepo, metrics).AddTask(ctx, domain.Task{Title: "Write docs"})
+		assert.NoError(t, err)
+		assert.Equal(t, "1", id)
+		assert.Equal(t, []repositoryCall{{"task", "AddTask", nil}}, metrics.calls)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("RecordsErrors", func(t *testing.T) {
+		metrics := &recordingMetrics{}
+		dbErr := errors.New("db down")
+		mockRepo := &MockUserRepository{}
+		mockRepo.On("FindUserByUsername", mock.Anything, "alice").Return((*domain.User)(nil), dbErr).Once()
+
+		_, err := InstrumentUserRepository(mockRepo, metrics).FindUserByUsername(ctx, "alice")
+		assert.Equal(t, dbErr, err)
+		assert.Equal(t, []repositoryCall{{"user", "FindUserByUsername", dbErr}}, metrics.calls)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("Settings", func(t *testing.T) {
+		metrics := &recordingMetrics{}
+		settings := InstrumentSettingsRepository(stubSettingsRepository{}, metrics)
+
+		got, err := settings.GetSecuritySettings(ctx)
+		assert.NoError(t, err)
+		assert.True(t, got.RequireAdminMFA)
+		assert.Equal(t, []repositoryCall{{"settings", "GetSecuritySettings", nil}}, metrics.calls)
+	})
+}
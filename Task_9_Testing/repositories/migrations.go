@@ -0,0 +1,287 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"task_manager/domain"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Migration changes the schema or the data to what the current code expects.
+// Up returns one line per change it made or, with dryRun, would make, and
+// writes nothing when dryRun is set. It must be safe to run again, since a
+// crash between running it and recording it runs it twice.
+type Migration struct {
+	Version     int
+	Description string
+	Up          func(ctx context.Context, db *mongo.Database, dryRun bool) ([]string, error)
+}
+
+// Migrations run in version order. Append new ones with the next version and
+// never change one that may have run somewhere.
+var Migrations = []Migration{
+	{
+		Version:     1,
+		Description: "map free-form task statuses from before input validation to pending, in_progress or done",
+		Up:          normalizeTaskStatus,
+	},
+	{
+		Version:     2,
+		Description: "give users without a known role the user role",
+		Up:          defaultUserRole,
+	},
+	{
+		Version:     3,
+		Description: "remove empty owner ids, so ownerless tasks all lack the field",
+		Up:          clearEmptyOwnerIDs,
+	},
+	{
+		Version:     4,
+		Description: "unique indexes on username, email and single sign-on identity",
+		Up:          userIndexes,
+	},
+	{
+		Version:     5,
+		Description: "indexes for task lookups by owner, status and due date",
+		Up:          taskIndexes,
+	},
+	{
+		Version:     6,
+		Description: "expire sessions, password resets and two-factor challenges, and index api key lookups",
+		Up:          expiryAndLookupIndexes,
+	},
+	{
+		Version:     7,
+		Description: "start the version of tasks written before it was recorded at 1",
+		Up:          backfillTaskVersion,
+	},
+	{
+		Version:     8,
+		Description: "expire login attempt counters and lock events",
+		Up:          loginThrottleExpiry,
+	},
+}
+
+// How long login throttling data is kept after it was last written. A day
+// without failures forgets the counter; the lockout itself only lasts
+// minutes. Lock events are kept longer for investigating attacks.
+const (
+	loginAttemptsTTL = 24 * time.Hour
+	lockEventsTTL    = 90 * 24 * time.Hour
+)
+
+var validTaskStatuses = bson.A{domain.TaskStatusPending, domain.TaskStatusInProgress, domain.TaskStatusDone}
+
+// normalizeTaskStatus recognises the spellings clients used before the
+// status was validated. Anything else, including a missing status, becomes
+// pending, which is what AddTask defaults to.
+func normalizeTaskStatus(ctx context.Context, db *mongo.Database, dryRun bool) ([]string, error) {
+	tasks := db.Collection("tasks")
+	synonyms := []struct {
+		pattern string
+		status  string
+	}{
+		{`^\s*(pending|todo|to[ _-]?do|open|not[ _-]?started)\s*$`, domain.TaskStatusPending},
+		{`^\s*(in[ _-]?progress|started|doing|ongoing)\s*$`, domain.TaskStatusInProgress},
+		{`^\s*(done|complete|completed|finished|closed)\s*$`, domain.TaskStatusDone},
+	}
+	var changes []string
+	for _, s := range synonyms {
+		filter := bson.M{"status": bson.M{
+			"$nin":   validTaskStatuses,
+			"$regex": primitive.Regex{Pattern: s.pattern, Options: "i"},
+		}}
+		change, err := updateMany(ctx, tasks, dryRun, filter, bson.M{"$set": bson.M{"status": s.status}}, "status set to "+s.status)
+		if err != nil {
+			return changes, err
+		}
+		changes = append(changes, change...)
+	}
+	filter := bson.M{"status": bson.M{"$nin": validTaskStatuses}}
+	change, err := updateMany(ctx, tasks, dryRun, filter, bson.M{"$set": bson.M{"status": domain.TaskStatusPending}}, "unknown status set to "+domain.TaskStatusPending)
+	return append(changes, change...), err
+}
+
+func defaultUserRole(ctx context.Context, db *mongo.Database, dryRun bool) ([]string, error) {
+	filter := bson.M{"role": bson.M{"$nin": bson.A{domain.RoleAdmin, domain.RoleUser}}}
+	return updateMany(ctx, db.Collection("users"), dryRun, filter, bson.M{"$set": bson.M{"role": domain.RoleUser}}, "role set to "+domain.RoleUser)
+}
+
+// clearEmptyOwnerIDs cannot tell who created a task from before ownership
+// was recorded; those tasks stay ownerless, as domain.Task documents.
+func clearEmptyOwnerIDs(ctx context.Context, db *mongo.Database, dryRun bool) ([]string, error) {
+	filter := bson.M{"owner_id": bson.M{"$in": bson.A{"", nil}, "$exists": true}}
+	return updateMany(ctx, db.Collection("tasks"), dryRun, filter, bson.M{"$unset": bson.M{"owner_id": ""}}, "empty owner_id removed")
+}
+
+// backfillTaskVersion also repairs versions that are not a positive
+// number, so the $inc of later writes always has a number to work on.
+func backfillTaskVersion(ctx context.Context, db *mongo.Database, dryRun bool) ([]string, error) {
+	filter := bson.M{"$or": bson.A{
+		bson.M{"version": bson.M{"$exists": false}},
+		bson.M{"version": bson.M{"$not": bson.M{"$type": "number"}}},
+		bson.M{"version": bson.M{"$lt": 1}},
+	}}
+	return updateMany(ctx, db.Collection("tasks"), dryRun, filter, bson.M{"$set": bson.M{"version": 1}}, "version set to 1")
+}
+
+// userIndexes makes the database enforce what Register and the OIDC login
+// only check before inserting, so concurrent requests cannot create two
+// users with the same username. Existing duplicates have to be resolved
+// first; the migration lists them and stops.
+func userIndexes(ctx context.Context, db *mongo.Database, dryRun bool) ([]string, error) {
+	users := db.Collection("users")
+	for _, field := range []string{"username", "email"} {
+		dups, err := duplicates(ctx, users, field)
+		if err != nil {
+			return nil, err
+		}
+		if len(dups) > 0 {
+			return nil, fmt.Errorf("users share a %s, rename or delete the duplicates first: %s", field, strings.Join(dups, ", "))
+		}
+	}
+	return ensureIndexes(ctx, users, dryRun, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "username", Value: 1}}, Options: options.Index().SetName("username_unique").SetUnique(true)},
+		{
+			Keys: bson.D{{Key: "email", Value: 1}},
+			Options: options.Index().SetName("email_unique").SetUnique(true).
+				SetPartialFilterExpression(bson.M{"email": bson.M{"$type": "string"}}),
+		},
+		{
+			Keys: bson.D{{Key: "oidc_issuer", Value: 1}, {Key: "oidc_subject", Value: 1}},
+			Options: options.Index().SetName("oidc_identity_unique").SetUnique(true).
+				SetPartialFilterExpression(bson.M{"oidc_subject": bson.M{"$type": "string"}}),
+		},
+	})
+}
+
+// taskIndexes serve FindTasks, which filters by owner or status and sorts
+// by due date, and the owner reassignment of DeleteUser.
+func taskIndexes(ctx context.Context, db *mongo.Database, dryRun bool) ([]string, error) {
+	return ensureIndexes(ctx, db.Collection("tasks"), dryRun, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "owner_id", Value: 1}, {Key: "due_date", Value: 1}}, Options: options.Index().SetName("owner_due")},
+		{Keys: bson.D{{Key: "status", Value: 1}, {Key: "due_date", Value: 1}}, Options: options.Index().SetName("status_due")},
+		{Keys: bson.D{{Key: "due_date", Value: 1}}, Options: options.Index().SetName("due")},
+	})
+}
+
+// expiryAndLookupIndexes lets MongoDB delete sessions, password resets and
+// two-factor challenges once expires_at has passed. Lookups already check
+// the expiry; this only keeps the collections from growing.
+func expiryAndLookupIndexes(ctx context.Context, db *mongo.Database, dryRun bool) ([]string, error) {
+	var changes []string
+	for _, name := range []string{"sessions", "password_resets", "mfa_challenges"} {
+		change, err := ensureIndexes(ctx, db.Collection(name), dryRun, []mongo.IndexModel{
+			{Keys: bson.D{{Key: "expires_at", Value: 1}}, Options: options.Index().SetName("expires_at_ttl").SetExpireAfterSeconds(0)},
+		})
+		if err != nil {
+			return changes, err
+		}
+		changes = append(changes, change...)
+	}
+	change, err := ensureIndexes(ctx, db.Collection("api_keys"), dryRun, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "key_hash", Value: 1}}, Options: options.Index().SetName("key_hash_unique").SetUnique(true)},
+		{Keys: bson.D{{Key: "user_id", Value: 1}}, Options: options.Index().SetName("user")},
+	})
+	return append(changes, change...), err
+}
+
+// loginThrottleExpiry bounds login_attempts and lock_events, which anonymous
+// callers fill with one document per username and address they try.
+func loginThrottleExpiry(ctx context.Context, db *mongo.Database, dryRun bool) ([]string, error) {
+	changes, err := ensureIndexes(ctx, db.Collection("login_attempts"), dryRun, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "last_failure", Value: 1}}, Options: options.Index().SetName("last_failure_ttl").SetExpireAfterSeconds(int32(loginAttemptsTTL.Seconds()))},
+	})
+	if err != nil {
+		return changes, err
+	}
+	change, err := ensureIndexes(ctx, db.Collection("lock_events"), dryRun, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "locked_at", Value: 1}}, Options: options.Index().SetName("locked_at_ttl").SetExpireAfterSeconds(int32(lockEventsTTL.Seconds()))},
+	})
+	return append(changes, change...), err
+}
+
+// updateMany counts instead of updating on a dry run. It reports nothing
+// when no document matches.
+func updateMany(ctx context.Context, coll *mongo.Collection, dryRun bool, filter, update bson.M, what string) ([]string, error) {
+	if dryRun {
+		n, err := coll.CountDocuments(ctx, filter)
+		if err != nil || n == 0 {
+			return nil, err
+		}
+		return []string{fmt.Sprintf("would update %d %s: %s", n, coll.Name(), what)}, nil
+	}
+	result, err := coll.UpdateMany(ctx, filter, update)
+	if err != nil || result.ModifiedCount == 0 {
+		return nil, err
+	}
+	return []string{fmt.Sprintf("updated %d %s: %s", result.ModifiedCount, coll.Name(), what)}, nil
+}
+
+// ensureIndexes creates the indexes whose name does not exist yet.
+func ensureIndexes(ctx context.Context, coll *mongo.Collection, dryRun bool, models []mongo.IndexModel) ([]string, error) {
+	existing := map[string]bool{}
+	cursor, err := coll.Indexes().List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var specs []struct {
+		Name string `bson:"name"`
+	}
+	if err := cursor.All(ctx, &specs); err != nil {
+		return nil, err
+	}
+	for _, spec := range specs {
+		existing[spec.Name] = true
+	}
+
+	var changes []string
+	for _, model := range models {
+		name := *model.Options.Name
+		if existing[name] {
+			continue
+		}
+		if dryRun {
+			changes = append(changes, fmt.Sprintf("would create index %s.%s", coll.Name(), name))
+			continue
+		}
+		if _, err := coll.Indexes().CreateOne(ctx, model); err != nil {
+			return changes, fmt.Errorf("creating index %s.%s: %w", coll.Name(), name, err)
+		}
+		changes = append(changes, fmt.Sprintf("created index %s.%s", coll.Name(), name))
+	}
+	return changes, nil
+}
+
+// duplicates returns up to ten values of field that more than one document
+// has.
+func duplicates(ctx context.Context, coll *mongo.Collection, field string) ([]string, error) {
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{field: bson.M{"$type": "string"}}}},
+		{{Key: "$group", Value: bson.M{"_id": "$" + field, "count": bson.M{"$sum": 1}}}},
+		{{Key: "$match", Value: bson.M{"count": bson.M{"$gt": 1}}}},
+		{{Key: "$sort", Value: bson.M{"_id": 1}}},
+		{{Key: "$limit", Value: 10}},
+	}
+	cursor, err := coll.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	var groups []struct {
+		Value string `bson:"_id"`
+	}
+	if err := cursor.All(ctx, &groups); err != nil {
+		return nil, err
+	}
+	values := make([]string, len(groups))
+	for i, g := range groups {
+		values[i] = g.Value
+	}
+	return values, nil
+}
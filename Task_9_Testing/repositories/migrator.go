@@ -0,0 +1,245 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"task_manager/domain"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// AppliedMigration is the record of a migration in the schema_migrations
+// collection.
+type AppliedMigration struct {
+	Version     int       `bson:"_id"`
+	Description string    `bson:"description"`
+	AppliedAt   time.Time `bson:"applied_at"`
+}
+
+// MigrationStatus tells whether a migration has run. Known is false for a
+// migration recorded by a newer version of the application.
+type MigrationStatus struct {
+	Version     int
+	Description string
+	AppliedAt   *time.Time
+	Known       bool
+}
+
+// MigrationResult lists what one migration changed, or would change on a
+// dry run.
+type MigrationResult struct {
+	Version     int
+	Description string
+	Changes     []string
+}
+
+// migrationLog records applied migrations and keeps two processes from
+// migrating at the same time.
+type migrationLog interface {
+	Applied(ctx context.Context) ([]AppliedMigration, error)
+	Record(ctx context.Context, applied AppliedMigration) error
+	// Lock takes or renews the lock until the given time. It reports false
+	// while another owner holds it.
+	Lock(ctx context.Context, owner string, now, until time.Time) (bool, error)
+	Unlock(ctx context.Context, owner string) error
+}
+
+// Migrator runs the Migrations that have not been applied to a database.
+type Migrator struct {
+	db         *mongo.Database
+	log        migrationLog
+	migrations []Migration
+	owner      string
+	lease      time.Duration
+	retry      time.Duration
+	now        func() time.Time
+}
+
+func NewMigrator(db *mongo.Database) *Migrator {
+	return newMigrator(db, newMongoMigrationLog(db), Migrations)
+}
+
+func newMigrator(db *mongo.Database, log migrationLog, migrations []Migration) *Migrator {
+	return &Migrator{
+		db:         db,
+		log:        log,
+		migrations: migrations,
+		owner:      primitive.NewObjectID().Hex(),
+		lease:      10 * time.Minute,
+		retry:      time.Second,
+		now:        time.Now,
+	}
+}
+
+// Status lists every migration with the time it was applied, followed by
+// applied migrations this version does not know.
+func (m *Migrator) Status(ctx context.Context) ([]MigrationStatus, error) {
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+	statuses := make([]MigrationStatus, 0, len(m.migrations))
+	for _, migration := range m.migrations {
+		status := MigrationStatus{Version: migration.Version, Description: migration.Description, Known: true}
+		if a, ok := applied[migration.Version]; ok {
+			status.AppliedAt = &a.AppliedAt
+			delete(applied, migration.Version)
+		}
+		statuses = append(statuses, status)
+	}
+	var unknown []MigrationStatus
+	for _, a := range applied {
+		at := a.AppliedAt
+		unknown = append(unknown, MigrationStatus{Version: a.Version, Description: a.Description, AppliedAt: &at})
+	}
+	sort.Slice(unknown, func(i, j int) bool { return unknown[i].Version < unknown[j].Version })
+	return append(statuses, unknown...), nil
+}
+
+// Up runs the pending migrations in order and records each one as it
+// succeeds, so a failed run continues with the failed migration. If
+// another process is migrating, Up waits for it until ctx is done.
+//
+// A dry run neither locks nor records anything. Each migration reports
+// against the current data, not the data the migrations before it would
+// leave behind.
+func (m *Migrator) Up(ctx context.Context, dryRun bool) ([]MigrationResult, error) {
+	if err := m.validate(); err != nil {
+		return nil, err
+	}
+	if !dryRun {
+		if err := m.lock(ctx); err != nil {
+			return nil, err
+		}
+		defer m.log.Unlock(context.WithoutCancel(ctx), m.owner)
+	}
+	// Read after locking, since whoever held the lock may have applied
+	// the same migrations.
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	results := []MigrationResult{}
+	for _, migration := range m.migrations {
+		if _, ok := applied[migration.Version]; ok {
+			continue
+		}
+		if !dryRun {
+			// Renew the lease so a long index build does not let
+			// another process start.
+			if err := m.lock(ctx); err != nil {
+				return results, err
+			}
+		}
+		changes, err := migration.Up(ctx, m.db, dryRun)
+		if err != nil {
+			return results, fmt.Errorf("migration %d (%s): %w", migration.Version, migration.Description, err)
+		}
+		results = append(results, MigrationResult{Version: migration.Version, Description: migration.Description, Changes: changes})
+		if dryRun {
+			continue
+		}
+		record := AppliedMigration{Version: migration.Version, Description: migration.Description, AppliedAt: m.now().UTC()}
+		if err := m.log.Record(ctx, record); err != nil {
+			return results, fmt.Errorf("recording migration %d: %w", migration.Version, err)
+		}
+		domain.Logger(ctx).Info("migration applied", "version", migration.Version, "changes", len(changes))
+	}
+	return results, nil
+}
+
+func (m *Migrator) validate() error {
+	for i, migration := range m.migrations {
+		if migration.Version < 1 || (i > 0 && migration.Version <= m.migrations[i-1].Version) {
+			return fmt.Errorf("migration versions must be positive and increasing, found %d", migration.Version)
+		}
+	}
+	return nil
+}
+
+func (m *Migrator) applied(ctx context.Context) (map[int]AppliedMigration, error) {
+	records, err := m.log.Applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+	applied := make(map[int]AppliedMigration, len(records))
+	for _, r := range records {
+		applied[r.Version] = r
+	}
+	return applied, nil
+}
+
+func (m *Migrator) lock(ctx context.Context) error {
+	for {
+		now := m.now()
+		ok, err := m.log.Lock(ctx, m.owner, now, now.Add(m.lease))
+		if err != nil {
+			return err
+		}
+		if ok {
+			return nil
+		}
+		domain.Logger(ctx).Info("waiting for another process to finish migrating")
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("waiting for the migration lock: %w", ctx.Err())
+		case <-time.After(m.retry):
+		}
+	}
+}
+
+type mongoMigrationLog struct {
+	applied *mongo.Collection
+	locks   *mongo.Collection
+}
+
+func newMongoMigrationLog(db *mongo.Database) *mongoMigrationLog {
+	return &mongoMigrationLog{
+		applied: db.Collection("schema_migrations"),
+		locks:   db.Collection("migration_lock"),
+	}
+}
+
+func (l *mongoMigrationLog) Applied(ctx context.Context) ([]AppliedMigration, error) {
+	cursor, err := l.applied.Find(ctx, bson.M{}, options.Find().SetSort(bson.M{"_id": 1}))
+	if err != nil {
+		return nil, err
+	}
+	var applied []AppliedMigration
+	if err := cursor.All(ctx, &applied); err != nil {
+		return nil, err
+	}
+	return applied, nil
+}
+
+func (l *mongoMigrationLog) Record(ctx context.Context, applied AppliedMigration) error {
+	_, err := l.applied.ReplaceOne(ctx, bson.M{"_id": applied.Version}, applied, options.Replace().SetUpsert(true))
+	return err
+}
+
+// Lock upserts the single lock document unless another owner holds an
+// unexpired lease, in which case the upsert collides with that document's
+// _id.
+func (l *mongoMigrationLog) Lock(ctx context.Context, owner string, now, until time.Time) (bool, error) {
+	filter := bson.M{"_id": "migrations", "$or": bson.A{
+		bson.M{"owner": owner},
+		bson.M{"expires_at": bson.M{"$lte": now}},
+	}}
+	update := bson.M{"$set": bson.M{"owner": owner, "expires_at": until}}
+	_, err := l.locks.UpdateOne(ctx, filter, update, options.Update().SetUpsert(true))
+	if mongo.IsDuplicateKeyError(err) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+func (l *mongoMigrationLog) Unlock(ctx context.Context, owner string) error {
+	_, err := l.locks.DeleteOne(ctx, bson.M{"_id": "migrations", "owner": owner})
+	return err
+}
@@ -0,0 +1,193 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"task_manager/domain"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// memoryMigrationLog is a migrationLog whose lock can be held by a fake
+// other process until its lease runs out.
+type memoryMigrationLog struct {
+	applied   []AppliedMigration
+	lockOwner string
+	lockUntil time.Time
+	locks     int
+	unlocked  bool
+}
+
+func (l *memoryMigrationLog) Applied(ctx context.Context) ([]AppliedMigration, error) {
+	return l.applied, nil
+}
+
+func (l *memoryMigrationLog) Record(ctx context.Context, applied AppliedMigration) error {
+	l.applied = append(l.applied, applied)
+	return nil
+}
+
+func (l *memoryMigrationLog) Lock(ctx context.Context, owner string, now, until time.Time) (bool, error) {
+	l.locks++
+	if l.lockOwner != "" && l.lockOwner != owner && now.Before(l.lockUntil) {
+		return false, nil
+	}
+	l.lockOwner, l.lockUntil = owner, until
+	return true, nil
+}
+
+func (l *memoryMigrationLog) Unlock(ctx context.Context, owner string) error {
+	if l.lockOwner == owner {
+		l.lockOwner, l.unlocked = "", true
+	}
+	return nil
+}
+
+func recordingMigration(version int, ran *[]int, err error) Migration {
+	return Migration{
+		Version:     version,
+		Description: "test migration",
+		Up: func(ctx context.Context, db *mongo.Database, dryRun bool) ([]string, error) {
+			if err != nil {
+				return nil, err
+			}
+			if dryRun {
+				return []string{"would change"}, nil
+			}
+			*ran = append(*ran, version)
+			return []string{"changed"}, nil
+		},
+	}
+}
+
+func TestMigrator(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+
+	newTestMigrator := func(log *memoryMigrationLog, migrations ...Migration) *Migrator {
+		m := newMigrator(nil, log, migrations)
+		m.now = func() time.Time { return now }
+		m.retry = time.Millisecond
+		return m
+	}
+
+	t.Run("RunsPendingInOrderAndRecordsThem", func(t *testing.T) {
+		var ran []int
+		log := &memoryMigrationLog{applied: []AppliedMigration{{Version: 1, AppliedAt: now.Add(-time.Hour)}}}
+		m := newTestMigrator(log, recordingMigration(1, &ran, nil), recordingMigration(2, &ran, nil), recordingMigration(3, &ran, nil))
+
+		results, err := m.Up(ctx, false)
+		require.NoError(t, err)
+		assert.Equal(t, []int{2, 3}, ran)
+		assert.Equal(t, []MigrationResult{
+			{Version: 2, Description: "test migration", Changes: []string{"changed"}},
+			{Version: 3, Description: "test migration", Changes: []string{"changed"}},
+		}, results)
+		assert.Len(t, log.applied, 3)
+		assert.Equal(t, now, log.applied[2].AppliedAt)
+		assert.True(t, log.unlocked)
+
+		results, err = m.Up(ctx, false)
+		require.NoError(t, err)
+		assert.Empty(t, results)
+	})
+
+	t.Run("DryRunWritesNothing", func(t *testing.T) {
+		var ran []int
+		log := &memoryMigrationLog{}
+		m := newTestMigrator(log, recordingMigration(1, &ran, nil))
+
+		results, err := m.Up(ctx, true)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"would change"}, results[0].Changes)
+		assert.Empty(t, ran)
+		assert.Empty(t, log.applied)
+		assert.Zero(t, log.locks)
+	})
+
+	t.Run("StopsAtFailureAndKeepsEarlierRecords", func(t *testing.T) {
+		var ran []int
+		log := &memoryMigrationLog{}
+		m := newTestMigrator(log, recordingMigration(1, &ran, nil), recordingMigration(2, &ran, errors.New("duplicates")), recordingMigration(3, &ran, nil))
+
+		results, err := m.Up(ctx, false)
+		assert.EqualError(t, err, "migration 2 (test migration): duplicates")
+		assert.Len(t, results, 1)
+		assert.Equal(t, []int{1}, ran)
+		require.Len(t, log.applied, 1)
+		assert.Equal(t, 1, log.applied[0].Version)
+		assert.True(t, log.unlocked)
+	})
+
+	t.Run("RejectsUnorderedVersions", func(t *testing.T) {
+		var ran []int
+		m := newTestMigrator(&memoryMigrationLog{}, recordingMigration(2, &ran, nil), recordingMigration(2, &ran, nil))
+
+		_, err := m.Up(ctx, false)
+		assert.EqualError(t, err, "migration versions must be positive and increasing, found 2")
+		assert.Empty(t, ran)
+	})
+
+	t.Run("WaitsForLockUntilContextEnds", func(t *testing.T) {
+		var ran []int
+		log := &memoryMigrationLog{lockOwner: "other", lockUntil: now.Add(time.Minute)}
+		m := newTestMigrator(log, recordingMigration(1, &ran, nil))
+		ctx, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+		defer cancel()
+
+		_, err := m.Up(ctx, false)
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+		assert.Empty(t, ran)
+		assert.Greater(t, log.locks, 1)
+		assert.Equal(t, "other", log.lockOwner)
+	})
+
+	t.Run("TakesExpiredLock", func(t *testing.T) {
+		var ran []int
+		log := &memoryMigrationLog{lockOwner: "crashed", lockUntil: now.Add(-time.Second)}
+		m := newTestMigrator(log, recordingMigration(1, &ran, nil))
+
+		_, err := m.Up(ctx, false)
+		require.NoError(t, err)
+		assert.Equal(t, []int{1}, ran)
+	})
+
+	t.Run("Status", func(t *testing.T) {
+		var ran []int
+		log := &memoryMigrationLog{applied: []AppliedMigration{
+			{Version: 1, Description: "test migration", AppliedAt: now},
+			{Version: 9, Description: "from a newer release", AppliedAt: now},
+		}}
+		m := newTestMigrator(log, recordingMigration(1, &ran, nil), recordingMigration(2, &ran, nil))
+
+		statuses, err := m.Status(ctx)
+		require.NoError(t, err)
+		require.Len(t, statuses, 3)
+		assert.Equal(t, now, *statuses[0].AppliedAt)
+		assert.Nil(t, statuses[1].AppliedAt)
+		assert.Equal(t, MigrationStatus{Version: 9, Description: "from a newer release", AppliedAt: &now}, statuses[2])
+	})
+}
+
+func TestMigrationVersions(t *testing.T) {
+	m := newMigrator(nil, &memoryMigrationLog{}, Migrations)
+	assert.NoError(t, m.validate())
+}
+
+func TestDuplicateUserError(t *testing.T) {
+	duplicate := func(index string) error {
+		return mongo.WriteException{WriteErrors: []mongo.WriteError{{
+			Code:    11000,
+			Message: "E11000 duplicate key error collection: task_manager.users index: " + index + " dup key",
+		}}}
+	}
+
+	assert.ErrorIs(t, duplicateUserError(duplicate("username_unique")), domain.ErrUsernameTaken)
+	assert.ErrorIs(t, duplicateUserError(duplicate("email_unique")), domain.ErrEmailInUse)
+	assert.NotErrorIs(t, duplicateUserError(duplicate("_id_")), domain.ErrUsernameTaken)
+	assert.NoError(t, duplicateUserError(nil))
+}
@@ -0,0 +1,42 @@
+package repositories
+
+import (
+	"context"
+	"log/slog"
+	"task_manager/domain"
+
+	"go.mongodb.org/mongo-driver/event"
+	"go.opentelemetry.io/contrib/instrumentation/go.mongodb.org/mongo-driver/mongo/otelmongo"
+)
+
+// NewCommandMonitor traces every MongoDB command as a child span of the
+// request that issued it, and logs it through that request's logger, so the
+// database calls of a request can be found by its trace or request id.
+// Successful commands are logged at debug level.
+func NewCommandMonitor() *event.CommandMonitor {
+	tracing := otelmongo.NewMonitor()
+	return &event.CommandMonitor{
+		Started: tracing.Started,
+		Succeeded: func(ctx context.Context, evt *event.CommandSucceededEvent) {
+			tracing.Succeeded(ctx, evt)
+			logger := domain.Logger(ctx)
+			if !logger.Enabled(ctx, slog.LevelDebug) {
+				return
+			}
+			logger.LogAttrs(ctx, slog.LevelDebug, "mongo command",
+				slog.String("command", evt.CommandName),
+				slog.String("database", evt.DatabaseName),
+				slog.Duration("duration", evt.Duration),
+			)
+		},
+		Failed: func(ctx context.Context, evt *event.CommandFailedEvent) {
+			tracing.Failed(ctx, evt)
+			domain.Logger(ctx).LogAttrs(ctx, slog.LevelWarn, "mongo command failed",
+				slog.String("command", evt.CommandName),
+				slog.String("database", evt.DatabaseName),
+				slog.Duration("duration", evt.Duration),
+				slog.String("error", evt.Failure),
+			)
+		},
+	}
+}
@@ -0,0 +1,39 @@
+package repositories
+
+import (
+	"context"
+	"task_manager/domain"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type SessionRepositoryImpl struct {
+	collection *mongo.Collection
+}
+
+func NewSessionRepository(collection *mongo.Collection) domain.SessionRepository {
+	return &SessionRepositoryImpl{collection: collection}
+}
+
+func (r *SessionRepositoryImpl) CreateSession(ctx context.Context, session domain.Session) error {
+	_, err := r.collection.InsertOne(ctx, session)
+	return err
+}
+
+func (r *SessionRepositoryImpl) FindSession(ctx context.Context, id string) (*domain.Session, error) {
+	var session domain.Session
+	err := r.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&session)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+func (r *SessionRepositoryImpl) DeleteSession(ctx context.Context, id string) error {
+	_, err := r.collection.DeleteOne(ctx, bson.M{"_id": id})
+	return err
+}
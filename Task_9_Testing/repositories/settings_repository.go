@@ -0,0 +1,38 @@
+package repositories
+
+import (
+	"context"
+	"task_manager/domain"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const securitySettingsID = "security"
+
+type SettingsRepositoryImpl struct {
+	collection *mongo.Collection
+}
+
+func NewSettingsRepository(collection *mongo.Collection) domain.SettingsRepository {
+	return &SettingsRepositoryImpl{collection: collection}
+}
+
+// GetSecuritySettings returns the defaults when nothing has been saved yet.
+func (r *SettingsRepositoryImpl) GetSecuritySettings(ctx context.Context) (*domain.SecuritySettings, error) {
+	var settings domain.SecuritySettings
+	err := r.collection.FindOne(ctx, bson.M{"_id": securitySettingsID}).Decode(&settings)
+	if err == mongo.ErrNoDocuments {
+		return &domain.SecuritySettings{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &settings, nil
+}
+
+func (r *SettingsRepositoryImpl) SaveSecuritySettings(ctx context.Context, settings domain.SecuritySettings) error {
+	_, err := r.collection.ReplaceOne(ctx, bson.M{"_id": securitySettingsID}, settings, options.Replace().SetUpsert(true))
+	return err
+}
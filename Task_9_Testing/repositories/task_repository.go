@@ -2,10 +2,13 @@ package repositories
 
 import (
 	"context"
+	"regexp"
 	"task_manager/domain"
 
 	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 type TaskRepositoryImpl struct {
@@ -35,9 +38,45 @@ func (r *TaskRepositoryImpl) GetAllTasks(ctx context.Context) ([]domain.Task, er
 	return tasks, err
 }
 
+func (r *TaskRepositoryImpl) FindTasks(ctx context.Context, filter domain.TaskFilter) ([]domain.Task, error) {
+	query := bson.M{}
+	if filter.Status != "" {
+		query["status"] = filter.Status
+	}
+	if filter.OwnerIDs != nil {
+		query["owner_id"] = bson.M{"$in": filter.OwnerIDs}
+	}
+	due := bson.M{}
+	if filter.DueAfter != nil {
+		due["$gte"] = *filter.DueAfter
+	}
+	if filter.DueBefore != nil {
+		due["$lt"] = *filter.DueBefore
+	}
+	if len(due) > 0 {
+		query["due_date"] = due
+	}
+	if filter.Search != "" {
+		pattern := primitive.Regex{Pattern: regexp.QuoteMeta(filter.Search), Options: "i"}
+		query["$or"] = bson.A{bson.M{"title": pattern}, bson.M{"description": pattern}}
+	}
+
+	cursor, err := r.collection.Find(ctx, query, options.Find().SetSort(bson.D{{Key: "due_date", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+	tasks := []domain.Task{}
+	err = cursor.All(ctx, &tasks)
+	return tasks, err
+}
+
 func (r *TaskRepositoryImpl) GetTaskByID(ctx context.Context, id string) (*domain.Task, error) {
 	var task domain.Task
 	err := r.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&task)
+	if err == mongo.ErrNoDocuments {
+		return nil, domain.ErrTaskNotFound
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -45,11 +84,47 @@ func (r *TaskRepositoryImpl) GetTaskByID(ctx context.Context, id string) (*domai
 }
 
 func (r *TaskRepositoryImpl) UpdateTask(ctx context.Context, id string, task domain.Task) error {
-	_, err := r.collection.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": task})
+	// The body never carries the document key, so keep _id pinned to the path id.
+	task.ID = id
+	// Left out of the $set through omitempty, so it only moves by the $inc.
+	task.Version = 0
+	_, err := r.collection.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": task, "$inc": bson.M{"version": 1}})
 	return err
 }
 
+// PatchTask only $sets the given bson fields, leaving the rest of the document untouched.
+func (r *TaskRepositoryImpl) PatchTask(ctx context.Context, id string, version int, fields map[string]interface{}) error {
+	filter := bson.M{"_id": id, "version": version}
+	result, err := r.collection.UpdateOne(ctx, filter, bson.M{"$set": bson.M(fields), "$inc": bson.M{"version": 1}})
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount > 0 {
+		return nil
+	}
+	n, err := r.collection.CountDocuments(ctx, bson.M{"_id": id})
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return domain.ErrTaskNotFound
+	}
+	return domain.ErrTaskConflict
+}
+
 func (r *TaskRepositoryImpl) DeleteTask(ctx context.Context, id string) error {
 	_, err := r.collection.DeleteOne(ctx, bson.M{"_id": id})
 	return err
 }
+
+func (r *TaskRepositoryImpl) ReassignTasks(ctx context.Context, fromOwnerID, toOwnerID string) (int64, error) {
+	update := bson.M{"$set": bson.M{"owner_id": toOwnerID}, "$inc": bson.M{"version": 1}}
+	if toOwnerID == "" {
+		update = bson.M{"$unset": bson.M{"owner_id": ""}, "$inc": bson.M{"version": 1}}
+	}
+	result, err := r.collection.UpdateMany(ctx, bson.M{"owner_id": fromOwnerID}, update)
+	if err != nil {
+		return 0, err
+	}
+	return result.ModifiedCount, nil
+}
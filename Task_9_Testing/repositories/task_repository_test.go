@@ -23,6 +23,11 @@ func (m *MockTaskRepository) GetAllTasks(ctx context.Context) ([]domain.Task, er
 	return args.Get(0).([]domain.Task), args.Error(1)
 }
 
+func (m *MockTaskRepository) FindTasks(ctx context.Context, filter domain.TaskFilter) ([]domain.Task, error) {
+	args := m.Called(ctx, filter)
+	return args.Get(0).([]domain.Task), args.Error(1)
+}
+
 func (m *MockTaskRepository) GetTaskByID(ctx context.Context, id string) (*domain.Task, error) {
 	args := m.Called(ctx, id)
 	return args.Get(0).(*domain.Task), args.Error(1)
@@ -32,10 +37,19 @@ func (m *MockTaskRepository) UpdateTask(ctx context.Context, id string, task dom
 	return m.Called(ctx, id, task).Error(0)
 }
 
+func (m *MockTaskRepository) PatchTask(ctx context.Context, id string, version int, fields map[string]interface{}) error {
+	return m.Called(ctx, id, version, fields).Error(0)
+}
+
 func (m *MockTaskRepository) DeleteTask(ctx context.Context, id string) error {
 	return m.Called(ctx, id).Error(0)
 }
 
+func (m *MockTaskRepository) ReassignTasks(ctx context.Context, fromOwnerID, toOwnerID string) (int64, error) {
+	args := m.Called(ctx, fromOwnerID, toOwnerID)
+	return args.Get(0).(int64), args.Error(1)
+}
+
 func TestAddTask(t *testing.T) {
 	mockRepo := &MockTaskRepository{}
 	ctx := context.Background()
@@ -120,6 +134,41 @@ func TestUpdateTask(t *testing.T) {
 	})
 }
 
+func TestPatchTask(t *testing.T) {
+	mockRepo := &MockTaskRepository{}
+	ctx := context.Background()
+
+	t.Run("Success", func(t *testing.T) {
+		fields := map[string]interface{}{"status": "done"}
+		mockRepo.On("PatchTask", ctx, "1", 3, fields).Return(nil).Once()
+
+		err := mockRepo.PatchTask(ctx, "1", 3, fields)
+		assert.NoError(t, err, "PatchTask should succeed")
+
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("NotFound", func(t *testing.T) {
+		fields := map[string]interface{}{"status": "done"}
+		mockRepo.On("PatchTask", ctx, "9", 3, fields).Return(domain.ErrTaskNotFound).Once()
+
+		err := mockRepo.PatchTask(ctx, "9", 3, fields)
+		assert.ErrorIs(t, err, domain.ErrTaskNotFound, "PatchTask should report a missing task")
+
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("Conflict", func(t *testing.T) {
+		fields := map[string]interface{}{"status": "done"}
+		mockRepo.On("PatchTask", ctx, "1", 2, fields).Return(domain.ErrTaskConflict).Once()
+
+		err := mockRepo.PatchTask(ctx, "1", 2, fields)
+		assert.ErrorIs(t, err, domain.ErrTaskConflict, "PatchTask should report a stale version")
+
+		mockRepo.AssertExpectations(t)
+	})
+}
+
 func TestDeleteTask(t *testing.T) {
 	mockRepo := &MockTaskRepository{}
 	ctx := context.Background()
@@ -132,4 +181,20 @@ func TestDeleteTask(t *testing.T) {
 
 		mockRepo.AssertExpectations(t)
 	})
-}
\ No newline at end of file
+}
+
+func TestReassignTasks(t *testing.T) {
+	mockRepo := &MockTaskRepository{}
+	ctx := context.Background()
+
+	t.Run("Success", func(t *testing.T) {
+		mockRepo.On("ReassignTasks", ctx, "1", "2").Return(int64(3), nil).Once()
+
+		moved, err := mockRepo.ReassignTasks(ctx, "1", "2")
+		assert.NoError(t, err, "ReassignTasks should succeed")
+		assert.Equal(t, int64(3), moved, "Should report the number of tasks moved")
+
+		mockRepo.AssertExpectations(t)
+	})
+}
+
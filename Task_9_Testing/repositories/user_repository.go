@@ -2,22 +2,56 @@ package repositories
 
 import (
 	"context"
+	"regexp"
+	"strings"
 	"task_manager/domain"
+	"time"
 
 	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 type UserRepositoryImpl struct {
 	collection *mongo.Collection
+	attempts   *mongo.Collection
+	lockEvents *mongo.Collection
+	resets     *mongo.Collection
+	challenges *mongo.Collection
 }
 
+// NewUserRepository keeps login attempts, lock events, password resets and
+// two-factor challenges in sibling collections of the users collection.
 func NewUserRepository(collection *mongo.Collection) domain.UserRepository {
-	return &UserRepositoryImpl{collection: collection}
+	db := collection.Database()
+	return &UserRepositoryImpl{
+		collection: collection,
+		attempts:   db.Collection("login_attempts"),
+		lockEvents: db.Collection("lock_events"),
+		resets:     db.Collection("password_resets"),
+		challenges: db.Collection("mfa_challenges"),
+	}
 }
 
 func (r *UserRepositoryImpl) CreateUser(ctx context.Context, user domain.User) error {
 	_, err := r.collection.InsertOne(ctx, user)
+	return duplicateUserError(err)
+}
+
+// duplicateUserError turns a violation of the unique indexes created by
+// migration 4 into the errors the pre-insert checks return, for the request
+// that loses a race between check and write.
+func duplicateUserError(err error) error {
+	if !mongo.IsDuplicateKeyError(err) {
+		return err
+	}
+	switch {
+	case strings.Contains(err.Error(), "username_unique"):
+		return domain.ErrUsernameTaken
+	case strings.Contains(err.Error(), "email_unique"):
+		return domain.ErrEmailInUse
+	}
 	return err
 }
 
@@ -30,11 +64,135 @@ func (r *UserRepositoryImpl) FindUserByUsername(ctx context.Context, username st
 	return &user, err
 }
 
+func (r *UserRepositoryImpl) FindUserByID(ctx context.Context, id string) (*domain.User, error) {
+	return r.findOne(ctx, bson.M{"_id": id})
+}
+
+func (r *UserRepositoryImpl) FindUsersByIDs(ctx context.Context, ids []string) ([]domain.User, error) {
+	cursor, err := r.collection.Find(ctx, bson.M{"_id": bson.M{"$in": ids}})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+	users := []domain.User{}
+	err = cursor.All(ctx, &users)
+	return users, err
+}
+
+func (r *UserRepositoryImpl) FindUserByEmail(ctx context.Context, email string) (*domain.User, error) {
+	return r.findOne(ctx, bson.M{"email": email})
+}
+
+func (r *UserRepositoryImpl) findOne(ctx context.Context, filter bson.M) (*domain.User, error) {
+	var user domain.User
+	err := r.collection.FindOne(ctx, filter).Decode(&user)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+func (r *UserRepositoryImpl) UpdatePassword(ctx context.Context, id, hashed string, changedAt time.Time) error {
+	update := bson.M{"$set": bson.M{"password": hashed, "password_changed_at": changedAt}}
+	_, err := r.collection.UpdateOne(ctx, bson.M{"_id": id}, update)
+	return err
+}
+
+// MarkEmailVerified only matches while the user still has email, so a link sent
+// to an old address cannot verify a new one.
+func (r *UserRepositoryImpl) MarkEmailVerified(ctx context.Context, id, email string) (bool, error) {
+	result, err := r.collection.UpdateOne(ctx, bson.M{"_id": id, "email": email}, bson.M{"$set": bson.M{"verified": true}})
+	if err != nil {
+		return false, err
+	}
+	return result.MatchedCount > 0, nil
+}
+
+func (r *UserRepositoryImpl) UpdateProfile(ctx context.Context, id string, fields map[string]interface{}) error {
+	_, err := r.collection.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": bson.M(fields)})
+	return duplicateUserError(err)
+}
+
+func (r *UserRepositoryImpl) RecordLogin(ctx context.Context, id string, at time.Time) error {
+	_, err := r.collection.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": bson.M{"last_login_at": at}})
+	return err
+}
+
+func (r *UserRepositoryImpl) FindUserByOIDCSubject(ctx context.Context, issuer, subject string) (*domain.User, error) {
+	return r.findOne(ctx, bson.M{"oidc_issuer": issuer, "oidc_subject": subject})
+}
+
+func (r *UserRepositoryImpl) LinkOIDCIdentity(ctx context.Context, id, issuer, subject string) error {
+	update := bson.M{"$set": bson.M{"oidc_issuer": issuer, "oidc_subject": subject}}
+	_, err := r.collection.UpdateOne(ctx, bson.M{"_id": id}, update)
+	return err
+}
+
+// SetTOTPSecret stores a new, not yet enabled secret and replaces the recovery codes.
+func (r *UserRepositoryImpl) SetTOTPSecret(ctx context.Context, id, secret string, recoveryCodes []string) error {
+	update := bson.M{
+		"$set":   bson.M{"totp_secret": secret, "recovery_codes": recoveryCodes, "totp_enabled": false},
+		"$unset": bson.M{"totp_last_step": ""},
+	}
+	_, err := r.collection.UpdateOne(ctx, bson.M{"_id": id}, update)
+	return err
+}
+
+func (r *UserRepositoryImpl) EnableTOTP(ctx context.Context, id string) error {
+	_, err := r.collection.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": bson.M{"totp_enabled": true}})
+	return err
+}
+
+func (r *UserRepositoryImpl) DisableTOTP(ctx context.Context, id string) error {
+	update := bson.M{
+		"$set":   bson.M{"totp_enabled": false},
+		"$unset": bson.M{"totp_secret": "", "recovery_codes": "", "totp_last_step": ""},
+	}
+	_, err := r.collection.UpdateOne(ctx, bson.M{"_id": id}, update)
+	return err
+}
+
+// AdvanceTOTPStep records step as used. It fails to match, and returns false,
+// when that step or a later one was already used.
+func (r *UserRepositoryImpl) AdvanceTOTPStep(ctx context.Context, id string, step int64) (bool, error) {
+	filter := bson.M{"_id": id, "$or": bson.A{
+		bson.M{"totp_last_step": bson.M{"$exists": false}},
+		bson.M{"totp_last_step": bson.M{"$lt": step}},
+	}}
+	result, err := r.collection.UpdateOne(ctx, filter, bson.M{"$set": bson.M{"totp_last_step": step}})
+	if err != nil {
+		return false, err
+	}
+	return result.ModifiedCount > 0, nil
+}
+
+func (r *UserRepositoryImpl) ConsumeRecoveryCode(ctx context.Context, id, codeHash string) (bool, error) {
+	filter := bson.M{"_id": id, "recovery_codes": codeHash}
+	result, err := r.collection.UpdateOne(ctx, filter, bson.M{"$pull": bson.M{"recovery_codes": codeHash}})
+	if err != nil {
+		return false, err
+	}
+	return result.ModifiedCount > 0, nil
+}
+
 func (r *UserRepositoryImpl) PromoteUser(ctx context.Context, username string) error {
 	_, err := r.collection.UpdateOne(ctx, bson.M{"username": username}, bson.M{"$set": bson.M{"role": "admin"}})
 	return err
 }
 
+func (r *UserRepositoryImpl) SetRole(ctx context.Context, id, role string) error {
+	_, err := r.collection.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": bson.M{"role": role}})
+	return err
+}
+
+func (r *UserRepositoryImpl) SetDisabled(ctx context.Context, id string, disabled bool) error {
+	_, err := r.collection.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": bson.M{"disabled": disabled}})
+	return err
+}
+
 func (r *UserRepositoryImpl) IsFirstUser(ctx context.Context) (bool, error) {
 	count, err := r.collection.CountDocuments(ctx, bson.M{})
 	return count == 0, err
@@ -62,7 +220,152 @@ func (r *UserRepositoryImpl) GetAllUsers(ctx context.Context) ([]*domain.User, e
 	}
 	return users, nil
 }
+// ListUsers returns one page of users sorted by username, plus the number of
+// users matching the query across all pages.
+func (r *UserRepositoryImpl) ListUsers(ctx context.Context, query domain.UserQuery) ([]domain.User, int64, error) {
+	filter := bson.M{}
+	if query.Search != "" {
+		pattern := primitive.Regex{Pattern: regexp.QuoteMeta(query.Search), Options: "i"}
+		filter["$or"] = bson.A{bson.M{"username": pattern}, bson.M{"email": pattern}}
+	}
+	if query.Role != "" {
+		filter["role"] = query.Role
+	}
+	if query.Disabled != nil {
+		filter["disabled"] = *query.Disabled
+	}
+
+	total, err := r.collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+	opts := options.Find().
+		SetSort(bson.D{{Key: "username", Value: 1}}).
+		SetSkip(int64((query.Page - 1) * query.PerPage)).
+		SetLimit(int64(query.PerPage))
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer cursor.Close(ctx)
+
+	users := []domain.User{}
+	if err := cursor.All(ctx, &users); err != nil {
+		return nil, 0, err
+	}
+	return users, total, nil
+}
+
 func (r *UserRepositoryImpl) DeleteUser(ctx context.Context, id string) error {
 	_, err := r.collection.DeleteOne(ctx, bson.M{"_id": id})
 	return err
 }
+
+func (r *UserRepositoryImpl) GetLoginAttempts(ctx context.Context, key string) (*domain.LoginAttempts, error) {
+	var attempts domain.LoginAttempts
+	err := r.attempts.FindOne(ctx, bson.M{"_id": key}).Decode(&attempts)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &attempts, nil
+}
+
+func (r *UserRepositoryImpl) RecordFailedLogin(ctx context.Context, key string, at time.Time) (*domain.LoginAttempts, error) {
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+	update := bson.M{
+		"$inc": bson.M{"failures": 1},
+		"$set": bson.M{"last_failure": at},
+	}
+	var attempts domain.LoginAttempts
+	if err := r.attempts.FindOneAndUpdate(ctx, bson.M{"_id": key}, update, opts).Decode(&attempts); err != nil {
+		return nil, err
+	}
+	return &attempts, nil
+}
+
+// LockLogin sets the lock and clears the failure count, so the key starts over once the lock expires.
+func (r *UserRepositoryImpl) LockLogin(ctx context.Context, key string, until time.Time) error {
+	update := bson.M{"$set": bson.M{"locked_until": until, "failures": 0}}
+	_, err := r.attempts.UpdateOne(ctx, bson.M{"_id": key}, update, options.Update().SetUpsert(true))
+	return err
+}
+
+func (r *UserRepositoryImpl) ResetLoginAttempts(ctx context.Context, key string) error {
+	_, err := r.attempts.DeleteOne(ctx, bson.M{"_id": key})
+	return err
+}
+
+func (r *UserRepositoryImpl) RecordLockEvent(ctx context.Context, event domain.LockEvent) error {
+	_, err := r.lockEvents.InsertOne(ctx, event)
+	return err
+}
+
+func (r *UserRepositoryImpl) CreatePasswordReset(ctx context.Context, reset domain.PasswordReset) error {
+	_, err := r.resets.InsertOne(ctx, reset)
+	return err
+}
+
+func (r *UserRepositoryImpl) FindPasswordReset(ctx context.Context, tokenHash string) (*domain.PasswordReset, error) {
+	var reset domain.PasswordReset
+	err := r.resets.FindOne(ctx, bson.M{"_id": tokenHash}).Decode(&reset)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &reset, nil
+}
+
+// ConsumePasswordReset deletes and returns an unexpired reset in one step, so a
+// token can only ever be redeemed once.
+func (r *UserRepositoryImpl) ConsumePasswordReset(ctx context.Context, tokenHash string, now time.Time) (*domain.PasswordReset, error) {
+	var reset domain.PasswordReset
+	filter := bson.M{"_id": tokenHash, "expires_at": bson.M{"$gt": now}}
+	err := r.resets.FindOneAndDelete(ctx, filter).Decode(&reset)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &reset, nil
+}
+
+func (r *UserRepositoryImpl) DeletePasswordResets(ctx context.Context, userID string) error {
+	_, err := r.resets.DeleteMany(ctx, bson.M{"user_id": userID})
+	return err
+}
+
+func (r *UserRepositoryImpl) CreateMFAChallenge(ctx context.Context, challenge domain.MFAChallenge) error {
+	_, err := r.challenges.InsertOne(ctx, challenge)
+	return err
+}
+
+func (r *UserRepositoryImpl) FindMFAChallenge(ctx context.Context, tokenHash string) (*domain.MFAChallenge, error) {
+	var challenge domain.MFAChallenge
+	err := r.challenges.FindOne(ctx, bson.M{"_id": tokenHash}).Decode(&challenge)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &challenge, nil
+}
+
+func (r *UserRepositoryImpl) ConsumeMFAChallenge(ctx context.Context, tokenHash string, now time.Time) (*domain.MFAChallenge, error) {
+	var challenge domain.MFAChallenge
+	filter := bson.M{"_id": tokenHash, "expires_at": bson.M{"$gt": now}}
+	err := r.challenges.FindOneAndDelete(ctx, filter).Decode(&challenge)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &challenge, nil
+}
@@ -3,6 +3,7 @@ package repositories
 import (
 	"context"
 	"testing"
+	"time"
 	"task_manager/domain"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -21,6 +22,47 @@ func (m *MockUserRepository) FindUserByUsername(ctx context.Context, username st
 	return args.Get(0).(*domain.User), args.Error(1)
 }
 
+func (m *MockUserRepository) FindUserByID(ctx context.Context, id string) (*domain.User, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(*domain.User), args.Error(1)
+}
+
+func (m *MockUserRepository) FindUsersByIDs(ctx context.Context, ids []string) ([]domain.User, error) {
+	args := m.Called(ctx, ids)
+	return args.Get(0).([]domain.User), args.Error(1)
+}
+
+func (m *MockUserRepository) FindUserByEmail(ctx context.Context, email string) (*domain.User, error) {
+	args := m.Called(ctx, email)
+	return args.Get(0).(*domain.User), args.Error(1)
+}
+
+func (m *MockUserRepository) UpdatePassword(ctx context.Context, id, hashed string, changedAt time.Time) error {
+	return m.Called(ctx, id, hashed, changedAt).Error(0)
+}
+
+func (m *MockUserRepository) MarkEmailVerified(ctx context.Context, id, email string) (bool, error) {
+	args := m.Called(ctx, id, email)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockUserRepository) UpdateProfile(ctx context.Context, id string, fields map[string]interface{}) error {
+	return m.Called(ctx, id, fields).Error(0)
+}
+
+func (m *MockUserRepository) RecordLogin(ctx context.Context, id string, at time.Time) error {
+	return m.Called(ctx, id, at).Error(0)
+}
+
+func (m *MockUserRepository) FindUserByOIDCSubject(ctx context.Context, issuer, subject string) (*domain.User, error) {
+	args := m.Called(ctx, issuer, subject)
+	return args.Get(0).(*domain.User), args.Error(1)
+}
+
+func (m *MockUserRepository) LinkOIDCIdentity(ctx context.Context, id, issuer, subject string) error {
+	return m.Called(ctx, id, issuer, subject).Error(0)
+}
+
 func (m *MockUserRepository) PromoteUser(ctx context.Context, username string) error {
 	return m.Called(ctx, username).Error(0)
 }
@@ -39,6 +81,95 @@ func (m *MockUserRepository) DeleteUser(ctx context.Context, id string) error {
 	return m.Called(ctx, id).Error(0)
 }
 
+func (m *MockUserRepository) SetRole(ctx context.Context, id, role string) error {
+	return m.Called(ctx, id, role).Error(0)
+}
+
+func (m *MockUserRepository) SetDisabled(ctx context.Context, id string, disabled bool) error {
+	return m.Called(ctx, id, disabled).Error(0)
+}
+
+func (m *MockUserRepository) ListUsers(ctx context.Context, query domain.UserQuery) ([]domain.User, int64, error) {
+	args := m.Called(ctx, query)
+	return args.Get(0).([]domain.User), args.Get(1).(int64), args.Error(2)
+}
+
+func (m *MockUserRepository) GetLoginAttempts(ctx context.Context, key string) (*domain.LoginAttempts, error) {
+	args := m.Called(ctx, key)
+	return args.Get(0).(*domain.LoginAttempts), args.Error(1)
+}
+
+func (m *MockUserRepository) RecordFailedLogin(ctx context.Context, key string, at time.Time) (*domain.LoginAttempts, error) {
+	args := m.Called(ctx, key, at)
+	return args.Get(0).(*domain.LoginAttempts), args.Error(1)
+}
+
+func (m *MockUserRepository) LockLogin(ctx context.Context, key string, until time.Time) error {
+	return m.Called(ctx, key, until).Error(0)
+}
+
+func (m *MockUserRepository) ResetLoginAttempts(ctx context.Context, key string) error {
+	return m.Called(ctx, key).Error(0)
+}
+
+func (m *MockUserRepository) RecordLockEvent(ctx context.Context, event domain.LockEvent) error {
+	return m.Called(ctx, event).Error(0)
+}
+
+func (m *MockUserRepository) CreatePasswordReset(ctx context.Context, reset domain.PasswordReset) error {
+	return m.Called(ctx, reset).Error(0)
+}
+
+func (m *MockUserRepository) FindPasswordReset(ctx context.Context, tokenHash string) (*domain.PasswordReset, error) {
+	args := m.Called(ctx, tokenHash)
+	return args.Get(0).(*domain.PasswordReset), args.Error(1)
+}
+
+func (m *MockUserRepository) ConsumePasswordReset(ctx context.Context, tokenHash string, now time.Time) (*domain.PasswordReset, error) {
+	args := m.Called(ctx, tokenHash, now)
+	return args.Get(0).(*domain.PasswordReset), args.Error(1)
+}
+
+func (m *MockUserRepository) DeletePasswordResets(ctx context.Context, userID string) error {
+	return m.Called(ctx, userID).Error(0)
+}
+
+func (m *MockUserRepository) SetTOTPSecret(ctx context.Context, id, secret string, recoveryCodes []string) error {
+	return m.Called(ctx, id, secret, recoveryCodes).Error(0)
+}
+
+func (m *MockUserRepository) EnableTOTP(ctx context.Context, id string) error {
+	return m.Called(ctx, id).Error(0)
+}
+
+func (m *MockUserRepository) DisableTOTP(ctx context.Context, id string) error {
+	return m.Called(ctx, id).Error(0)
+}
+
+func (m *MockUserRepository) AdvanceTOTPStep(ctx context.Context, id string, step int64) (bool, error) {
+	args := m.Called(ctx, id, step)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockUserRepository) ConsumeRecoveryCode(ctx context.Context, id, codeHash string) (bool, error) {
+	args := m.Called(ctx, id, codeHash)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockUserRepository) CreateMFAChallenge(ctx context.Context, challenge domain.MFAChallenge) error {
+	return m.Called(ctx, challenge).Error(0)
+}
+
+func (m *MockUserRepository) FindMFAChallenge(ctx context.Context, tokenHash string) (*domain.MFAChallenge, error) {
+	args := m.Called(ctx, tokenHash)
+	return args.Get(0).(*domain.MFAChallenge), args.Error(1)
+}
+
+func (m *MockUserRepository) ConsumeMFAChallenge(ctx context.Context, tokenHash string, now time.Time) (*domain.MFAChallenge, error) {
+	args := m.Called(ctx, tokenHash, now)
+	return args.Get(0).(*domain.MFAChallenge), args.Error(1)
+}
+
 func TestCreateUser(t *testing.T) {
 	mockRepo := &MockUserRepository{}
 	ctx := context.Background()
@@ -163,4 +294,155 @@ func TestDeleteUser(t *testing.T) {
 
 		mockRepo.AssertExpectations(t)
 	})
-}
\ No newline at end of file
+}
+func TestLoginAttempts(t *testing.T) {
+	mockRepo := &MockUserRepository{}
+	ctx := context.Background()
+	now := time.Date(2025, 4, 3, 12, 0, 0, 0, time.UTC)
+
+	t.Run("RecordFailedLogin", func(t *testing.T) {
+		attempts := &domain.LoginAttempts{Key: "user:testuser", Failures: 2, LastFailure: now}
+		mockRepo.On("RecordFailedLogin", ctx, "user:testuser", now).Return(attempts, nil).Once()
+
+		result, err := mockRepo.RecordFailedLogin(ctx, "user:testuser", now)
+		assert.NoError(t, err, "RecordFailedLogin should succeed")
+		assert.Equal(t, 2, result.Failures, "Failure count should be returned")
+
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("LockAndReset", func(t *testing.T) {
+		until := now.Add(15 * time.Minute)
+		mockRepo.On("LockLogin", ctx, "ip:10.0.0.1", until).Return(nil).Once()
+		mockRepo.On("ResetLoginAttempts", ctx, "ip:10.0.0.1").Return(nil).Once()
+
+		assert.NoError(t, mockRepo.LockLogin(ctx, "ip:10.0.0.1", until), "LockLogin should succeed")
+		assert.NoError(t, mockRepo.ResetLoginAttempts(ctx, "ip:10.0.0.1"), "ResetLoginAttempts should succeed")
+
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("NoAttempts", func(t *testing.T) {
+		mockRepo.On("GetLoginAttempts", ctx, "user:nobody").Return((*domain.LoginAttempts)(nil), nil).Once()
+
+		result, err := mockRepo.GetLoginAttempts(ctx, "user:nobody")
+		assert.NoError(t, err, "GetLoginAttempts should succeed")
+		assert.Nil(t, result, "Result should be nil when nothing was recorded")
+
+		mockRepo.AssertExpectations(t)
+	})
+}
+
+func TestPasswordResets(t *testing.T) {
+	mockRepo := &MockUserRepository{}
+	ctx := context.Background()
+	now := time.Date(2025, 4, 3, 12, 0, 0, 0, time.UTC)
+	reset := domain.PasswordReset{TokenHash: "abc", UserID: "1", ExpiresAt: now.Add(30 * time.Minute)}
+
+	t.Run("CreateAndConsume", func(t *testing.T) {
+		mockRepo.On("CreatePasswordReset", ctx, reset).Return(nil).Once()
+		mockRepo.On("ConsumePasswordReset", ctx, "abc", now).Return(&reset, nil).Once()
+
+		assert.NoError(t, mockRepo.CreatePasswordReset(ctx, reset), "CreatePasswordReset should succeed")
+		result, err := mockRepo.ConsumePasswordReset(ctx, "abc", now)
+		assert.NoError(t, err, "ConsumePasswordReset should succeed")
+		assert.Equal(t, "1", result.UserID, "Reset should belong to the user")
+
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("ConsumedTwice", func(t *testing.T) {
+		mockRepo.On("ConsumePasswordReset", ctx, "abc", now).Return((*domain.PasswordReset)(nil), nil).Once()
+
+		result, err := mockRepo.ConsumePasswordReset(ctx, "abc", now)
+		assert.NoError(t, err, "ConsumePasswordReset should succeed")
+		assert.Nil(t, result, "A consumed reset should not be returned again")
+
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("UpdatePassword", func(t *testing.T) {
+		mockRepo.On("UpdatePassword", ctx, "1", "newhash", now).Return(nil).Once()
+
+		assert.NoError(t, mockRepo.UpdatePassword(ctx, "1", "newhash", now), "UpdatePassword should succeed")
+
+		mockRepo.AssertExpectations(t)
+	})
+}
+
+func TestTOTP(t *testing.T) {
+	mockRepo := &MockUserRepository{}
+	ctx := context.Background()
+	now := time.Date(2025, 4, 3, 12, 0, 0, 0, time.UTC)
+
+	t.Run("EnrollAndEnable", func(t *testing.T) {
+		mockRepo.On("SetTOTPSecret", ctx, "1", "SECRET", []string{"hash"}).Return(nil).Once()
+		mockRepo.On("EnableTOTP", ctx, "1").Return(nil).Once()
+
+		assert.NoError(t, mockRepo.SetTOTPSecret(ctx, "1", "SECRET", []string{"hash"}), "SetTOTPSecret should succeed")
+		assert.NoError(t, mockRepo.EnableTOTP(ctx, "1"), "EnableTOTP should succeed")
+
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("StepUsedOnce", func(t *testing.T) {
+		mockRepo.On("AdvanceTOTPStep", ctx, "1", int64(100)).Return(true, nil).Once()
+		mockRepo.On("AdvanceTOTPStep", ctx, "1", int64(100)).Return(false, nil).Once()
+
+		ok, err := mockRepo.AdvanceTOTPStep(ctx, "1", 100)
+		assert.NoError(t, err)
+		assert.True(t, ok, "A new step should be accepted")
+		ok, err = mockRepo.AdvanceTOTPStep(ctx, "1", 100)
+		assert.NoError(t, err)
+		assert.False(t, ok, "The same step should not be accepted twice")
+
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("RecoveryCodeUsedOnce", func(t *testing.T) {
+		mockRepo.On("ConsumeRecoveryCode", ctx, "1", "hash").Return(true, nil).Once()
+		mockRepo.On("ConsumeRecoveryCode", ctx, "1", "hash").Return(false, nil).Once()
+
+		ok, _ := mockRepo.ConsumeRecoveryCode(ctx, "1", "hash")
+		assert.True(t, ok, "An unused recovery code should be accepted")
+		ok, _ = mockRepo.ConsumeRecoveryCode(ctx, "1", "hash")
+		assert.False(t, ok, "A used recovery code should be rejected")
+
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("ChallengeConsumedOnce", func(t *testing.T) {
+		challenge := domain.MFAChallenge{TokenHash: "abc", UserID: "1", ExpiresAt: now.Add(5 * time.Minute)}
+		mockRepo.On("CreateMFAChallenge", ctx, challenge).Return(nil).Once()
+		mockRepo.On("ConsumeMFAChallenge", ctx, "abc", now).Return(&challenge, nil).Once()
+		mockRepo.On("ConsumeMFAChallenge", ctx, "abc", now).Return((*domain.MFAChallenge)(nil), nil).Once()
+
+		assert.NoError(t, mockRepo.CreateMFAChallenge(ctx, challenge), "CreateMFAChallenge should succeed")
+		result, err := mockRepo.ConsumeMFAChallenge(ctx, "abc", now)
+		assert.NoError(t, err)
+		assert.Equal(t, "1", result.UserID)
+		result, _ = mockRepo.ConsumeMFAChallenge(ctx, "abc", now)
+		assert.Nil(t, result, "A consumed challenge should not be returned again")
+
+		mockRepo.AssertExpectations(t)
+	})
+}
+
+func TestListUsers(t *testing.T) {
+	mockRepo := &MockUserRepository{}
+	ctx := context.Background()
+
+	t.Run("Success", func(t *testing.T) {
+		query := domain.UserQuery{Search: "user", Page: 2, PerPage: 1}
+		users := []domain.User{{ID: "2", Username: "user2", Role: "admin"}}
+		mockRepo.On("ListUsers", ctx, query).Return(users, int64(2), nil).Once()
+
+		result, total, err := mockRepo.ListUsers(ctx, query)
+		assert.NoError(t, err, "ListUsers should succeed")
+		assert.Len(t, result, 1, "Should return one page of users")
+		assert.Equal(t, int64(2), total, "Total should count every matching user")
+
+		mockRepo.AssertExpectations(t)
+	})
+}
+
@@ -0,0 +1,118 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"task_manager/domain"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// lastUsedPrecision limits how often authenticating with a key writes its
+// last-used time; a CI job making hundreds of calls only updates it once.
+const lastUsedPrecision = time.Minute
+
+type APIKeyUsecaseImpl struct {
+	keyRepo  domain.APIKeyRepository
+	userRepo domain.UserRepository
+	now      func() time.Time
+}
+
+func NewAPIKeyUsecase(keyRepo domain.APIKeyRepository, userRepo domain.UserRepository) domain.APIKeyUsecase {
+	return &APIKeyUsecaseImpl{keyRepo: keyRepo, userRepo: userRepo, now: time.Now}
+}
+
+func (u *APIKeyUsecaseImpl) CreateAPIKey(ctx context.Context, name string, scopes []string, ttl time.Duration) (*domain.APIKey, string, error) {
+	userID, err := domain.CallerID(ctx)
+	if err != nil {
+		return nil, "", err
+	}
+	user, err := u.userRepo.FindUserByID(ctx, userID)
+	if err != nil {
+		return nil, "", err
+	}
+	if user == nil {
+		return nil, "", errors.New("user not found")
+	}
+	if err := validateAPIKey(name, scopes, ttl, user.Role); err != nil {
+		return nil, "", err
+	}
+
+	secret, err := newSecretToken()
+	if err != nil {
+		return nil, "", err
+	}
+	plain := domain.APIKeyPrefix + secret
+	now := u.now().UTC().Truncate(time.Second)
+	key := domain.APIKey{
+		ID:        uuid.New().String(),
+		UserID:    user.ID,
+		Name:      strings.TrimSpace(name),
+		Prefix:    plain[:len(domain.APIKeyPrefix)+6],
+		KeyHash:   hashToken(plain),
+		Scopes:    scopes,
+		CreatedAt: now,
+		ExpiresAt: now.Add(ttl),
+	}
+	if err := u.keyRepo.CreateAPIKey(ctx, key); err != nil {
+		return nil, "", err
+	}
+	return &key, plain, nil
+}
+
+func (u *APIKeyUsecaseImpl) ListAPIKeys(ctx context.Context) ([]domain.APIKey, error) {
+	userID, err := domain.CallerID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return u.keyRepo.ListAPIKeys(ctx, userID)
+}
+
+func (u *APIKeyUsecaseImpl) RevokeAPIKey(ctx context.Context, id string) error {
+	userID, err := domain.CallerID(ctx)
+	if err != nil {
+		return err
+	}
+	deleted, err := u.keyRepo.DeleteAPIKey(ctx, userID, id)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return domain.ErrAPIKeyNotFound
+	}
+	return nil
+}
+
+// Authenticate resolves a plain key to its owner. Expired keys and keys of
+// deleted users are rejected the same way as unknown ones.
+func (u *APIKeyUsecaseImpl) Authenticate(ctx context.Context, plain string) (*domain.User, *domain.APIKey, error) {
+	if !strings.HasPrefix(plain, domain.APIKeyPrefix) {
+		return nil, nil, domain.ErrInvalidAPIKey
+	}
+	now := u.now()
+
+	key, err := u.keyRepo.FindAPIKeyByHash(ctx, hashToken(plain))
+	if err != nil {
+		return nil, nil, err
+	}
+	if key == nil || !key.ExpiresAt.After(now) {
+		return nil, nil, domain.ErrInvalidAPIKey
+	}
+	user, err := u.userRepo.FindUserByID(ctx, key.UserID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if user == nil || user.Disabled {
+		return nil, nil, domain.ErrInvalidAPIKey
+	}
+
+	if key.LastUsedAt == nil || now.Sub(*key.LastUsedAt) >= lastUsedPrecision {
+		if err := u.keyRepo.TouchAPIKey(ctx, key.ID, now); err != nil {
+			return nil, nil, err
+		}
+		key.LastUsedAt = &now
+	}
+	return user, key, nil
+}
@@ -0,0 +1,169 @@
+package usecases
+
+import (
+	"context"
+	"strings"
+	"task_manager/domain"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+type MockAPIKeyRepository struct {
+	mock.Mock
+}
+
+func (m *MockAPIKeyRepository) CreateAPIKey(ctx context.Context, key domain.APIKey) error {
+	return m.Called(ctx, key).Error(0)
+}
+
+func (m *MockAPIKeyRepository) FindAPIKeyByHash(ctx context.Context, keyHash string) (*domain.APIKey, error) {
+	args := m.Called(ctx, keyHash)
+	return args.Get(0).(*domain.APIKey), args.Error(1)
+}
+
+func (m *MockAPIKeyRepository) ListAPIKeys(ctx context.Context, userID string) ([]domain.APIKey, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).([]domain.APIKey), args.Error(1)
+}
+
+func (m *MockAPIKeyRepository) DeleteAPIKey(ctx context.Context, userID, id string) (bool, error) {
+	args := m.Called(ctx, userID, id)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockAPIKeyRepository) TouchAPIKey(ctx context.Context, id string, at time.Time) error {
+	return m.Called(ctx, id, at).Error(0)
+}
+
+type APIKeyUsecaseTestSuite struct {
+	suite.Suite
+	mockKeys *MockAPIKeyRepository
+	mockRepo *MockUserRepository
+	usecase  domain.APIKeyUsecase
+	ctx      context.Context
+	now      time.Time
+}
+
+func (s *APIKeyUsecaseTestSuite) SetupTest() {
+	s.mockKeys = &MockAPIKeyRepository{}
+	s.mockRepo = &MockUserRepository{}
+	s.usecase = NewAPIKeyUsecase(s.mockKeys, s.mockRepo)
+	s.ctx = domain.WithPrincipal(context.Background(), &domain.Principal{UserID: "1", Username: "testuser"})
+	s.now = time.Date(2025, 4, 3, 12, 0, 0, 0, time.UTC)
+	s.usecase.(*APIKeyUsecaseImpl).now = func() time.Time { return s.now }
+}
+
+func (s *APIKeyUsecaseTestSuite) TearDownTest() {
+	s.mockKeys.AssertExpectations(s.T())
+	s.mockRepo.AssertExpectations(s.T())
+}
+
+func (s *APIKeyUsecaseTestSuite) TestCreateAPIKey() {
+	user := &domain.User{ID: "1", Username: "testuser", Role: "user"}
+	scopes := []string{domain.ScopeTasksRead}
+
+	s.Run("Success", func() {
+		s.mockRepo.On("FindUserByID", s.ctx, "1").Return(user, nil).Once()
+		var stored domain.APIKey
+		s.mockKeys.On("CreateAPIKey", s.ctx, mock.Anything).Run(func(args mock.Arguments) {
+			stored = args.Get(1).(domain.APIKey)
+		}).Return(nil).Once()
+
+		key, plain, err := s.usecase.CreateAPIKey(s.ctx, " ci ", scopes, 30*24*time.Hour)
+		s.NoError(err)
+		s.True(strings.HasPrefix(plain, domain.APIKeyPrefix))
+		s.True(strings.HasPrefix(plain, key.Prefix))
+		s.Equal("ci", key.Name)
+		s.Equal(hashToken(plain), stored.KeyHash, "Only the hash of the key should be stored")
+		s.Equal(s.now.Add(30*24*time.Hour), key.ExpiresAt)
+	})
+
+	s.Run("AdminScopeNeedsAdmin", func() {
+		s.mockRepo.On("FindUserByID", s.ctx, "1").Return(user, nil).Once()
+
+		_, _, err := s.usecase.CreateAPIKey(s.ctx, "ci", []string{domain.ScopeAdmin}, time.Hour)
+		var verrs domain.ValidationErrors
+		s.ErrorAs(err, &verrs)
+		s.Equal("scopes", verrs[0].Field)
+	})
+
+	s.Run("InvalidInput", func() {
+		s.mockRepo.On("FindUserByID", s.ctx, "1").Return(user, nil).Once()
+
+		_, _, err := s.usecase.CreateAPIKey(s.ctx, "", []string{"everything"}, 400*24*time.Hour)
+		var verrs domain.ValidationErrors
+		s.ErrorAs(err, &verrs)
+		s.Len(verrs, 3)
+	})
+}
+
+func (s *APIKeyUsecaseTestSuite) TestRevokeAPIKey() {
+	s.Run("Success", func() {
+		s.mockKeys.On("DeleteAPIKey", s.ctx, "1", "k1").Return(true, nil).Once()
+		s.NoError(s.usecase.RevokeAPIKey(s.ctx, "k1"))
+	})
+
+	s.Run("NotOwned", func() {
+		other := domain.WithPrincipal(context.Background(), &domain.Principal{UserID: "2"})
+		s.mockKeys.On("DeleteAPIKey", other, "2", "k1").Return(false, nil).Once()
+		s.ErrorIs(s.usecase.RevokeAPIKey(other, "k1"), domain.ErrAPIKeyNotFound)
+	})
+}
+
+func (s *APIKeyUsecaseTestSuite) TestAuthenticate() {
+	user := &domain.User{ID: "1", Username: "testuser", Role: "user"}
+	hash := hashToken("tm_secret")
+
+	s.Run("RecordsLastUse", func() {
+		key := &domain.APIKey{ID: "k1", UserID: "1", ExpiresAt: s.now.Add(time.Hour)}
+		s.mockKeys.On("FindAPIKeyByHash", s.ctx, hash).Return(key, nil).Once()
+		s.mockRepo.On("FindUserByID", s.ctx, "1").Return(user, nil).Once()
+		s.mockKeys.On("TouchAPIKey", s.ctx, "k1", s.now).Return(nil).Once()
+
+		owner, result, err := s.usecase.Authenticate(s.ctx, "tm_secret")
+		s.NoError(err)
+		s.Equal("1", owner.ID)
+		s.Equal(s.now, *result.LastUsedAt)
+	})
+
+	s.Run("RecentlyUsed", func() {
+		lastUsed := s.now.Add(-10 * time.Second)
+		key := &domain.APIKey{ID: "k1", UserID: "1", ExpiresAt: s.now.Add(time.Hour), LastUsedAt: &lastUsed}
+		s.mockKeys.On("FindAPIKeyByHash", s.ctx, hash).Return(key, nil).Once()
+		s.mockRepo.On("FindUserByID", s.ctx, "1").Return(user, nil).Once()
+
+		_, _, err := s.usecase.Authenticate(s.ctx, "tm_secret")
+		s.NoError(err)
+	})
+
+	s.Run("Expired", func() {
+		key := &domain.APIKey{ID: "k1", UserID: "1", ExpiresAt: s.now}
+		s.mockKeys.On("FindAPIKeyByHash", s.ctx, hash).Return(key, nil).Once()
+
+		_, _, err := s.usecase.Authenticate(s.ctx, "tm_secret")
+		s.ErrorIs(err, domain.ErrInvalidAPIKey)
+	})
+
+	s.Run("Unknown", func() {
+		s.mockKeys.On("FindAPIKeyByHash", s.ctx, hash).Return((*domain.APIKey)(nil), nil).Once()
+
+		_, _, err := s.usecase.Authenticate(s.ctx, "tm_secret")
+		s.ErrorIs(err, domain.ErrInvalidAPIKey)
+	})
+
+	s.Run("OwnerDeleted", func() {
+		key := &domain.APIKey{ID: "k1", UserID: "1", ExpiresAt: s.now.Add(time.Hour)}
+		s.mockKeys.On("FindAPIKeyByHash", s.ctx, hash).Return(key, nil).Once()
+		s.mockRepo.On("FindUserByID", s.ctx, "1").Return((*domain.User)(nil), nil).Once()
+
+		_, _, err := s.usecase.Authenticate(s.ctx, "tm_secret")
+		s.ErrorIs(err, domain.ErrInvalidAPIKey)
+	})
+}
+
+func TestAPIKeyUsecaseSuite(t *testing.T) {
+	suite.Run(t, new(APIKeyUsecaseTestSuite))
+}
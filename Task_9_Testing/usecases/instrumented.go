@@ -0,0 +1,414 @@
+package usecases
+
+import (
+	"context"
+	"task_manager/domain"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+)
+
+// The instrumented usecases open a span for every call and count it, and
+// whether it failed, through domain.Metrics. The login entry points also
+// report their result, so failed and blocked logins can be alerted on.
+
+var tracer = otel.Tracer("task_manager/usecases")
+
+// startOperation starts the span of one usecase call, named like
+// "TaskUsecase.AddTask". The returned function ends it and counts the call.
+func startOperation(ctx context.Context, metrics domain.Metrics, usecase, iface, operation string) (context.Context, func(error)) {
+	ctx, span := tracer.Start(ctx, iface+"."+operation)
+	return ctx, func(err error) {
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+		metrics.CountOperation(usecase, operation, err)
+	}
+}
+
+type instrumentedTaskUsecase struct {
+	next    domain.TaskUsecase
+	metrics domain.Metrics
+}
+
+func InstrumentTaskUsecase(next domain.TaskUsecase, metrics domain.Metrics) domain.TaskUsecase {
+	return &instrumentedTaskUsecase{next: next, metrics: metrics}
+}
+
+func (u *instrumentedTaskUsecase) start(ctx context.Context, name string) (context.Context, func(error)) {
+	return startOperation(ctx, u.metrics, "task", "TaskUsecase", name)
+}
+
+func (u *instrumentedTaskUsecase) AddTask(ctx context.Context, task domain.Task) (_ string, err error) {
+	ctx, end := u.start(ctx, "AddTask")
+	defer func() { end(err) }()
+	return u.next.AddTask(ctx, task)
+}
+
+func (u *instrumentedTaskUsecase) GetAllTasks(ctx context.Context) (_ []domain.Task, err error) {
+	ctx, end := u.start(ctx, "GetAllTasks")
+	defer func() { end(err) }()
+	return u.next.GetAllTasks(ctx)
+}
+
+func (u *instrumentedTaskUsecase) FindTasks(ctx context.Context, filter domain.TaskFilter) (_ []domain.Task, err error) {
+	ctx, end := u.start(ctx, "FindTasks")
+	defer func() { end(err) }()
+	return u.next.FindTasks(ctx, filter)
+}
+
+func (u *instrumentedTaskUsecase) GetTaskByID(ctx context.Context, id string) (_ *domain.Task, err error) {
+	ctx, end := u.start(ctx, "GetTaskByID")
+	defer func() { end(err) }()
+	return u.next.GetTaskByID(ctx, id)
+}
+
+func (u *instrumentedTaskUsecase) UpdateTask(ctx context.Context, id string, task domain.Task) (err error) {
+	ctx, end := u.start(ctx, "UpdateTask")
+	defer func() { end(err) }()
+	return u.next.UpdateTask(ctx, id, task)
+}
+
+func (u *instrumentedTaskUsecase) PatchTask(ctx context.Context, id, mediaType string, patch []byte) (_ *domain.Task, err error) {
+	ctx, end := u.start(ctx, "PatchTask")
+	defer func() { end(err) }()
+	return u.next.PatchTask(ctx, id, mediaType, patch)
+}
+
+func (u *instrumentedTaskUsecase) DeleteTask(ctx context.Context, id string) (err error) {
+	ctx, end := u.start(ctx, "DeleteTask")
+	defer func() { end(err) }()
+	return u.next.DeleteTask(ctx, id)
+}
+
+type instrumentedUserUsecase struct {
+	next    domain.UserUsecase
+	metrics domain.Metrics
+}
+
+func InstrumentUserUsecase(next domain.UserUsecase, metrics domain.Metrics) domain.UserUsecase {
+	return &instrumentedUserUsecase{next: next, metrics: metrics}
+}
+
+func (u *instrumentedUserUsecase) start(ctx context.Context, name string) (context.Context, func(error)) {
+	return startOperation(ctx, u.metrics, "user", "UserUsecase", name)
+}
+
+func (u *instrumentedUserUsecase) Register(ctx context.Context, user domain.User) (err error) {
+	ctx, end := u.start(ctx, "Register")
+	defer func() { end(err) }()
+	return u.next.Register(ctx, user)
+}
+
+func (u *instrumentedUserUsecase) Login(ctx context.Context, username, password, ip string) (_ string, err error) {
+	ctx, end := u.start(ctx, "Login")
+	defer func() {
+		end(err)
+		u.metrics.CountLogin(domain.LoginMethodPassword, domain.LoginResult(err))
+	}()
+	return u.next.Login(ctx, username, password, ip)
+}
+
+func (u *instrumentedUserUsecase) PromoteUser(ctx context.Context, username string) (err error) {
+	ctx, end := u.start(ctx, "PromoteUser")
+	defer func() { end(err) }()
+	return u.next.PromoteUser(ctx, username)
+}
+
+func (u *instrumentedUserUsecase) UnlockLogin(ctx context.Context, username, ip string) (err error) {
+	ctx, end := u.start(ctx, "UnlockLogin")
+	defer func() { end(err) }()
+	return u.next.UnlockLogin(ctx, username, ip)
+}
+
+func (u *instrumentedUserUsecase) FindUsersByIDs(ctx context.Context, ids []string) (_ []domain.User, err error) {
+	ctx, end := u.start(ctx, "FindUsersByIDs")
+	defer func() { end(err) }()
+	return u.next.FindUsersByIDs(ctx, ids)
+}
+
+type instrumentedMFAUsecase struct {
+	next    domain.MFAUsecase
+	metrics domain.Metrics
+}
+
+func InstrumentMFAUsecase(next domain.MFAUsecase, metrics domain.Metrics) domain.MFAUsecase {
+	return &instrumentedMFAUsecase{next: next, metrics: metrics}
+}
+
+func (u *instrumentedMFAUsecase) start(ctx context.Context, name string) (context.Context, func(error)) {
+	return startOperation(ctx, u.metrics, "mfa", "MFAUsecase", name)
+}
+
+func (u *instrumentedMFAUsecase) EnrollTOTP(ctx context.Context) (_ *domain.TOTPEnrollment, err error) {
+	ctx, end := u.start(ctx, "EnrollTOTP")
+	defer func() { end(err) }()
+	return u.next.EnrollTOTP(ctx)
+}
+
+func (u *instrumentedMFAUsecase) ConfirmTOTP(ctx context.Context, code string) (err error) {
+	ctx, end := u.start(ctx, "ConfirmTOTP")
+	defer func() { end(err) }()
+	return u.next.ConfirmTOTP(ctx, code)
+}
+
+func (u *instrumentedMFAUsecase) DisableTOTP(ctx context.Context, code string) (err error) {
+	ctx, end := u.start(ctx, "DisableTOTP")
+	defer func() { end(err) }()
+	return u.next.DisableTOTP(ctx, code)
+}
+
+func (u *instrumentedMFAUsecase) CompleteLogin(ctx context.Context, mfaToken, code, ip string) (_ string, err error) {
+	ctx, end := u.start(ctx, "CompleteLogin")
+	defer func() {
+		end(err)
+		u.metrics.CountLogin(domain.LoginMethodMFA, domain.LoginResult(err))
+	}()
+	return u.next.CompleteLogin(ctx, mfaToken, code, ip)
+}
+
+func (u *instrumentedMFAUsecase) GetSecuritySettings(ctx context.Context) (_ *domain.SecuritySettings, err error) {
+	ctx, end := u.start(ctx, "GetSecuritySettings")
+	defer func() { end(err) }()
+	return u.next.GetSecuritySettings(ctx)
+}
+
+func (u *instrumentedMFAUsecase) UpdateSecuritySettings(ctx context.Context, settings domain.SecuritySettings) (err error) {
+	ctx, end := u.start(ctx, "UpdateSecuritySettings")
+	defer func() { end(err) }()
+	return u.next.UpdateSecuritySettings(ctx, settings)
+}
+
+type instrumentedOIDCUsecase struct {
+	next    domain.OIDCUsecase
+	metrics domain.Metrics
+}
+
+func InstrumentOIDCUsecase(next domain.OIDCUsecase, metrics domain.Metrics) domain.OIDCUsecase {
+	return &instrumentedOIDCUsecase{next: next, metrics: metrics}
+}
+
+func (u *instrumentedOIDCUsecase) start(ctx context.Context, name string) (context.Context, func(error)) {
+	return startOperation(ctx, u.metrics, "oidc", "OIDCUsecase", name)
+}
+
+func (u *instrumentedOIDCUsecase) BeginLogin(ctx context.Context) (_ *domain.OIDCAuthRequest, _ string, err error) {
+	ctx, end := u.start(ctx, "BeginLogin")
+	defer func() { end(err) }()
+	return u.next.BeginLogin(ctx)
+}
+
+func (u *instrumentedOIDCUsecase) CompleteLogin(ctx context.Context, req domain.OIDCAuthRequest, state, code string) (_ string, err error) {
+	ctx, end := u.start(ctx, "CompleteLogin")
+	defer func() {
+		end(err)
+		u.metrics.CountLogin(domain.LoginMethodOIDC, domain.LoginResult(err))
+	}()
+	return u.next.CompleteLogin(ctx, req, state, code)
+}
+
+type instrumentedProfileUsecase struct {
+	next    domain.ProfileUsecase
+	metrics domain.Metrics
+}
+
+func InstrumentProfileUsecase(next domain.ProfileUsecase, metrics domain.Metrics) domain.ProfileUsecase {
+	return &instrumentedProfileUsecase{next: next, metrics: metrics}
+}
+
+func (u *instrumentedProfileUsecase) start(ctx context.Context, name string) (context.Context, func(error)) {
+	return startOperation(ctx, u.metrics, "profile", "ProfileUsecase", name)
+}
+
+func (u *instrumentedProfileUsecase) GetProfile(ctx context.Context) (_ *domain.User, err error) {
+	ctx, end := u.start(ctx, "GetProfile")
+	defer func() { end(err) }()
+	return u.next.GetProfile(ctx)
+}
+
+func (u *instrumentedProfileUsecase) UpdateProfile(ctx context.Context, update domain.ProfileUpdate) (_ *domain.User, err error) {
+	ctx, end := u.start(ctx, "UpdateProfile")
+	defer func() { end(err) }()
+	return u.next.UpdateProfile(ctx, update)
+}
+
+type instrumentedPasswordUsecase struct {
+	next    domain.PasswordUsecase
+	metrics domain.Metrics
+}
+
+func InstrumentPasswordUsecase(next domain.PasswordUsecase, metrics domain.Metrics) domain.PasswordUsecase {
+	return &instrumentedPasswordUsecase{next: next, metrics: metrics}
+}
+
+func (u *instrumentedPasswordUsecase) start(ctx context.Context, name string) (context.Context, func(error)) {
+	return startOperation(ctx, u.metrics, "password", "PasswordUsecase", name)
+}
+
+func (u *instrumentedPasswordUsecase) ChangePassword(ctx context.Context, currentPassword, newPassword string) (err error) {
+	ctx, end := u.start(ctx, "ChangePassword")
+	defer func() { end(err) }()
+	return u.next.ChangePassword(ctx, currentPassword, newPassword)
+}
+
+func (u *instrumentedPasswordUsecase) ForgotPassword(ctx context.Context, email string) (err error) {
+	ctx, end := u.start(ctx, "ForgotPassword")
+	defer func() { end(err) }()
+	return u.next.ForgotPassword(ctx, email)
+}
+
+func (u *instrumentedPasswordUsecase) ResetPassword(ctx context.Context, token, newPassword string) (err error) {
+	ctx, end := u.start(ctx, "ResetPassword")
+	defer func() { end(err) }()
+	return u.next.ResetPassword(ctx, token, newPassword)
+}
+
+type instrumentedVerificationUsecase struct {
+	next    domain.VerificationUsecase
+	metrics domain.Metrics
+}
+
+func InstrumentVerificationUsecase(next domain.VerificationUsecase, metrics domain.Metrics) domain.VerificationUsecase {
+	return &instrumentedVerificationUsecase{next: next, metrics: metrics}
+}
+
+func (u *instrumentedVerificationUsecase) start(ctx context.Context, name string) (context.Context, func(error)) {
+	return startOperation(ctx, u.metrics, "verification", "VerificationUsecase", name)
+}
+
+func (u *instrumentedVerificationUsecase) SendVerification(ctx context.Context, user domain.User) (err error) {
+	ctx, end := u.start(ctx, "SendVerification")
+	defer func() { end(err) }()
+	return u.next.SendVerification(ctx, user)
+}
+
+func (u *instrumentedVerificationUsecase) VerifyEmail(ctx context.Context, token string) (err error) {
+	ctx, end := u.start(ctx, "VerifyEmail")
+	defer func() { end(err) }()
+	return u.next.VerifyEmail(ctx, token)
+}
+
+func (u *instrumentedVerificationUsecase) ResendVerification(ctx context.Context, email string) (err error) {
+	ctx, end := u.start(ctx, "ResendVerification")
+	defer func() { end(err) }()
+	return u.next.ResendVerification(ctx, email)
+}
+
+type instrumentedAPIKeyUsecase struct {
+	next    domain.APIKeyUsecase
+	metrics domain.Metrics
+}
+
+func InstrumentAPIKeyUsecase(next domain.APIKeyUsecase, metrics domain.Metrics) domain.APIKeyUsecase {
+	return &instrumentedAPIKeyUsecase{next: next, metrics: metrics}
+}
+
+func (u *instrumentedAPIKeyUsecase) start(ctx context.Context, name string) (context.Context, func(error)) {
+	return startOperation(ctx, u.metrics, "api_key", "APIKeyUsecase", name)
+}
+
+func (u *instrumentedAPIKeyUsecase) CreateAPIKey(ctx context.Context, name string, scopes []string, ttl time.Duration) (_ *domain.APIKey, _ string, err error) {
+	ctx, end := u.start(ctx, "CreateAPIKey")
+	defer func() { end(err) }()
+	return u.next.CreateAPIKey(ctx, name, scopes, ttl)
+}
+
+func (u *instrumentedAPIKeyUsecase) ListAPIKeys(ctx context.Context) (_ []domain.APIKey, err error) {
+	ctx, end := u.start(ctx, "ListAPIKeys")
+	defer func() { end(err) }()
+	return u.next.ListAPIKeys(ctx)
+}
+
+func (u *instrumentedAPIKeyUsecase) RevokeAPIKey(ctx context.Context, id string) (err error) {
+	ctx, end := u.start(ctx, "RevokeAPIKey")
+	defer func() { end(err) }()
+	return u.next.RevokeAPIKey(ctx, id)
+}
+
+func (u *instrumentedAPIKeyUsecase) Authenticate(ctx context.Context, key string) (_ *domain.User, _ *domain.APIKey, err error) {
+	ctx, end := u.start(ctx, "Authenticate")
+	defer func() { end(err) }()
+	return u.next.Authenticate(ctx, key)
+}
+
+type instrumentedUserAdminUsecase struct {
+	next    domain.UserAdminUsecase
+	metrics domain.Metrics
+}
+
+func InstrumentUserAdminUsecase(next domain.UserAdminUsecase, metrics domain.Metrics) domain.UserAdminUsecase {
+	return &instrumentedUserAdminUsecase{next: next, metrics: metrics}
+}
+
+func (u *instrumentedUserAdminUsecase) start(ctx context.Context, name string) (context.Context, func(error)) {
+	return startOperation(ctx, u.metrics, "user_admin", "UserAdminUsecase", name)
+}
+
+func (u *instrumentedUserAdminUsecase) ListUsers(ctx context.Context, query domain.UserQuery) (_ *domain.UserPage, err error) {
+	ctx, end := u.start(ctx, "ListUsers")
+	defer func() { end(err) }()
+	return u.next.ListUsers(ctx, query)
+}
+
+func (u *instrumentedUserAdminUsecase) GetUser(ctx context.Context, id string) (_ *domain.User, err error) {
+	ctx, end := u.start(ctx, "GetUser")
+	defer func() { end(err) }()
+	return u.next.GetUser(ctx, id)
+}
+
+func (u *instrumentedUserAdminUsecase) SetRole(ctx context.Context, id, role string) (err error) {
+	ctx, end := u.start(ctx, "SetRole")
+	defer func() { end(err) }()
+	return u.next.SetRole(ctx, id, role)
+}
+
+func (u *instrumentedUserAdminUsecase) SetDisabled(ctx context.Context, id string, disabled bool) (err error) {
+	ctx, end := u.start(ctx, "SetDisabled")
+	defer func() { end(err) }()
+	return u.next.SetDisabled(ctx, id, disabled)
+}
+
+func (u *instrumentedUserAdminUsecase) DeleteUser(ctx context.Context, id, reassignTo string) (err error) {
+	ctx, end := u.start(ctx, "DeleteUser")
+	defer func() { end(err) }()
+	return u.next.DeleteUser(ctx, id, reassignTo)
+}
+
+type instrumentedSessionUsecase struct {
+	next    domain.SessionUsecase
+	metrics domain.Metrics
+}
+
+func InstrumentSessionUsecase(next domain.SessionUsecase, metrics domain.Metrics) domain.SessionUsecase {
+	return &instrumentedSessionUsecase{next: next, metrics: metrics}
+}
+
+func (u *instrumentedSessionUsecase) start(ctx context.Context, name string) (context.Context, func(error)) {
+	return startOperation(ctx, u.metrics, "session", "SessionUsecase", name)
+}
+
+func (u *instrumentedSessionUsecase) StartSession(ctx context.Context) (_ *domain.Session, _ string, _ string, err error) {
+	ctx, end := u.start(ctx, "StartSession")
+	defer func() { end(err) }()
+	return u.next.StartSession(ctx)
+}
+
+func (u *instrumentedSessionUsecase) Authenticate(ctx context.Context, token string) (_ *domain.Session, err error) {
+	ctx, end := u.start(ctx, "Authenticate")
+	defer func() { end(err) }()
+	return u.next.Authenticate(ctx, token)
+}
+
+// VerifyCSRF only compares tokens, so it gets no span.
+func (u *instrumentedSessionUsecase) VerifyCSRF(session *domain.Session, csrfToken string) bool {
+	return u.next.VerifyCSRF(session, csrfToken)
+}
+
+func (u *instrumentedSessionUsecase) EndSession(ctx context.Context) (err error) {
+	ctx, end := u.start(ctx, "EndSession")
+	defer func() { end(err) }()
+	return u.next.EndSession(ctx)
+}
@@ -0,0 +1,87 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"task_manager/domain"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+type recordingMetrics struct {
+	domain.Metrics
+	operations []string
+	logins     []string
+}
+
+func (m *recordingMetrics) CountOperation(usecase, operation string, err error) {
+	m.operations = append(m.operations, usecase+"."+operation)
+}
+
+func (m *recordingMetrics) CountLogin(method, result string) {
+	m.logins = append(m.logins, method+":"+result)
+}
+
+type stubUserUsecase struct {
+	domain.UserUsecase
+	err error
+}
+
+func (u *stubUserUsecase) Login(ctx context.Context, username, password, ip string) (string, error) {
+	return "", u.err
+}
+
+func TestInstrumentedUserUsecase(t *testing.T) {
+	cases := map[string]error{
+		domain.LoginSucceeded:  nil,
+		domain.LoginNeedsMFA:   &domain.MFARequiredError{Token: "challenge"},
+		domain.LoginWasBlocked: &domain.LoginBlockedError{Until: time.Now()},
+		domain.LoginFailed:     errors.New("invalid credentials"),
+	}
+	for result, loginErr := range cases {
+		t.Run(result, func(t *testing.T) {
+			metrics := &recordingMetrics{}
+			_, err := InstrumentUserUsecase(&stubUserUsecase{err: loginErr}, metrics).Login(context.Background(), "alice", "secret", "127.0.0.1")
+			assert.Equal(t, loginErr, err)
+			assert.Equal(t, []string{"user.Login"}, metrics.operations)
+			assert.Equal(t, []string{"password:" + result}, metrics.logins)
+		})
+	}
+}
+
+type stubSessionUsecase struct {
+	domain.SessionUsecase
+}
+
+func (u stubSessionUsecase) EndSession(ctx context.Context) error { return nil }
+
+func (u stubSessionUsecase) VerifyCSRF(session *domain.Session, csrfToken string) bool {
+	return csrfToken == "csrf"
+}
+
+func TestInstrumentedSpans(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+
+	loginErr := errors.New("invalid credentials")
+	InstrumentUserUsecase(&stubUserUsecase{err: loginErr}, &recordingMetrics{}).Login(context.Background(), "alice", "secret", "127.0.0.1")
+
+	// VerifyCSRF only compares tokens and gets no span.
+	sessions := InstrumentSessionUsecase(stubSessionUsecase{}, &recordingMetrics{})
+	sessions.EndSession(context.Background())
+	assert.True(t, sessions.VerifyCSRF(&domain.Session{}, "csrf"))
+
+	spans := recorder.Ended()
+	if assert.Len(t, spans, 2) {
+		assert.Equal(t, "UserUsecase.Login", spans[0].Name())
+		assert.Equal(t, codes.Error, spans[0].Status().Code)
+		assert.Equal(t, loginErr.Error(), spans[0].Status().Description)
+		assert.Equal(t, "SessionUsecase.EndSession", spans[1].Name())
+	}
+}
@@ -0,0 +1,114 @@
+package usecases
+
+import (
+	"context"
+	"task_manager/domain"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// dummyPasswordHash is compared against when the username does not exist, so
+// unknown and known usernames take the same bcrypt time to reject.
+const dummyPasswordHash = "$2a$10$u11hJLLC0qiy2kES9lbi5eCC88CIYkPoLcCEy9u2YELVbu3KZ3cvG"
+
+const (
+	maxLoginDelay     = 30 * time.Second
+	loginLockDuration = 15 * time.Minute
+)
+
+// loginGuard is the throttling policy for one kind of key. After delayAfter
+// failures every further attempt has to wait twice as long as the previous one;
+// reaching lockAfter locks the key for loginLockDuration.
+type loginGuard struct {
+	key        string
+	delayAfter int
+	lockAfter  int
+}
+
+// loginGuards tracks the username being attacked and the address attacking it.
+// The IP limits are looser because many users can share one address.
+func loginGuards(username, ip string) []loginGuard {
+	return []loginGuard{
+		{key: userLoginKey(username), delayAfter: 3, lockAfter: 5},
+		{key: ipLoginKey(ip), delayAfter: 10, lockAfter: 20},
+	}
+}
+
+func userLoginKey(username string) string { return "user:" + username }
+
+func ipLoginKey(ip string) string { return "ip:" + ip }
+
+func (g loginGuard) delay(failures int) time.Duration {
+	if failures < g.delayAfter {
+		return 0
+	}
+	delay := time.Second << uint(failures-g.delayAfter)
+	if delay > maxLoginDelay || delay <= 0 {
+		return maxLoginDelay
+	}
+	return delay
+}
+
+// checkLoginAllowed returns a *domain.LoginBlockedError while any guard is locked or cooling down.
+func checkLoginAllowed(ctx context.Context, userRepo domain.UserRepository, guards []loginGuard, now time.Time) error {
+	for _, g := range guards {
+		attempts, err := userRepo.GetLoginAttempts(ctx, g.key)
+		if err != nil {
+			return err
+		}
+		if attempts == nil {
+			continue
+		}
+		if attempts.LockedUntil.After(now) {
+			return &domain.LoginBlockedError{Until: attempts.LockedUntil, Locked: true}
+		}
+		if next := attempts.LastFailure.Add(g.delay(attempts.Failures)); now.Before(next) {
+			return &domain.LoginBlockedError{Until: next}
+		}
+	}
+	return nil
+}
+
+// recordLoginFailure counts the failure against every guard and locks the ones
+// that crossed their threshold. Errors are only logged: the caller already has
+// a failed login to report.
+func recordLoginFailure(ctx context.Context, userRepo domain.UserRepository, guards []loginGuard, username, ip string, now time.Time) {
+	for _, g := range guards {
+		attempts, err := userRepo.RecordFailedLogin(ctx, g.key, now)
+		if err != nil {
+			domain.Logger(ctx).Error("record failed login", "error", err)
+			continue
+		}
+		if attempts.Failures < g.lockAfter {
+			continue
+		}
+
+		until := now.Add(loginLockDuration)
+		if err := userRepo.LockLogin(ctx, g.key, until); err != nil {
+			domain.Logger(ctx).Error("lock login", "error", err)
+			continue
+		}
+		event := domain.LockEvent{
+			ID:          uuid.New().String(),
+			Key:         g.key,
+			Username:    username,
+			IP:          ip,
+			Failures:    attempts.Failures,
+			LockedAt:    now,
+			LockedUntil: until,
+		}
+		domain.Logger(ctx).Warn("login locked", "key", g.key, "failures", attempts.Failures, "until", until)
+		if err := userRepo.RecordLockEvent(ctx, event); err != nil {
+			domain.Logger(ctx).Error("record lock event", "error", err)
+		}
+	}
+}
+
+// recordLogin stamps a successful login. Like failures, it only logs errors:
+// a bookkeeping write should not turn a good login into a failed one.
+func recordLogin(ctx context.Context, userRepo domain.UserRepository, userID string, now time.Time) {
+	if err := userRepo.RecordLogin(ctx, userID, now); err != nil {
+		domain.Logger(ctx).Error("record login", "user_id", userID, "error", err)
+	}
+}
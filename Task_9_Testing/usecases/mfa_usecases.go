@@ -0,0 +1,228 @@
+package usecases
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base32"
+	"errors"
+	"strings"
+	"task_manager/domain"
+	"time"
+)
+
+const (
+	mfaChallengeTTL   = 5 * time.Minute
+	recoveryCodeCount = 10
+)
+
+type MFAUsecaseImpl struct {
+	userRepo     domain.UserRepository
+	settingsRepo domain.SettingsRepository
+	totpSvc      domain.TOTPService
+	jwtSvc       domain.JWTService
+	now          func() time.Time
+}
+
+func NewMFAUsecase(userRepo domain.UserRepository, settingsRepo domain.SettingsRepository, totpSvc domain.TOTPService, jwtSvc domain.JWTService) domain.MFAUsecase {
+	return &MFAUsecaseImpl{
+		userRepo:     userRepo,
+		settingsRepo: settingsRepo,
+		totpSvc:      totpSvc,
+		jwtSvc:       jwtSvc,
+		now:          time.Now,
+	}
+}
+
+// EnrollTOTP starts (or restarts) enrolment. The secret is inactive until
+// ConfirmTOTP sees a code generated from it.
+func (u *MFAUsecaseImpl) EnrollTOTP(ctx context.Context) (*domain.TOTPEnrollment, error) {
+	user, err := u.caller(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if user.TOTPEnabled {
+		return nil, domain.ErrMFAAlreadyEnabled
+	}
+
+	secret, uri, err := u.totpSvc.GenerateSecret(user.Username)
+	if err != nil {
+		return nil, err
+	}
+	qr, err := u.totpSvc.QRCode(uri)
+	if err != nil {
+		return nil, err
+	}
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	if err := u.userRepo.SetTOTPSecret(ctx, user.ID, secret, hashes); err != nil {
+		return nil, err
+	}
+	return &domain.TOTPEnrollment{Secret: secret, URI: uri, QRCodePNG: qr, RecoveryCodes: codes}, nil
+}
+
+func (u *MFAUsecaseImpl) ConfirmTOTP(ctx context.Context, code string) error {
+	user, err := u.caller(ctx)
+	if err != nil {
+		return err
+	}
+	if user.TOTPEnabled {
+		return domain.ErrMFAAlreadyEnabled
+	}
+	if user.TOTPSecret == "" {
+		return domain.ErrMFANotEnrolled
+	}
+	ok, err := u.checkTOTP(ctx, user, code)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return domain.ErrInvalidMFACode
+	}
+	return u.userRepo.EnableTOTP(ctx, user.ID)
+}
+
+// DisableTOTP needs a current code or a recovery code, not just a session.
+func (u *MFAUsecaseImpl) DisableTOTP(ctx context.Context, code string) error {
+	user, err := u.caller(ctx)
+	if err != nil {
+		return err
+	}
+	if !user.TOTPEnabled {
+		return domain.ErrMFANotEnrolled
+	}
+	ok, err := u.checkSecondFactor(ctx, user, code)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return domain.ErrInvalidMFACode
+	}
+	return u.userRepo.DisableTOTP(ctx, user.ID)
+}
+
+// CompleteLogin exchanges the intermediate token from Login plus a code for a
+// session token. Wrong codes count against the same lockout as wrong passwords.
+func (u *MFAUsecaseImpl) CompleteLogin(ctx context.Context, mfaToken, code, ip string) (string, error) {
+	now := u.now()
+	tokenHash := hashToken(mfaToken)
+
+	challenge, err := u.userRepo.FindMFAChallenge(ctx, tokenHash)
+	if err != nil {
+		return "", err
+	}
+	if challenge == nil || !challenge.ExpiresAt.After(now) {
+		return "", domain.ErrInvalidMFAToken
+	}
+	user, err := u.userRepo.FindUserByID(ctx, challenge.UserID)
+	if err != nil {
+		return "", err
+	}
+	if user == nil || !user.TOTPEnabled {
+		return "", domain.ErrInvalidMFAToken
+	}
+
+	guards := loginGuards(user.Username, ip)
+	if err := checkLoginAllowed(ctx, u.userRepo, guards, now); err != nil {
+		return "", err
+	}
+	ok, err := u.checkSecondFactor(ctx, user, code)
+	if err != nil {
+		return "", err
+	}
+	if !ok {
+		recordLoginFailure(ctx, u.userRepo, guards, user.Username, ip, now)
+		return "", domain.ErrInvalidMFACode
+	}
+	if user.Disabled {
+		return "", domain.ErrAccountDisabled
+	}
+
+	consumed, err := u.userRepo.ConsumeMFAChallenge(ctx, tokenHash, now)
+	if err != nil {
+		return "", err
+	}
+	if consumed == nil {
+		return "", domain.ErrInvalidMFAToken
+	}
+	if err := u.userRepo.ResetLoginAttempts(ctx, userLoginKey(user.Username)); err != nil {
+		return "", err
+	}
+	amr := []string{"pwd"}
+	if len(challenge.AMR) > 0 {
+		amr = challenge.AMR
+	}
+	token, err := u.jwtSvc.GenerateToken(user.ID, user.Username, user.Role, append(amr, "otp")...)
+	if err != nil {
+		return "", err
+	}
+	recordLogin(ctx, u.userRepo, user.ID, now)
+	return token, nil
+}
+
+func (u *MFAUsecaseImpl) GetSecuritySettings(ctx context.Context) (*domain.SecuritySettings, error) {
+	return u.settingsRepo.GetSecuritySettings(ctx)
+}
+
+func (u *MFAUsecaseImpl) UpdateSecuritySettings(ctx context.Context, settings domain.SecuritySettings) error {
+	return u.settingsRepo.SaveSecuritySettings(ctx, settings)
+}
+
+// caller loads the user behind the principal in ctx.
+func (u *MFAUsecaseImpl) caller(ctx context.Context) (*domain.User, error) {
+	userID, err := domain.CallerID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	user, err := u.userRepo.FindUserByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, errors.New("user not found")
+	}
+	return user, nil
+}
+
+// checkTOTP accepts a code at most once per time step.
+func (u *MFAUsecaseImpl) checkTOTP(ctx context.Context, user *domain.User, code string) (bool, error) {
+	step, ok := u.totpSvc.MatchStep(user.TOTPSecret, strings.TrimSpace(code), u.now())
+	if !ok {
+		return false, nil
+	}
+	return u.userRepo.AdvanceTOTPStep(ctx, user.ID, step)
+}
+
+// checkSecondFactor accepts either an authenticator code or an unused recovery code.
+func (u *MFAUsecaseImpl) checkSecondFactor(ctx context.Context, user *domain.User, code string) (bool, error) {
+	ok, err := u.checkTOTP(ctx, user, code)
+	if err != nil || ok {
+		return ok, err
+	}
+	return u.userRepo.ConsumeRecoveryCode(ctx, user.ID, hashToken(normalizeRecoveryCode(code)))
+}
+
+// newRecoveryCodes returns codes shaped like "abcde-fghij" together with the
+// hashes that get stored.
+func newRecoveryCodes() (codes, hashes []string, err error) {
+	for i := 0; i < recoveryCodeCount; i++ {
+		b := make([]byte, 7)
+		if _, err := rand.Read(b); err != nil {
+			return nil, nil, err
+		}
+		raw := strings.ToLower(base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(b))[:10]
+		code := raw[:5] + "-" + raw[5:]
+		codes = append(codes, code)
+		hashes = append(hashes, hashToken(code))
+	}
+	return codes, hashes, nil
+}
+
+func normalizeRecoveryCode(code string) string {
+	code = strings.ToLower(strings.ReplaceAll(strings.TrimSpace(code), " ", ""))
+	if len(code) == 10 && !strings.Contains(code, "-") {
+		code = code[:5] + "-" + code[5:]
+	}
+	return code
+}
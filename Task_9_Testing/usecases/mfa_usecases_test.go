@@ -0,0 +1,220 @@
+package usecases
+
+import (
+	"context"
+	"task_manager/domain"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+type MockTOTPService struct {
+	mock.Mock
+}
+
+func (m *MockTOTPService) GenerateSecret(accountName string) (string, string, error) {
+	args := m.Called(accountName)
+	return args.String(0), args.String(1), args.Error(2)
+}
+
+func (m *MockTOTPService) QRCode(uri string) ([]byte, error) {
+	args := m.Called(uri)
+	return args.Get(0).([]byte), args.Error(1)
+}
+
+func (m *MockTOTPService) MatchStep(secret, code string, at time.Time) (int64, bool) {
+	args := m.Called(secret, code, at)
+	return args.Get(0).(int64), args.Bool(1)
+}
+
+type MockSettingsRepository struct {
+	mock.Mock
+}
+
+func (m *MockSettingsRepository) GetSecuritySettings(ctx context.Context) (*domain.SecuritySettings, error) {
+	args := m.Called(ctx)
+	return args.Get(0).(*domain.SecuritySettings), args.Error(1)
+}
+
+func (m *MockSettingsRepository) SaveSecuritySettings(ctx context.Context, settings domain.SecuritySettings) error {
+	return m.Called(ctx, settings).Error(0)
+}
+
+type MFAUsecaseTestSuite struct {
+	suite.Suite
+	mockRepo     *MockUserRepository
+	mockSettings *MockSettingsRepository
+	mockTOTP     *MockTOTPService
+	mockJWT      *MockJWTService
+	usecase      domain.MFAUsecase
+	ctx          context.Context
+	now          time.Time
+}
+
+func (s *MFAUsecaseTestSuite) SetupTest() {
+	s.mockRepo = &MockUserRepository{}
+	s.mockSettings = &MockSettingsRepository{}
+	s.mockTOTP = &MockTOTPService{}
+	s.mockJWT = &MockJWTService{}
+	s.usecase = NewMFAUsecase(s.mockRepo, s.mockSettings, s.mockTOTP, s.mockJWT)
+	s.ctx = domain.WithPrincipal(context.Background(), &domain.Principal{UserID: "1", Username: "testuser"})
+	s.now = time.Date(2025, 4, 3, 12, 0, 0, 0, time.UTC)
+	s.usecase.(*MFAUsecaseImpl).now = func() time.Time { return s.now }
+}
+
+func (s *MFAUsecaseTestSuite) TearDownTest() {
+	s.mockRepo.AssertExpectations(s.T())
+	s.mockSettings.AssertExpectations(s.T())
+	s.mockTOTP.AssertExpectations(s.T())
+	s.mockJWT.AssertExpectations(s.T())
+}
+
+func (s *MFAUsecaseTestSuite) enrolledUser() *domain.User {
+	return &domain.User{ID: "1", Username: "testuser", Role: "admin", TOTPSecret: "SECRET", TOTPEnabled: true}
+}
+
+func (s *MFAUsecaseTestSuite) TestEnrollTOTP() {
+	s.Run("Success", func() {
+		s.mockRepo.On("FindUserByID", s.ctx, "1").Return(&domain.User{ID: "1", Username: "testuser"}, nil).Once()
+		s.mockTOTP.On("GenerateSecret", "testuser").Return("SECRET", "otpauth://totp/x", nil).Once()
+		s.mockTOTP.On("QRCode", "otpauth://totp/x").Return([]byte("png"), nil).Once()
+		s.mockRepo.On("SetTOTPSecret", s.ctx, "1", "SECRET", mock.MatchedBy(func(h []string) bool { return len(h) == recoveryCodeCount })).Return(nil).Once()
+
+		enrollment, err := s.usecase.EnrollTOTP(s.ctx)
+		s.NoError(err)
+		s.Equal("SECRET", enrollment.Secret)
+		s.Len(enrollment.RecoveryCodes, recoveryCodeCount)
+		s.Regexp(`^[a-z2-7]{5}-[a-z2-7]{5}$`, enrollment.RecoveryCodes[0])
+	})
+
+	s.Run("AlreadyEnabled", func() {
+		s.mockRepo.On("FindUserByID", s.ctx, "1").Return(s.enrolledUser(), nil).Once()
+
+		_, err := s.usecase.EnrollTOTP(s.ctx)
+		s.ErrorIs(err, domain.ErrMFAAlreadyEnabled)
+	})
+}
+
+func (s *MFAUsecaseTestSuite) TestConfirmTOTP() {
+	pending := &domain.User{ID: "1", Username: "testuser", TOTPSecret: "SECRET"}
+
+	s.Run("Success", func() {
+		s.mockRepo.On("FindUserByID", s.ctx, "1").Return(pending, nil).Once()
+		s.mockTOTP.On("MatchStep", "SECRET", "123456", s.now).Return(int64(100), true).Once()
+		s.mockRepo.On("AdvanceTOTPStep", s.ctx, "1", int64(100)).Return(true, nil).Once()
+		s.mockRepo.On("EnableTOTP", s.ctx, "1").Return(nil).Once()
+
+		s.NoError(s.usecase.ConfirmTOTP(s.ctx, " 123456 "))
+	})
+
+	s.Run("WrongCode", func() {
+		s.mockRepo.On("FindUserByID", s.ctx, "1").Return(pending, nil).Once()
+		s.mockTOTP.On("MatchStep", "SECRET", "000000", s.now).Return(int64(0), false).Once()
+
+		s.ErrorIs(s.usecase.ConfirmTOTP(s.ctx, "000000"), domain.ErrInvalidMFACode)
+	})
+
+	s.Run("NotEnrolled", func() {
+		s.mockRepo.On("FindUserByID", s.ctx, "1").Return(&domain.User{ID: "1"}, nil).Once()
+
+		s.ErrorIs(s.usecase.ConfirmTOTP(s.ctx, "123456"), domain.ErrMFANotEnrolled)
+	})
+}
+
+func (s *MFAUsecaseTestSuite) TestDisableTOTP() {
+	s.Run("WithRecoveryCode", func() {
+		s.mockRepo.On("FindUserByID", s.ctx, "1").Return(s.enrolledUser(), nil).Once()
+		s.mockTOTP.On("MatchStep", "SECRET", "ABCDE FGHIJ", s.now).Return(int64(0), false).Once()
+		s.mockRepo.On("ConsumeRecoveryCode", s.ctx, "1", hashToken("abcde-fghij")).Return(true, nil).Once()
+		s.mockRepo.On("DisableTOTP", s.ctx, "1").Return(nil).Once()
+
+		s.NoError(s.usecase.DisableTOTP(s.ctx, "ABCDE FGHIJ"))
+	})
+
+	s.Run("WrongCode", func() {
+		s.mockRepo.On("FindUserByID", s.ctx, "1").Return(s.enrolledUser(), nil).Once()
+		s.mockTOTP.On("MatchStep", "SECRET", "000000", s.now).Return(int64(0), false).Once()
+		s.mockRepo.On("ConsumeRecoveryCode", s.ctx, "1", hashToken("000000")).Return(false, nil).Once()
+
+		s.ErrorIs(s.usecase.DisableTOTP(s.ctx, "000000"), domain.ErrInvalidMFACode)
+	})
+}
+
+func (s *MFAUsecaseTestSuite) TestCompleteLogin() {
+	noAttempts := (*domain.LoginAttempts)(nil)
+	challenge := &domain.MFAChallenge{TokenHash: hashToken("mfa-token"), UserID: "1", ExpiresAt: s.now.Add(time.Minute)}
+
+	s.Run("Success", func() {
+		s.mockRepo.On("FindMFAChallenge", s.ctx, challenge.TokenHash).Return(challenge, nil).Once()
+		s.mockRepo.On("FindUserByID", s.ctx, "1").Return(s.enrolledUser(), nil).Once()
+		s.mockRepo.On("GetLoginAttempts", s.ctx, "user:testuser").Return(noAttempts, nil).Once()
+		s.mockRepo.On("GetLoginAttempts", s.ctx, "ip:10.0.0.1").Return(noAttempts, nil).Once()
+		s.mockTOTP.On("MatchStep", "SECRET", "123456", s.now).Return(int64(100), true).Once()
+		s.mockRepo.On("AdvanceTOTPStep", s.ctx, "1", int64(100)).Return(true, nil).Once()
+		s.mockRepo.On("ConsumeMFAChallenge", s.ctx, challenge.TokenHash, s.now).Return(challenge, nil).Once()
+		s.mockRepo.On("ResetLoginAttempts", s.ctx, "user:testuser").Return(nil).Once()
+		s.mockJWT.On("GenerateToken", "1", "testuser", "admin", []string{"pwd", "otp"}).Return("token", nil).Once()
+		s.mockRepo.On("RecordLogin", s.ctx, "1", s.now).Return(nil).Once()
+
+		token, err := s.usecase.CompleteLogin(s.ctx, "mfa-token", "123456", "10.0.0.1")
+		s.NoError(err)
+		s.Equal("token", token)
+	})
+
+	s.Run("AfterSingleSignOn", func() {
+		ssoChallenge := &domain.MFAChallenge{TokenHash: challenge.TokenHash, UserID: "1", ExpiresAt: challenge.ExpiresAt, AMR: []string{"oidc"}}
+		s.mockRepo.On("FindMFAChallenge", s.ctx, challenge.TokenHash).Return(ssoChallenge, nil).Once()
+		s.mockRepo.On("FindUserByID", s.ctx, "1").Return(s.enrolledUser(), nil).Once()
+		s.mockRepo.On("GetLoginAttempts", s.ctx, "user:testuser").Return(noAttempts, nil).Once()
+		s.mockRepo.On("GetLoginAttempts", s.ctx, "ip:10.0.0.1").Return(noAttempts, nil).Once()
+		s.mockTOTP.On("MatchStep", "SECRET", "123456", s.now).Return(int64(100), true).Once()
+		s.mockRepo.On("AdvanceTOTPStep", s.ctx, "1", int64(100)).Return(true, nil).Once()
+		s.mockRepo.On("ConsumeMFAChallenge", s.ctx, challenge.TokenHash, s.now).Return(ssoChallenge, nil).Once()
+		s.mockRepo.On("ResetLoginAttempts", s.ctx, "user:testuser").Return(nil).Once()
+		s.mockJWT.On("GenerateToken", "1", "testuser", "admin", []string{"oidc", "otp"}).Return("token", nil).Once()
+		s.mockRepo.On("RecordLogin", s.ctx, "1", s.now).Return(nil).Once()
+
+		_, err := s.usecase.CompleteLogin(s.ctx, "mfa-token", "123456", "10.0.0.1")
+		s.NoError(err)
+	})
+
+	s.Run("ReplayedCode", func() {
+		s.mockRepo.On("FindMFAChallenge", s.ctx, challenge.TokenHash).Return(challenge, nil).Once()
+		s.mockRepo.On("FindUserByID", s.ctx, "1").Return(s.enrolledUser(), nil).Once()
+		s.mockRepo.On("GetLoginAttempts", s.ctx, "user:testuser").Return(noAttempts, nil).Once()
+		s.mockRepo.On("GetLoginAttempts", s.ctx, "ip:10.0.0.1").Return(noAttempts, nil).Once()
+		s.mockTOTP.On("MatchStep", "SECRET", "123456", s.now).Return(int64(100), true).Once()
+		s.mockRepo.On("AdvanceTOTPStep", s.ctx, "1", int64(100)).Return(false, nil).Once()
+		s.mockRepo.On("ConsumeRecoveryCode", s.ctx, "1", hashToken("123456")).Return(false, nil).Once()
+		s.mockRepo.On("RecordFailedLogin", s.ctx, "user:testuser", s.now).Return(&domain.LoginAttempts{Failures: 1}, nil).Once()
+		s.mockRepo.On("RecordFailedLogin", s.ctx, "ip:10.0.0.1", s.now).Return(&domain.LoginAttempts{Failures: 1}, nil).Once()
+
+		_, err := s.usecase.CompleteLogin(s.ctx, "mfa-token", "123456", "10.0.0.1")
+		s.ErrorIs(err, domain.ErrInvalidMFACode)
+	})
+
+	s.Run("ExpiredChallenge", func() {
+		expired := &domain.MFAChallenge{TokenHash: challenge.TokenHash, UserID: "1", ExpiresAt: s.now.Add(-time.Second)}
+		s.mockRepo.On("FindMFAChallenge", s.ctx, challenge.TokenHash).Return(expired, nil).Once()
+
+		_, err := s.usecase.CompleteLogin(s.ctx, "mfa-token", "123456", "10.0.0.1")
+		s.ErrorIs(err, domain.ErrInvalidMFAToken)
+	})
+}
+
+func (s *MFAUsecaseTestSuite) TestSecuritySettings() {
+	settings := domain.SecuritySettings{RequireAdminMFA: true}
+	s.mockSettings.On("SaveSecuritySettings", s.ctx, settings).Return(nil).Once()
+	s.mockSettings.On("GetSecuritySettings", s.ctx).Return(&settings, nil).Once()
+
+	s.NoError(s.usecase.UpdateSecuritySettings(s.ctx, settings))
+	result, err := s.usecase.GetSecuritySettings(s.ctx)
+	s.NoError(err)
+	s.True(result.RequireAdminMFA)
+}
+
+func TestMFAUsecaseSuite(t *testing.T) {
+	suite.Run(t, new(MFAUsecaseTestSuite))
+}
@@ -0,0 +1,220 @@
+package usecases
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"strings"
+	"task_manager/domain"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// maxUsernameAttempts bounds the search for a free username when provisioning.
+const maxUsernameAttempts = 5
+
+type OIDCUsecaseImpl struct {
+	provider   domain.OIDCProvider
+	userRepo   domain.UserRepository
+	jwtSvc     domain.JWTService
+	adminRoles []string
+	trustAMR   bool
+	now        func() time.Time
+}
+
+// OIDCUsecaseOption configures optional behaviour of NewOIDCUsecase.
+type OIDCUsecaseOption func(*OIDCUsecaseImpl)
+
+// WithTrustedAMR passes the provider's amr values into our tokens, so an
+// admin who did MFA at the provider meets the admin two-factor policy. Only
+// enable it for a provider whose amr claim you trust.
+func WithTrustedAMR() OIDCUsecaseOption {
+	return func(u *OIDCUsecaseImpl) {
+		u.trustAMR = true
+	}
+}
+
+// NewOIDCUsecase maps IdP roles onto ours: a user whose role claim contains
+// one of adminRoles is an admin, everyone else a plain user, re-evaluated on
+// every login. With no adminRoles, roles are managed locally instead.
+func NewOIDCUsecase(provider domain.OIDCProvider, userRepo domain.UserRepository, jwtSvc domain.JWTService, adminRoles []string, opts ...OIDCUsecaseOption) domain.OIDCUsecase {
+	u := &OIDCUsecaseImpl{provider: provider, userRepo: userRepo, jwtSvc: jwtSvc, adminRoles: adminRoles, now: time.Now}
+	for _, opt := range opts {
+		opt(u)
+	}
+	return u
+}
+
+func (u *OIDCUsecaseImpl) BeginLogin(ctx context.Context) (*domain.OIDCAuthRequest, string, error) {
+	var req domain.OIDCAuthRequest
+	for _, field := range []*string{&req.State, &req.Nonce, &req.Verifier} {
+		token, err := newSecretToken()
+		if err != nil {
+			return nil, "", err
+		}
+		*field = token
+	}
+	return &req, u.provider.AuthCodeURL(req), nil
+}
+
+// CompleteLogin finds the local account for the identity, linking or creating
+// it on first use, and issues our own session token. An account with
+// two-factor authentication gets an MFARequiredError instead, as with a
+// password login: the provider only replaces the password.
+func (u *OIDCUsecaseImpl) CompleteLogin(ctx context.Context, req domain.OIDCAuthRequest, state, code string) (string, error) {
+	if req.State == "" || subtle.ConstantTimeCompare([]byte(state), []byte(req.State)) != 1 {
+		return "", fmt.Errorf("%w: state mismatch", domain.ErrOIDCLoginFailed)
+	}
+	identity, err := u.provider.Exchange(ctx, code, req)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", domain.ErrOIDCLoginFailed, err)
+	}
+	if identity.Issuer == "" || identity.Subject == "" {
+		return "", fmt.Errorf("%w: identity has no subject", domain.ErrOIDCLoginFailed)
+	}
+
+	now := u.now()
+	user, err := u.findOrProvision(ctx, identity, now)
+	if err != nil {
+		return "", err
+	}
+	if user.Disabled {
+		return "", domain.ErrAccountDisabled
+	}
+	if len(u.adminRoles) > 0 {
+		if role := u.mapRole(identity); role != user.Role {
+			if err := u.userRepo.SetRole(ctx, user.ID, role); err != nil {
+				return "", err
+			}
+			user.Role = role
+		}
+	}
+
+	if user.TOTPEnabled {
+		return "", startMFAChallenge(ctx, u.userRepo, user, now, "oidc")
+	}
+
+	amr := []string{"oidc"}
+	if u.trustAMR {
+		amr = append(amr, identity.AMR...)
+	}
+	token, err := u.jwtSvc.GenerateToken(user.ID, user.Username, user.Role, amr...)
+	if err != nil {
+		return "", err
+	}
+	recordLogin(ctx, u.userRepo, user.ID, now)
+	return token, nil
+}
+
+// findOrProvision links by verified email only: both the provider and our own
+// record must vouch for the address, or anyone able to register an address
+// at either end could take over the other account.
+func (u *OIDCUsecaseImpl) findOrProvision(ctx context.Context, identity *domain.OIDCIdentity, now time.Time) (*domain.User, error) {
+	user, err := u.userRepo.FindUserByOIDCSubject(ctx, identity.Issuer, identity.Subject)
+	if err != nil || user != nil {
+		return user, err
+	}
+
+	if identity.Email != "" {
+		existing, err := u.userRepo.FindUserByEmail(ctx, identity.Email)
+		if err != nil {
+			return nil, err
+		}
+		if existing != nil {
+			if !identity.EmailVerified || !existing.Verified {
+				return nil, domain.ErrOIDCEmailConflict
+			}
+			if err := u.userRepo.LinkOIDCIdentity(ctx, existing.ID, identity.Issuer, identity.Subject); err != nil {
+				return nil, err
+			}
+			existing.OIDCIssuer, existing.OIDCSubject = identity.Issuer, identity.Subject
+			return existing, nil
+		}
+	}
+
+	username, err := u.freeUsername(ctx, identity)
+	if err != nil {
+		return nil, err
+	}
+	role := domain.RoleUser
+	if len(u.adminRoles) > 0 {
+		role = u.mapRole(identity)
+	} else if isFirst, err := u.userRepo.IsFirstUser(ctx); err != nil {
+		return nil, err
+	} else if isFirst {
+		role = domain.RoleAdmin
+	}
+	// No password is set: the account signs in through the provider until the
+	// user sets one with the password reset flow.
+	user = &domain.User{
+		ID:          uuid.New().String(),
+		Username:    username,
+		Role:        role,
+		Email:       identity.Email,
+		Verified:    identity.Email != "" && identity.EmailVerified,
+		DisplayName: identity.Name,
+		CreatedAt:   now,
+		OIDCIssuer:  identity.Issuer,
+		OIDCSubject: identity.Subject,
+	}
+	if err := u.userRepo.CreateUser(ctx, *user); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+func (u *OIDCUsecaseImpl) mapRole(identity *domain.OIDCIdentity) string {
+	for _, role := range identity.Roles {
+		if contains(u.adminRoles, role) {
+			return domain.RoleAdmin
+		}
+	}
+	return domain.RoleUser
+}
+
+// freeUsername derives a username from the preferred username or the email,
+// adding a random suffix while the name is taken.
+func (u *OIDCUsecaseImpl) freeUsername(ctx context.Context, identity *domain.OIDCIdentity) (string, error) {
+	base := sanitizeUsername(identity.PreferredUsername)
+	if base == "" {
+		local, _, _ := strings.Cut(identity.Email, "@")
+		base = sanitizeUsername(local)
+	}
+	if len(base) < minUsernameLength {
+		base = "user"
+	}
+
+	candidate := base
+	for i := 0; i < maxUsernameAttempts; i++ {
+		existing, err := u.userRepo.FindUserByUsername(ctx, candidate)
+		if err != nil {
+			return "", err
+		}
+		if existing == nil {
+			return candidate, nil
+		}
+		suffix := uuid.New().String()[:6]
+		candidate = truncate(base, maxUsernameLength-len(suffix)-1) + "-" + suffix
+	}
+	return "", errors.New("could not find a free username")
+}
+
+// sanitizeUsername drops the characters usernamePattern does not allow.
+func sanitizeUsername(name string) string {
+	var b strings.Builder
+	for _, r := range name {
+		if r < 128 && usernamePattern.MatchString(string(r)) {
+			b.WriteRune(r)
+		}
+	}
+	return truncate(b.String(), maxUsernameLength)
+}
+
+func truncate(s string, n int) string {
+	if len(s) > n {
+		return s[:n]
+	}
+	return s
+}
@@ -0,0 +1,239 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"task_manager/domain"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+type MockOIDCProvider struct {
+	mock.Mock
+}
+
+func (m *MockOIDCProvider) AuthCodeURL(req domain.OIDCAuthRequest) string {
+	return m.Called(req).String(0)
+}
+
+func (m *MockOIDCProvider) Exchange(ctx context.Context, code string, req domain.OIDCAuthRequest) (*domain.OIDCIdentity, error) {
+	args := m.Called(ctx, code, req)
+	return args.Get(0).(*domain.OIDCIdentity), args.Error(1)
+}
+
+type OIDCUsecaseTestSuite struct {
+	suite.Suite
+	mockProvider *MockOIDCProvider
+	mockRepo     *MockUserRepository
+	mockJWT      *MockJWTService
+	usecase      domain.OIDCUsecase
+	ctx          context.Context
+	now          time.Time
+	req          domain.OIDCAuthRequest
+}
+
+func (s *OIDCUsecaseTestSuite) SetupTest() {
+	s.mockProvider = &MockOIDCProvider{}
+	s.mockRepo = &MockUserRepository{}
+	s.mockJWT = &MockJWTService{}
+	s.usecase = NewOIDCUsecase(s.mockProvider, s.mockRepo, s.mockJWT, []string{"task-admins"})
+	s.ctx = context.Background()
+	s.now = time.Date(2025, 4, 3, 12, 0, 0, 0, time.UTC)
+	s.usecase.(*OIDCUsecaseImpl).now = func() time.Time { return s.now }
+	s.req = domain.OIDCAuthRequest{State: "state", Nonce: "nonce", Verifier: "verifier"}
+}
+
+func (s *OIDCUsecaseTestSuite) TearDownTest() {
+	s.mockProvider.AssertExpectations(s.T())
+	s.mockRepo.AssertExpectations(s.T())
+	s.mockJWT.AssertExpectations(s.T())
+}
+
+func (s *OIDCUsecaseTestSuite) identity() *domain.OIDCIdentity {
+	return &domain.OIDCIdentity{
+		Issuer:            "https://idp.example.com",
+		Subject:           "sub-1",
+		Email:             "alice@example.com",
+		EmailVerified:     true,
+		Name:              "Alice",
+		PreferredUsername: "alice",
+	}
+}
+
+func (s *OIDCUsecaseTestSuite) TestBeginLogin() {
+	s.mockProvider.On("AuthCodeURL", mock.Anything).Return("https://idp.example.com/authorize").Once()
+
+	req, authURL, err := s.usecase.BeginLogin(s.ctx)
+	s.NoError(err)
+	s.Equal("https://idp.example.com/authorize", authURL)
+	s.NotEmpty(req.State)
+	s.NotEqual(req.State, req.Nonce)
+	s.GreaterOrEqual(len(req.Verifier), 43, "PKCE verifiers must be at least 43 characters")
+}
+
+func (s *OIDCUsecaseTestSuite) TestCompleteLogin() {
+	s.Run("StateMismatch", func() {
+		_, err := s.usecase.CompleteLogin(s.ctx, s.req, "forged", "code")
+		s.ErrorIs(err, domain.ErrOIDCLoginFailed)
+	})
+
+	s.Run("MissingState", func() {
+		_, err := s.usecase.CompleteLogin(s.ctx, domain.OIDCAuthRequest{}, "", "code")
+		s.ErrorIs(err, domain.ErrOIDCLoginFailed)
+	})
+
+	s.Run("ExchangeFails", func() {
+		s.mockProvider.On("Exchange", s.ctx, "code", s.req).Return((*domain.OIDCIdentity)(nil), errors.New("invalid_grant")).Once()
+
+		_, err := s.usecase.CompleteLogin(s.ctx, s.req, "state", "code")
+		s.ErrorIs(err, domain.ErrOIDCLoginFailed)
+	})
+
+	s.Run("LinkedUser", func() {
+		identity := s.identity()
+		identity.Roles = []string{"task-admins"}
+		user := &domain.User{ID: "1", Username: "alice", Role: domain.RoleAdmin}
+		s.mockProvider.On("Exchange", s.ctx, "code", s.req).Return(identity, nil).Once()
+		s.mockRepo.On("FindUserByOIDCSubject", s.ctx, identity.Issuer, "sub-1").Return(user, nil).Once()
+		s.mockJWT.On("GenerateToken", "1", "alice", domain.RoleAdmin, []string{"oidc"}).Return("token", nil).Once()
+		s.mockRepo.On("RecordLogin", s.ctx, "1", s.now).Return(nil).Once()
+
+		token, err := s.usecase.CompleteLogin(s.ctx, s.req, "state", "code")
+		s.NoError(err)
+		s.Equal("token", token)
+	})
+
+	s.Run("ProviderAMRIgnoredByDefault", func() {
+		identity := s.identity()
+		identity.Roles = []string{"task-admins"}
+		identity.AMR = []string{"otp", "mfa"}
+		user := &domain.User{ID: "1", Username: "alice", Role: domain.RoleAdmin}
+		s.mockProvider.On("Exchange", s.ctx, "code", s.req).Return(identity, nil).Once()
+		s.mockRepo.On("FindUserByOIDCSubject", s.ctx, identity.Issuer, "sub-1").Return(user, nil).Once()
+		s.mockJWT.On("GenerateToken", "1", "alice", domain.RoleAdmin, []string{"oidc"}).Return("token", nil).Once()
+		s.mockRepo.On("RecordLogin", s.ctx, "1", s.now).Return(nil).Once()
+
+		_, err := s.usecase.CompleteLogin(s.ctx, s.req, "state", "code")
+		s.NoError(err, "An untrusted provider must not meet the admin two-factor policy by claiming otp")
+	})
+
+	s.Run("ProviderAMRTrustedWhenEnabled", func() {
+		usecase := NewOIDCUsecase(s.mockProvider, s.mockRepo, s.mockJWT, []string{"task-admins"}, WithTrustedAMR())
+		usecase.(*OIDCUsecaseImpl).now = func() time.Time { return s.now }
+		identity := s.identity()
+		identity.Roles = []string{"task-admins"}
+		identity.AMR = []string{"mfa"}
+		user := &domain.User{ID: "1", Username: "alice", Role: domain.RoleAdmin}
+		s.mockProvider.On("Exchange", s.ctx, "code", s.req).Return(identity, nil).Once()
+		s.mockRepo.On("FindUserByOIDCSubject", s.ctx, identity.Issuer, "sub-1").Return(user, nil).Once()
+		s.mockJWT.On("GenerateToken", "1", "alice", domain.RoleAdmin, []string{"oidc", "mfa"}).Return("token", nil).Once()
+		s.mockRepo.On("RecordLogin", s.ctx, "1", s.now).Return(nil).Once()
+
+		_, err := usecase.CompleteLogin(s.ctx, s.req, "state", "code")
+		s.NoError(err)
+	})
+
+	s.Run("TOTPEnabledUserGetsChallenge", func() {
+		identity := s.identity()
+		identity.AMR = []string{"otp"}
+		existing := &domain.User{ID: "2", Username: "alice", Role: domain.RoleUser, Email: "alice@example.com", Verified: true, TOTPEnabled: true}
+		s.mockProvider.On("Exchange", s.ctx, "code", s.req).Return(identity, nil).Once()
+		s.mockRepo.On("FindUserByOIDCSubject", s.ctx, identity.Issuer, "sub-1").Return((*domain.User)(nil), nil).Once()
+		s.mockRepo.On("FindUserByEmail", s.ctx, "alice@example.com").Return(existing, nil).Once()
+		s.mockRepo.On("LinkOIDCIdentity", s.ctx, "2", identity.Issuer, "sub-1").Return(nil).Once()
+		s.mockRepo.On("CreateMFAChallenge", s.ctx, mock.MatchedBy(func(c domain.MFAChallenge) bool {
+			return c.UserID == "2" && c.ExpiresAt.After(s.now) && len(c.AMR) == 1 && c.AMR[0] == "oidc"
+		})).Return(nil).Once()
+
+		token, err := s.usecase.CompleteLogin(s.ctx, s.req, "state", "code")
+		var mfaRequired *domain.MFARequiredError
+		s.Require().ErrorAs(err, &mfaRequired, "A linked account keeps its second factor")
+		s.NotEmpty(mfaRequired.Token)
+		s.Empty(token)
+	})
+
+	s.Run("RoleMappedOnEveryLogin", func() {
+		identity := s.identity()
+		user := &domain.User{ID: "1", Username: "alice", Role: domain.RoleAdmin}
+		s.mockProvider.On("Exchange", s.ctx, "code", s.req).Return(identity, nil).Once()
+		s.mockRepo.On("FindUserByOIDCSubject", s.ctx, identity.Issuer, "sub-1").Return(user, nil).Once()
+		s.mockRepo.On("SetRole", s.ctx, "1", domain.RoleUser).Return(nil).Once()
+		s.mockJWT.On("GenerateToken", "1", "alice", domain.RoleUser, []string{"oidc"}).Return("token", nil).Once()
+		s.mockRepo.On("RecordLogin", s.ctx, "1", s.now).Return(nil).Once()
+
+		_, err := s.usecase.CompleteLogin(s.ctx, s.req, "state", "code")
+		s.NoError(err, "Leaving the admin group at the IdP should demote the user")
+	})
+
+	s.Run("LinksByVerifiedEmail", func() {
+		identity := s.identity()
+		existing := &domain.User{ID: "2", Username: "alice", Role: domain.RoleUser, Email: "alice@example.com", Verified: true}
+		s.mockProvider.On("Exchange", s.ctx, "code", s.req).Return(identity, nil).Once()
+		s.mockRepo.On("FindUserByOIDCSubject", s.ctx, identity.Issuer, "sub-1").Return((*domain.User)(nil), nil).Once()
+		s.mockRepo.On("FindUserByEmail", s.ctx, "alice@example.com").Return(existing, nil).Once()
+		s.mockRepo.On("LinkOIDCIdentity", s.ctx, "2", identity.Issuer, "sub-1").Return(nil).Once()
+		s.mockJWT.On("GenerateToken", "2", "alice", domain.RoleUser, []string{"oidc"}).Return("token", nil).Once()
+		s.mockRepo.On("RecordLogin", s.ctx, "2", s.now).Return(nil).Once()
+
+		_, err := s.usecase.CompleteLogin(s.ctx, s.req, "state", "code")
+		s.NoError(err)
+	})
+
+	s.Run("UnverifiedEmailNotLinked", func() {
+		identity := s.identity()
+		existing := &domain.User{ID: "2", Email: "alice@example.com", Verified: false}
+		s.mockProvider.On("Exchange", s.ctx, "code", s.req).Return(identity, nil).Once()
+		s.mockRepo.On("FindUserByOIDCSubject", s.ctx, identity.Issuer, "sub-1").Return((*domain.User)(nil), nil).Once()
+		s.mockRepo.On("FindUserByEmail", s.ctx, "alice@example.com").Return(existing, nil).Once()
+
+		_, err := s.usecase.CompleteLogin(s.ctx, s.req, "state", "code")
+		s.ErrorIs(err, domain.ErrOIDCEmailConflict)
+	})
+
+	s.Run("Provisions", func() {
+		identity := s.identity()
+		s.mockProvider.On("Exchange", s.ctx, "code", s.req).Return(identity, nil).Once()
+		s.mockRepo.On("FindUserByOIDCSubject", s.ctx, identity.Issuer, "sub-1").Return((*domain.User)(nil), nil).Once()
+		s.mockRepo.On("FindUserByEmail", s.ctx, "alice@example.com").Return((*domain.User)(nil), nil).Once()
+		s.mockRepo.On("FindUserByUsername", s.ctx, "alice").Return(&domain.User{ID: "3"}, nil).Once()
+		s.mockRepo.On("FindUserByUsername", s.ctx, mock.MatchedBy(func(name string) bool {
+			return len(name) == len("alice-")+6 && name[:6] == "alice-"
+		})).Return((*domain.User)(nil), nil).Once()
+		var created domain.User
+		s.mockRepo.On("CreateUser", s.ctx, mock.Anything).Run(func(args mock.Arguments) {
+			created = args.Get(1).(domain.User)
+		}).Return(nil).Once()
+		s.mockJWT.On("GenerateToken", mock.Anything, mock.Anything, domain.RoleUser, []string{"oidc"}).Return("token", nil).Once()
+		s.mockRepo.On("RecordLogin", s.ctx, mock.Anything, s.now).Return(nil).Once()
+
+		_, err := s.usecase.CompleteLogin(s.ctx, s.req, "state", "code")
+		s.NoError(err)
+		s.Equal("sub-1", created.OIDCSubject)
+		s.True(created.Verified)
+		s.Empty(created.Password, "Provisioned accounts have no password")
+		s.Equal("Alice", created.DisplayName)
+		s.Equal(s.now, created.CreatedAt)
+	})
+
+	s.Run("Disabled", func() {
+		identity := s.identity()
+		s.mockProvider.On("Exchange", s.ctx, "code", s.req).Return(identity, nil).Once()
+		s.mockRepo.On("FindUserByOIDCSubject", s.ctx, identity.Issuer, "sub-1").Return(&domain.User{ID: "1", Disabled: true}, nil).Once()
+
+		_, err := s.usecase.CompleteLogin(s.ctx, s.req, "state", "code")
+		s.ErrorIs(err, domain.ErrAccountDisabled)
+	})
+}
+
+func (s *OIDCUsecaseTestSuite) TestSanitizeUsername() {
+	s.Equal("j.doe", sanitizeUsername("j. doe"))
+	s.Equal("zo", sanitizeUsername("zoë!"))
+	s.Len(sanitizeUsername("a_very_long_preferred_username_from_the_idp"), maxUsernameLength)
+}
+
+func TestOIDCUsecaseSuite(t *testing.T) {
+	suite.Run(t, new(OIDCUsecaseTestSuite))
+}
@@ -0,0 +1,147 @@
+package usecases
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"task_manager/domain"
+	"time"
+)
+
+const passwordResetTTL = 30 * time.Minute
+
+type PasswordUsecaseImpl struct {
+	userRepo    domain.UserRepository
+	passwordSvc domain.PasswordService
+	mailer      domain.Mailer
+	now         func() time.Time
+}
+
+func NewPasswordUsecase(userRepo domain.UserRepository, passwordSvc domain.PasswordService, mailer domain.Mailer) domain.PasswordUsecase {
+	return &PasswordUsecaseImpl{
+		userRepo:    userRepo,
+		passwordSvc: passwordSvc,
+		mailer:      mailer,
+		now:         time.Now,
+	}
+}
+
+func (u *PasswordUsecaseImpl) ChangePassword(ctx context.Context, currentPassword, newPassword string) error {
+	userID, err := domain.CallerID(ctx)
+	if err != nil {
+		return err
+	}
+	user, err := u.userRepo.FindUserByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if user == nil {
+		return errors.New("user not found")
+	}
+	if err := u.passwordSvc.ComparePassword(user.Password, currentPassword); err != nil {
+		return domain.ErrIncorrectPassword
+	}
+	return u.setPassword(ctx, user, newPassword)
+}
+
+// ForgotPassword mails a reset token when the address belongs to a user. It
+// reports success either way so the endpoint cannot be used to probe addresses.
+func (u *PasswordUsecaseImpl) ForgotPassword(ctx context.Context, email string) error {
+	if email == "" {
+		return domain.ValidationErrors{{Field: "email", Message: "is required"}}
+	}
+	user, err := u.userRepo.FindUserByEmail(ctx, email)
+	if err != nil || user == nil {
+		return err
+	}
+
+	token, err := newSecretToken()
+	if err != nil {
+		return err
+	}
+	reset := domain.PasswordReset{
+		TokenHash: hashToken(token),
+		UserID:    user.ID,
+		ExpiresAt: u.now().Add(passwordResetTTL),
+	}
+	if err := u.userRepo.CreatePasswordReset(ctx, reset); err != nil {
+		return err
+	}
+
+	return u.mailer.Send(ctx, domain.Email{
+		To:      user.Email,
+		Subject: "Reset your task manager password",
+		Body: fmt.Sprintf("Hi %s,\n\nUse this token to choose a new password within %d minutes:\n\n%s\n\n"+
+			"Send it with your new password to POST /v1/password/reset. If you did not ask for this, ignore this email.\n",
+			user.Username, int(passwordResetTTL.Minutes()), token),
+	})
+}
+
+func (u *PasswordUsecaseImpl) ResetPassword(ctx context.Context, token, newPassword string) error {
+	tokenHash := hashToken(token)
+	now := u.now()
+
+	// Look the token up before consuming it, so a password that fails
+	// validation does not burn the token.
+	reset, err := u.userRepo.FindPasswordReset(ctx, tokenHash)
+	if err != nil {
+		return err
+	}
+	if reset == nil || !reset.ExpiresAt.After(now) {
+		return domain.ErrInvalidResetToken
+	}
+	user, err := u.userRepo.FindUserByID(ctx, reset.UserID)
+	if err != nil {
+		return err
+	}
+	if user == nil {
+		return domain.ErrInvalidResetToken
+	}
+	if msg := passwordProblem(newPassword, user.Username); msg != "" {
+		return domain.ValidationErrors{{Field: "new_password", Message: msg}}
+	}
+
+	consumed, err := u.userRepo.ConsumePasswordReset(ctx, tokenHash, now)
+	if err != nil {
+		return err
+	}
+	if consumed == nil {
+		return domain.ErrInvalidResetToken
+	}
+	if err := u.setPassword(ctx, user, newPassword); err != nil {
+		return err
+	}
+	return u.userRepo.DeletePasswordResets(ctx, user.ID)
+}
+
+// setPassword validates, hashes and stores a new password. Bumping the change
+// time revokes every token issued before it.
+func (u *PasswordUsecaseImpl) setPassword(ctx context.Context, user *domain.User, newPassword string) error {
+	if msg := passwordProblem(newPassword, user.Username); msg != "" {
+		return domain.ValidationErrors{{Field: "new_password", Message: msg}}
+	}
+	hashed, err := u.passwordSvc.HashPassword(newPassword)
+	if err != nil {
+		return err
+	}
+	return u.userRepo.UpdatePassword(ctx, user.ID, hashed, u.now().Truncate(time.Second))
+}
+
+// newSecretToken returns 256 random bits, URL-safe encoded. Such tokens are only
+// ever stored as hashToken digests.
+func newSecretToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,168 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"task_manager/domain"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+type MockMailer struct {
+	mock.Mock
+}
+
+func (m *MockMailer) Send(ctx context.Context, email domain.Email) error {
+	return m.Called(ctx, email).Error(0)
+}
+
+type PasswordUsecaseTestSuite struct {
+	suite.Suite
+	mockRepo   *MockUserRepository
+	mockPass   *MockPasswordService
+	mockMailer *MockMailer
+	usecase    domain.PasswordUsecase
+	ctx        context.Context
+	now        time.Time
+}
+
+func (s *PasswordUsecaseTestSuite) SetupTest() {
+	s.mockRepo = &MockUserRepository{}
+	s.mockPass = &MockPasswordService{}
+	s.mockMailer = &MockMailer{}
+	s.usecase = NewPasswordUsecase(s.mockRepo, s.mockPass, s.mockMailer)
+	s.ctx = domain.WithPrincipal(context.Background(), &domain.Principal{UserID: "1", Username: "testuser"})
+	s.now = time.Date(2025, 4, 3, 12, 0, 0, 0, time.UTC)
+	s.usecase.(*PasswordUsecaseImpl).now = func() time.Time { return s.now }
+}
+
+func (s *PasswordUsecaseTestSuite) TearDownTest() {
+	s.mockRepo.AssertExpectations(s.T())
+	s.mockPass.AssertExpectations(s.T())
+	s.mockMailer.AssertExpectations(s.T())
+}
+
+func (s *PasswordUsecaseTestSuite) user() *domain.User {
+	return &domain.User{ID: "1", Username: "testuser", Password: "hashed", Email: "test@example.com"}
+}
+
+func (s *PasswordUsecaseTestSuite) TestChangePassword() {
+	s.Run("Success", func() {
+		s.mockRepo.On("FindUserByID", s.ctx, "1").Return(s.user(), nil).Once()
+		s.mockPass.On("ComparePassword", "hashed", "old9password").Return(nil).Once()
+		s.mockPass.On("HashPassword", "new9password").Return("newhash", nil).Once()
+		s.mockRepo.On("UpdatePassword", s.ctx, "1", "newhash", s.now).Return(nil).Once()
+
+		err := s.usecase.ChangePassword(s.ctx, "old9password", "new9password")
+		s.NoError(err)
+	})
+
+	s.Run("WrongCurrentPassword", func() {
+		s.mockRepo.On("FindUserByID", s.ctx, "1").Return(s.user(), nil).Once()
+		s.mockPass.On("ComparePassword", "hashed", "guess").Return(errors.New("mismatch")).Once()
+
+		err := s.usecase.ChangePassword(s.ctx, "guess", "new9password")
+		s.ErrorIs(err, domain.ErrIncorrectPassword)
+	})
+
+	s.Run("WeakNewPassword", func() {
+		s.mockRepo.On("FindUserByID", s.ctx, "1").Return(s.user(), nil).Once()
+		s.mockPass.On("ComparePassword", "hashed", "old9password").Return(nil).Once()
+
+		err := s.usecase.ChangePassword(s.ctx, "old9password", "short")
+		var verrs domain.ValidationErrors
+		s.ErrorAs(err, &verrs)
+		s.Equal("new_password", verrs[0].Field)
+	})
+
+	s.Run("NoCaller", func() {
+		err := s.usecase.ChangePassword(context.Background(), "old9password", "new9password")
+		s.ErrorIs(err, domain.ErrUnauthenticated)
+	})
+}
+
+func (s *PasswordUsecaseTestSuite) TestForgotPassword() {
+	s.Run("UnknownEmail", func() {
+		s.mockRepo.On("FindUserByEmail", s.ctx, "nobody@example.com").Return((*domain.User)(nil), nil).Once()
+
+		s.NoError(s.usecase.ForgotPassword(s.ctx, "nobody@example.com"))
+	})
+
+	s.Run("SendsHashedToken", func() {
+		var stored domain.PasswordReset
+		var sent domain.Email
+		s.mockRepo.On("FindUserByEmail", s.ctx, "test@example.com").Return(s.user(), nil).Once()
+		s.mockRepo.On("CreatePasswordReset", s.ctx, mock.AnythingOfType("domain.PasswordReset")).
+			Run(func(args mock.Arguments) { stored = args.Get(1).(domain.PasswordReset) }).Return(nil).Once()
+		s.mockMailer.On("Send", s.ctx, mock.AnythingOfType("domain.Email")).
+			Run(func(args mock.Arguments) { sent = args.Get(1).(domain.Email) }).Return(nil).Once()
+
+		s.NoError(s.usecase.ForgotPassword(s.ctx, "test@example.com"))
+
+		s.Equal("1", stored.UserID)
+		s.Equal(s.now.Add(passwordResetTTL), stored.ExpiresAt)
+		s.Equal("test@example.com", sent.To)
+		token := regexp.MustCompile(`(?m)^[A-Za-z0-9_-]{43}$`).FindString(sent.Body)
+		s.NotEmpty(token, "The email should contain the token")
+		s.Equal(hashToken(token), stored.TokenHash, "Only the token hash should be stored")
+		s.NotContains(sent.Body, stored.TokenHash)
+	})
+
+	s.Run("MissingEmail", func() {
+		var verrs domain.ValidationErrors
+		s.ErrorAs(s.usecase.ForgotPassword(s.ctx, ""), &verrs)
+	})
+}
+
+func (s *PasswordUsecaseTestSuite) TestResetPassword() {
+	hash := hashToken("token")
+	reset := &domain.PasswordReset{TokenHash: hash, UserID: "1", ExpiresAt: time.Date(2025, 4, 3, 12, 30, 0, 0, time.UTC)}
+
+	s.Run("Success", func() {
+		s.mockRepo.On("FindPasswordReset", s.ctx, hash).Return(reset, nil).Once()
+		s.mockRepo.On("FindUserByID", s.ctx, "1").Return(s.user(), nil).Once()
+		s.mockRepo.On("ConsumePasswordReset", s.ctx, hash, s.now).Return(reset, nil).Once()
+		s.mockPass.On("HashPassword", "new9password").Return("newhash", nil).Once()
+		s.mockRepo.On("UpdatePassword", s.ctx, "1", "newhash", s.now).Return(nil).Once()
+		s.mockRepo.On("DeletePasswordResets", s.ctx, "1").Return(nil).Once()
+
+		s.NoError(s.usecase.ResetPassword(s.ctx, "token", "new9password"))
+	})
+
+	s.Run("UnknownToken", func() {
+		s.mockRepo.On("FindPasswordReset", s.ctx, hash).Return((*domain.PasswordReset)(nil), nil).Once()
+
+		s.ErrorIs(s.usecase.ResetPassword(s.ctx, "token", "new9password"), domain.ErrInvalidResetToken)
+	})
+
+	s.Run("Expired", func() {
+		expired := &domain.PasswordReset{TokenHash: hash, UserID: "1", ExpiresAt: s.now.Add(-time.Second)}
+		s.mockRepo.On("FindPasswordReset", s.ctx, hash).Return(expired, nil).Once()
+
+		s.ErrorIs(s.usecase.ResetPassword(s.ctx, "token", "new9password"), domain.ErrInvalidResetToken)
+	})
+
+	s.Run("WeakPasswordKeepsToken", func() {
+		s.mockRepo.On("FindPasswordReset", s.ctx, hash).Return(reset, nil).Once()
+		s.mockRepo.On("FindUserByID", s.ctx, "1").Return(s.user(), nil).Once()
+
+		var verrs domain.ValidationErrors
+		s.ErrorAs(s.usecase.ResetPassword(s.ctx, "token", "short"), &verrs)
+	})
+
+	s.Run("AlreadyUsed", func() {
+		s.mockRepo.On("FindPasswordReset", s.ctx, hash).Return(reset, nil).Once()
+		s.mockRepo.On("FindUserByID", s.ctx, "1").Return(s.user(), nil).Once()
+		s.mockRepo.On("ConsumePasswordReset", s.ctx, hash, s.now).Return((*domain.PasswordReset)(nil), nil).Once()
+
+		s.ErrorIs(s.usecase.ResetPassword(s.ctx, "token", "new9password"), domain.ErrInvalidResetToken)
+	})
+}
+
+func TestPasswordUsecaseSuite(t *testing.T) {
+	suite.Run(t, new(PasswordUsecaseTestSuite))
+}
@@ -0,0 +1,89 @@
+package usecases
+
+import (
+	"context"
+	"strings"
+	"task_manager/domain"
+)
+
+type ProfileUsecaseImpl struct {
+	userRepo domain.UserRepository
+	verifier domain.VerificationUsecase
+}
+
+func NewProfileUsecase(userRepo domain.UserRepository, verifier domain.VerificationUsecase) domain.ProfileUsecase {
+	return &ProfileUsecaseImpl{userRepo: userRepo, verifier: verifier}
+}
+
+func (u *ProfileUsecaseImpl) GetProfile(ctx context.Context) (*domain.User, error) {
+	userID, err := domain.CallerID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	user, err := u.userRepo.FindUserByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, domain.ErrUserNotFound
+	}
+	return user, nil
+}
+
+// UpdateProfile only writes the fields that changed. A new email address is
+// stored unverified: with verification required, the account cannot log in
+// again until the link sent to the new address has been opened.
+func (u *ProfileUsecaseImpl) UpdateProfile(ctx context.Context, update domain.ProfileUpdate) (*domain.User, error) {
+	if err := validateProfile(update); err != nil {
+		return nil, err
+	}
+	user, err := u.GetProfile(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	fields := map[string]interface{}{}
+	setField := func(name string, value *string, current *string) {
+		if value != nil && *value != *current {
+			*current = *value
+			fields[name] = *value
+		}
+	}
+	if update.DisplayName != nil {
+		trimmed := strings.TrimSpace(*update.DisplayName)
+		update.DisplayName = &trimmed
+	}
+	setField("display_name", update.DisplayName, &user.DisplayName)
+	setField("avatar_url", update.AvatarURL, &user.AvatarURL)
+	setField("time_zone", update.TimeZone, &user.TimeZone)
+	setField("locale", update.Locale, &user.Locale)
+
+	emailChanged := update.Email != nil && *update.Email != user.Email
+	if emailChanged {
+		existing, err := u.userRepo.FindUserByEmail(ctx, *update.Email)
+		if err != nil {
+			return nil, err
+		}
+		if existing != nil && existing.ID != user.ID {
+			return nil, domain.ErrEmailInUse
+		}
+		user.Email = *update.Email
+		user.Verified = false
+		fields["email"] = user.Email
+		fields["verified"] = false
+	}
+
+	if len(fields) == 0 {
+		return user, nil
+	}
+	if err := u.userRepo.UpdateProfile(ctx, user.ID, fields); err != nil {
+		return nil, err
+	}
+	if emailChanged {
+		// As with registration, a lost email can be sent again through the resend endpoint.
+		if err := u.verifier.SendVerification(ctx, *user); err != nil {
+			domain.Logger(ctx).Error("send verification", "error", err)
+		}
+	}
+	return user, nil
+}
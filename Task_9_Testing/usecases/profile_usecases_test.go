@@ -0,0 +1,120 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"task_manager/domain"
+	"testing"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+type ProfileUsecaseTestSuite struct {
+	suite.Suite
+	mockRepo     *MockUserRepository
+	mockVerifier *MockVerificationUsecase
+	usecase      domain.ProfileUsecase
+	ctx          context.Context
+}
+
+func (s *ProfileUsecaseTestSuite) SetupTest() {
+	s.mockRepo = &MockUserRepository{}
+	s.mockVerifier = &MockVerificationUsecase{}
+	s.usecase = NewProfileUsecase(s.mockRepo, s.mockVerifier)
+	s.ctx = domain.WithPrincipal(context.Background(), &domain.Principal{UserID: "1", Username: "testuser"})
+}
+
+func (s *ProfileUsecaseTestSuite) TearDownTest() {
+	s.mockRepo.AssertExpectations(s.T())
+	s.mockVerifier.AssertExpectations(s.T())
+}
+
+func (s *ProfileUsecaseTestSuite) user() *domain.User {
+	return &domain.User{ID: "1", Username: "testuser", Email: "old@example.com", Verified: true, Locale: "en"}
+}
+
+func strPtr(v string) *string {
+	return &v
+}
+
+func (s *ProfileUsecaseTestSuite) TestGetProfile() {
+	s.Run("Success", func() {
+		s.mockRepo.On("FindUserByID", s.ctx, "1").Return(s.user(), nil).Once()
+
+		user, err := s.usecase.GetProfile(s.ctx)
+		s.NoError(err)
+		s.Equal("testuser", user.Username)
+	})
+
+	s.Run("Unauthenticated", func() {
+		_, err := s.usecase.GetProfile(context.Background())
+		s.ErrorIs(err, domain.ErrUnauthenticated)
+	})
+}
+
+func (s *ProfileUsecaseTestSuite) TestUpdateProfile() {
+	s.Run("ChangedFieldsOnly", func() {
+		s.mockRepo.On("FindUserByID", s.ctx, "1").Return(s.user(), nil).Once()
+		s.mockRepo.On("UpdateProfile", s.ctx, "1", map[string]interface{}{
+			"display_name": "Test User",
+			"time_zone":    "Europe/Berlin",
+		}).Return(nil).Once()
+
+		user, err := s.usecase.UpdateProfile(s.ctx, domain.ProfileUpdate{
+			DisplayName: strPtr("  Test User "),
+			TimeZone:    strPtr("Europe/Berlin"),
+			Locale:      strPtr("en"),
+		})
+		s.NoError(err)
+		s.Equal("Test User", user.DisplayName)
+		s.True(user.Verified)
+	})
+
+	s.Run("NothingChanged", func() {
+		s.mockRepo.On("FindUserByID", s.ctx, "1").Return(s.user(), nil).Once()
+
+		_, err := s.usecase.UpdateProfile(s.ctx, domain.ProfileUpdate{Email: strPtr("old@example.com")})
+		s.NoError(err)
+	})
+
+	s.Run("EmailChangeRequiresVerification", func() {
+		s.mockRepo.On("FindUserByID", s.ctx, "1").Return(s.user(), nil).Once()
+		s.mockRepo.On("FindUserByEmail", s.ctx, "new@example.com").Return((*domain.User)(nil), nil).Once()
+		s.mockRepo.On("UpdateProfile", s.ctx, "1", map[string]interface{}{
+			"email":    "new@example.com",
+			"verified": false,
+		}).Return(nil).Once()
+		s.mockVerifier.On("SendVerification", s.ctx, mock.MatchedBy(func(u domain.User) bool {
+			return u.Email == "new@example.com"
+		})).Return(nil).Once()
+
+		user, err := s.usecase.UpdateProfile(s.ctx, domain.ProfileUpdate{Email: strPtr("new@example.com")})
+		s.NoError(err)
+		s.False(user.Verified)
+	})
+
+	s.Run("EmailInUse", func() {
+		s.mockRepo.On("FindUserByID", s.ctx, "1").Return(s.user(), nil).Once()
+		s.mockRepo.On("FindUserByEmail", s.ctx, "taken@example.com").Return(&domain.User{ID: "2"}, nil).Once()
+
+		_, err := s.usecase.UpdateProfile(s.ctx, domain.ProfileUpdate{Email: strPtr("taken@example.com")})
+		s.ErrorIs(err, domain.ErrEmailInUse)
+	})
+
+	s.Run("InvalidFields", func() {
+		_, err := s.usecase.UpdateProfile(s.ctx, domain.ProfileUpdate{
+			Email:     strPtr(""),
+			AvatarURL: strPtr("javascript:alert(1)"),
+			TimeZone:  strPtr("Mars/Olympus"),
+			Locale:    strPtr("not a locale"),
+		})
+		var verrs domain.ValidationErrors
+		s.Require().True(errors.As(err, &verrs))
+		s.Len(verrs, 4)
+	})
+}
+
+func TestProfileUsecaseSuite(t *testing.T) {
+	suite.Run(t, new(ProfileUsecaseTestSuite))
+}
@@ -0,0 +1,92 @@
+package usecases
+
+import (
+	"context"
+	"crypto/subtle"
+	"task_manager/domain"
+	"time"
+)
+
+// sessionTTL is the absolute lifetime of a cookie session. Logging out or
+// changing the password ends it earlier.
+const sessionTTL = 12 * time.Hour
+
+type SessionUsecaseImpl struct {
+	sessionRepo domain.SessionRepository
+	now         func() time.Time
+}
+
+func NewSessionUsecase(sessionRepo domain.SessionRepository) domain.SessionUsecase {
+	return &SessionUsecaseImpl{sessionRepo: sessionRepo, now: time.Now}
+}
+
+// StartSession copies the caller's identity, including how they signed in, so
+// the admin two-factor policy treats the cookie like the token it came from.
+// A session cannot start another one, or sessions would outlive sessionTTL.
+func (u *SessionUsecaseImpl) StartSession(ctx context.Context) (*domain.Session, string, string, error) {
+	p, ok := domain.PrincipalFrom(ctx)
+	if !ok || p.IsAPIKey() {
+		return nil, "", "", domain.ErrUnauthenticated
+	}
+	if p.SessionID != "" {
+		return nil, "", "", domain.ErrSessionExists
+	}
+	token, err := newSecretToken()
+	if err != nil {
+		return nil, "", "", err
+	}
+	csrfToken, err := newSecretToken()
+	if err != nil {
+		return nil, "", "", err
+	}
+
+	now := u.now()
+	session := domain.Session{
+		ID:          hashToken(token),
+		UserID:      p.UserID,
+		Username:    p.Username,
+		Role:        p.Role,
+		AuthMethods: p.AuthMethods,
+		CSRFHash:    hashToken(csrfToken),
+		CreatedAt:   now,
+		ExpiresAt:   now.Add(sessionTTL),
+	}
+	if err := u.sessionRepo.CreateSession(ctx, session); err != nil {
+		return nil, "", "", err
+	}
+	return &session, token, csrfToken, nil
+}
+
+func (u *SessionUsecaseImpl) Authenticate(ctx context.Context, token string) (*domain.Session, error) {
+	if token == "" {
+		return nil, domain.ErrInvalidSession
+	}
+	session, err := u.sessionRepo.FindSession(ctx, hashToken(token))
+	if err != nil {
+		return nil, err
+	}
+	if session == nil || !session.ExpiresAt.After(u.now()) {
+		return nil, domain.ErrInvalidSession
+	}
+	return session, nil
+}
+
+func (u *SessionUsecaseImpl) VerifyCSRF(session *domain.Session, csrfToken string) bool {
+	if csrfToken == "" {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(hashToken(csrfToken)), []byte(session.CSRFHash)) == 1
+}
+
+// EndSession logs out the cookie session of the caller. Bearer token callers
+// have nothing to end; their token simply expires.
+func (u *SessionUsecaseImpl) EndSession(ctx context.Context) error {
+	p, ok := domain.PrincipalFrom(ctx)
+	if !ok {
+		return domain.ErrUnauthenticated
+	}
+	if p.SessionID == "" {
+		return nil
+	}
+	return u.sessionRepo.DeleteSession(ctx, p.SessionID)
+}
@@ -0,0 +1,124 @@
+package usecases
+
+import (
+	"context"
+	"task_manager/domain"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+type MockSessionRepository struct {
+	mock.Mock
+}
+
+func (m *MockSessionRepository) CreateSession(ctx context.Context, session domain.Session) error {
+	return m.Called(ctx, session).Error(0)
+}
+
+func (m *MockSessionRepository) FindSession(ctx context.Context, id string) (*domain.Session, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(*domain.Session), args.Error(1)
+}
+
+func (m *MockSessionRepository) DeleteSession(ctx context.Context, id string) error {
+	return m.Called(ctx, id).Error(0)
+}
+
+type SessionUsecaseTestSuite struct {
+	suite.Suite
+	mockRepo *MockSessionRepository
+	usecase  domain.SessionUsecase
+	ctx      context.Context
+	now      time.Time
+}
+
+func (s *SessionUsecaseTestSuite) SetupTest() {
+	s.mockRepo = &MockSessionRepository{}
+	s.usecase = NewSessionUsecase(s.mockRepo)
+	s.ctx = domain.WithPrincipal(context.Background(), &domain.Principal{UserID: "1", Username: "testuser", Role: domain.RoleAdmin, AuthMethods: []string{"pwd", "otp"}})
+	s.now = time.Date(2025, 4, 3, 12, 0, 0, 0, time.UTC)
+	s.usecase.(*SessionUsecaseImpl).now = func() time.Time { return s.now }
+}
+
+func (s *SessionUsecaseTestSuite) TearDownTest() {
+	s.mockRepo.AssertExpectations(s.T())
+}
+
+func (s *SessionUsecaseTestSuite) TestStartSession() {
+	s.Run("Success", func() {
+		var stored domain.Session
+		s.mockRepo.On("CreateSession", s.ctx, mock.Anything).Run(func(args mock.Arguments) {
+			stored = args.Get(1).(domain.Session)
+		}).Return(nil).Once()
+
+		session, token, csrfToken, err := s.usecase.StartSession(s.ctx)
+		s.NoError(err)
+		s.Equal(hashToken(token), stored.ID, "Only the hash of the cookie value should be stored")
+		s.Equal(hashToken(csrfToken), stored.CSRFHash)
+		s.NotEqual(token, csrfToken)
+		s.Equal([]string{"pwd", "otp"}, session.AuthMethods)
+		s.Equal(domain.RoleAdmin, session.Role)
+		s.Equal(s.now.Add(sessionTTL), session.ExpiresAt)
+		s.True(s.usecase.VerifyCSRF(session, csrfToken))
+		s.False(s.usecase.VerifyCSRF(session, token))
+		s.False(s.usecase.VerifyCSRF(session, ""))
+	})
+
+	s.Run("APIKey", func() {
+		ctx := domain.WithPrincipal(context.Background(), &domain.Principal{UserID: "1", APIKeyID: "k1"})
+		_, _, _, err := s.usecase.StartSession(ctx)
+		s.ErrorIs(err, domain.ErrUnauthenticated, "API keys should not be traded for cookies")
+	})
+
+	s.Run("FromSession", func() {
+		ctx := domain.WithPrincipal(context.Background(), &domain.Principal{UserID: "1", SessionID: hashToken("token")})
+		_, _, _, err := s.usecase.StartSession(ctx)
+		s.ErrorIs(err, domain.ErrSessionExists, "A session should not renew itself past sessionTTL")
+	})
+}
+
+func (s *SessionUsecaseTestSuite) TestAuthenticate() {
+	s.Run("Valid", func() {
+		session := &domain.Session{ID: hashToken("token"), UserID: "1", ExpiresAt: s.now.Add(time.Hour)}
+		s.mockRepo.On("FindSession", s.ctx, hashToken("token")).Return(session, nil).Once()
+
+		got, err := s.usecase.Authenticate(s.ctx, "token")
+		s.NoError(err)
+		s.Equal(session, got)
+	})
+
+	s.Run("Expired", func() {
+		session := &domain.Session{ID: hashToken("old"), ExpiresAt: s.now}
+		s.mockRepo.On("FindSession", s.ctx, hashToken("old")).Return(session, nil).Once()
+
+		_, err := s.usecase.Authenticate(s.ctx, "old")
+		s.ErrorIs(err, domain.ErrInvalidSession)
+	})
+
+	s.Run("Unknown", func() {
+		s.mockRepo.On("FindSession", s.ctx, hashToken("forged")).Return((*domain.Session)(nil), nil).Once()
+
+		_, err := s.usecase.Authenticate(s.ctx, "forged")
+		s.ErrorIs(err, domain.ErrInvalidSession)
+	})
+}
+
+func (s *SessionUsecaseTestSuite) TestEndSession() {
+	s.Run("Cookie", func() {
+		ctx := domain.WithPrincipal(context.Background(), &domain.Principal{UserID: "1", SessionID: "hash"})
+		s.mockRepo.On("DeleteSession", ctx, "hash").Return(nil).Once()
+
+		s.NoError(s.usecase.EndSession(ctx))
+	})
+
+	s.Run("Bearer", func() {
+		s.NoError(s.usecase.EndSession(s.ctx), "Bearer callers have no session to delete")
+	})
+}
+
+func TestSessionUsecaseSuite(t *testing.T) {
+	suite.Run(t, new(SessionUsecaseTestSuite))
+}
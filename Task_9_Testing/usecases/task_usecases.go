@@ -1,9 +1,13 @@
 package usecases
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
+	"fmt"
 	"task_manager/domain"
 
+	jsonpatch "github.com/evanphx/json-patch/v5"
 	"github.com/google/uuid"
 )
 
@@ -16,8 +20,18 @@ func NewTaskUsecase(taskRepo domain.TaskRepository) domain.TaskUsecase {
 }
 
 func (u *TaskUsecaseImpl) AddTask(ctx context.Context, task domain.Task) (string, error) {
-	
+	if task.Status == "" {
+		task.Status = domain.TaskStatusPending
+	}
+	if err := validateTask(task, true); err != nil {
+		return "", err
+	}
+
 	task.ID = uuid.New().String()
+	task.Version = 1
+	if p, ok := domain.PrincipalFrom(ctx); ok {
+		task.OwnerID = p.UserID
+	}
 
 	return u.taskRepo.AddTask(ctx, task)
 }
@@ -26,14 +40,106 @@ func (u *TaskUsecaseImpl) GetAllTasks(ctx context.Context) ([]domain.Task, error
 	return u.taskRepo.GetAllTasks(ctx)
 }
 
+func (u *TaskUsecaseImpl) FindTasks(ctx context.Context, filter domain.TaskFilter) ([]domain.Task, error) {
+	return u.taskRepo.FindTasks(ctx, filter)
+}
+
 func (u *TaskUsecaseImpl) GetTaskByID(ctx context.Context, id string) (*domain.Task, error) {
 	return u.taskRepo.GetTaskByID(ctx, id)
 }
 
 func (u *TaskUsecaseImpl) UpdateTask(ctx context.Context, id string, task domain.Task) error {
+	if err := validateTask(task, false); err != nil {
+		return err
+	}
 	return u.taskRepo.UpdateTask(ctx, id, task)
 }
 
+func (u *TaskUsecaseImpl) PatchTask(ctx context.Context, id, mediaType string, patch []byte) (*domain.Task, error) {
+	task, err := u.taskRepo.GetTaskByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	patched, err := applyTaskPatch(*task, mediaType, patch)
+	if err != nil {
+		return nil, err
+	}
+	if patched.ID != task.ID {
+		return nil, fmt.Errorf("%w: id cannot be changed", domain.ErrInvalidPatch)
+	}
+	if patched.OwnerID != task.OwnerID {
+		return nil, fmt.Errorf("%w: owner_id cannot be changed", domain.ErrInvalidPatch)
+	}
+	if err := validateTask(patched, false); err != nil {
+		return nil, err
+	}
+
+	patched.Version = task.Version
+	fields := changedTaskFields(*task, patched)
+	if len(fields) == 0 {
+		return &patched, nil
+	}
+	// Patching the version that was read makes a concurrent write fail with
+	// ErrTaskConflict instead of being overwritten.
+	if err := u.taskRepo.PatchTask(ctx, id, task.Version, fields); err != nil {
+		return nil, err
+	}
+	patched.Version++
+	return &patched, nil
+}
+
 func (u *TaskUsecaseImpl) DeleteTask(ctx context.Context, id string) error {
 	return u.taskRepo.DeleteTask(ctx, id)
 }
+
+// applyTaskPatch runs a merge patch or JSON patch document against the JSON form of task.
+func applyTaskPatch(task domain.Task, mediaType string, patch []byte) (domain.Task, error) {
+	original, err := json.Marshal(task)
+	if err != nil {
+		return domain.Task{}, err
+	}
+
+	var modified []byte
+	switch mediaType {
+	case domain.MergePatchMediaType:
+		modified, err = jsonpatch.MergePatch(original, patch)
+	case domain.JSONPatchMediaType:
+		var ops jsonpatch.Patch
+		ops, err = jsonpatch.DecodePatch(patch)
+		if err == nil {
+			modified, err = ops.Apply(original)
+		}
+	default:
+		return domain.Task{}, domain.ErrUnsupportedPatch
+	}
+	if err != nil {
+		return domain.Task{}, fmt.Errorf("%w: %v", domain.ErrInvalidPatch, err)
+	}
+
+	var patched domain.Task
+	decoder := json.NewDecoder(bytes.NewReader(modified))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&patched); err != nil {
+		return domain.Task{}, fmt.Errorf("%w: %v", domain.ErrInvalidPatch, err)
+	}
+	return patched, nil
+}
+
+// changedTaskFields maps every field that differs between old and patched to its bson name.
+func changedTaskFields(old, patched domain.Task) map[string]interface{} {
+	fields := map[string]interface{}{}
+	if old.Title != patched.Title {
+		fields["title"] = patched.Title
+	}
+	if old.Description != patched.Description {
+		fields["description"] = patched.Description
+	}
+	if !old.DueDate.Equal(patched.DueDate) {
+		fields["due_date"] = patched.DueDate
+	}
+	if old.Status != patched.Status {
+		fields["status"] = patched.Status
+	}
+	return fields
+}
@@ -24,6 +24,11 @@ func (m *MockTaskRepository) GetAllTasks(ctx context.Context) ([]domain.Task, er
 	return args.Get(0).([]domain.Task), args.Error(1)
 }
 
+func (m *MockTaskRepository) FindTasks(ctx context.Context, filter domain.TaskFilter) ([]domain.Task, error) {
+	args := m.Called(ctx, filter)
+	return args.Get(0).([]domain.Task), args.Error(1)
+}
+
 func (m *MockTaskRepository) GetTaskByID(ctx context.Context, id string) (*domain.Task, error) {
 	args := m.Called(ctx, id)
 	return args.Get(0).(*domain.Task), args.Error(1)
@@ -33,10 +38,19 @@ func (m *MockTaskRepository) UpdateTask(ctx context.Context, id string, task dom
 	return m.Called(ctx, id, task).Error(0)
 }
 
+func (m *MockTaskRepository) PatchTask(ctx context.Context, id string, version int, fields map[string]interface{}) error {
+	return m.Called(ctx, id, version, fields).Error(0)
+}
+
 func (m *MockTaskRepository) DeleteTask(ctx context.Context, id string) error {
 	return m.Called(ctx, id).Error(0)
 }
 
+func (m *MockTaskRepository) ReassignTasks(ctx context.Context, fromOwnerID, toOwnerID string) (int64, error) {
+	args := m.Called(ctx, fromOwnerID, toOwnerID)
+	return args.Get(0).(int64), args.Error(1)
+}
+
 type TaskUsecaseTestSuite struct {
 	suite.Suite
 	mockRepo *MockTaskRepository
@@ -63,6 +77,36 @@ func (s *TaskUsecaseTestSuite) TestAddTask() {
 		s.NoError(err)
 		s.Equal("1", id)
 	})
+
+	s.Run("DefaultsStatusAndVersion", func() {
+		task := domain.Task{Title: "Test Task", Version: 9}
+		s.mockRepo.On("AddTask", s.ctx, mock.MatchedBy(func(t domain.Task) bool {
+			return t.Status == domain.TaskStatusPending && t.Version == 1
+		})).Return("2", nil).Once()
+
+		id, err := s.usecase.AddTask(s.ctx, task)
+		s.NoError(err)
+		s.Equal("2", id)
+	})
+
+	s.Run("RecordsOwner", func() {
+		ctx := domain.WithPrincipal(s.ctx, &domain.Principal{UserID: "7"})
+		s.mockRepo.On("AddTask", ctx, mock.MatchedBy(func(t domain.Task) bool {
+			return t.OwnerID == "7"
+		})).Return("3", nil).Once()
+
+		_, err := s.usecase.AddTask(ctx, domain.Task{Title: "Test Task", OwnerID: "8"})
+		s.NoError(err)
+	})
+
+	s.Run("InvalidTask", func() {
+		task := domain.Task{Title: "  ", DueDate: time.Now().AddDate(0, 0, -2), Status: "archived"}
+
+		_, err := s.usecase.AddTask(s.ctx, task)
+		var verrs domain.ValidationErrors
+		s.ErrorAs(err, &verrs)
+		s.Len(verrs, 3)
+	})
 }
 
 func (s *TaskUsecaseTestSuite) TestGetAllTasks() {
@@ -108,6 +152,112 @@ func (s *TaskUsecaseTestSuite) TestUpdateTask() {
 		err := s.usecase.UpdateTask(s.ctx, "1", task)
 		s.NoError(err)
 	})
+
+	s.Run("InvalidStatus", func() {
+		task := domain.Task{Title: "Updated Task", Status: "finished"}
+
+		err := s.usecase.UpdateTask(s.ctx, "1", task)
+		var verrs domain.ValidationErrors
+		s.ErrorAs(err, &verrs)
+	})
+}
+
+func (s *TaskUsecaseTestSuite) TestPatchTask() {
+	due := time.Date(2025, 4, 3, 0, 0, 0, 0, time.UTC)
+	existing := func() *domain.Task {
+		return &domain.Task{ID: "1", Title: "Task", Description: "keep me", DueDate: due, Status: "pending", Version: 3}
+	}
+
+	s.Run("MergePatch", func() {
+		s.mockRepo.On("GetTaskByID", s.ctx, "1").Return(existing(), nil).Once()
+		s.mockRepo.On("PatchTask", s.ctx, "1", 3, map[string]interface{}{"status": "done"}).Return(nil).Once()
+
+		result, err := s.usecase.PatchTask(s.ctx, "1", domain.MergePatchMediaType, []byte(`{"status":"done","version":42}`))
+		s.NoError(err)
+		s.Equal("done", result.Status)
+		s.Equal("keep me", result.Description)
+		s.Equal("1", result.ID)
+		s.Equal(4, result.Version, "The repository increments the stored version; the client's is ignored")
+	})
+
+	s.Run("JSONPatch", func() {
+		s.mockRepo.On("GetTaskByID", s.ctx, "1").Return(existing(), nil).Once()
+		s.mockRepo.On("PatchTask", s.ctx, "1", 3, map[string]interface{}{"title": "Renamed", "description": ""}).Return(nil).Once()
+
+		patch := `[{"op":"replace","path":"/title","value":"Renamed"},{"op":"replace","path":"/description","value":""}]`
+		result, err := s.usecase.PatchTask(s.ctx, "1", domain.JSONPatchMediaType, []byte(patch))
+		s.NoError(err)
+		s.Equal("Renamed", result.Title)
+		s.Empty(result.Description)
+	})
+
+	s.Run("ConcurrentWrite", func() {
+		s.mockRepo.On("GetTaskByID", s.ctx, "1").Return(existing(), nil).Once()
+		s.mockRepo.On("PatchTask", s.ctx, "1", 3, map[string]interface{}{"status": "done"}).Return(domain.ErrTaskConflict).Once()
+
+		_, err := s.usecase.PatchTask(s.ctx, "1", domain.MergePatchMediaType, []byte(`{"status":"done"}`))
+		s.ErrorIs(err, domain.ErrTaskConflict)
+	})
+
+	s.Run("NoChanges", func() {
+		s.mockRepo.On("GetTaskByID", s.ctx, "1").Return(existing(), nil).Once()
+
+		result, err := s.usecase.PatchTask(s.ctx, "1", domain.MergePatchMediaType, []byte(`{"status":"pending"}`))
+		s.NoError(err)
+		s.Equal("pending", result.Status)
+	})
+
+	s.Run("IDChange", func() {
+		s.mockRepo.On("GetTaskByID", s.ctx, "1").Return(existing(), nil).Once()
+
+		_, err := s.usecase.PatchTask(s.ctx, "1", domain.MergePatchMediaType, []byte(`{"id":"2"}`))
+		s.ErrorIs(err, domain.ErrInvalidPatch)
+	})
+
+	s.Run("OwnerChange", func() {
+		s.mockRepo.On("GetTaskByID", s.ctx, "1").Return(existing(), nil).Once()
+
+		_, err := s.usecase.PatchTask(s.ctx, "1", domain.MergePatchMediaType, []byte(`{"owner_id":"2"}`))
+		s.ErrorIs(err, domain.ErrInvalidPatch)
+	})
+
+	s.Run("InvalidResult", func() {
+		s.mockRepo.On("GetTaskByID", s.ctx, "1").Return(existing(), nil).Once()
+
+		_, err := s.usecase.PatchTask(s.ctx, "1", domain.MergePatchMediaType, []byte(`{"title":null,"status":"later"}`))
+		var verrs domain.ValidationErrors
+		s.ErrorAs(err, &verrs)
+		s.Len(verrs, 2)
+	})
+
+	s.Run("UnknownField", func() {
+		s.mockRepo.On("GetTaskByID", s.ctx, "1").Return(existing(), nil).Once()
+
+		_, err := s.usecase.PatchTask(s.ctx, "1", domain.MergePatchMediaType, []byte(`{"owner":"bob"}`))
+		s.ErrorIs(err, domain.ErrInvalidPatch)
+	})
+
+	s.Run("FailedTest", func() {
+		s.mockRepo.On("GetTaskByID", s.ctx, "1").Return(existing(), nil).Once()
+
+		patch := `[{"op":"test","path":"/status","value":"done"},{"op":"replace","path":"/title","value":"x"}]`
+		_, err := s.usecase.PatchTask(s.ctx, "1", domain.JSONPatchMediaType, []byte(patch))
+		s.ErrorIs(err, domain.ErrInvalidPatch)
+	})
+
+	s.Run("UnsupportedMediaType", func() {
+		s.mockRepo.On("GetTaskByID", s.ctx, "1").Return(existing(), nil).Once()
+
+		_, err := s.usecase.PatchTask(s.ctx, "1", "application/json", []byte(`{}`))
+		s.ErrorIs(err, domain.ErrUnsupportedPatch)
+	})
+
+	s.Run("NotFound", func() {
+		s.mockRepo.On("GetTaskByID", s.ctx, "9").Return((*domain.Task)(nil), domain.ErrTaskNotFound).Once()
+
+		_, err := s.usecase.PatchTask(s.ctx, "9", domain.MergePatchMediaType, []byte(`{}`))
+		s.ErrorIs(err, domain.ErrTaskNotFound)
+	})
 }
 
 func (s *TaskUsecaseTestSuite) TestDeleteTask() {
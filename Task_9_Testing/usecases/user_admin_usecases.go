@@ -0,0 +1,104 @@
+package usecases
+
+import (
+	"context"
+	"task_manager/domain"
+)
+
+type UserAdminUsecaseImpl struct {
+	userRepo domain.UserRepository
+	taskRepo domain.TaskRepository
+}
+
+func NewUserAdminUsecase(userRepo domain.UserRepository, taskRepo domain.TaskRepository) domain.UserAdminUsecase {
+	return &UserAdminUsecaseImpl{userRepo: userRepo, taskRepo: taskRepo}
+}
+
+func (u *UserAdminUsecaseImpl) ListUsers(ctx context.Context, query domain.UserQuery) (*domain.UserPage, error) {
+	if err := validateUserQuery(&query); err != nil {
+		return nil, err
+	}
+	users, total, err := u.userRepo.ListUsers(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	return &domain.UserPage{Users: users, Page: query.Page, PerPage: query.PerPage, Total: total}, nil
+}
+
+func (u *UserAdminUsecaseImpl) GetUser(ctx context.Context, id string) (*domain.User, error) {
+	user, err := u.userRepo.FindUserByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, domain.ErrUserNotFound
+	}
+	return user, nil
+}
+
+func (u *UserAdminUsecaseImpl) SetRole(ctx context.Context, id, role string) error {
+	if err := validateRole(role); err != nil {
+		return err
+	}
+	if _, err := u.target(ctx, id); err != nil {
+		return err
+	}
+	if err := u.userRepo.SetRole(ctx, id, role); err != nil {
+		return err
+	}
+	domain.Logger(ctx).Info("user role changed", "target_id", id, "role", role)
+	return nil
+}
+
+func (u *UserAdminUsecaseImpl) SetDisabled(ctx context.Context, id string, disabled bool) error {
+	if _, err := u.target(ctx, id); err != nil {
+		return err
+	}
+	if err := u.userRepo.SetDisabled(ctx, id, disabled); err != nil {
+		return err
+	}
+	domain.Logger(ctx).Info("user access changed", "target_id", id, "disabled", disabled)
+	return nil
+}
+
+// DeleteUser moves the tasks before removing the account, so a failure part
+// way leaves a user without tasks rather than tasks pointing at nobody.
+func (u *UserAdminUsecaseImpl) DeleteUser(ctx context.Context, id, reassignTo string) error {
+	if _, err := u.target(ctx, id); err != nil {
+		return err
+	}
+	if reassignTo != "" {
+		if reassignTo == id {
+			return domain.ValidationErrors{{Field: "reassign_to", Message: "must be a different user"}}
+		}
+		heir, err := u.userRepo.FindUserByID(ctx, reassignTo)
+		if err != nil {
+			return err
+		}
+		if heir == nil {
+			return domain.ValidationErrors{{Field: "reassign_to", Message: "user does not exist"}}
+		}
+	}
+	moved, err := u.taskRepo.ReassignTasks(ctx, id, reassignTo)
+	if err != nil {
+		return err
+	}
+	if err := u.userRepo.DeleteUser(ctx, id); err != nil {
+		return err
+	}
+	domain.Logger(ctx).Info("user deleted", "target_id", id, "tasks_reassigned_to", reassignTo, "tasks", moved)
+	return nil
+}
+
+// target loads the user an admin action applies to. Admins cannot act on
+// their own account, so the last admin cannot lock everyone out by accident.
+func (u *UserAdminUsecaseImpl) target(ctx context.Context, id string) (*domain.User, error) {
+	callerID, err := domain.CallerID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if callerID == id {
+		return nil, domain.ErrCannotModifySelf
+	}
+	return u.GetUser(ctx, id)
+}
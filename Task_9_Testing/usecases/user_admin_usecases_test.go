@@ -0,0 +1,126 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"task_manager/domain"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type UserAdminUsecaseTestSuite struct {
+	suite.Suite
+	mockRepo  *MockUserRepository
+	mockTasks *MockTaskRepository
+	usecase   domain.UserAdminUsecase
+	ctx       context.Context
+}
+
+func (s *UserAdminUsecaseTestSuite) SetupTest() {
+	s.mockRepo = &MockUserRepository{}
+	s.mockTasks = &MockTaskRepository{}
+	s.usecase = NewUserAdminUsecase(s.mockRepo, s.mockTasks)
+	s.ctx = domain.WithPrincipal(context.Background(), &domain.Principal{UserID: "1", Username: "admin", Role: domain.RoleAdmin})
+}
+
+func (s *UserAdminUsecaseTestSuite) TearDownTest() {
+	s.mockRepo.AssertExpectations(s.T())
+	s.mockTasks.AssertExpectations(s.T())
+}
+
+func (s *UserAdminUsecaseTestSuite) TestListUsers() {
+	s.Run("Defaults", func() {
+		users := []domain.User{{ID: "2", Username: "bob"}}
+		s.mockRepo.On("ListUsers", s.ctx, domain.UserQuery{Search: "bo", Page: 1, PerPage: 20}).Return(users, int64(1), nil).Once()
+
+		page, err := s.usecase.ListUsers(s.ctx, domain.UserQuery{Search: "bo"})
+		s.NoError(err)
+		s.Equal(&domain.UserPage{Users: users, Page: 1, PerPage: 20, Total: 1}, page)
+	})
+
+	s.Run("InvalidQuery", func() {
+		_, err := s.usecase.ListUsers(s.ctx, domain.UserQuery{Role: "root", Page: -1, PerPage: 500})
+		var verrs domain.ValidationErrors
+		s.Require().True(errors.As(err, &verrs))
+		s.Len(verrs, 3)
+	})
+}
+
+func (s *UserAdminUsecaseTestSuite) TestGetUser() {
+	s.Run("NotFound", func() {
+		s.mockRepo.On("FindUserByID", s.ctx, "9").Return((*domain.User)(nil), nil).Once()
+
+		_, err := s.usecase.GetUser(s.ctx, "9")
+		s.ErrorIs(err, domain.ErrUserNotFound)
+	})
+}
+
+func (s *UserAdminUsecaseTestSuite) TestSetRole() {
+	s.Run("Demote", func() {
+		s.mockRepo.On("FindUserByID", s.ctx, "2").Return(&domain.User{ID: "2", Role: domain.RoleAdmin}, nil).Once()
+		s.mockRepo.On("SetRole", s.ctx, "2", domain.RoleUser).Return(nil).Once()
+
+		s.NoError(s.usecase.SetRole(s.ctx, "2", domain.RoleUser))
+	})
+
+	s.Run("Self", func() {
+		err := s.usecase.SetRole(s.ctx, "1", domain.RoleUser)
+		s.ErrorIs(err, domain.ErrCannotModifySelf)
+	})
+
+	s.Run("UnknownRole", func() {
+		err := s.usecase.SetRole(s.ctx, "2", "root")
+		var verrs domain.ValidationErrors
+		s.True(errors.As(err, &verrs))
+	})
+}
+
+func (s *UserAdminUsecaseTestSuite) TestSetDisabled() {
+	s.Run("Success", func() {
+		s.mockRepo.On("FindUserByID", s.ctx, "2").Return(&domain.User{ID: "2"}, nil).Once()
+		s.mockRepo.On("SetDisabled", s.ctx, "2", true).Return(nil).Once()
+
+		s.NoError(s.usecase.SetDisabled(s.ctx, "2", true))
+	})
+
+	s.Run("Self", func() {
+		s.ErrorIs(s.usecase.SetDisabled(s.ctx, "1", true), domain.ErrCannotModifySelf)
+	})
+}
+
+func (s *UserAdminUsecaseTestSuite) TestDeleteUser() {
+	s.Run("OrphansTasks", func() {
+		s.mockRepo.On("FindUserByID", s.ctx, "2").Return(&domain.User{ID: "2"}, nil).Once()
+		s.mockTasks.On("ReassignTasks", s.ctx, "2", "").Return(int64(3), nil).Once()
+		s.mockRepo.On("DeleteUser", s.ctx, "2").Return(nil).Once()
+
+		s.NoError(s.usecase.DeleteUser(s.ctx, "2", ""))
+	})
+
+	s.Run("ReassignsTasks", func() {
+		s.mockRepo.On("FindUserByID", s.ctx, "2").Return(&domain.User{ID: "2"}, nil).Once()
+		s.mockRepo.On("FindUserByID", s.ctx, "3").Return(&domain.User{ID: "3"}, nil).Once()
+		s.mockTasks.On("ReassignTasks", s.ctx, "2", "3").Return(int64(3), nil).Once()
+		s.mockRepo.On("DeleteUser", s.ctx, "2").Return(nil).Once()
+
+		s.NoError(s.usecase.DeleteUser(s.ctx, "2", "3"))
+	})
+
+	s.Run("UnknownHeir", func() {
+		s.mockRepo.On("FindUserByID", s.ctx, "2").Return(&domain.User{ID: "2"}, nil).Once()
+		s.mockRepo.On("FindUserByID", s.ctx, "9").Return((*domain.User)(nil), nil).Once()
+
+		err := s.usecase.DeleteUser(s.ctx, "2", "9")
+		var verrs domain.ValidationErrors
+		s.True(errors.As(err, &verrs), "Tasks should not be moved to a user that does not exist")
+	})
+
+	s.Run("Self", func() {
+		s.ErrorIs(s.usecase.DeleteUser(s.ctx, "1", ""), domain.ErrCannotModifySelf)
+	})
+}
+
+func TestUserAdminUsecaseSuite(t *testing.T) {
+	suite.Run(t, new(UserAdminUsecaseTestSuite))
+}
@@ -4,6 +4,8 @@ import (
 	"context"
 	"errors"
 	"task_manager/domain"
+	"time"
+
 	"github.com/google/uuid"
 )
 
@@ -11,23 +13,58 @@ type UserUsecaseImpl struct {
 	userRepo    domain.UserRepository
 	passwordSvc domain.PasswordService
 	jwtSvc      domain.JWTService
+	now         func() time.Time
+
+	verifier            domain.VerificationUsecase
+	requireVerification bool
+}
+
+// UserUsecaseOption configures optional behaviour of NewUserUsecase.
+type UserUsecaseOption func(*UserUsecaseImpl)
+
+// WithEmailVerification mails a verification link on registration. With
+// required set, registration needs an email address and unverified users
+// cannot log in.
+func WithEmailVerification(verifier domain.VerificationUsecase, required bool) UserUsecaseOption {
+	return func(u *UserUsecaseImpl) {
+		u.verifier = verifier
+		u.requireVerification = required
+	}
 }
 
-func NewUserUsecase(userRepo domain.UserRepository, passwordSvc domain.PasswordService, jwtSvc domain.JWTService) domain.UserUsecase {
-	return &UserUsecaseImpl{
+func NewUserUsecase(userRepo domain.UserRepository, passwordSvc domain.PasswordService, jwtSvc domain.JWTService, opts ...UserUsecaseOption) domain.UserUsecase {
+	u := &UserUsecaseImpl{
 		userRepo:    userRepo,
 		passwordSvc: passwordSvc,
 		jwtSvc:      jwtSvc,
+		now:         time.Now,
 	}
+	for _, opt := range opts {
+		opt(u)
+	}
+	return u
 }
 
 func (u *UserUsecaseImpl) Register(ctx context.Context, user domain.User) error {
+	if err := validateRegistration(user); err != nil {
+		return err
+	}
+	if u.requireVerification && user.Email == "" {
+		return domain.ValidationErrors{{Field: "email", Message: "is required"}}
+	}
+
 	if existing, _ := u.userRepo.FindUserByUsername(ctx, user.Username); existing != nil {
-		return errors.New("username already exists")
+		return domain.ErrUsernameTaken
+	}
+	if user.Email != "" {
+		if existing, _ := u.userRepo.FindUserByEmail(ctx, user.Email); existing != nil {
+			return domain.ErrEmailInUse
+		}
 	}
 
-	
 	user.ID = uuid.New().String()
+	user.Verified = false
+	user.CreatedAt = u.now()
 
 	hashed, err := u.passwordSvc.HashPassword(user.Password)
 	if err != nil {
@@ -45,41 +82,111 @@ func (u *UserUsecaseImpl) Register(ctx context.Context, user domain.User) error
 		user.Role = "user"
 	}
 
-	return u.userRepo.CreateUser(ctx, user)
+	if err := u.userRepo.CreateUser(ctx, user); err != nil {
+		return err
+	}
+	if u.verifier != nil && user.Email != "" {
+		// The account exists either way; a lost email can be sent again through the resend endpoint.
+		if err := u.verifier.SendVerification(ctx, user); err != nil {
+			domain.Logger(ctx).Error("send verification", "error", err)
+		}
+	}
+	return nil
 }
 
-func (u *UserUsecaseImpl) Login(ctx context.Context, username, password string) (string, error) {
+func (u *UserUsecaseImpl) Login(ctx context.Context, username, password, ip string) (string, error) {
+	now := u.now()
+	guards := loginGuards(username, ip)
+	if err := checkLoginAllowed(ctx, u.userRepo, guards, now); err != nil {
+		return "", err
+	}
+
 	user, err := u.userRepo.FindUserByUsername(ctx, username)
-	if err != nil || user == nil {
-		return "", errors.New("invalid credentials")
+	if err != nil {
+		return "", err
 	}
 
-	if err := u.passwordSvc.ComparePassword(user.Password, password); err != nil {
+	hash := dummyPasswordHash
+	if user != nil {
+		hash = user.Password
+	}
+	if err := u.passwordSvc.ComparePassword(hash, password); err != nil || user == nil {
+		recordLoginFailure(ctx, u.userRepo, guards, username, ip, now)
 		return "", errors.New("invalid credentials")
 	}
 
-	token, err := u.jwtSvc.GenerateToken(user.ID, user.Username, user.Role)
+	// With two-factor enabled, failures stay on the books until the code is
+	// also right, otherwise knowing the password would reset the guessing budget.
+	if !user.TOTPEnabled {
+		if err := u.userRepo.ResetLoginAttempts(ctx, userLoginKey(username)); err != nil {
+			return "", err
+		}
+	}
+	if user.Disabled {
+		return "", domain.ErrAccountDisabled
+	}
+	if u.requireVerification && !user.Verified {
+		return "", domain.ErrEmailNotVerified
+	}
+	if user.TOTPEnabled {
+		return "", startMFAChallenge(ctx, u.userRepo, user, now)
+	}
+
+	token, err := u.jwtSvc.GenerateToken(user.ID, user.Username, user.Role, "pwd")
 	if err != nil {
 		return "", err
 	}
+	recordLogin(ctx, u.userRepo, user.ID, now)
 	return token, nil
 }
 
+// startMFAChallenge returns the MFARequiredError that hands the caller a
+// token for MFAUsecase.CompleteLogin. amr is how the first factor was
+// proven, when not with a password.
+func startMFAChallenge(ctx context.Context, userRepo domain.UserRepository, user *domain.User, now time.Time, amr ...string) error {
+	token, err := newSecretToken()
+	if err != nil {
+		return err
+	}
+	challenge := domain.MFAChallenge{
+		TokenHash: hashToken(token),
+		UserID:    user.ID,
+		ExpiresAt: now.Add(mfaChallengeTTL),
+		AMR:       amr,
+	}
+	if err := userRepo.CreateMFAChallenge(ctx, challenge); err != nil {
+		return err
+	}
+	return &domain.MFARequiredError{Token: token}
+}
+
 func (u *UserUsecaseImpl) PromoteUser(ctx context.Context, username string) error {
 	user, err := u.userRepo.FindUserByUsername(ctx, username)
 	if err != nil || user == nil {
-		return errors.New("user not found")
+		return domain.ErrUserNotFound
 	}
 	return u.userRepo.PromoteUser(ctx, username)
 }
 
-
-func (u *UserUsecaseImpl) GetAllUsers(ctx context.Context) ([]*domain.User, error) {
-	users, err := u.userRepo.GetAllUsers(ctx)
-	if err != nil || len(users) == 0 {
-		return nil, errors.New("no users found")
+func (u *UserUsecaseImpl) FindUsersByIDs(ctx context.Context, ids []string) ([]domain.User, error) {
+	if len(ids) == 0 {
+		return []domain.User{}, nil
 	}
-	return users, nil
+	return u.userRepo.FindUsersByIDs(ctx, ids)
 }
 
-
+// UnlockLogin clears failures and locks for a username, an IP, or both.
+func (u *UserUsecaseImpl) UnlockLogin(ctx context.Context, username, ip string) error {
+	if username == "" && ip == "" {
+		return domain.ValidationErrors{{Field: "username", Message: "username or ip is required"}}
+	}
+	if username != "" {
+		if err := u.userRepo.ResetLoginAttempts(ctx, userLoginKey(username)); err != nil {
+			return err
+		}
+	}
+	if ip != "" {
+		return u.userRepo.ResetLoginAttempts(ctx, ipLoginKey(ip))
+	}
+	return nil
+}
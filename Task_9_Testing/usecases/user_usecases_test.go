@@ -2,10 +2,11 @@ package usecases
 
 import (
 	"context"
+	"errors"
 	"task_manager/domain"
 	"testing"
+	"time"
 
-	"github.com/golang-jwt/jwt/v5"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/suite"
 )
@@ -23,6 +24,47 @@ func (m *MockUserRepository) FindUserByUsername(ctx context.Context, username st
 	return args.Get(0).(*domain.User), args.Error(1)
 }
 
+func (m *MockUserRepository) FindUserByID(ctx context.Context, id string) (*domain.User, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(*domain.User), args.Error(1)
+}
+
+func (m *MockUserRepository) FindUsersByIDs(ctx context.Context, ids []string) ([]domain.User, error) {
+	args := m.Called(ctx, ids)
+	return args.Get(0).([]domain.User), args.Error(1)
+}
+
+func (m *MockUserRepository) FindUserByEmail(ctx context.Context, email string) (*domain.User, error) {
+	args := m.Called(ctx, email)
+	return args.Get(0).(*domain.User), args.Error(1)
+}
+
+func (m *MockUserRepository) UpdatePassword(ctx context.Context, id, hashed string, changedAt time.Time) error {
+	return m.Called(ctx, id, hashed, changedAt).Error(0)
+}
+
+func (m *MockUserRepository) MarkEmailVerified(ctx context.Context, id, email string) (bool, error) {
+	args := m.Called(ctx, id, email)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockUserRepository) UpdateProfile(ctx context.Context, id string, fields map[string]interface{}) error {
+	return m.Called(ctx, id, fields).Error(0)
+}
+
+func (m *MockUserRepository) RecordLogin(ctx context.Context, id string, at time.Time) error {
+	return m.Called(ctx, id, at).Error(0)
+}
+
+func (m *MockUserRepository) FindUserByOIDCSubject(ctx context.Context, issuer, subject string) (*domain.User, error) {
+	args := m.Called(ctx, issuer, subject)
+	return args.Get(0).(*domain.User), args.Error(1)
+}
+
+func (m *MockUserRepository) LinkOIDCIdentity(ctx context.Context, id, issuer, subject string) error {
+	return m.Called(ctx, id, issuer, subject).Error(0)
+}
+
 func (m *MockUserRepository) PromoteUser(ctx context.Context, username string) error {
 	return m.Called(ctx, username).Error(0)
 }
@@ -38,7 +80,96 @@ func (m *MockUserRepository) GetAllUsers(ctx context.Context) ([]*domain.User, e
 }
 
 func (m *MockUserRepository) DeleteUser(ctx context.Context, id string) error {
-	return nil
+	return m.Called(ctx, id).Error(0)
+}
+
+func (m *MockUserRepository) SetRole(ctx context.Context, id, role string) error {
+	return m.Called(ctx, id, role).Error(0)
+}
+
+func (m *MockUserRepository) SetDisabled(ctx context.Context, id string, disabled bool) error {
+	return m.Called(ctx, id, disabled).Error(0)
+}
+
+func (m *MockUserRepository) ListUsers(ctx context.Context, query domain.UserQuery) ([]domain.User, int64, error) {
+	args := m.Called(ctx, query)
+	return args.Get(0).([]domain.User), args.Get(1).(int64), args.Error(2)
+}
+
+func (m *MockUserRepository) GetLoginAttempts(ctx context.Context, key string) (*domain.LoginAttempts, error) {
+	args := m.Called(ctx, key)
+	return args.Get(0).(*domain.LoginAttempts), args.Error(1)
+}
+
+func (m *MockUserRepository) RecordFailedLogin(ctx context.Context, key string, at time.Time) (*domain.LoginAttempts, error) {
+	args := m.Called(ctx, key, at)
+	return args.Get(0).(*domain.LoginAttempts), args.Error(1)
+}
+
+func (m *MockUserRepository) LockLogin(ctx context.Context, key string, until time.Time) error {
+	return m.Called(ctx, key, until).Error(0)
+}
+
+func (m *MockUserRepository) ResetLoginAttempts(ctx context.Context, key string) error {
+	return m.Called(ctx, key).Error(0)
+}
+
+func (m *MockUserRepository) RecordLockEvent(ctx context.Context, event domain.LockEvent) error {
+	return m.Called(ctx, event).Error(0)
+}
+
+func (m *MockUserRepository) CreatePasswordReset(ctx context.Context, reset domain.PasswordReset) error {
+	return m.Called(ctx, reset).Error(0)
+}
+
+func (m *MockUserRepository) FindPasswordReset(ctx context.Context, tokenHash string) (*domain.PasswordReset, error) {
+	args := m.Called(ctx, tokenHash)
+	return args.Get(0).(*domain.PasswordReset), args.Error(1)
+}
+
+func (m *MockUserRepository) ConsumePasswordReset(ctx context.Context, tokenHash string, now time.Time) (*domain.PasswordReset, error) {
+	args := m.Called(ctx, tokenHash, now)
+	return args.Get(0).(*domain.PasswordReset), args.Error(1)
+}
+
+func (m *MockUserRepository) DeletePasswordResets(ctx context.Context, userID string) error {
+	return m.Called(ctx, userID).Error(0)
+}
+
+func (m *MockUserRepository) SetTOTPSecret(ctx context.Context, id, secret string, recoveryCodes []string) error {
+	return m.Called(ctx, id, secret, recoveryCodes).Error(0)
+}
+
+func (m *MockUserRepository) EnableTOTP(ctx context.Context, id string) error {
+	return m.Called(ctx, id).Error(0)
+}
+
+func (m *MockUserRepository) DisableTOTP(ctx context.Context, id string) error {
+	return m.Called(ctx, id).Error(0)
+}
+
+func (m *MockUserRepository) AdvanceTOTPStep(ctx context.Context, id string, step int64) (bool, error) {
+	args := m.Called(ctx, id, step)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockUserRepository) ConsumeRecoveryCode(ctx context.Context, id, codeHash string) (bool, error) {
+	args := m.Called(ctx, id, codeHash)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockUserRepository) CreateMFAChallenge(ctx context.Context, challenge domain.MFAChallenge) error {
+	return m.Called(ctx, challenge).Error(0)
+}
+
+func (m *MockUserRepository) FindMFAChallenge(ctx context.Context, tokenHash string) (*domain.MFAChallenge, error) {
+	args := m.Called(ctx, tokenHash)
+	return args.Get(0).(*domain.MFAChallenge), args.Error(1)
+}
+
+func (m *MockUserRepository) ConsumeMFAChallenge(ctx context.Context, tokenHash string, now time.Time) (*domain.MFAChallenge, error) {
+	args := m.Called(ctx, tokenHash, now)
+	return args.Get(0).(*domain.MFAChallenge), args.Error(1)
 }
 
 type MockPasswordService struct {
@@ -58,15 +189,35 @@ type MockJWTService struct {
 	mock.Mock
 }
 
-func (m *MockJWTService) GenerateToken(userID, username, role string) (string, error) {
-	args := m.Called(userID, username, role)
+func (m *MockJWTService) GenerateToken(userID, username, role string, amr ...string) (string, error) {
+	args := m.Called(userID, username, role, amr)
 	return args.String(0), args.Error(1)
 }
 
-func (m *MockJWTService) ValidateToken(tokenString string) (*jwt.Token, error) {
+func (m *MockJWTService) ValidateToken(tokenString string) (*domain.Claims, error) {
 	return nil, nil
 }
 
+func (m *MockJWTService) JWKS() domain.JSONWebKeySet {
+	return domain.JSONWebKeySet{}
+}
+
+type MockVerificationUsecase struct {
+	mock.Mock
+}
+
+func (m *MockVerificationUsecase) SendVerification(ctx context.Context, user domain.User) error {
+	return m.Called(ctx, user).Error(0)
+}
+
+func (m *MockVerificationUsecase) VerifyEmail(ctx context.Context, token string) error {
+	return m.Called(ctx, token).Error(0)
+}
+
+func (m *MockVerificationUsecase) ResendVerification(ctx context.Context, email string) error {
+	return m.Called(ctx, email).Error(0)
+}
+
 type UserUsecaseTestSuite struct {
 	suite.Suite
 	mockRepo *MockUserRepository
@@ -92,9 +243,9 @@ func (s *UserUsecaseTestSuite) TearDownTest() {
 
 func (s *UserUsecaseTestSuite) TestRegister() {
 	s.Run("SuccessFirstUser", func() {
-		user := domain.User{Username: "testuser", Password: "plain"}
+		user := domain.User{Username: "testuser", Password: "s3cretpass"}
 		s.mockRepo.On("FindUserByUsername", s.ctx, "testuser").Return((*domain.User)(nil), nil).Once()
-		s.mockPass.On("HashPassword", "plain").Return("hashed", nil).Once()
+		s.mockPass.On("HashPassword", "s3cretpass").Return("hashed", nil).Once()
 		s.mockRepo.On("IsFirstUser", s.ctx).Return(true, nil).Once()
 		s.mockRepo.On("CreateUser", s.ctx, mock.Anything).Return(nil).Once()
 
@@ -103,9 +254,9 @@ func (s *UserUsecaseTestSuite) TestRegister() {
 	})
 
 	s.Run("SuccessNotFirstUser", func() {
-		user := domain.User{Username: "testuser2", Password: "plain"}
+		user := domain.User{Username: "testuser2", Password: "s3cretpass"}
 		s.mockRepo.On("FindUserByUsername", s.ctx, "testuser2").Return((*domain.User)(nil), nil).Once()
-		s.mockPass.On("HashPassword", "plain").Return("hashed", nil).Once()
+		s.mockPass.On("HashPassword", "s3cretpass").Return("hashed", nil).Once()
 		s.mockRepo.On("IsFirstUser", s.ctx).Return(false, nil).Once()
 		s.mockRepo.On("CreateUser", s.ctx, mock.Anything).Return(nil).Once()
 
@@ -114,7 +265,7 @@ func (s *UserUsecaseTestSuite) TestRegister() {
 	})
 
 	s.Run("UsernameExists", func() {
-		user := domain.User{Username: "testuser", Password: "plain"}
+		user := domain.User{Username: "testuser", Password: "s3cretpass"}
 		existing := &domain.User{Username: "testuser"}
 		s.mockRepo.On("FindUserByUsername", s.ctx, "testuser").Return(existing, nil).Once()
 
@@ -122,28 +273,196 @@ func (s *UserUsecaseTestSuite) TestRegister() {
 		s.Error(err)
 		s.Equal("username already exists", err.Error())
 	})
+
+	s.Run("InvalidInput", func() {
+		user := domain.User{Username: "a!", Password: "short"}
+
+		err := s.usecase.Register(s.ctx, user)
+		var verrs domain.ValidationErrors
+		s.ErrorAs(err, &verrs)
+		s.Len(verrs, 2)
+		s.Equal("username", verrs[0].Field)
+		s.Equal("password", verrs[1].Field)
+	})
+}
+
+func (s *UserUsecaseTestSuite) TestRegisterWithVerification() {
+	verifier := &MockVerificationUsecase{}
+	s.usecase = NewUserUsecase(s.mockRepo, s.mockPass, s.mockJWT, WithEmailVerification(verifier, true))
+	defer verifier.AssertExpectations(s.T())
+
+	s.Run("SendsLink", func() {
+		user := domain.User{Username: "testuser", Password: "s3cretpass", Email: "test@example.com", Verified: true}
+		s.mockRepo.On("FindUserByUsername", s.ctx, "testuser").Return((*domain.User)(nil), nil).Once()
+		s.mockRepo.On("FindUserByEmail", s.ctx, "test@example.com").Return((*domain.User)(nil), nil).Once()
+		s.mockPass.On("HashPassword", "s3cretpass").Return("hashed", nil).Once()
+		s.mockRepo.On("IsFirstUser", s.ctx).Return(false, nil).Once()
+		s.mockRepo.On("CreateUser", s.ctx, mock.MatchedBy(func(u domain.User) bool { return !u.Verified })).Return(nil).Once()
+		verifier.On("SendVerification", s.ctx, mock.MatchedBy(func(u domain.User) bool { return u.Email == "test@example.com" })).Return(nil).Once()
+
+		s.NoError(s.usecase.Register(s.ctx, user))
+	})
+
+	s.Run("EmailRequired", func() {
+		user := domain.User{Username: "testuser", Password: "s3cretpass"}
+
+		var verrs domain.ValidationErrors
+		s.ErrorAs(s.usecase.Register(s.ctx, user), &verrs)
+		s.Equal("email", verrs[0].Field)
+	})
+
+	s.Run("EmailTaken", func() {
+		user := domain.User{Username: "testuser", Password: "s3cretpass", Email: "test@example.com"}
+		s.mockRepo.On("FindUserByUsername", s.ctx, "testuser").Return((*domain.User)(nil), nil).Once()
+		s.mockRepo.On("FindUserByEmail", s.ctx, "test@example.com").Return(&domain.User{ID: "2"}, nil).Once()
+
+		err := s.usecase.Register(s.ctx, user)
+		s.EqualError(err, "email already in use")
+	})
+
+	s.Run("UnverifiedCannotLogIn", func() {
+		user := &domain.User{ID: "1", Username: "testuser", Password: "hashed", Role: "user"}
+		s.mockRepo.On("GetLoginAttempts", s.ctx, mock.Anything).Return((*domain.LoginAttempts)(nil), nil).Twice()
+		s.mockRepo.On("FindUserByUsername", s.ctx, "testuser").Return(user, nil).Once()
+		s.mockPass.On("ComparePassword", "hashed", "plain").Return(nil).Once()
+		s.mockRepo.On("ResetLoginAttempts", s.ctx, "user:testuser").Return(nil).Once()
+
+		_, err := s.usecase.Login(s.ctx, "testuser", "plain", "10.0.0.1")
+		s.ErrorIs(err, domain.ErrEmailNotVerified)
+	})
 }
 
 func (s *UserUsecaseTestSuite) TestLogin() {
+	now := time.Date(2025, 4, 3, 12, 0, 0, 0, time.UTC)
+	s.usecase.(*UserUsecaseImpl).now = func() time.Time { return now }
+	noAttempts := (*domain.LoginAttempts)(nil)
+
 	s.Run("Success", func() {
 		user := &domain.User{ID: "1", Username: "testuser", Password: "hashed", Role: "user"}
+		s.mockRepo.On("GetLoginAttempts", s.ctx, "user:testuser").Return(noAttempts, nil).Once()
+		s.mockRepo.On("GetLoginAttempts", s.ctx, "ip:10.0.0.1").Return(noAttempts, nil).Once()
 		s.mockRepo.On("FindUserByUsername", s.ctx, "testuser").Return(user, nil).Once()
 		s.mockPass.On("ComparePassword", "hashed", "plain").Return(nil).Once()
-		s.mockJWT.On("GenerateToken", "1", "testuser", "user").Return("token", nil).Once()
+		s.mockRepo.On("ResetLoginAttempts", s.ctx, "user:testuser").Return(nil).Once()
+		s.mockJWT.On("GenerateToken", "1", "testuser", "user", []string{"pwd"}).Return("token", nil).Once()
+		s.mockRepo.On("RecordLogin", s.ctx, "1", now).Return(nil).Once()
 
-		token, err := s.usecase.Login(s.ctx, "testuser", "plain")
+		token, err := s.usecase.Login(s.ctx, "testuser", "plain", "10.0.0.1")
 		s.NoError(err)
 		s.Equal("token", token)
 	})
 
+	s.Run("Disabled", func() {
+		user := &domain.User{ID: "1", Username: "testuser", Password: "hashed", Role: "user", Disabled: true}
+		s.mockRepo.On("GetLoginAttempts", s.ctx, "user:testuser").Return(noAttempts, nil).Once()
+		s.mockRepo.On("GetLoginAttempts", s.ctx, "ip:10.0.0.1").Return(noAttempts, nil).Once()
+		s.mockRepo.On("FindUserByUsername", s.ctx, "testuser").Return(user, nil).Once()
+		s.mockPass.On("ComparePassword", "hashed", "plain").Return(nil).Once()
+		s.mockRepo.On("ResetLoginAttempts", s.ctx, "user:testuser").Return(nil).Once()
+
+		_, err := s.usecase.Login(s.ctx, "testuser", "plain", "10.0.0.1")
+		s.ErrorIs(err, domain.ErrAccountDisabled)
+	})
+
+	s.Run("RequiresSecondFactor", func() {
+		user := &domain.User{ID: "1", Username: "testuser", Password: "hashed", Role: "user", TOTPEnabled: true}
+		s.mockRepo.On("GetLoginAttempts", s.ctx, "user:testuser").Return(noAttempts, nil).Once()
+		s.mockRepo.On("GetLoginAttempts", s.ctx, "ip:10.0.0.1").Return(noAttempts, nil).Once()
+		s.mockRepo.On("FindUserByUsername", s.ctx, "testuser").Return(user, nil).Once()
+		s.mockPass.On("ComparePassword", "hashed", "plain").Return(nil).Once()
+		s.mockRepo.On("CreateMFAChallenge", s.ctx, mock.MatchedBy(func(c domain.MFAChallenge) bool {
+			return c.UserID == "1" && c.ExpiresAt.Equal(now.Add(mfaChallengeTTL))
+		})).Return(nil).Once()
+
+		token, err := s.usecase.Login(s.ctx, "testuser", "plain", "10.0.0.1")
+		var mfaRequired *domain.MFARequiredError
+		s.ErrorAs(err, &mfaRequired)
+		s.NotEmpty(mfaRequired.Token)
+		s.Empty(token)
+	})
+
 	s.Run("InvalidCredentials", func() {
+		s.mockRepo.On("GetLoginAttempts", s.ctx, "user:testuser").Return(noAttempts, nil).Once()
+		s.mockRepo.On("GetLoginAttempts", s.ctx, "ip:10.0.0.1").Return(noAttempts, nil).Once()
 		s.mockRepo.On("FindUserByUsername", s.ctx, "testuser").Return((*domain.User)(nil), nil).Once()
+		s.mockPass.On("ComparePassword", dummyPasswordHash, "plain").Return(errors.New("mismatch")).Once()
+		s.mockRepo.On("RecordFailedLogin", s.ctx, "user:testuser", now).Return(&domain.LoginAttempts{Failures: 1}, nil).Once()
+		s.mockRepo.On("RecordFailedLogin", s.ctx, "ip:10.0.0.1", now).Return(&domain.LoginAttempts{Failures: 1}, nil).Once()
 
-		token, err := s.usecase.Login(s.ctx, "testuser", "plain")
+		token, err := s.usecase.Login(s.ctx, "testuser", "plain", "10.0.0.1")
 		s.Error(err)
 		s.Equal("invalid credentials", err.Error())
 		s.Empty(token)
 	})
+
+	s.Run("LocksAfterRepeatedFailures", func() {
+		user := &domain.User{ID: "1", Username: "testuser", Password: "hashed", Role: "user"}
+		until := now.Add(loginLockDuration)
+		s.mockRepo.On("GetLoginAttempts", s.ctx, "user:testuser").Return(&domain.LoginAttempts{Failures: 4, LastFailure: now.Add(-time.Minute)}, nil).Once()
+		s.mockRepo.On("GetLoginAttempts", s.ctx, "ip:10.0.0.1").Return(noAttempts, nil).Once()
+		s.mockRepo.On("FindUserByUsername", s.ctx, "testuser").Return(user, nil).Once()
+		s.mockPass.On("ComparePassword", "hashed", "wrong").Return(errors.New("mismatch")).Once()
+		s.mockRepo.On("RecordFailedLogin", s.ctx, "user:testuser", now).Return(&domain.LoginAttempts{Failures: 5}, nil).Once()
+		s.mockRepo.On("RecordFailedLogin", s.ctx, "ip:10.0.0.1", now).Return(&domain.LoginAttempts{Failures: 1}, nil).Once()
+		s.mockRepo.On("LockLogin", s.ctx, "user:testuser", until).Return(nil).Once()
+		s.mockRepo.On("RecordLockEvent", s.ctx, mock.MatchedBy(func(e domain.LockEvent) bool {
+			return e.Key == "user:testuser" && e.IP == "10.0.0.1" && e.Failures == 5 && e.LockedUntil.Equal(until)
+		})).Return(nil).Once()
+
+		_, err := s.usecase.Login(s.ctx, "testuser", "wrong", "10.0.0.1")
+		s.Equal("invalid credentials", err.Error())
+	})
+
+	s.Run("Locked", func() {
+		until := now.Add(10 * time.Minute)
+		s.mockRepo.On("GetLoginAttempts", s.ctx, "user:testuser").Return(&domain.LoginAttempts{LockedUntil: until}, nil).Once()
+
+		_, err := s.usecase.Login(s.ctx, "testuser", "plain", "10.0.0.1")
+		var blocked *domain.LoginBlockedError
+		s.ErrorAs(err, &blocked)
+		s.True(blocked.Locked)
+		s.Equal(until, blocked.Until)
+	})
+
+	s.Run("ProgressiveDelay", func() {
+		attempts := &domain.LoginAttempts{Failures: 4, LastFailure: now.Add(-time.Second)}
+		s.mockRepo.On("GetLoginAttempts", s.ctx, "user:testuser").Return(attempts, nil).Once()
+
+		_, err := s.usecase.Login(s.ctx, "testuser", "plain", "10.0.0.1")
+		var blocked *domain.LoginBlockedError
+		s.ErrorAs(err, &blocked)
+		s.False(blocked.Locked)
+		s.Equal(now.Add(time.Second), blocked.Until)
+	})
+
+	s.Run("IPLocked", func() {
+		s.mockRepo.On("GetLoginAttempts", s.ctx, "user:other").Return(noAttempts, nil).Once()
+		s.mockRepo.On("GetLoginAttempts", s.ctx, "ip:10.0.0.9").Return(&domain.LoginAttempts{LockedUntil: now.Add(time.Minute)}, nil).Once()
+
+		_, err := s.usecase.Login(s.ctx, "other", "plain", "10.0.0.9")
+		var blocked *domain.LoginBlockedError
+		s.ErrorAs(err, &blocked)
+	})
+}
+
+func (s *UserUsecaseTestSuite) TestUnlockLogin() {
+	s.Run("UsernameAndIP", func() {
+		s.mockRepo.On("ResetLoginAttempts", s.ctx, "user:testuser").Return(nil).Once()
+		s.mockRepo.On("ResetLoginAttempts", s.ctx, "ip:10.0.0.1").Return(nil).Once()
+
+		s.NoError(s.usecase.UnlockLogin(s.ctx, "testuser", "10.0.0.1"))
+	})
+
+	s.Run("UsernameOnly", func() {
+		s.mockRepo.On("ResetLoginAttempts", s.ctx, "user:testuser").Return(nil).Once()
+
+		s.NoError(s.usecase.UnlockLogin(s.ctx, "testuser", ""))
+	})
+
+	s.Run("NothingGiven", func() {
+		var verrs domain.ValidationErrors
+		s.ErrorAs(s.usecase.UnlockLogin(s.ctx, "", ""), &verrs)
+	})
 }
 
 func (s *UserUsecaseTestSuite) TestPromoteUser() {
@@ -165,27 +484,20 @@ func (s *UserUsecaseTestSuite) TestPromoteUser() {
 	})
 }
 
-func (s *UserUsecaseTestSuite) TestGetAllUsers() {
+func (s *UserUsecaseTestSuite) TestFindUsersByIDs() {
 	s.Run("Success", func() {
-		users := []*domain.User{
-			{ID: "1", Username: "user1"},
-			{ID: "2", Username: "user2"},
-		}
-		s.mockRepo.On("GetAllUsers", s.ctx).Return(users, nil).Once()
+		users := []domain.User{{ID: "1"}, {ID: "2"}}
+		s.mockRepo.On("FindUsersByIDs", s.ctx, []string{"1", "2"}).Return(users, nil).Once()
 
-		result, err := s.usecase.GetAllUsers(s.ctx)
+		found, err := s.usecase.FindUsersByIDs(s.ctx, []string{"1", "2"})
 		s.NoError(err)
-		s.Len(result, 2)
-		s.Equal("user1", result[0].Username)
+		s.Equal(users, found)
 	})
 
-	s.Run("NoUsers", func() {
-		s.mockRepo.On("GetAllUsers", s.ctx).Return([]*domain.User{}, nil).Once()
-
-		result, err := s.usecase.GetAllUsers(s.ctx)
-		s.Error(err)
-		s.Equal("no users found", err.Error())
-		s.Nil(result)
+	s.Run("NoIDs", func() {
+		found, err := s.usecase.FindUsersByIDs(s.ctx, nil)
+		s.NoError(err)
+		s.Empty(found, "Nothing to look up should not query the repository")
 	})
 }
 
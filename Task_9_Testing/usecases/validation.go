@@ -0,0 +1,256 @@
+package usecases
+
+import (
+	"net/mail"
+	"net/url"
+	"regexp"
+	"strings"
+	"task_manager/domain"
+	"time"
+	// Time zones are checked against the embedded database, so validation
+	// does not depend on the zoneinfo files of the host or container.
+	_ "time/tzdata"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	maxTitleLength       = 200
+	maxDescriptionLength = 2000
+	minUsernameLength    = 3
+	maxUsernameLength    = 32
+	minPasswordLength    = 8
+	// bcrypt ignores everything past 72 bytes, so longer passwords are rejected outright.
+	maxPasswordBytes = 72
+	maxAPIKeyName    = 64
+	maxAPIKeyTTL     = 365 * 24 * time.Hour
+	defaultPerPage   = 20
+	maxPerPage       = 100
+	maxDisplayName   = 64
+	maxAvatarURL     = 2048
+)
+
+var usernamePattern = regexp.MustCompile(`^[a-zA-Z0-9_.-]+$`)
+
+// localePattern accepts BCP 47 style tags such as "en", "pt-BR" or "zh-Hant-TW".
+var localePattern = regexp.MustCompile(`^[a-zA-Z]{2,3}(-[a-zA-Z0-9]{2,8})*$`)
+
+var taskStatuses = []string{domain.TaskStatusPending, domain.TaskStatusInProgress, domain.TaskStatusDone}
+
+var userRoles = []string{domain.RoleAdmin, domain.RoleUser}
+
+var apiKeyScopes = []string{domain.ScopeTasksRead, domain.ScopeTasksWrite, domain.ScopeAdmin}
+
+// validateTask checks a task before it is stored. Due dates are only required
+// to lie in the future when the task is being created.
+func validateTask(task domain.Task, creating bool) error {
+	var errs domain.ValidationErrors
+
+	title := strings.TrimSpace(task.Title)
+	switch {
+	case title == "":
+		errs.Add("title", "is required")
+	case utf8.RuneCountInString(title) > maxTitleLength:
+		errs.Add("title", "must be at most 200 characters")
+	}
+
+	if utf8.RuneCountInString(task.Description) > maxDescriptionLength {
+		errs.Add("description", "must be at most 2000 characters")
+	}
+
+	if !validStatus(task.Status) {
+		errs.Add("status", "must be one of "+strings.Join(taskStatuses, ", "))
+	}
+
+	if creating && !task.DueDate.IsZero() && task.DueDate.Before(startOfToday()) {
+		errs.Add("due_date", "must not be in the past")
+	}
+
+	return errs.Err()
+}
+
+// ValidateRegistration applies the rules of Register to users created without
+// it, such as by the taskadmin tool.
+func ValidateRegistration(user domain.User) error {
+	return validateRegistration(user)
+}
+
+// validateRegistration checks the username and plain-text password of a new user.
+func validateRegistration(user domain.User) error {
+	var errs domain.ValidationErrors
+
+	switch n := utf8.RuneCountInString(user.Username); {
+	case n == 0:
+		errs.Add("username", "is required")
+	case n < minUsernameLength || n > maxUsernameLength:
+		errs.Add("username", "must be between 3 and 32 characters")
+	case !usernamePattern.MatchString(user.Username):
+		errs.Add("username", "may only contain letters, digits, '.', '_' and '-'")
+	}
+
+	if msg := passwordProblem(user.Password, user.Username); msg != "" {
+		errs.Add("password", msg)
+	}
+
+	if user.Email != "" && !validEmail(user.Email) {
+		errs.Add("email", "must be a valid email address")
+	}
+
+	return errs.Err()
+}
+
+// validateProfile checks the fields present in a PATCH /me body.
+func validateProfile(update domain.ProfileUpdate) error {
+	var errs domain.ValidationErrors
+
+	if update.DisplayName != nil && utf8.RuneCountInString(strings.TrimSpace(*update.DisplayName)) > maxDisplayName {
+		errs.Add("display_name", "must be at most 64 characters")
+	}
+
+	if update.Email != nil {
+		switch {
+		case *update.Email == "":
+			errs.Add("email", "cannot be removed")
+		case !validEmail(*update.Email):
+			errs.Add("email", "must be a valid email address")
+		}
+	}
+
+	if update.AvatarURL != nil && *update.AvatarURL != "" && !validAvatarURL(*update.AvatarURL) {
+		errs.Add("avatar_url", "must be an absolute http or https URL")
+	}
+
+	if update.TimeZone != nil && *update.TimeZone != "" {
+		if _, err := time.LoadLocation(*update.TimeZone); err != nil || *update.TimeZone == "Local" {
+			errs.Add("time_zone", "must be an IANA time zone such as Europe/Berlin")
+		}
+	}
+
+	if update.Locale != nil && *update.Locale != "" && !localePattern.MatchString(*update.Locale) {
+		errs.Add("locale", "must be a language tag such as en or pt-BR")
+	}
+
+	return errs.Err()
+}
+
+// validateAPIKey checks a new API key. Only admins may hand the admin scope to a key.
+func validateAPIKey(name string, scopes []string, ttl time.Duration, role string) error {
+	var errs domain.ValidationErrors
+
+	switch n := utf8.RuneCountInString(strings.TrimSpace(name)); {
+	case n == 0:
+		errs.Add("name", "is required")
+	case n > maxAPIKeyName:
+		errs.Add("name", "must be at most 64 characters")
+	}
+
+	if len(scopes) == 0 {
+		errs.Add("scopes", "at least one scope is required")
+	}
+	for _, scope := range scopes {
+		if !contains(apiKeyScopes, scope) {
+			errs.Add("scopes", "must be one of "+strings.Join(apiKeyScopes, ", "))
+			break
+		}
+		if scope == domain.ScopeAdmin && role != "admin" {
+			errs.Add("scopes", "admin scope requires the admin role")
+			break
+		}
+	}
+
+	if ttl <= 0 || ttl > maxAPIKeyTTL {
+		errs.Add("expires_in_days", "must be between 1 and 365")
+	}
+
+	return errs.Err()
+}
+
+// passwordProblem returns a description of what is wrong with password, or "" if it is acceptable.
+func passwordProblem(password, username string) string {
+	if password == "" {
+		return "is required"
+	}
+	if utf8.RuneCountInString(password) < minPasswordLength {
+		return "must be at least 8 characters"
+	}
+	if len(password) > maxPasswordBytes {
+		return "must be at most 72 bytes"
+	}
+
+	var hasLetter, hasDigit bool
+	for _, r := range password {
+		switch {
+		case unicode.IsLetter(r):
+			hasLetter = true
+		case unicode.IsDigit(r):
+			hasDigit = true
+		}
+	}
+	if !hasLetter || !hasDigit {
+		return "must contain at least one letter and one digit"
+	}
+	if username != "" && strings.EqualFold(password, username) {
+		return "must not match the username"
+	}
+	return ""
+}
+
+func validEmail(email string) bool {
+	addr, err := mail.ParseAddress(email)
+	return err == nil && addr.Address == email
+}
+
+func validAvatarURL(raw string) bool {
+	if len(raw) > maxAvatarURL {
+		return false
+	}
+	u, err := url.Parse(raw)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
+
+func validStatus(status string) bool {
+	return contains(taskStatuses, status)
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func startOfToday() time.Time {
+	return time.Now().UTC().Truncate(24 * time.Hour)
+}
+
+// validateUserQuery fills in the paging defaults and rejects out of range values.
+func validateUserQuery(query *domain.UserQuery) error {
+	var errs domain.ValidationErrors
+
+	if query.Page == 0 {
+		query.Page = 1
+	}
+	if query.PerPage == 0 {
+		query.PerPage = defaultPerPage
+	}
+	if query.Page < 1 {
+		errs.Add("page", "must be at least 1")
+	}
+	if query.PerPage < 1 || query.PerPage > maxPerPage {
+		errs.Add("per_page", "must be between 1 and 100")
+	}
+	if query.Role != "" && !contains(userRoles, query.Role) {
+		errs.Add("role", "must be one of "+strings.Join(userRoles, ", "))
+	}
+
+	return errs.Err()
+}
+
+func validateRole(role string) error {
+	if !contains(userRoles, role) {
+		return domain.ValidationErrors{{Field: "role", Message: "must be one of " + strings.Join(userRoles, ", ")}}
+	}
+	return nil
+}
@@ -0,0 +1,81 @@
+package usecases
+
+import (
+	"strings"
+	"task_manager/domain"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func fieldsOf(err error) []string {
+	verrs, ok := err.(domain.ValidationErrors)
+	if !ok {
+		return nil
+	}
+	fields := make([]string, len(verrs))
+	for i, fe := range verrs {
+		fields[i] = fe.Field
+	}
+	return fields
+}
+
+func TestValidateTask(t *testing.T) {
+	tests := []struct {
+		name     string
+		task     domain.Task
+		creating bool
+		fields   []string
+	}{
+		{"Valid", domain.Task{Title: "Write docs", Status: "pending", DueDate: time.Now().Add(time.Hour)}, true, nil},
+		{"NoDueDate", domain.Task{Title: "Write docs", Status: "done"}, true, nil},
+		{"MissingTitle", domain.Task{Status: "pending"}, true, []string{"title"}},
+		{"LongTitle", domain.Task{Title: strings.Repeat("a", 201), Status: "pending"}, false, []string{"title"}},
+		{"LongDescription", domain.Task{Title: "t", Description: strings.Repeat("a", 2001), Status: "pending"}, false, []string{"description"}},
+		{"UnknownStatus", domain.Task{Title: "t", Status: "Completed"}, false, []string{"status"}},
+		{"PastDueOnCreate", domain.Task{Title: "t", Status: "pending", DueDate: time.Now().AddDate(0, 0, -1)}, true, []string{"due_date"}},
+		{"PastDueOnUpdate", domain.Task{Title: "t", Status: "pending", DueDate: time.Now().AddDate(0, 0, -1)}, false, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateTask(tt.task, tt.creating)
+			if tt.fields == nil {
+				assert.NoError(t, err)
+				return
+			}
+			assert.Equal(t, tt.fields, fieldsOf(err))
+		})
+	}
+}
+
+func TestValidateRegistration(t *testing.T) {
+	tests := []struct {
+		name   string
+		user   domain.User
+		fields []string
+	}{
+		{"Valid", domain.User{Username: "jane.doe", Password: "correct9horse"}, nil},
+		{"Empty", domain.User{}, []string{"username", "password"}},
+		{"ShortUsername", domain.User{Username: "ab", Password: "correct9horse"}, []string{"username"}},
+		{"UsernameCharset", domain.User{Username: "jane doe", Password: "correct9horse"}, []string{"username"}},
+		{"ShortPassword", domain.User{Username: "jane", Password: "abc123"}, []string{"password"}},
+		{"PasswordOver72Bytes", domain.User{Username: "jane", Password: strings.Repeat("a1", 37)}, []string{"password"}},
+		{"PasswordNoDigit", domain.User{Username: "jane", Password: "correcthorse"}, []string{"password"}},
+		{"ValidEmail", domain.User{Username: "jane", Password: "correct9horse", Email: "jane@example.com"}, nil},
+		{"InvalidEmail", domain.User{Username: "jane", Password: "correct9horse", Email: "Jane <jane@example.com>"}, []string{"email"}},
+		{"PasswordIsUsername", domain.User{Username: "jane12345", Password: "JANE12345"}, []string{"password"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateRegistration(tt.user)
+			if tt.fields == nil {
+				assert.NoError(t, err)
+				return
+			}
+			assert.Equal(t, tt.fields, fieldsOf(err))
+		})
+	}
+}
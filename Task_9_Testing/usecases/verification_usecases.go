@@ -0,0 +1,116 @@
+package usecases
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"task_manager/domain"
+	"time"
+)
+
+const verificationTTL = 48 * time.Hour
+
+// VerificationUsecaseImpl sends and checks signed email verification links.
+// The link carries the user id, the address and an expiry, signed with HMAC, so
+// nothing has to be stored until the link is used.
+type VerificationUsecaseImpl struct {
+	userRepo domain.UserRepository
+	mailer   domain.Mailer
+	secret   []byte
+	baseURL  string
+	now      func() time.Time
+}
+
+func NewVerificationUsecase(userRepo domain.UserRepository, mailer domain.Mailer, secret, baseURL string) domain.VerificationUsecase {
+	return &VerificationUsecaseImpl{
+		userRepo: userRepo,
+		mailer:   mailer,
+		secret:   []byte(secret),
+		baseURL:  strings.TrimRight(baseURL, "/"),
+		now:      time.Now,
+	}
+}
+
+func (u *VerificationUsecaseImpl) SendVerification(ctx context.Context, user domain.User) error {
+	if user.Email == "" {
+		return domain.ValidationErrors{{Field: "email", Message: "is required"}}
+	}
+	token := u.sign(user.ID, user.Email, u.now().Add(verificationTTL))
+	link := u.baseURL + "/v1/verify?token=" + url.QueryEscape(token)
+	return u.mailer.Send(ctx, domain.Email{
+		To:      user.Email,
+		Subject: "Confirm your task manager email address",
+		Body: fmt.Sprintf("Hi %s,\n\nOpen this link within %d hours to confirm this address:\n\n%s\n",
+			user.Username, int(verificationTTL.Hours()), link),
+	})
+}
+
+func (u *VerificationUsecaseImpl) VerifyEmail(ctx context.Context, token string) error {
+	userID, email, ok := u.parse(token)
+	if !ok {
+		return domain.ErrInvalidVerifyToken
+	}
+	matched, err := u.userRepo.MarkEmailVerified(ctx, userID, email)
+	if err != nil {
+		return err
+	}
+	if !matched {
+		return domain.ErrInvalidVerifyToken
+	}
+	return nil
+}
+
+// ResendVerification quietly does nothing for unknown or already verified
+// addresses, so the endpoint does not reveal who is registered.
+func (u *VerificationUsecaseImpl) ResendVerification(ctx context.Context, email string) error {
+	if email == "" {
+		return domain.ValidationErrors{{Field: "email", Message: "is required"}}
+	}
+	user, err := u.userRepo.FindUserByEmail(ctx, email)
+	if err != nil || user == nil || user.Verified {
+		return err
+	}
+	return u.SendVerification(ctx, *user)
+}
+
+func (u *VerificationUsecaseImpl) sign(userID, email string, expires time.Time) string {
+	payload := userID + "\n" + email + "\n" + strconv.FormatInt(expires.Unix(), 10)
+	mac := hmac.New(sha256.New, u.secret)
+	mac.Write([]byte(payload))
+	return base64.RawURLEncoding.EncodeToString([]byte(payload)) + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func (u *VerificationUsecaseImpl) parse(token string) (userID, email string, ok bool) {
+	encPayload, encSig, found := strings.Cut(token, ".")
+	if !found {
+		return "", "", false
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(encPayload)
+	if err != nil {
+		return "", "", false
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(encSig)
+	if err != nil {
+		return "", "", false
+	}
+	mac := hmac.New(sha256.New, u.secret)
+	mac.Write(payload)
+	if !hmac.Equal(sig, mac.Sum(nil)) {
+		return "", "", false
+	}
+
+	parts := strings.Split(string(payload), "\n")
+	if len(parts) != 3 {
+		return "", "", false
+	}
+	expires, err := strconv.ParseInt(parts[2], 10, 64)
+	if err != nil || !u.now().Before(time.Unix(expires, 0)) {
+		return "", "", false
+	}
+	return parts[0], parts[1], true
+}
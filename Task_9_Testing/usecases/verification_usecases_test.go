@@ -0,0 +1,120 @@
+package usecases
+
+import (
+	"context"
+	"net/url"
+	"strings"
+	"task_manager/domain"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+type VerificationUsecaseTestSuite struct {
+	suite.Suite
+	mockRepo   *MockUserRepository
+	mockMailer *MockMailer
+	usecase    *VerificationUsecaseImpl
+	ctx        context.Context
+	now        time.Time
+}
+
+func (s *VerificationUsecaseTestSuite) SetupTest() {
+	s.mockRepo = &MockUserRepository{}
+	s.mockMailer = &MockMailer{}
+	s.usecase = NewVerificationUsecase(s.mockRepo, s.mockMailer, "secret", "http://localhost:8080/").(*VerificationUsecaseImpl)
+	s.ctx = context.Background()
+	s.now = time.Date(2025, 4, 3, 12, 0, 0, 0, time.UTC)
+	s.usecase.now = func() time.Time { return s.now }
+}
+
+func (s *VerificationUsecaseTestSuite) TearDownTest() {
+	s.mockRepo.AssertExpectations(s.T())
+	s.mockMailer.AssertExpectations(s.T())
+}
+
+// sentToken sends a verification mail for user and returns the token from its link.
+func (s *VerificationUsecaseTestSuite) sentToken(user domain.User) string {
+	var sent domain.Email
+	s.mockMailer.On("Send", s.ctx, mock.AnythingOfType("domain.Email")).
+		Run(func(args mock.Arguments) { sent = args.Get(1).(domain.Email) }).Return(nil).Once()
+	s.Require().NoError(s.usecase.SendVerification(s.ctx, user))
+
+	_, link, found := strings.Cut(sent.Body, "http://localhost:8080/v1/verify?")
+	s.Require().True(found, "The email should contain a verification link")
+	query, err := url.ParseQuery(strings.TrimSpace(link))
+	s.Require().NoError(err)
+	return query.Get("token")
+}
+
+func (s *VerificationUsecaseTestSuite) TestVerifyEmail() {
+	user := domain.User{ID: "1", Username: "testuser", Email: "test@example.com"}
+
+	s.Run("Success", func() {
+		token := s.sentToken(user)
+		s.mockRepo.On("MarkEmailVerified", s.ctx, "1", "test@example.com").Return(true, nil).Once()
+
+		s.NoError(s.usecase.VerifyEmail(s.ctx, token))
+	})
+
+	s.Run("EmailChanged", func() {
+		token := s.sentToken(user)
+		s.mockRepo.On("MarkEmailVerified", s.ctx, "1", "test@example.com").Return(false, nil).Once()
+
+		s.ErrorIs(s.usecase.VerifyEmail(s.ctx, token), domain.ErrInvalidVerifyToken)
+	})
+
+	s.Run("Expired", func() {
+		token := s.sentToken(user)
+		s.usecase.now = func() time.Time { return s.now.Add(verificationTTL) }
+		defer func() { s.usecase.now = func() time.Time { return s.now } }()
+
+		s.ErrorIs(s.usecase.VerifyEmail(s.ctx, token), domain.ErrInvalidVerifyToken)
+	})
+
+	s.Run("Tampered", func() {
+		other := s.usecase.sign("2", "attacker@example.com", s.now.Add(time.Hour))
+		token := s.sentToken(user)
+		payload, _, _ := strings.Cut(other, ".")
+		_, sig, _ := strings.Cut(token, ".")
+
+		s.ErrorIs(s.usecase.VerifyEmail(s.ctx, payload+"."+sig), domain.ErrInvalidVerifyToken)
+		s.ErrorIs(s.usecase.VerifyEmail(s.ctx, "garbage"), domain.ErrInvalidVerifyToken)
+	})
+
+	s.Run("WrongSecret", func() {
+		other := NewVerificationUsecase(s.mockRepo, s.mockMailer, "other", "").(*VerificationUsecaseImpl)
+		token := other.sign("1", "test@example.com", s.now.Add(time.Hour))
+
+		s.ErrorIs(s.usecase.VerifyEmail(s.ctx, token), domain.ErrInvalidVerifyToken)
+	})
+}
+
+func (s *VerificationUsecaseTestSuite) TestResendVerification() {
+	s.Run("Unverified", func() {
+		user := &domain.User{ID: "1", Username: "testuser", Email: "test@example.com"}
+		s.mockRepo.On("FindUserByEmail", s.ctx, "test@example.com").Return(user, nil).Once()
+		s.mockMailer.On("Send", s.ctx, mock.MatchedBy(func(e domain.Email) bool { return e.To == "test@example.com" })).Return(nil).Once()
+
+		s.NoError(s.usecase.ResendVerification(s.ctx, "test@example.com"))
+	})
+
+	s.Run("AlreadyVerified", func() {
+		user := &domain.User{ID: "1", Email: "test@example.com", Verified: true}
+		s.mockRepo.On("FindUserByEmail", s.ctx, "test@example.com").Return(user, nil).Once()
+
+		s.NoError(s.usecase.ResendVerification(s.ctx, "test@example.com"))
+	})
+
+	s.Run("Unknown", func() {
+		s.mockRepo.On("FindUserByEmail", s.ctx, "nobody@example.com").Return((*domain.User)(nil), nil).Once()
+
+		s.NoError(s.usecase.ResendVerification(s.ctx, "nobody@example.com"))
+	})
+}
+
+func TestVerificationUsecaseSuite(t *testing.T) {
+	suite.Run(t, new(VerificationUsecaseTestSuite))
+}